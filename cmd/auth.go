@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/evanxg852000/foxdb/internal/auth"
+	"github.com/evanxg852000/foxdb/internal/core"
+	wire "github.com/jeroenrinzema/psql-wire"
+	"github.com/jeroenrinzema/psql-wire/codes"
+	psqlerr "github.com/jeroenrinzema/psql-wire/errors"
+	"github.com/jeroenrinzema/psql-wire/pkg/buffer"
+	pgtypes "github.com/jeroenrinzema/psql-wire/pkg/types"
+)
+
+// client authentication methods, named as in pg_hba.conf
+const (
+	AUTH_TRUST    = "trust"
+	AUTH_PASSWORD = "password"
+	AUTH_MD5      = "md5"
+	AUTH_SCRAM    = "scram-sha-256"
+)
+
+// authentication request codes of the AuthenticationXXX messages
+const (
+	AUTH_REQUEST_OK            int32 = 0
+	AUTH_REQUEST_MD5           int32 = 5
+	AUTH_REQUEST_SASL          int32 = 10
+	AUTH_REQUEST_SASL_CONTINUE int32 = 11
+	AUTH_REQUEST_SASL_FINAL    int32 = 12
+)
+
+// newAuthStrategy returns how clients authenticate as one of the users of
+// the database, trust lets anyone connect as any user.
+func newAuthStrategy(db *core.Database, method string) (wire.AuthStrategy, error) {
+	switch method {
+	case AUTH_TRUST:
+		return nil, nil
+	case AUTH_PASSWORD:
+		return wire.ClearTextPassword(func(ctx context.Context, database, userName, password string) (context.Context, bool, error) {
+			credentials, _ := db.UserCredentials(userName)
+			return ctx, credentials != nil && credentials.VerifyPassword(password), nil
+		}), nil
+	case AUTH_MD5:
+		return md5Password(db), nil
+	case AUTH_SCRAM:
+		return scramSha256(db), nil
+	default:
+		return nil, fmt.Errorf("unknown authentication method %s", method)
+	}
+}
+
+func md5Password(db *core.Database) wire.AuthStrategy {
+	return func(ctx context.Context, writer *buffer.Writer, reader *buffer.Reader) (context.Context, error) {
+		salt := make([]byte, 4)
+		if _, err := rand.Read(salt); err != nil {
+			return ctx, err
+		}
+
+		writer.Start(pgtypes.ServerAuth)
+		writer.AddInt32(AUTH_REQUEST_MD5)
+		writer.AddBytes(salt)
+		if err := writer.End(); err != nil {
+			return ctx, err
+		}
+
+		if err := readPasswordMessage(reader); err != nil {
+			return ctx, err
+		}
+		response, err := reader.GetString()
+		if err != nil {
+			return ctx, err
+		}
+
+		userName := wire.AuthenticatedUsername(ctx)
+		credentials, _ := db.UserCredentials(userName)
+		if credentials == nil || !credentials.VerifyMd5(salt, response) {
+			return ctx, authenticationFailed(writer, userName)
+		}
+		return ctx, writeAuthRequest(writer, AUTH_REQUEST_OK, nil)
+	}
+}
+
+func scramSha256(db *core.Database) wire.AuthStrategy {
+	return func(ctx context.Context, writer *buffer.Writer, reader *buffer.Reader) (context.Context, error) {
+		// the list of mechanisms is terminated by an empty name
+		if err := writeAuthRequest(writer, AUTH_REQUEST_SASL, []byte(auth.SCRAM_SHA_256+"\x00\x00")); err != nil {
+			return ctx, err
+		}
+
+		// SASLInitialResponse: mechanism, then the length prefixed client-first-message
+		if err := readPasswordMessage(reader); err != nil {
+			return ctx, err
+		}
+		mechanism, err := reader.GetString()
+		if err != nil {
+			return ctx, err
+		}
+		length, err := reader.GetInt32()
+		if err != nil {
+			return ctx, err
+		}
+		clientFirst, err := reader.GetBytes(int(length))
+		if err != nil {
+			return ctx, err
+		}
+
+		userName := wire.AuthenticatedUsername(ctx)
+		if mechanism != auth.SCRAM_SHA_256 {
+			return ctx, authenticationFailed(writer, userName)
+		}
+
+		// like Postgres, users that cannot log in go through a mock exchange
+		// failing at the same step as a wrong password
+		exchange := auth.NewMockScramExchange(userName)
+		if credentials, _ := db.UserCredentials(userName); credentials != nil {
+			if exchange, err = auth.NewScramExchange(credentials); err != nil {
+				return ctx, err
+			}
+		}
+		serverFirst, err := exchange.ServerFirst(string(clientFirst))
+		if err != nil {
+			return ctx, authenticationFailed(writer, userName)
+		}
+		if err := writeAuthRequest(writer, AUTH_REQUEST_SASL_CONTINUE, []byte(serverFirst)); err != nil {
+			return ctx, err
+		}
+
+		// SASLResponse: the whole message is the client-final-message
+		if err := readPasswordMessage(reader); err != nil {
+			return ctx, err
+		}
+		clientFinal, err := reader.GetBytes(len(reader.Msg))
+		if err != nil {
+			return ctx, err
+		}
+		serverFinal, err := exchange.ServerFinal(string(clientFinal))
+		if err != nil {
+			return ctx, authenticationFailed(writer, userName)
+		}
+		if err := writeAuthRequest(writer, AUTH_REQUEST_SASL_FINAL, []byte(serverFinal)); err != nil {
+			return ctx, err
+		}
+		return ctx, writeAuthRequest(writer, AUTH_REQUEST_OK, nil)
+	}
+}
+
+func readPasswordMessage(reader *buffer.Reader) error {
+	messageType, _, err := reader.ReadTypedMsg()
+	if err != nil {
+		return err
+	}
+	if messageType != pgtypes.ClientPassword {
+		return errors.New("unexpected authentication message")
+	}
+	return nil
+}
+
+func writeAuthRequest(writer *buffer.Writer, request int32, data []byte) error {
+	writer.Start(pgtypes.ServerAuth)
+	writer.AddInt32(request)
+	if len(data) > 0 {
+		writer.AddBytes(data)
+	}
+	return writer.End()
+}
+
+// authenticationFailed reports the failure to the client, the returned
+// error closes the connection. It does not tell whether the user exists.
+func authenticationFailed(writer *buffer.Writer, userName string) error {
+	err := psqlerr.WithCode(fmt.Errorf("password authentication failed for user %q", userName), codes.InvalidPassword)
+	if writeErr := wire.ErrorCode(writer, err); writeErr != nil {
+		return writeErr
+	}
+	return err
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+
+	"github.com/evanxg852000/foxdb/internal/core"
+	wire "github.com/jeroenrinzema/psql-wire"
+	"github.com/jeroenrinzema/psql-wire/codes"
+	psqlerr "github.com/jeroenrinzema/psql-wire/errors"
+	"github.com/jeroenrinzema/psql-wire/pkg/buffer"
+	pgtypes "github.com/jeroenrinzema/psql-wire/pkg/types"
+)
+
+var errTlsRequired = errors.New("the server only accepts SSL connections")
+
+// clientListener hands the wire server connections where SSL has already
+// been negotiated, the server only ever reads the startup message in plain
+// text. Owning the connection lets sessions write messages the server has
+// no API for, such as ParameterStatus.
+type clientListener struct {
+	net.Listener
+	tlsConfig  *tls.Config
+	requireTls bool
+}
+
+func newClientListener(listener net.Listener, options tlsOptions) (net.Listener, error) {
+	tlsConfig, err := newTlsConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	return &clientListener{Listener: listener, tlsConfig: tlsConfig, requireTls: options.required()}, nil
+}
+
+func (l *clientListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &clientConn{Conn: conn, listener: l}, nil
+}
+
+// clientConn negotiates SSL on the first read, which happens in the
+// goroutine serving the connection rather than in the accept loop.
+type clientConn struct {
+	// the TCP connection, or the TLS one once it has been negotiated
+	net.Conn
+	listener   *clientListener
+	negotiated bool
+	secure     bool
+	// start of the startup message, read while negotiating
+	pending []byte
+	// database session of the connection once it has started
+	session *core.Session
+	// written bytes of a message that is not complete yet
+	outgoing []byte
+}
+
+func (c *clientConn) Read(p []byte) (int, error) {
+	if !c.negotiated {
+		c.negotiated = true
+		if err := c.negotiate(); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(c.pending) > 0 {
+		n := copy(p, c.pending)
+		c.pending = c.pending[n:]
+		return n, nil
+	}
+	return c.Conn.Read(p)
+}
+
+// Write reports the transaction status of the session in ReadyForQuery
+// messages, the wire server always reports an idle session. The written
+// bytes are split into messages, those are sent once complete so that a
+// ReadyForQuery is found whatever the writes it spans or shares.
+func (c *clientConn) Write(p []byte) (int, error) {
+	c.outgoing = append(c.outgoing, p...)
+	end := 0
+	for len(c.outgoing)-end >= 5 {
+		// the type byte is followed by the length of the message, which
+		// counts itself
+		length := int(binary.BigEndian.Uint32(c.outgoing[end+1:]))
+		if length < 4 {
+			// not a message, the bytes are sent as they are
+			end = len(c.outgoing)
+			break
+		}
+		if len(c.outgoing)-end < 1+length {
+			break
+		}
+		if c.session != nil && c.outgoing[end] == byte(pgtypes.ServerReady) && length == 5 {
+			c.outgoing[end+5] = byte(c.session.TransactionStatus())
+		}
+		end += 1 + length
+	}
+
+	if end > 0 {
+		if _, err := c.Conn.Write(c.outgoing[:end]); err != nil {
+			c.outgoing = c.outgoing[:0]
+			return 0, err
+		}
+		c.outgoing = append(c.outgoing[:0], c.outgoing[end:]...)
+	}
+	return len(p), nil
+}
+
+// Close rolls back the transaction a client leaves open when it disconnects
+func (c *clientConn) Close() error {
+	if c.session != nil {
+		c.session.Close()
+	}
+	return c.Conn.Close()
+}
+
+// negotiate answers the SSLRequest and GSSENCRequest messages that can
+// precede the startup message, it is kept for the server to read.
+func (c *clientConn) negotiate() error {
+	for {
+		// length of the message followed by the protocol version
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(c.Conn, header); err != nil {
+			return err
+		}
+
+		switch pgtypes.Version(binary.BigEndian.Uint32(header[4:])) {
+		case pgtypes.VersionSSLRequest:
+			if c.secure || c.listener.tlsConfig == nil {
+				if _, err := c.Conn.Write([]byte{'N'}); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := c.Conn.Write([]byte{'S'}); err != nil {
+				return err
+			}
+			tlsConn := tls.Server(c.Conn, c.listener.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return err
+			}
+			c.Conn, c.secure = tlsConn, true
+		case pgtypes.VersionGSSENC:
+			if _, err := c.Conn.Write([]byte{'N'}); err != nil {
+				return err
+			}
+		case pgtypes.VersionCancel:
+			c.pending = header
+			return nil
+		default:
+			if c.listener.requireTls && !c.secure {
+				err := psqlerr.WithSeverity(psqlerr.WithCode(errTlsRequired, codes.InvalidAuthorizationSpecification), psqlerr.LevelFatal)
+				wire.ErrorCode(buffer.NewWriter(slog.Default(), c.Conn), err)
+				return errTlsRequired
+			}
+			c.pending = header
+			return nil
+		}
+	}
+}
+
+// RemoteAddr is kept by the wire server in the context of the connection,
+// the address leads statements back to the connection.
+func (c *clientConn) RemoteAddr() net.Addr {
+	return &clientAddr{Addr: c.Conn.RemoteAddr(), conn: c}
+}
+
+type clientAddr struct {
+	net.Addr
+	conn *clientConn
+}
+
+// connFromContext returns the connection serving ctx
+func connFromContext(ctx context.Context) (*clientConn, error) {
+	addr, ok := wire.RemoteAddress(ctx).(*clientAddr)
+	if !ok {
+		return nil, errors.New("connection was not accepted by a client listener")
+	}
+	return addr.conn, nil
+}
+
+// writeParameterStatus tells the client of the connection serving ctx
+// about the new value of a setting.
+func writeParameterStatus(ctx context.Context, name string, value string) error {
+	conn, err := connFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	writer := buffer.NewWriter(slog.Default(), conn)
+	writer.Start(pgtypes.ServerParameterStatus)
+	writer.AddString(name)
+	writer.AddNullTerminate()
+	writer.AddString(value)
+	writer.AddNullTerminate()
+	return writer.End()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evanxg852000/foxdb/internal/core"
+)
+
+// bufferConn keeps what is written to it
+type bufferConn struct {
+	net.Conn
+	written bytes.Buffer
+}
+
+func (c *bufferConn) Write(p []byte) (int, error) {
+	return c.written.Write(p)
+}
+
+func TestClientConnWrite(t *testing.T) {
+	db, err := core.Open(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	session := db.NewSession("", "")
+	_, err = session.Run(context.Background(), "BEGIN;")
+	require.NoError(t, err)
+
+	target := &bufferConn{}
+	conn := &clientConn{Conn: target, session: session}
+	commandComplete := []byte{'C', 0, 0, 0, 10, 'B', 'E', 'G', 'I', 'N', 0}
+	ready := []byte{'Z', 0, 0, 0, 5, 'I'}
+	expected := append(append([]byte{}, commandComplete...), 'Z', 0, 0, 0, 5, 'T')
+
+	// a ReadyForQuery sharing a write with another message
+	written := append(append([]byte{}, commandComplete...), ready...)
+	n, err := conn.Write(written)
+	require.NoError(t, err)
+	assert.Equal(t, len(written), n)
+	assert.Equal(t, expected, target.written.Bytes())
+	assert.Equal(t, byte('I'), written[len(written)-1], "the written bytes are not changed")
+
+	// a ReadyForQuery split across writes is only sent once complete
+	target.written.Reset()
+	for _, part := range [][]byte{commandComplete[:3], commandComplete[3:], ready[:4], ready[4:5]} {
+		_, err := conn.Write(part)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, commandComplete, target.written.Bytes())
+	_, err = conn.Write(ready[5:])
+	require.NoError(t, err)
+	assert.Equal(t, expected, target.written.Bytes())
+
+	// a 6-byte message of another type is left as is
+	target.written.Reset()
+	_, err = conn.Write([]byte{'D', 0, 0, 0, 5, 'I'})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{'D', 0, 0, 0, 5, 'I'}, target.written.Bytes())
+}
@@ -4,17 +4,23 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"os"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/chzyer/readline"
 	"github.com/evanxg852000/foxdb/internal/core"
-	wire "github.com/jeroenrinzema/psql-wire"
 )
 
 func main() {
 	dirPtr := flag.String("dir", "./data", "database directory path")
 	servePtr := flag.Bool("serve", false, "start the database server")
 	portPtr := flag.Int("port", 5444, "server port to listen on")
+	authPtr := flag.String("auth", AUTH_TRUST, "client authentication method: trust, password, md5 or scram-sha-256")
+	tlsCertPtr := flag.String("tls-cert", "", "server TLS certificate file, enables SSL connections")
+	tlsKeyPtr := flag.String("tls-key", "", "server TLS private key file")
+	tlsRequirePtr := flag.Bool("tls-require", false, "reject connections that do not use SSL")
+	tlsClientCaPtr := flag.String("tls-client-ca", "", "CA bundle verifying client certificates, implies -tls-require")
 	flag.Parse()
 
 	db, err := core.Open(*dirPtr)
@@ -28,11 +34,21 @@ func main() {
 		return
 	}
 
-	runServerMode(db, *portPtr)
+	runServerMode(db, *portPtr, serverOptions{
+		authMethod: *authPtr,
+		tls: tlsOptions{
+			certFile:     *tlsCertPtr,
+			keyFile:      *tlsKeyPtr,
+			clientCaFile: *tlsClientCaPtr,
+			require:      *tlsRequirePtr,
+		},
+	})
 }
 
 func runInteractiveMode(db *core.Database) {
 	fmt.Println("Running in interactive mode. Type '\\exit' to quit.")
+	session := db.NewSession("", "")
+	defer session.Close()
 
 	config := &readline.Config{
 		Prompt:                 "> ",
@@ -53,7 +69,7 @@ func runInteractiveMode(db *core.Database) {
 
 		if strings.TrimSpace(sqlCommand) != "" {
 			rl.SaveHistory(sqlCommand)
-			err = executeCommand(db, sqlCommand)
+			err = executeCommand(session, sqlCommand)
 			if err != nil {
 				fmt.Printf("Error executing command: %v\n", err)
 			}
@@ -62,30 +78,6 @@ func runInteractiveMode(db *core.Database) {
 
 }
 
-func runServerMode(db *core.Database, port int) {
-	serverAddress := fmt.Sprintf(":%d", port)
-	fmt.Printf("Starting server on %s...\n", serverAddress)
-	if err := wire.ListenAndServe(serverAddress, createRequestHandler(db)); err != nil {
-		fmt.Printf("Error starting server: %v\n", err)
-	}
-}
-
-func createRequestHandler(db *core.Database) wire.ParseFn {
-	return func(ctx context.Context, sqlStmt string) (wire.PreparedStatements, error) {
-		// session, exist := wire.GetSession(ctx)
-		clientParams := wire.ClientParameters(ctx)
-		userName := clientParams["user"]
-		dbName := clientParams["database"]
-		return wire.Prepared(wire.NewStatement(func(ctx context.Context, writer wire.DataWriter, parameters []wire.Parameter) error {
-			fmt.Printf("Connected user: %s\n", userName)
-			fmt.Printf("Connected to database: %s\n", dbName)
-			fmt.Printf("Received query: %s\n", sqlStmt)
-			fmt.Printf("Received parameters: %v\n", parameters)
-			return writer.Complete("OK")
-		})), nil
-	}
-}
-
 func readSqlInput(rl *readline.Instance) (string, error) {
 	sqlCommand := ""
 	for {
@@ -118,13 +110,51 @@ func readSqlInput(rl *readline.Instance) (string, error) {
 
 }
 
-func executeCommand(db *core.Database, sqlCommand string) error {
+func executeCommand(session *core.Session, sqlCommand string) error {
 	fmt.Printf("Executing SQL command:\n%s\n", sqlCommand)
-	data, err := db.Run(context.TODO(), sqlCommand)
+	rows, err := session.Query(context.TODO(), sqlCommand)
 	if err != nil {
 		return err
 	}
-	//TODO:
-	fmt.Printf("Command result:\n%s\n", data)
+	defer rows.Close()
+	if rows.Schema() == nil {
+		fmt.Println("OK")
+		return nil
+	}
+	return printRows(rows)
+}
+
+// printRows prints the rows as they are read, the columns are aligned
+// within each chunk.
+func printRows(rows *core.Rows) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	names := make([]string, len(rows.Schema().Columns))
+	for i, column := range rows.Schema().Columns {
+		names[i] = column.Name
+	}
+	fmt.Fprintln(writer, strings.Join(names, "\t"))
+
+	count := 0
+	for {
+		chunk, err := rows.Next()
+		if err != nil {
+			writer.Flush()
+			return err
+		}
+		if chunk == nil {
+			break
+		}
+		for _, row := range chunk.GetRows() {
+			values := make([]string, len(row.Values))
+			for i, value := range row.Values {
+				values[i] = value.String()
+			}
+			fmt.Fprintln(writer, strings.Join(values, "\t"))
+		}
+		writer.Flush()
+		count += chunk.Len()
+	}
+	writer.Flush()
+	fmt.Printf("(%d rows)\n", count)
 	return nil
 }
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/evanxg852000/foxdb/internal/core"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+	wire "github.com/jeroenrinzema/psql-wire"
+	"github.com/jeroenrinzema/psql-wire/codes"
+	psqlerr "github.com/jeroenrinzema/psql-wire/errors"
+	"github.com/lib/pq/oid"
+)
+
+// serverOptions are the settings of the Postgres server
+type serverOptions struct {
+	authMethod string
+	tls        tlsOptions
+}
+
+func runServerMode(db *core.Database, port int, options serverOptions) {
+	serverAddress := fmt.Sprintf(":%d", port)
+	fmt.Printf("Starting server on %s...\n", serverAddress)
+	server, err := newServer(db, options)
+	if err != nil {
+		fmt.Printf("Error creating server: %v\n", err)
+		return
+	}
+
+	listener, err := net.Listen("tcp", serverAddress)
+	if err != nil {
+		fmt.Printf("Error starting server: %v\n", err)
+		return
+	}
+	clientListener, err := newClientListener(listener, options.tls)
+	if err != nil {
+		listener.Close()
+		fmt.Printf("Error creating server: %v\n", err)
+		return
+	}
+	if err := server.Serve(clientListener); err != nil {
+		fmt.Printf("Error starting server: %v\n", err)
+	}
+}
+
+// newServer creates the server with the authentication method of options,
+// wireOptions come last and can override the defaults. TLS is up to the
+// listener the server is given, see newClientListener.
+func newServer(db *core.Database, options serverOptions, wireOptions ...wire.OptionFn) (*wire.Server, error) {
+	authStrategy, err := newAuthStrategy(db, options.authMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	allOptions := []wire.OptionFn{
+		wire.SessionAuthStrategy(authStrategy),
+		wire.SessionMiddleware(startSession(db)),
+		wire.GlobalParameters(wire.Parameters{
+			// string literals take backslashes as is
+			"standard_conforming_strings": "on",
+		}),
+	}
+	allOptions = append(allOptions, wireOptions...)
+	return wire.NewServer(createRequestHandler(db), allOptions...)
+}
+
+type sessionKey struct{}
+
+// startSession opens a database session for each connection, the
+// database the client asks for is the schema searched first and the
+// other startup parameters naming a setting set it.
+func startSession(db *core.Database) wire.SessionHandler {
+	return func(ctx context.Context) (context.Context, error) {
+		parameters := wire.ClientParameters(ctx)
+		session := db.NewSession(parameters[wire.ParamUsername], parameters[wire.ParamDatabase])
+		for name, value := range parameters {
+			if name == wire.ParamUsername || name == wire.ParamDatabase {
+				continue
+			}
+			err := session.Set(string(name), []string{value})
+			if types.GetErrorCode(err) == types.ERR_UNDEFINED_OBJECT {
+				continue
+			}
+			if err != nil {
+				return ctx, err
+			}
+		}
+
+		conn, err := connFromContext(ctx)
+		if err != nil {
+			return ctx, err
+		}
+		conn.session = session
+
+		if err := reportSettings(ctx, nil, session.ReportedSettings()); err != nil {
+			return ctx, err
+		}
+		return context.WithValue(ctx, sessionKey{}, session), nil
+	}
+}
+
+// reportSettings sends a ParameterStatus for each setting whose value
+// differs from the one reported before.
+func reportSettings(ctx context.Context, before map[string]string, after map[string]string) error {
+	for name, value := range after {
+		if previous, ok := before[name]; ok && previous == value {
+			continue
+		}
+		if err := writeParameterStatus(ctx, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sessionFromContext(ctx context.Context) (*core.Session, error) {
+	session, ok := ctx.Value(sessionKey{}).(*core.Session)
+	if !ok {
+		return nil, errors.New("connection has no session")
+	}
+	return session, nil
+}
+
+// createRequestHandler parses a query into one prepared statement per SQL
+// statement, the row description of a SELECT and the parameter types are
+// known before it runs since they come from its plan. The statements of a
+// batch are described as they run, see prepareBatch.
+func createRequestHandler(db *core.Database) wire.ParseFn {
+	return func(ctx context.Context, sqlStmt string) (wire.PreparedStatements, error) {
+		session, err := sessionFromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		statements, err := db.Parse(sqlStmt)
+		if err != nil {
+			return nil, wireError(err)
+		}
+
+		if len(statements) > 1 {
+			return prepareBatch(session, statements), nil
+		}
+		prepared := make(wire.PreparedStatements, 0, len(statements))
+		for _, stmt := range statements {
+			dataSchema, err := session.Describe(stmt)
+			if err != nil {
+				return nil, wireError(err)
+			}
+			parameterTypes, err := session.DescribeParameters(stmt)
+			if err != nil {
+				return nil, wireError(err)
+			}
+			prepared = append(prepared, wire.NewStatement(
+				executeStatement(session, stmt, parameterTypes),
+				wire.WithColumns(wireColumns(dataSchema)),
+				wire.WithParameters(wireOids(parameterTypes)),
+			))
+		}
+		return prepared, nil
+	}
+}
+
+// prepareBatch prepares the statements of a simple query, only the extended
+// protocol gets a single statement. They run one after the other and may
+// use the objects the ones before them create, so a statement is described
+// once the previous one ran: psql-wire sends its row description right
+// before running it. A statement that cannot be described fails when it
+// runs, and the simple protocol has no parameters.
+func prepareBatch(session *core.Session, statements []ast.Statement) wire.PreparedStatements {
+	prepared := make(wire.PreparedStatements, len(statements))
+	describe := func(i int) {
+		if dataSchema, err := session.Describe(statements[i]); err == nil {
+			wire.WithColumns(wireColumns(dataSchema))(prepared[i])
+		}
+	}
+
+	for i, stmt := range statements {
+		execute := executeStatement(session, stmt, nil)
+		prepared[i] = wire.NewStatement(func(ctx context.Context, writer wire.DataWriter, parameters []wire.Parameter) error {
+			if err := execute(ctx, writer, parameters); err != nil {
+				return err
+			}
+			if i+1 < len(statements) {
+				describe(i + 1)
+			}
+			return nil
+		})
+	}
+	describe(0)
+	return prepared
+}
+
+func executeStatement(session *core.Session, stmt ast.Statement, parameterTypes []types.DataType) wire.PreparedStatementFn {
+	return func(ctx context.Context, writer wire.DataWriter, parameters []wire.Parameter) error {
+		values, err := parameterValues(parameters, parameterTypes)
+		if err != nil {
+			return wireError(err)
+		}
+
+		reported := session.ReportedSettings()
+		failed := session.TransactionStatus() == core.TRANSACTION_FAILED
+		rows, err := session.QueryStatement(ctx, stmt, values)
+		if err != nil {
+			return wireError(err)
+		}
+		defer rows.Close()
+		if err := reportSettings(ctx, reported, session.ReportedSettings()); err != nil {
+			return err
+		}
+
+		// rows are sent as they are read, DML statements return the
+		// affected row count instead
+		var count int64
+		for {
+			chunk, err := rows.Next()
+			if err != nil {
+				return wireError(err)
+			}
+			if chunk == nil {
+				break
+			}
+			if !returnsRows(stmt) {
+				count = affectedRows(chunk)
+				continue
+			}
+			for _, row := range chunk.GetRows() {
+				if err := writer.Row(wireRow(row)); err != nil {
+					return err
+				}
+			}
+			count += int64(chunk.Len())
+		}
+		// committing a failed transaction rolls it back
+		if stmt, ok := stmt.(*ast.TransactionStatement); ok && stmt.Action == ast.TRANSACTION_COMMIT && failed {
+			return writer.Complete("ROLLBACK")
+		}
+		return writer.Complete(commandTag(stmt, count))
+	}
+}
+
+func returnsRows(stmt ast.Statement) bool {
+	switch stmt.(type) {
+	case *ast.SelectStatement, *ast.ShowStatement:
+		return true
+	default:
+		return false
+	}
+}
+
+// commandTag builds the CommandComplete tag of a statement, DML statements
+// report the affected row count they returned and SELECT the number of
+// rows sent.
+func commandTag(stmt ast.Statement, count int64) string {
+	switch stmt := stmt.(type) {
+	case *ast.SelectStatement:
+		return fmt.Sprintf("SELECT %d", count)
+	case *ast.InsertStatement:
+		return fmt.Sprintf("INSERT 0 %d", count)
+	case *ast.UpdateStatement:
+		return fmt.Sprintf("UPDATE %d", count)
+	case *ast.DeleteStatement:
+		return fmt.Sprintf("DELETE %d", count)
+	case *ast.CreateSchemaStatement:
+		return "CREATE SCHEMA"
+	case *ast.DropSchemaStatement:
+		return "DROP SCHEMA"
+	case *ast.CreateTableStatement:
+		return "CREATE TABLE"
+	case *ast.DropTableStatement:
+		return "DROP TABLE"
+	case *ast.AlterTableStatement:
+		return "ALTER TABLE"
+	case *ast.CreateIndexStatement:
+		return "CREATE INDEX"
+	case *ast.DropIndexStatement:
+		return "DROP INDEX"
+	case *ast.CreateUserStatement:
+		return "CREATE ROLE"
+	case *ast.DropUserStatement:
+		return "DROP ROLE"
+	case *ast.AlterUserStatement:
+		return "ALTER ROLE"
+	case *ast.SetStatement:
+		return "SET"
+	case *ast.ResetStatement:
+		return "RESET"
+	case *ast.ShowStatement:
+		return "SHOW"
+	case *ast.TransactionStatement:
+		switch stmt.Action {
+		case ast.TRANSACTION_BEGIN:
+			return "BEGIN"
+		case ast.TRANSACTION_COMMIT:
+			return "COMMIT"
+		case ast.TRANSACTION_SAVEPOINT:
+			return "SAVEPOINT"
+		case ast.TRANSACTION_RELEASE:
+			return "RELEASE"
+		default:
+			return "ROLLBACK"
+		}
+	default:
+		return "OK"
+	}
+}
+
+func affectedRows(chunk *types.DataChunk) int64 {
+	if chunk == nil || chunk.Len() == 0 {
+		return 0
+	}
+	count, _ := chunk.GetRow(0).Values[0].Int()
+	return count
+}
+
+func wireColumns(dataSchema *types.DataSchema) wire.Columns {
+	if dataSchema == nil {
+		return nil
+	}
+
+	columns := make(wire.Columns, len(dataSchema.Columns))
+	for i, column := range dataSchema.Columns {
+		columns[i] = wire.Column{
+			Name:  column.Name,
+			Oid:   wireOid(column.DataType),
+			Width: wireWidth(column.DataType),
+		}
+	}
+	return columns
+}
+
+func wireOids(dataTypes []types.DataType) []oid.Oid {
+	oids := make([]oid.Oid, len(dataTypes))
+	for i, dataType := range dataTypes {
+		oids[i] = wireOid(dataType)
+	}
+	return oids
+}
+
+func wireOid(dataType types.DataType) oid.Oid {
+	switch dataType {
+	case types.TYPE_INT:
+		return oid.T_int8
+	case types.TYPE_FLOAT:
+		return oid.T_float8
+	case types.TYPE_BOOL:
+		return oid.T_bool
+	default:
+		return oid.T_text
+	}
+}
+
+// wireWidth is the fixed size of a type, variable sized types are -1
+func wireWidth(dataType types.DataType) int16 {
+	switch dataType {
+	case types.TYPE_INT, types.TYPE_FLOAT:
+		return 8
+	case types.TYPE_BOOL:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// wireRow converts a row to the Go values the wire encoder
+// expects for the column types, NULL is sent as nil.
+func wireRow(row types.DataRow) []any {
+	values := make([]any, len(row.Values))
+	for i := range row.Values {
+		value := &row.Values[i]
+		switch value.GetDataType() {
+		case types.TYPE_INT:
+			values[i], _ = value.Int()
+		case types.TYPE_FLOAT:
+			values[i], _ = value.Float()
+		case types.TYPE_BOOL:
+			values[i], _ = value.Bool()
+		case types.TYPE_TEXT:
+			values[i], _ = value.Text()
+		}
+	}
+	return values
+}
+
+// parameterValues decodes the bound parameters, text or binary, as the
+// types advertised for them in the ParameterDescription.
+func parameterValues(parameters []wire.Parameter, parameterTypes []types.DataType) ([]types.Value, error) {
+	values := make([]types.Value, len(parameters))
+	for i, parameter := range parameters {
+		if i >= len(parameterTypes) {
+			break // the count mismatch is reported when binding
+		}
+
+		decoded, err := parameter.Scan(uint32(wireOid(parameterTypes[i])))
+		if err != nil {
+			return nil, types.NewError(types.ERR_INVALID_PARAMETER_VALUE, "invalid value for parameter $%d: %v", i+1, err)
+		}
+
+		switch v := decoded.(type) {
+		case int64:
+			values[i] = *types.NewIntValue(v)
+		case float64:
+			values[i] = *types.NewFloatValue(v)
+		case bool:
+			values[i] = *types.NewBoolValue(v)
+		case string:
+			values[i] = *types.NewTextValue(v)
+		default:
+			values[i] = *types.NewNullValue()
+		}
+	}
+	return values, nil
+}
+
+// wireError attaches the SQLSTATE of err so that it is
+// reported in the ErrorResponse sent to the client.
+func wireError(err error) error {
+	return psqlerr.WithCode(err, codes.Code(types.GetErrorCode(err)))
+}
@@ -0,0 +1,532 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	wire "github.com/jeroenrinzema/psql-wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evanxg852000/foxdb/internal/core"
+)
+
+func setupTestServer(t *testing.T, authMethod string, statements ...string) string {
+	address := startTestServer(t, serverOptions{authMethod: authMethod}, statements...)
+	return "postgres://foxdb@" + address + "/foxdb?sslmode=disable"
+}
+
+// startTestServer serves a new database and returns the address it listens on
+func startTestServer(t *testing.T, options serverOptions, statements ...string) string {
+	db, err := core.Open(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	for _, sql := range statements {
+		_, err := db.Run(context.Background(), sql)
+		require.NoError(t, err, "statement failed: %s", sql)
+	}
+
+	server, err := newServer(db, options, wire.Logger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	clientListener, err := newClientListener(listener, options.tls)
+	require.NoError(t, err)
+	go server.Serve(clientListener)
+	t.Cleanup(func() { server.Close() })
+
+	return listener.Addr().String()
+}
+
+func connect(t *testing.T, connString string) *pgx.Conn {
+	return connectWithMode(t, connString, pgx.QueryExecModeSimpleProtocol)
+}
+
+func connectWithMode(t *testing.T, connString string, mode pgx.QueryExecMode) *pgx.Conn {
+	config, err := pgx.ParseConfig(connString)
+	require.NoError(t, err)
+	config.DefaultQueryExecMode = mode
+
+	conn, err := pgx.ConnectConfig(context.Background(), config)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close(context.Background()) })
+	return conn
+}
+
+func TestServerCommandTags(t *testing.T) {
+	conn := connect(t, setupTestServer(t, AUTH_TRUST))
+	ctx := context.Background()
+
+	tests := []struct {
+		sql string
+		tag string
+	}{
+		{"CREATE TABLE items (id INT PRIMARY KEY, name TEXT, price FLOAT, sold BOOL);", "CREATE TABLE"},
+		{"INSERT INTO items VALUES (1, 'pen', 1.5, true), (2, 'ink', 3.0, false);", "INSERT 0 2"},
+		{"UPDATE items SET sold = true WHERE id = 2;", "UPDATE 1"},
+		{"CREATE INDEX idx_name ON items (name);", "CREATE INDEX"},
+		{"SELECT id FROM items;", "SELECT 2"},
+		{"DELETE FROM items WHERE id = 1;", "DELETE 1"},
+		{"DROP INDEX idx_name;", "DROP INDEX"},
+		{"DROP TABLE items;", "DROP TABLE"},
+	}
+
+	for _, tt := range tests {
+		tag, err := conn.Exec(ctx, tt.sql)
+		require.NoError(t, err, tt.sql)
+		assert.Equal(t, tt.tag, tag.String(), tt.sql)
+	}
+}
+
+func TestServerSelectRows(t *testing.T) {
+	conn := connect(t, setupTestServer(t, AUTH_TRUST))
+	ctx := context.Background()
+
+	_, err := conn.Exec(ctx, "CREATE TABLE items (id INT PRIMARY KEY, name TEXT, price FLOAT, sold BOOL);")
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, "INSERT INTO items VALUES (1, 'pen', 1.5, true), (2, NULL, 3.0, false);")
+	require.NoError(t, err)
+
+	rows, err := conn.Query(ctx, "SELECT id, name, price, sold FROM items;")
+	require.NoError(t, err)
+
+	fields := rows.FieldDescriptions()
+	require.Len(t, fields, 4)
+	assert.Equal(t, []uint32{20, 25, 701, 16}, []uint32{fields[0].DataTypeOID, fields[1].DataTypeOID, fields[2].DataTypeOID, fields[3].DataTypeOID})
+	assert.Equal(t, "name", fields[1].Name)
+
+	values := [][]any{}
+	for rows.Next() {
+		var id int64
+		var name *string
+		var price float64
+		var sold bool
+		require.NoError(t, rows.Scan(&id, &name, &price, &sold))
+		values = append(values, []any{id, name, price, sold})
+	}
+	require.NoError(t, rows.Err())
+
+	pen := "pen"
+	assert.Equal(t, [][]any{
+		{int64(1), &pen, 1.5, true},
+		{int64(2), (*string)(nil), 3.0, false},
+	}, values)
+
+	// computed columns are described with the type of their expression
+	rows, err = conn.Query(ctx, "SELECT id * price, id + 1, sold AND id > 1 FROM items ORDER BY id;")
+	require.NoError(t, err)
+	fields = rows.FieldDescriptions()
+	require.Len(t, fields, 3)
+	assert.Equal(t, []uint32{701, 20, 16}, []uint32{fields[0].DataTypeOID, fields[1].DataTypeOID, fields[2].DataTypeOID})
+	assert.Equal(t, "?column?", fields[0].Name)
+	computed := [][]any{}
+	for rows.Next() {
+		var total float64
+		var next int64
+		var sold bool
+		require.NoError(t, rows.Scan(&total, &next, &sold))
+		computed = append(computed, []any{total, next, sold})
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, [][]any{{1.5, int64(2), false}, {6.0, int64(3), false}}, computed)
+}
+
+func TestServerErrors(t *testing.T) {
+	conn := connect(t, setupTestServer(t, AUTH_TRUST))
+	ctx := context.Background()
+
+	_, err := conn.Exec(ctx, "CREATE TABLE items (id INT PRIMARY KEY, name TEXT NOT NULL);")
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, "INSERT INTO items VALUES (1, 'pen');")
+	require.NoError(t, err)
+
+	tests := []struct {
+		sql  string
+		code string
+	}{
+		{"SELEC id FROM items;", "42601"},
+		{"SELECT id FROM missing;", "42P01"},
+		{"SELECT missing FROM items;", "42703"},
+		{"INSERT INTO items VALUES (1, 'ink');", "23505"},
+		{"INSERT INTO items VALUES (2, NULL);", "23502"},
+		{"CREATE TABLE items (id INT PRIMARY KEY);", "42P07"},
+	}
+
+	for _, tt := range tests {
+		_, err := conn.Exec(ctx, tt.sql)
+		var pgErr *pgconn.PgError
+		require.True(t, errors.As(err, &pgErr), "%s: %v", tt.sql, err)
+		assert.Equal(t, tt.code, pgErr.Code, tt.sql)
+		assert.Equal(t, "ERROR", pgErr.Severity, tt.sql)
+	}
+
+	// the connection is still usable after errors
+	var count int
+	rows, err := conn.Query(ctx, "SELECT id FROM items;")
+	require.NoError(t, err)
+	for rows.Next() {
+		count++
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, 1, count)
+}
+
+func TestServerQueryBatch(t *testing.T) {
+	conn := connect(t, setupTestServer(t, AUTH_TRUST))
+	ctx := context.Background()
+
+	// each statement is described once the ones before it ran
+	results, err := conn.PgConn().Exec(ctx, "CREATE TABLE b (id INT); INSERT INTO b VALUES (1); SELECT * FROM b; ALTER TABLE b ADD COLUMN name TEXT; SELECT * FROM b;").ReadAll()
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	tags := []string{}
+	for _, result := range results {
+		tags = append(tags, result.CommandTag.String())
+	}
+	assert.Equal(t, []string{"CREATE TABLE", "INSERT 0 1", "SELECT 1", "ALTER TABLE", "SELECT 1"}, tags)
+	require.Len(t, results[2].FieldDescriptions, 1)
+	require.Len(t, results[4].FieldDescriptions, 2)
+	assert.Equal(t, "name", results[4].FieldDescriptions[1].Name)
+	require.Len(t, results[4].Rows, 1)
+	assert.Equal(t, []byte("1"), results[4].Rows[0][0])
+
+	// the batch stops at the first statement that fails
+	_, err = conn.PgConn().Exec(ctx, "INSERT INTO b VALUES (2, 'x'); SELECT * FROM missing; INSERT INTO b VALUES (3, 'y');").ReadAll()
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr), "unexpected error: %v", err)
+	assert.Equal(t, "42P01", pgErr.Code)
+	var count int64
+	require.NoError(t, conn.QueryRow(ctx, "SELECT COUNT(*) FROM b;").Scan(&count))
+	assert.Equal(t, int64(2), count)
+}
+
+func TestServerBindParameters(t *testing.T) {
+	conn := connectWithMode(t, setupTestServer(t, AUTH_TRUST), pgx.QueryExecModeCacheStatement)
+	ctx := context.Background()
+
+	_, err := conn.Exec(ctx, "CREATE TABLE items (id INT PRIMARY KEY, name TEXT, price FLOAT, sold BOOL);")
+	require.NoError(t, err)
+
+	description, err := conn.Prepare(ctx, "insert_item", "INSERT INTO items (id, name, price, sold) VALUES ($1, $2, $3, $4);")
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{20, 25, 701, 16}, description.ParamOIDs)
+
+	tag, err := conn.Exec(ctx, "insert_item", 1, "pen", 1.5, true)
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT 0 1", tag.String())
+	_, err = conn.Exec(ctx, "insert_item", 2, nil, 3.0, false)
+	require.NoError(t, err)
+	_, err = conn.Exec(ctx, "insert_item", 3, "it's; DROP TABLE items;", 0.5, false)
+	require.NoError(t, err)
+
+	tag, err = conn.Exec(ctx, "UPDATE items SET price = price * $1 WHERE sold = $2;", 2, false)
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE 2", tag.String())
+
+	var name string
+	var price float64
+	err = conn.QueryRow(ctx, "SELECT name, price FROM items WHERE id = ?;", 3).Scan(&name, &price)
+	require.NoError(t, err)
+	assert.Equal(t, "it's; DROP TABLE items;", name)
+	assert.Equal(t, 1.0, price)
+
+	rows, err := conn.Query(ctx, "SELECT id FROM items WHERE price > $1 OR name IS NULL ORDER BY id;", 1.0)
+	require.NoError(t, err)
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, ids)
+
+	_, err = conn.Exec(ctx, "insert_item", 1, "ink", 2.0, false)
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr), "%v", err)
+	assert.Equal(t, "23505", pgErr.Code)
+}
+
+func TestServerAuthentication(t *testing.T) {
+	methods := []string{AUTH_PASSWORD, AUTH_MD5, AUTH_SCRAM}
+	for _, method := range methods {
+		t.Run(method, func(t *testing.T) {
+			address := setupTestServer(t, method,
+				"CREATE USER alice WITH PASSWORD 's3cret';",
+				"CREATE USER bob;",
+			)
+			ctx := context.Background()
+
+			config, err := pgx.ParseConfig(strings.Replace(address, "foxdb@", "alice:s3cret@", 1))
+			require.NoError(t, err)
+			conn, err := pgx.ConnectConfig(ctx, config)
+			require.NoError(t, err)
+			_, err = conn.Exec(ctx, "CREATE SCHEMA app;")
+			require.NoError(t, err)
+			conn.Close(ctx)
+
+			failures := []string{
+				"alice:wrong@", // wrong password
+				"bob:s3cret@",  // user without password
+				"eve:s3cret@",  // unknown user
+			}
+			for _, credentials := range failures {
+				config, err := pgx.ParseConfig(strings.Replace(address, "foxdb@", credentials, 1))
+				require.NoError(t, err)
+				_, err = pgx.ConnectConfig(ctx, config)
+				var pgErr *pgconn.PgError
+				require.True(t, errors.As(err, &pgErr), "%s: %v", credentials, err)
+				assert.Equal(t, "28P01", pgErr.Code, credentials)
+			}
+		})
+	}
+}
+
+func TestServerUserStatements(t *testing.T) {
+	address := setupTestServer(t, AUTH_SCRAM, "CREATE USER admin WITH PASSWORD 'admin';")
+	conn := connect(t, strings.Replace(address, "foxdb@", "admin:admin@", 1))
+	ctx := context.Background()
+
+	tests := []struct {
+		sql string
+		tag string
+	}{
+		{"CREATE USER carol WITH PASSWORD 'first';", "CREATE ROLE"},
+		{"ALTER USER carol WITH PASSWORD 'second';", "ALTER ROLE"},
+	}
+	for _, tt := range tests {
+		tag, err := conn.Exec(ctx, tt.sql)
+		require.NoError(t, err, tt.sql)
+		assert.Equal(t, tt.tag, tag.String(), tt.sql)
+	}
+
+	carol := strings.Replace(address, "foxdb@", "carol:second@", 1)
+	connect(t, carol)
+
+	_, err := conn.Exec(ctx, "CREATE USER carol;")
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr), "%v", err)
+	assert.Equal(t, "42710", pgErr.Code)
+
+	tag, err := conn.Exec(ctx, "DROP USER carol;")
+	require.NoError(t, err)
+	assert.Equal(t, "DROP ROLE", tag.String())
+
+	_, err = pgx.Connect(ctx, carol)
+	require.True(t, errors.As(err, &pgErr), "%v", err)
+	assert.Equal(t, "28P01", pgErr.Code)
+}
+
+func TestServerSearchPath(t *testing.T) {
+	address := startTestServer(t, serverOptions{authMethod: AUTH_TRUST},
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.items (name TEXT);",
+		`INSERT INTO app.items VALUES ("pen");`,
+	)
+	ctx := context.Background()
+
+	// the database of the connection is the schema searched first
+	conn := connectWithMode(t, "postgres://foxdb@"+address+"/app?sslmode=disable", pgx.QueryExecModeCacheStatement)
+	var searchPath, name string
+	require.NoError(t, conn.QueryRow(ctx, "SHOW search_path;").Scan(&searchPath))
+	assert.Equal(t, "app, public", searchPath)
+	require.NoError(t, conn.QueryRow(ctx, "SELECT name FROM items;").Scan(&name))
+	assert.Equal(t, "pen", name)
+
+	tag, err := conn.Exec(ctx, "SET search_path = public;")
+	require.NoError(t, err)
+	assert.Equal(t, "SET", tag.String())
+	_, err = conn.Exec(ctx, "CREATE TABLE items (name TEXT);")
+	require.NoError(t, err)
+	rows, err := conn.Query(ctx, "SELECT name FROM items;")
+	require.NoError(t, err)
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	// other connections keep their own search path
+	other := connect(t, "postgres://foxdb@"+address+"/app?sslmode=disable")
+	require.NoError(t, other.QueryRow(ctx, "SELECT name FROM items;").Scan(&name))
+	assert.Equal(t, "pen", name)
+}
+
+func TestServerSettings(t *testing.T) {
+	address := startTestServer(t, serverOptions{authMethod: AUTH_TRUST})
+	ctx := context.Background()
+
+	config, err := pgx.ParseConfig("postgres://foxdb@" + address + "/foxdb?sslmode=disable&application_name=foxtest")
+	require.NoError(t, err)
+	conn, err := pgx.ConnectConfig(ctx, config)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close(ctx) })
+
+	// settings are reported when the session starts and whenever they change
+	pgConn := conn.PgConn()
+	assert.Equal(t, "foxtest", pgConn.ParameterStatus("application_name"))
+	assert.Equal(t, "ISO, MDY", pgConn.ParameterStatus("DateStyle"))
+	assert.Equal(t, "UTC", pgConn.ParameterStatus("TimeZone"))
+
+	tag, err := conn.Exec(ctx, "SET datestyle = 'German';")
+	require.NoError(t, err)
+	assert.Equal(t, "SET", tag.String())
+	assert.Equal(t, "German, MDY", pgConn.ParameterStatus("DateStyle"))
+
+	var value string
+	require.NoError(t, conn.QueryRow(ctx, "SHOW DateStyle;").Scan(&value))
+	assert.Equal(t, "German, MDY", value)
+
+	tag, err = conn.Exec(ctx, "RESET ALL;")
+	require.NoError(t, err)
+	assert.Equal(t, "RESET", tag.String())
+	assert.Equal(t, "ISO, MDY", pgConn.ParameterStatus("DateStyle"))
+	assert.Equal(t, "", pgConn.ParameterStatus("application_name"))
+
+	// rolling back a transaction restores the settings it changed
+	tx, err := conn.Begin(ctx)
+	require.NoError(t, err)
+	_, err = tx.Exec(ctx, "SET datestyle = 'German';")
+	require.NoError(t, err)
+	assert.Equal(t, "German, MDY", pgConn.ParameterStatus("DateStyle"))
+	require.NoError(t, tx.Rollback(ctx))
+	assert.Equal(t, "ISO, MDY", pgConn.ParameterStatus("DateStyle"))
+
+	rows, err := conn.Query(ctx, "SHOW ALL;")
+	require.NoError(t, err)
+	names, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (string, error) {
+		var name, setting, description string
+		err := row.Scan(&name, &setting, &description)
+		return name, err
+	})
+	require.NoError(t, err)
+	assert.Contains(t, names, "statement_timeout")
+
+	_, err = conn.Exec(ctx, "SET TimeZone = 'Nowhere/Atlantis';")
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr), "unexpected error: %v", err)
+	assert.Equal(t, "22023", pgErr.Code)
+}
+
+func TestServerTransactions(t *testing.T) {
+	connString := setupTestServer(t, AUTH_TRUST,
+		"CREATE TABLE accounts (id INT PRIMARY KEY, balance INT NOT NULL);",
+		"INSERT INTO accounts VALUES (1, 100), (2, 50);",
+	)
+	conn := connect(t, connString)
+	other := connect(t, connString)
+	ctx := context.Background()
+	countRows := func(conn *pgx.Conn) int {
+		var count int
+		rows, err := conn.Query(ctx, "SELECT id FROM accounts;")
+		require.NoError(t, err)
+		for rows.Next() {
+			count++
+		}
+		require.NoError(t, rows.Err())
+		return count
+	}
+
+	// the transaction status is reported in ReadyForQuery
+	tx, err := conn.Begin(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, byte('T'), conn.PgConn().TxStatus())
+	_, err = tx.Exec(ctx, "INSERT INTO accounts VALUES (3, 10);")
+	require.NoError(t, err)
+	assert.Equal(t, 2, countRows(other))
+	require.NoError(t, tx.Commit(ctx))
+	assert.Equal(t, byte('I'), conn.PgConn().TxStatus())
+	assert.Equal(t, 3, countRows(other))
+
+	tx, err = conn.Begin(ctx)
+	require.NoError(t, err)
+	_, err = tx.Exec(ctx, "DELETE FROM accounts;")
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback(ctx))
+	assert.Equal(t, 3, countRows(conn))
+
+	// committing a failed transaction rolls it back
+	tx, err = conn.Begin(ctx)
+	require.NoError(t, err)
+	_, err = tx.Exec(ctx, "INSERT INTO accounts VALUES (4, 10);")
+	require.NoError(t, err)
+	_, err = tx.Exec(ctx, "INSERT INTO accounts VALUES (1, 10);")
+	require.Error(t, err)
+	assert.Equal(t, byte('E'), conn.PgConn().TxStatus())
+	_, err = tx.Exec(ctx, "SELECT id FROM accounts;")
+	var pgErr *pgconn.PgError
+	require.True(t, errors.As(err, &pgErr), "unexpected error: %v", err)
+	assert.Equal(t, "25P02", pgErr.Code)
+	assert.ErrorIs(t, tx.Commit(ctx), pgx.ErrTxCommitRollback)
+	assert.Equal(t, 3, countRows(conn))
+
+	// concurrent updates of a row fail to commit
+	tx, err = conn.Begin(ctx)
+	require.NoError(t, err)
+	otherTx, err := other.Begin(ctx)
+	require.NoError(t, err)
+	_, err = tx.Exec(ctx, "UPDATE accounts SET balance = 0 WHERE id = 1;")
+	require.NoError(t, err)
+	_, err = otherTx.Exec(ctx, "UPDATE accounts SET balance = 1 WHERE id = 1;")
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit(ctx))
+	err = otherTx.Commit(ctx)
+	require.True(t, errors.As(err, &pgErr), "unexpected error: %v", err)
+	assert.Equal(t, "40001", pgErr.Code)
+	assert.Equal(t, byte('I'), other.PgConn().TxStatus())
+}
+
+func TestServerSavepoints(t *testing.T) {
+	connString := setupTestServer(t, AUTH_TRUST,
+		"CREATE TABLE items (id INT PRIMARY KEY);",
+	)
+	conn := connect(t, connString)
+	ctx := context.Background()
+
+	tx, err := conn.Begin(ctx)
+	require.NoError(t, err)
+	_, err = tx.Exec(ctx, "INSERT INTO items VALUES (1);")
+	require.NoError(t, err)
+
+	// pgx nests transactions with savepoints
+	nested, err := tx.Begin(ctx)
+	require.NoError(t, err)
+	_, err = nested.Exec(ctx, "INSERT INTO items VALUES (1);")
+	require.Error(t, err)
+	require.NoError(t, nested.Rollback(ctx))
+	assert.Equal(t, byte('T'), conn.PgConn().TxStatus())
+
+	nested, err = tx.Begin(ctx)
+	require.NoError(t, err)
+	_, err = nested.Exec(ctx, "INSERT INTO items VALUES (2);")
+	require.NoError(t, err)
+	require.NoError(t, nested.Commit(ctx))
+	require.NoError(t, tx.Commit(ctx))
+
+	var count int
+	rows, err := conn.Query(ctx, "SELECT id FROM items;")
+	require.NoError(t, err)
+	for rows.Next() {
+		count++
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, 2, count)
+
+	tags := []struct {
+		sql string
+		tag string
+	}{
+		{"BEGIN;", "BEGIN"},
+		{"SAVEPOINT sp;", "SAVEPOINT"},
+		{"ROLLBACK TO SAVEPOINT sp;", "ROLLBACK"},
+		{"RELEASE SAVEPOINT sp;", "RELEASE"},
+		{"COMMIT;", "COMMIT"},
+	}
+	for _, tt := range tags {
+		tag, err := conn.Exec(ctx, tt.sql)
+		require.NoError(t, err, tt.sql)
+		assert.Equal(t, tt.tag, tag.String(), tt.sql)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// tlsOptions are the TLS settings of the server, connections stay in
+// plain text when no certificate is given.
+type tlsOptions struct {
+	certFile     string
+	keyFile      string
+	clientCaFile string
+	// reject clients that do not ask for SSL
+	require bool
+}
+
+func (o tlsOptions) enabled() bool {
+	return o.certFile != "" || o.keyFile != ""
+}
+
+// verifying client certificates requires TLS too
+func (o tlsOptions) required() bool {
+	return o.require || o.clientCaFile != ""
+}
+
+// newTlsConfig loads the certificate of the server, client certificates
+// are verified against the clientCaFile bundle when there is one.
+func newTlsConfig(options tlsOptions) (*tls.Config, error) {
+	if !options.enabled() {
+		if options.required() {
+			return nil, fmt.Errorf("requiring TLS needs a server certificate and key")
+		}
+		return nil, nil
+	}
+	if options.certFile == "" || options.keyFile == "" {
+		return nil, fmt.Errorf("both a TLS certificate and key are needed")
+	}
+
+	certificate, err := tls.LoadX509KeyPair(options.certFile, options.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS certificate: %w", err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if options.clientCaFile == "" {
+		return config, nil
+	}
+
+	caBundle, err := os.ReadFile(options.clientCaFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS client CA: %w", err)
+	}
+	config.ClientCAs = x509.NewCertPool()
+	if !config.ClientCAs.AppendCertsFromPEM(caBundle) {
+		return nil, fmt.Errorf("no certificate found in TLS client CA %s", options.clientCaFile)
+	}
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	return config, nil
+}
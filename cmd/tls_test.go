@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCertificates struct {
+	caFile         string
+	serverCertFile string
+	serverKeyFile  string
+	clientCertFile string
+	clientKeyFile  string
+}
+
+// writeTestCertificates generates a self-signed CA with a server certificate
+// for 127.0.0.1 and a client certificate, both signed by it.
+func writeTestCertificates(t *testing.T) testCertificates {
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "foxdb test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDer)
+	require.NoError(t, err)
+
+	issue := func(name string, template *x509.Certificate) (string, string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		keyDer, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+
+		certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+		writePem(t, certFile, "CERTIFICATE", der)
+		writePem(t, keyFile, "EC PRIVATE KEY", keyDer)
+		return certFile, keyFile
+	}
+
+	certificates := testCertificates{caFile: filepath.Join(dir, "ca.crt")}
+	writePem(t, certificates.caFile, "CERTIFICATE", caDer)
+	certificates.serverCertFile, certificates.serverKeyFile = issue("server", &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	certificates.clientCertFile, certificates.clientKeyFile = issue("client", &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "foxdb"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return certificates
+}
+
+func writePem(t *testing.T, path string, blockType string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+}
+
+func TestServerTLS(t *testing.T) {
+	certificates := writeTestCertificates(t)
+	serverTls := tlsOptions{certFile: certificates.serverCertFile, keyFile: certificates.serverKeyFile}
+	verifyFull := "sslmode=verify-full&sslrootcert=" + certificates.caFile
+	clientCert := "&sslcert=" + certificates.clientCertFile + "&sslkey=" + certificates.clientKeyFile
+
+	requiredTls := serverTls
+	requiredTls.require = true
+	clientCaTls := serverTls
+	clientCaTls.clientCaFile = certificates.caFile
+
+	tests := []struct {
+		name    string
+		tls     tlsOptions
+		params  string
+		secure  bool
+		fails   bool
+		errCode string
+	}{
+		{"plain text without TLS", tlsOptions{}, "sslmode=disable", false, false, ""},
+		{"plain text when TLS is optional", serverTls, "sslmode=disable", false, false, ""},
+		{"TLS when it is optional", serverTls, verifyFull, true, false, ""},
+		{"plain text when TLS is required", requiredTls, "sslmode=disable", false, true, "28000"},
+		{"TLS when it is required", requiredTls, "sslmode=require", true, false, ""},
+		{"TLS without a client certificate", clientCaTls, verifyFull, false, true, ""},
+		{"TLS with a client certificate", clientCaTls, verifyFull + clientCert, true, false, ""},
+		{"plain text with a client CA", clientCaTls, "sslmode=disable", false, true, "28000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address := startTestServer(t, serverOptions{authMethod: AUTH_TRUST, tls: tt.tls})
+			conn, err := pgx.Connect(context.Background(), "postgres://foxdb@"+address+"/foxdb?"+tt.params)
+			if tt.fails {
+				require.Error(t, err)
+				var pgErr *pgconn.PgError
+				// a missing client certificate fails the TLS handshake itself
+				if tt.errCode != "" {
+					require.True(t, errors.As(err, &pgErr), "unexpected error: %v", err)
+					assert.Equal(t, tt.errCode, pgErr.Code)
+				}
+				return
+			}
+			require.NoError(t, err)
+			defer conn.Close(context.Background())
+
+			_, isTls := conn.PgConn().Conn().(*tls.Conn)
+			assert.Equal(t, tt.secure, isTls)
+			_, err = conn.Exec(context.Background(), "CREATE SCHEMA app;")
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNewTlsConfig(t *testing.T) {
+	certificates := writeTestCertificates(t)
+
+	config, err := newTlsConfig(tlsOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, config)
+
+	config, err = newTlsConfig(tlsOptions{
+		certFile:     certificates.serverCertFile,
+		keyFile:      certificates.serverKeyFile,
+		clientCaFile: certificates.caFile,
+	})
+	require.NoError(t, err)
+	assert.Len(t, config.Certificates, 1)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+
+	for _, options := range []tlsOptions{
+		{require: true},
+		{clientCaFile: certificates.caFile},
+		{certFile: certificates.serverCertFile},
+		{certFile: certificates.serverCertFile, keyFile: certificates.clientKeyFile},
+		{certFile: certificates.serverCertFile, keyFile: certificates.serverKeyFile, clientCaFile: certificates.serverKeyFile},
+	} {
+		_, err := newTlsConfig(options)
+		assert.Error(t, err, "%+v", options)
+	}
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialsVerifyPassword(t *testing.T) {
+	credentials, err := NewCredentials("alice", "secret")
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(credentials.Scram, "SCRAM-SHA-256$4096:"))
+	assert.NotContains(t, credentials.Scram, "secret")
+	assert.True(t, credentials.VerifyPassword("secret"))
+	assert.False(t, credentials.VerifyPassword("Secret"))
+	assert.False(t, credentials.VerifyPassword(""))
+
+	// the salt is random, hashing twice gives two verifiers
+	other, err := NewCredentials("alice", "secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, credentials.Scram, other.Scram)
+}
+
+func TestCredentialsVerifyMd5(t *testing.T) {
+	credentials, err := NewCredentials("alice", "secret")
+	require.NoError(t, err)
+
+	inner := md5.Sum([]byte("secretalice"))
+	assert.Equal(t, "md5"+hex.EncodeToString(inner[:]), credentials.Md5)
+
+	salt := []byte{1, 2, 3, 4}
+	outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), salt...))
+	response := "md5" + hex.EncodeToString(outer[:])
+	assert.True(t, credentials.VerifyMd5(salt, response))
+	assert.False(t, credentials.VerifyMd5([]byte{4, 3, 2, 1}, response))
+}
+
+// test vector of RFC 7677 section 3
+func TestScramExchange(t *testing.T) {
+	salt, err := base64.StdEncoding.DecodeString("W22ZaJ0SNY7soEsUEjb6gQ==")
+	require.NoError(t, err)
+	credentials := &Credentials{Scram: newScramVerifier("pencil", salt, 4096).String()}
+
+	exchange, err := NewScramExchange(credentials)
+	require.NoError(t, err)
+
+	serverFirst, err := exchange.ServerFirst("n,,n=user,r=rOprNGfwEbeRWgbNEkqO")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(serverFirst, "r=rOprNGfwEbeRWgbNEkqO"))
+	assert.True(t, strings.HasSuffix(serverFirst, ",s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"))
+
+	// replay the server nonce of the RFC instead of the random one
+	exchange.nonce = "rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0"
+	exchange.serverFirst = "r=" + exchange.nonce + ",s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"
+
+	serverFinal, err := exchange.ServerFinal("c=biws,r=" + exchange.nonce + ",p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=")
+	require.NoError(t, err)
+	assert.Equal(t, "v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4=", serverFinal)
+}
+
+func TestScramExchangeErrors(t *testing.T) {
+	credentials, err := NewCredentials("alice", "secret")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		clientFirst string
+		clientFinal func(nonce string) string
+	}{
+		{"channel binding required", "p=tls-server-end-point,,n=,r=abc", nil},
+		{"missing nonce", "n,,n=alice", nil},
+		{"wrong proof", "n,,n=,r=abc", func(nonce string) string {
+			return "c=biws,r=" + nonce + ",p=" + base64.StdEncoding.EncodeToString(make([]byte, 32))
+		}},
+		{"wrong nonce", "n,,n=,r=abc", func(nonce string) string {
+			return "c=biws,r=abc,p=" + base64.StdEncoding.EncodeToString(make([]byte, 32))
+		}},
+		{"wrong channel binding", "n,,n=,r=abc", func(nonce string) string {
+			return "c=eSws,r=" + nonce + ",p=" + base64.StdEncoding.EncodeToString(make([]byte, 32))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exchange, err := NewScramExchange(credentials)
+			require.NoError(t, err)
+
+			_, err = exchange.ServerFirst(tt.clientFirst)
+			if tt.clientFinal == nil {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			_, err = exchange.ServerFinal(tt.clientFinal(exchange.nonce))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMockScramExchange(t *testing.T) {
+	exchange := NewMockScramExchange("eve")
+	serverFirst, err := exchange.ServerFirst("n,,n=eve,r=abc")
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(serverFirst, ",i=4096"))
+
+	// the salt of a user name does not change between attempts
+	salt, _ := scramAttribute(serverFirst, 's')
+	other, err := NewMockScramExchange("eve").ServerFirst("n,,n=eve,r=abc")
+	require.NoError(t, err)
+	otherSalt, _ := scramAttribute(other, 's')
+	assert.Equal(t, salt, otherSalt)
+	other, err = NewMockScramExchange("mallory").ServerFirst("n,,n=mallory,r=abc")
+	require.NoError(t, err)
+	otherSalt, _ = scramAttribute(other, 's')
+	assert.NotEqual(t, salt, otherSalt)
+
+	// the proof is only rejected with the client-final-message
+	_, err = exchange.ServerFinal("c=biws,r=" + exchange.nonce + ",p=" + base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	assert.ErrorIs(t, err, ErrInvalidProof)
+}
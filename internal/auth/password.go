@@ -0,0 +1,125 @@
+// Package auth hashes user passwords and verifies the credentials
+// clients send with the PostgreSQL authentication methods.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	SCRAM_ITERATIONS = 4096
+	SCRAM_SALT_SIZE  = 16
+)
+
+// Credentials are the forms a password is stored in, it is never kept in
+// clear. Scram is a SCRAM-SHA-256 verifier in the format Postgres uses:
+// SCRAM-SHA-256$<iterations>:<salt>$<StoredKey>:<ServerKey>, Md5 is the
+// "md5" prefixed hex digest of the password followed by the user name.
+type Credentials struct {
+	Scram string `json:"scram"`
+	Md5   string `json:"md5"`
+}
+
+func NewCredentials(userName string, password string) (*Credentials, error) {
+	salt := make([]byte, SCRAM_SALT_SIZE)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return &Credentials{
+		Scram: newScramVerifier(password, salt, SCRAM_ITERATIONS).String(),
+		Md5:   md5Hash(password + userName),
+	}, nil
+}
+
+// VerifyPassword checks a password received in clear
+func (c *Credentials) VerifyPassword(password string) bool {
+	verifier, err := parseScramVerifier(c.Scram)
+	if err != nil {
+		return false
+	}
+	computed := newScramVerifier(password, verifier.salt, verifier.iterations)
+	return subtle.ConstantTimeCompare(computed.storedKey, verifier.storedKey) == 1
+}
+
+// VerifyMd5 checks the response to an MD5 challenge made with salt, it is
+// "md5" followed by the hex digest of the stored hash (without its prefix)
+// and the salt.
+func (c *Credentials) VerifyMd5(salt []byte, response string) bool {
+	expected := md5Hash(strings.TrimPrefix(c.Md5, "md5") + string(salt))
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(response)) == 1
+}
+
+func md5Hash(data string) string {
+	sum := md5.Sum([]byte(data))
+	return "md5" + hex.EncodeToString(sum[:])
+}
+
+type scramVerifier struct {
+	iterations int
+	salt       []byte
+	storedKey  []byte
+	serverKey  []byte
+}
+
+// NOTE: the password is used as is, without the SASLprep normalization
+// RFC 7677 asks for, so non ASCII passwords only work with clients that
+// send them the same way.
+func newScramVerifier(password string, salt []byte, iterations int) *scramVerifier {
+	saltedPassword := pbkdf2.Key([]byte(password), salt, iterations, sha256.Size, sha256.New)
+	clientKey := hmacSha256(saltedPassword, []byte("Client Key"))
+	storedKey := sha256.Sum256(clientKey)
+	return &scramVerifier{
+		iterations: iterations,
+		salt:       salt,
+		storedKey:  storedKey[:],
+		serverKey:  hmacSha256(saltedPassword, []byte("Server Key")),
+	}
+}
+
+func (v *scramVerifier) String() string {
+	encoding := base64.StdEncoding
+	return fmt.Sprintf("SCRAM-SHA-256$%d:%s$%s:%s", v.iterations,
+		encoding.EncodeToString(v.salt), encoding.EncodeToString(v.storedKey), encoding.EncodeToString(v.serverKey))
+}
+
+func parseScramVerifier(encoded string) (*scramVerifier, error) {
+	method, rest, _ := strings.Cut(encoded, "$")
+	params, keys, _ := strings.Cut(rest, "$")
+	iterations, salt, _ := strings.Cut(params, ":")
+	storedKey, serverKey, _ := strings.Cut(keys, ":")
+	if method != "SCRAM-SHA-256" {
+		return nil, fmt.Errorf("invalid SCRAM verifier")
+	}
+
+	verifier := &scramVerifier{}
+	var err error
+	if verifier.iterations, err = strconv.Atoi(iterations); err != nil {
+		return nil, fmt.Errorf("invalid SCRAM verifier: %w", err)
+	}
+	for _, field := range []struct {
+		dst     *[]byte
+		encoded string
+	}{{&verifier.salt, salt}, {&verifier.storedKey, storedKey}, {&verifier.serverKey, serverKey}} {
+		if *field.dst, err = base64.StdEncoding.DecodeString(field.encoded); err != nil {
+			return nil, fmt.Errorf("invalid SCRAM verifier: %w", err)
+		}
+	}
+	return verifier, nil
+}
+
+func hmacSha256(key []byte, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const SCRAM_SHA_256 = "SCRAM-SHA-256"
+
+var ErrInvalidProof = errors.New("invalid SCRAM proof")
+
+// ScramExchange is the server side of a SCRAM-SHA-256 authentication
+// (RFC 5802, RFC 7677) without channel binding.
+type ScramExchange struct {
+	verifier        *scramVerifier
+	gs2Header       string
+	clientFirstBare string
+	serverFirst     string
+	nonce           string
+	// the exchange stands for a user that cannot authenticate with SCRAM
+	mock bool
+}
+
+func NewScramExchange(credentials *Credentials) (*ScramExchange, error) {
+	verifier, err := parseScramVerifier(credentials.Scram)
+	if err != nil {
+		return nil, err
+	}
+	return &ScramExchange{verifier: verifier}, nil
+}
+
+// mockScramSecret keys the salts of mock exchanges, it is drawn once per
+// process so that a user name gets the same salt on every attempt.
+var mockScramSecret = func() []byte {
+	secret := make([]byte, sha256.Size)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return secret
+}()
+
+// NewMockScramExchange returns the exchange of a user that does not exist
+// or has no password. It sends the same messages as a real exchange, with
+// a salt derived from the user name, and rejects the client proof so that
+// clients cannot tell which users exist.
+func NewMockScramExchange(userName string) *ScramExchange {
+	salt := hmacSha256(mockScramSecret, []byte(userName))[:SCRAM_SALT_SIZE]
+	verifier := &scramVerifier{
+		iterations: SCRAM_ITERATIONS,
+		salt:       salt,
+		storedKey:  make([]byte, sha256.Size),
+		serverKey:  make([]byte, sha256.Size),
+	}
+	return &ScramExchange{verifier: verifier, mock: true}
+}
+
+// ServerFirst reads the client-first-message and returns the
+// server-first-message carrying the salt and the iteration count.
+func (e *ScramExchange) ServerFirst(clientFirst string) (string, error) {
+	parts := strings.SplitN(clientFirst, ",", 3)
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed SCRAM client-first-message")
+	}
+	// n: the client does not support channel binding, y: it does
+	// but thinks the server does not
+	if parts[0] != "n" && parts[0] != "y" {
+		return "", fmt.Errorf("SCRAM channel binding is not supported")
+	}
+	e.gs2Header = parts[0] + "," + parts[1] + ","
+	e.clientFirstBare = parts[2]
+
+	// the user name attribute is ignored, the one of the startup message is used
+	clientNonce, ok := scramAttribute(e.clientFirstBare, 'r')
+	if !ok || clientNonce == "" {
+		return "", fmt.Errorf("malformed SCRAM client-first-message")
+	}
+
+	serverNonce := make([]byte, 18)
+	if _, err := rand.Read(serverNonce); err != nil {
+		return "", err
+	}
+	e.nonce = clientNonce + base64.RawStdEncoding.EncodeToString(serverNonce)
+	e.serverFirst = fmt.Sprintf("r=%s,s=%s,i=%d", e.nonce, base64.StdEncoding.EncodeToString(e.verifier.salt), e.verifier.iterations)
+	return e.serverFirst, nil
+}
+
+// ServerFinal checks the proof of the client-final-message and returns
+// the server-final-message with which the client authenticates the server.
+func (e *ScramExchange) ServerFinal(clientFinal string) (string, error) {
+	withoutProof, proofAttribute, ok := strings.Cut(clientFinal, ",p=")
+	if !ok {
+		return "", fmt.Errorf("malformed SCRAM client-final-message")
+	}
+
+	channelBinding, _ := scramAttribute(withoutProof, 'c')
+	if channelBinding != base64.StdEncoding.EncodeToString([]byte(e.gs2Header)) {
+		return "", fmt.Errorf("SCRAM channel binding does not match")
+	}
+	if nonce, _ := scramAttribute(withoutProof, 'r'); nonce != e.nonce {
+		return "", fmt.Errorf("SCRAM nonce does not match")
+	}
+
+	proof, err := base64.StdEncoding.DecodeString(proofAttribute)
+	if err != nil || len(proof) != sha256.Size {
+		return "", fmt.Errorf("malformed SCRAM client proof")
+	}
+
+	authMessage := []byte(e.clientFirstBare + "," + e.serverFirst + "," + withoutProof)
+	clientSignature := hmacSha256(e.verifier.storedKey, authMessage)
+	clientKey := make([]byte, len(proof))
+	for i := range proof {
+		clientKey[i] = proof[i] ^ clientSignature[i]
+	}
+	storedKey := sha256.Sum256(clientKey)
+	if subtle.ConstantTimeCompare(storedKey[:], e.verifier.storedKey) != 1 || e.mock {
+		return "", ErrInvalidProof
+	}
+
+	serverSignature := hmacSha256(e.verifier.serverKey, authMessage)
+	return "v=" + base64.StdEncoding.EncodeToString(serverSignature), nil
+}
+
+// scramAttribute returns the value of the `name=value` attribute of message
+func scramAttribute(message string, name byte) (string, bool) {
+	for _, attribute := range strings.Split(message, ",") {
+		if len(attribute) >= 2 && attribute[0] == name && attribute[1] == '=' {
+			return attribute[2:], true
+		}
+	}
+	return "", false
+}
@@ -16,10 +16,13 @@ type Constraint struct {
 }
 
 type Column struct {
-	id          ObjectId
-	name        string
-	dataType    types.DataType
-	constraints Constraint
+	id           ObjectId
+	ordinal      uint32
+	name         string
+	dataType     types.DataType
+	constraints  Constraint
+	defaultValue types.Value
+	missingValue types.Value
 }
 
 func NewColumn(id ObjectId, name string, dataType types.DataType, constraints Constraint) *Column {
@@ -31,6 +34,11 @@ func NewColumn(id ObjectId, name string, dataType types.DataType, constraints Co
 	}
 }
 
+func (c *Column) Clone() *Column {
+	clone := *c
+	return &clone
+}
+
 func (c *Column) GetName() string {
 	return c.name
 }
@@ -38,3 +46,39 @@ func (c *Column) GetName() string {
 func (c *Column) GetDataType() types.DataType {
 	return c.dataType
 }
+
+func (c *Column) GetId() ObjectId {
+	return c.id
+}
+
+// position of the column in its table, starting at 1
+func (c *Column) GetOrdinal() uint32 {
+	return c.ordinal
+}
+
+func (c *Column) GetConstraints() Constraint {
+	return c.constraints
+}
+
+func (c *Column) SetConstraints(constraints Constraint) {
+	c.constraints = constraints
+}
+
+// the value inserted when the column is omitted, NULL unless set
+func (c *Column) GetDefault() types.Value {
+	return c.defaultValue
+}
+
+func (c *Column) SetDefault(value types.Value) {
+	c.defaultValue = value
+}
+
+// The value of the column for the records written before it was added.
+// Unlike the default it never changes once the column exists.
+func (c *Column) GetMissingValue() types.Value {
+	return c.missingValue
+}
+
+func (c *Column) SetMissingValue(value types.Value) {
+	c.missingValue = value
+}
@@ -1,5 +1,7 @@
 package catalog
 
+import "slices"
+
 type Index struct {
 	id        ObjectId
 	name      string
@@ -16,6 +18,14 @@ func NewIndex(id ObjectId, name string, columnIds []ObjectId, unique bool) *Inde
 	}
 }
 
+func (idx *Index) Clone() *Index {
+	return NewIndex(idx.id, idx.name, slices.Clone(idx.columnIds), idx.unique)
+}
+
+func (idx *Index) GetId() ObjectId {
+	return idx.id
+}
+
 func (idx *Index) GetName() string {
 	return idx.name
 }
@@ -0,0 +1,276 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/evanxg852000/foxdb/internal/auth"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// The catalog objects keep their fields unexported, the json
+// representation goes through the following snapshot structs.
+
+type rootCatalogSnapshot struct {
+	NextObjectId uint32    `json:"next_object_id"`
+	Schemas      []*Schema `json:"schemas"`
+	Users        []*User   `json:"users,omitempty"`
+}
+
+type userSnapshot struct {
+	Id          ObjectId          `json:"id"`
+	Name        string            `json:"name"`
+	Credentials *auth.Credentials `json:"credentials,omitempty"`
+}
+
+type schemaSnapshot struct {
+	Id           ObjectId `json:"id"`
+	Name         string   `json:"name"`
+	NextObjectId uint32   `json:"next_object_id"`
+	Tables       []*Table `json:"tables"`
+}
+
+type tableSnapshot struct {
+	Id           ObjectId   `json:"id"`
+	Name         string     `json:"name"`
+	NextObjectId uint32     `json:"next_object_id"`
+	Columns      []*Column  `json:"columns"`
+	Indexes      []*Index   `json:"indexes"`
+	PrimaryKeys  []ObjectId `json:"primary_keys"`
+}
+
+type columnSnapshot struct {
+	Id          ObjectId       `json:"id"`
+	Ordinal     uint32         `json:"ordinal"`
+	Name        string         `json:"name"`
+	DataType    types.DataType `json:"data_type"`
+	Constraints Constraint     `json:"constraints"`
+	Default     []byte         `json:"default,omitempty"`
+	Missing     []byte         `json:"missing,omitempty"`
+}
+
+type indexSnapshot struct {
+	Id        ObjectId   `json:"id"`
+	Name      string     `json:"name"`
+	ColumnIds []ObjectId `json:"column_ids"`
+	Unique    bool       `json:"unique"`
+}
+
+// the caller must hold the catalog lock
+func (rc *RootCatalog) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rootCatalogSnapshot{
+		NextObjectId: rc.nextObjectId.Load(),
+		Schemas:      rc.ListSchemas(),
+		Users:        rc.ListUsers(),
+	})
+}
+
+func (rc *RootCatalog) UnmarshalJSON(data []byte) error {
+	var snapshot rootCatalogSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	rc.schemaNames = make(map[string]ObjectId, len(snapshot.Schemas))
+	rc.schemas = make(map[ObjectId]*Schema, len(snapshot.Schemas))
+	for _, schema := range snapshot.Schemas {
+		if _, exists := rc.schemaNames[schema.name]; exists {
+			return fmt.Errorf("corrupted catalog: duplicate schema %s", schema.name)
+		}
+		rc.schemaNames[schema.name] = schema.id
+		rc.schemas[schema.id] = schema
+	}
+
+	rc.users = make(map[string]*User, len(snapshot.Users))
+	for _, user := range snapshot.Users {
+		if _, exists := rc.users[user.name]; exists {
+			return fmt.Errorf("corrupted catalog: duplicate user %s", user.name)
+		}
+		rc.users[user.name] = user
+	}
+	rc.nextObjectId.Store(snapshot.NextObjectId)
+	return nil
+}
+
+func (u *User) MarshalJSON() ([]byte, error) {
+	return json.Marshal(userSnapshot{
+		Id:          u.id,
+		Name:        u.name,
+		Credentials: u.credentials,
+	})
+}
+
+func (u *User) UnmarshalJSON(data []byte) error {
+	var snapshot userSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+	u.id = snapshot.Id
+	u.name = snapshot.Name
+	u.credentials = snapshot.Credentials
+	return nil
+}
+
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(schemaSnapshot{
+		Id:           s.id,
+		Name:         s.name,
+		NextObjectId: s.nextObjectId.Load(),
+		Tables:       s.ListTables(),
+	})
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	var snapshot schemaSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	*s = Schema{
+		id:         snapshot.Id,
+		name:       snapshot.Name,
+		tableNames: make(map[string]ObjectId, len(snapshot.Tables)),
+		tables:     make(map[ObjectId]*Table, len(snapshot.Tables)),
+	}
+	for _, table := range snapshot.Tables {
+		if _, exists := s.tableNames[table.name]; exists {
+			return fmt.Errorf("corrupted catalog: duplicate table %s", table.name)
+		}
+		s.tableNames[table.name] = table.id
+		s.tables[table.id] = table
+	}
+	s.nextObjectId.Store(snapshot.NextObjectId)
+	return nil
+}
+
+func (t *Table) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tableSnapshot{
+		Id:           t.id,
+		Name:         t.name,
+		NextObjectId: t.nextObjectId.Load(),
+		Columns:      t.ListColumns(),
+		Indexes:      t.ListIndexes(),
+		PrimaryKeys:  t.primaryKeys,
+	})
+}
+
+func (t *Table) UnmarshalJSON(data []byte) error {
+	var snapshot tableSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	*t = Table{
+		id:          snapshot.Id,
+		name:        snapshot.Name,
+		columnNames: make(map[string]ObjectId, len(snapshot.Columns)),
+		columns:     make(map[ObjectId]*Column, len(snapshot.Columns)),
+		indexNames:  make(map[string]ObjectId, len(snapshot.Indexes)),
+		indexes:     make(map[ObjectId]*Index, len(snapshot.Indexes)),
+		primaryKeys: snapshot.PrimaryKeys,
+		nextRowId:   &atomic.Uint64{},
+	}
+	if t.primaryKeys == nil {
+		t.primaryKeys = make([]ObjectId, 0)
+	}
+	for _, column := range snapshot.Columns {
+		if _, exists := t.columnNames[column.name]; exists {
+			return fmt.Errorf("corrupted catalog: duplicate column %s in table %s", column.name, t.name)
+		}
+		t.columnNames[column.name] = column.id
+		t.columns[column.id] = column
+	}
+	for _, index := range snapshot.Indexes {
+		if _, exists := t.indexNames[index.name]; exists {
+			return fmt.Errorf("corrupted catalog: duplicate index %s in table %s", index.name, t.name)
+		}
+		t.indexNames[index.name] = index.id
+		t.indexes[index.id] = index
+	}
+	t.nextObjectId.Store(snapshot.NextObjectId)
+	return nil
+}
+
+func (c *Column) MarshalJSON() ([]byte, error) {
+	defaultValue, err := encodeSnapshotValue(c.defaultValue)
+	if err != nil {
+		return nil, err
+	}
+	missingValue, err := encodeSnapshotValue(c.missingValue)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(columnSnapshot{
+		Id:          c.id,
+		Ordinal:     c.ordinal,
+		Name:        c.name,
+		DataType:    c.dataType,
+		Constraints: c.constraints,
+		Default:     defaultValue,
+		Missing:     missingValue,
+	})
+}
+
+func (c *Column) UnmarshalJSON(data []byte) error {
+	var snapshot columnSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+	*c = *NewColumn(snapshot.Id, snapshot.Name, snapshot.DataType, snapshot.Constraints)
+	c.ordinal = snapshot.Ordinal
+	if c.ordinal == 0 {
+		// stored before ordinals existed, columns were ordered by id
+		c.ordinal = uint32(c.id)
+	}
+
+	var err error
+	if c.defaultValue, err = decodeSnapshotValue(snapshot.Default); err != nil {
+		return fmt.Errorf("corrupted catalog: default of column %s: %w", c.name, err)
+	}
+	if c.missingValue, err = decodeSnapshotValue(snapshot.Missing); err != nil {
+		return fmt.Errorf("corrupted catalog: missing value of column %s: %w", c.name, err)
+	}
+	return nil
+}
+
+// column values are stored with the key codec, NULL is left out
+func encodeSnapshotValue(value types.Value) ([]byte, error) {
+	if value.IsNull() {
+		return nil, nil
+	}
+	return types.EncodeKey([]types.Value{value})
+}
+
+func decodeSnapshotValue(data []byte) (types.Value, error) {
+	if len(data) == 0 {
+		return types.Value{}, nil
+	}
+	values, err := types.DecodeKey(data)
+	if err != nil {
+		return types.Value{}, err
+	}
+	if len(values) != 1 {
+		return types.Value{}, fmt.Errorf("expected a single value, got %d", len(values))
+	}
+	return values[0], nil
+}
+
+func (idx *Index) MarshalJSON() ([]byte, error) {
+	return json.Marshal(indexSnapshot{
+		Id:        idx.id,
+		Name:      idx.name,
+		ColumnIds: idx.columnIds,
+		Unique:    idx.unique,
+	})
+}
+
+func (idx *Index) UnmarshalJSON(data []byte) error {
+	var snapshot indexSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+	*idx = *NewIndex(snapshot.Id, snapshot.Name, snapshot.ColumnIds, snapshot.Unique)
+	return nil
+}
@@ -1,10 +1,13 @@
 package catalog
 
 import (
-	"fmt"
+	"cmp"
+	"maps"
+	"slices"
 	"sync"
 	"sync/atomic"
 
+	"github.com/evanxg852000/foxdb/internal/types"
 	"github.com/evanxg852000/foxdb/internal/utils"
 )
 
@@ -14,6 +17,7 @@ type RootCatalog struct {
 	sync.RWMutex
 	schemaNames  map[string]ObjectId
 	schemas      map[ObjectId]*Schema
+	users        map[string]*User
 	nextObjectId atomic.Uint32
 }
 
@@ -21,12 +25,34 @@ func NewRootCatalog() *RootCatalog {
 	return &RootCatalog{
 		schemaNames: make(map[string]ObjectId),
 		schemas:     make(map[ObjectId]*Schema),
+		users:       make(map[string]*User),
 	}
 }
 
+// Clone copies the whole catalog. Catalog versions are never changed once
+// published, DDL statements change a copy which replaces the published
+// version when their transaction commits.
+func (rc *RootCatalog) Clone() *RootCatalog {
+	rc.RLock()
+	defer rc.RUnlock()
+	clone := &RootCatalog{
+		schemaNames: maps.Clone(rc.schemaNames),
+		schemas:     make(map[ObjectId]*Schema, len(rc.schemas)),
+		users:       make(map[string]*User, len(rc.users)),
+	}
+	for oid, schema := range rc.schemas {
+		clone.schemas[oid] = schema.Clone()
+	}
+	for name, user := range rc.users {
+		clone.users[name] = user.Clone()
+	}
+	clone.nextObjectId.Store(rc.nextObjectId.Load())
+	return clone
+}
+
 func (rc *RootCatalog) AddSchema(name string) (*Schema, error) {
 	if _, exists := rc.schemaNames[name]; exists {
-		return nil, fmt.Errorf("schema %s already exists", name)
+		return nil, types.NewError(types.ERR_DUPLICATE_SCHEMA, "schema %s already exists", name)
 	}
 
 	oid := ObjectId(rc.nextObjectId.Add(1))
@@ -49,7 +75,7 @@ func (rc *RootCatalog) GetSchema(name string) *Schema {
 func (rc *RootCatalog) RemoveSchema(name string) (*Schema, error) {
 	oid, ok := rc.schemaNames[name]
 	if !ok {
-		return nil, fmt.Errorf("schema %s does not exist", name)
+		return nil, types.NewError(types.ERR_INVALID_SCHEMA_NAME, "schema %s does not exist", name)
 	}
 	schema, ok := rc.schemas[oid]
 	utils.Assert(ok, "schema id should exist in schemas map")
@@ -63,5 +89,46 @@ func (rc *RootCatalog) ListSchemas() []*Schema {
 	for _, schema := range rc.schemas {
 		schemas = append(schemas, schema)
 	}
+	slices.SortFunc(schemas, func(a, b *Schema) int {
+		return cmp.Compare(a.id, b.id)
+	})
 	return schemas
 }
+
+func (rc *RootCatalog) AddUser(name string) (*User, error) {
+	if _, exists := rc.users[name]; exists {
+		return nil, types.NewError(types.ERR_DUPLICATE_OBJECT, "user %s already exists", name)
+	}
+
+	user := NewUser(ObjectId(rc.nextObjectId.Add(1)), name)
+	rc.users[user.name] = user
+	return user, nil
+}
+
+func (rc *RootCatalog) GetUser(name string) *User {
+	return rc.users[name]
+}
+
+func (rc *RootCatalog) RemoveUser(name string) (*User, error) {
+	user, ok := rc.users[name]
+	if !ok {
+		return nil, types.NewError(types.ERR_UNDEFINED_OBJECT, "user %s does not exist", name)
+	}
+	delete(rc.users, name)
+	return user, nil
+}
+
+func (rc *RootCatalog) RestoreUser(user *User) {
+	rc.users[user.name] = user
+}
+
+func (rc *RootCatalog) ListUsers() []*User {
+	users := make([]*User, 0, len(rc.users))
+	for _, user := range rc.users {
+		users = append(users, user)
+	}
+	slices.SortFunc(users, func(a, b *User) int {
+		return cmp.Compare(a.id, b.id)
+	})
+	return users
+}
@@ -1,9 +1,12 @@
 package catalog
 
 import (
-	"fmt"
+	"cmp"
+	"maps"
+	"slices"
 	"sync/atomic"
 
+	"github.com/evanxg852000/foxdb/internal/types"
 	"github.com/evanxg852000/foxdb/internal/utils"
 )
 
@@ -24,6 +27,21 @@ func NewSchema(oid ObjectId, name string) *Schema {
 	}
 }
 
+// Clone copies the schema and its tables
+func (s *Schema) Clone() *Schema {
+	clone := &Schema{
+		id:         s.id,
+		name:       s.name,
+		tableNames: maps.Clone(s.tableNames),
+		tables:     make(map[ObjectId]*Table, len(s.tables)),
+	}
+	for oid, table := range s.tables {
+		clone.tables[oid] = table.Clone()
+	}
+	clone.nextObjectId.Store(s.nextObjectId.Load())
+	return clone
+}
+
 func (s *Schema) GetId() ObjectId {
 	return s.id
 }
@@ -34,7 +52,7 @@ func (s *Schema) GetName() string {
 
 func (s *Schema) AddTable(name string) (*Table, error) {
 	if _, exists := s.tableNames[name]; exists {
-		return nil, fmt.Errorf("table %s already exists", name)
+		return nil, types.NewError(types.ERR_DUPLICATE_TABLE, "table %s already exists", name)
 	}
 
 	oid := ObjectId(s.nextObjectId.Add(1))
@@ -57,7 +75,7 @@ func (s *Schema) GetTable(name string) *Table {
 func (s *Schema) RemoveTable(name string) (*Table, error) {
 	oid, ok := s.tableNames[name]
 	if !ok {
-		return nil, fmt.Errorf("table %s does not exist", name)
+		return nil, types.NewError(types.ERR_UNDEFINED_TABLE, "table %s does not exist", name)
 	}
 	table, ok := s.tables[oid]
 	utils.Assert(ok, "table id should exist in tables map")
@@ -66,10 +84,47 @@ func (s *Schema) RemoveTable(name string) (*Table, error) {
 	return table, nil
 }
 
+// re-attaches a removed table, used to undo a failed DROP TABLE
+func (s *Schema) RestoreTable(table *Table) error {
+	if _, exists := s.tableNames[table.name]; exists {
+		return types.NewError(types.ERR_DUPLICATE_TABLE, "table %s already exists", table.name)
+	}
+	s.tableNames[table.name] = table.id
+	s.tables[table.id] = table
+	return nil
+}
+
+func (s *Schema) RenameTable(name string, newName string) error {
+	table := s.GetTable(name)
+	if table == nil {
+		return types.NewError(types.ERR_UNDEFINED_TABLE, "table %s does not exist", name)
+	}
+	if _, exists := s.tableNames[newName]; exists {
+		return types.NewError(types.ERR_DUPLICATE_TABLE, "table %s already exists", newName)
+	}
+	delete(s.tableNames, name)
+	table.name = newName
+	s.tableNames[newName] = table.id
+	return nil
+}
+
 func (s *Schema) ListTables() []*Table {
 	tables := make([]*Table, 0, len(s.tables))
 	for _, table := range s.tables {
 		tables = append(tables, table)
 	}
+	slices.SortFunc(tables, func(a, b *Table) int {
+		return cmp.Compare(a.id, b.id)
+	})
 	return tables
 }
+
+// looks up an index by name across the tables of the schema
+func (s *Schema) FindIndex(name string) (*Table, *Index) {
+	for _, table := range s.ListTables() {
+		if index := table.GetIndex(name); index != nil {
+			return table, index
+		}
+	}
+	return nil, nil
+}
@@ -2,23 +2,66 @@ package catalog
 
 import "github.com/evanxg852000/foxdb/internal/types"
 
+// AddInformationSchema adds the information schema and the system tables
+// missing from it, catalogs stored by older versions get the tables
+// introduced since when they are loaded.
 func AddInformationSchema(rootCatalog *RootCatalog) {
 	rootCatalog.Lock()
 	defer rootCatalog.Unlock()
 
 	// Add standard information schema, tables, indexes, etc. here as needed.
-	infoSchema, _ := rootCatalog.AddSchema("information_schema")
+	infoSchema := rootCatalog.GetSchema(INFORMATION_SCHEMA_NAME)
+	if infoSchema == nil {
+		infoSchema, _ = rootCatalog.AddSchema(INFORMATION_SCHEMA_NAME)
+	}
 
 	// schemas/databases
-	schemasTable, _ := infoSchema.AddTable("schemas")
-	schemasTable.AddColumn("id", types.TYPE_INT, UniqueConstraint)
-	schemasTable.AddColumn("name", types.TYPE_TEXT, NoConstraint)
-	schemasTable.SetPrimaryKeys([]string{"id"})
-
-	tablesTable, _ := infoSchema.AddTable("tables")
-	tablesTable.AddColumn("id", types.TYPE_INT, UniqueConstraint)
-	tablesTable.AddColumn("name", types.TYPE_TEXT, NoConstraint)
-	tablesTable.AddColumn("schema_id", types.TYPE_INT, NoConstraint)
-	tablesTable.AddColumn("sequence_value", types.TYPE_INT, NoConstraint)
-	tablesTable.SetPrimaryKeys([]string{"id"})
+	if infoSchema.GetTable("schemas") == nil {
+		schemasTable, _ := infoSchema.AddTable("schemas")
+		schemasTable.AddColumn("id", types.TYPE_INT, UniqueConstraint)
+		schemasTable.AddColumn("name", types.TYPE_TEXT, NoConstraint)
+		schemasTable.SetPrimaryKeys([]string{"id"})
+	}
+
+	// table ids are only unique within their schema
+	tablesTable := infoSchema.GetTable("tables")
+	if tablesTable == nil {
+		tablesTable, _ = infoSchema.AddTable("tables")
+		tablesTable.AddColumn("id", types.TYPE_INT, NotNullConstraint)
+		tablesTable.AddColumn("name", types.TYPE_TEXT, NoConstraint)
+		tablesTable.AddColumn("schema_id", types.TYPE_INT, NotNullConstraint)
+		tablesTable.AddColumn("sequence_value", types.TYPE_INT, NoConstraint)
+	}
+	// older versions keyed the rows by id alone
+	tablesTable.GetColumn("id").SetConstraints(NotNullConstraint)
+	tablesTable.GetColumn("schema_id").SetConstraints(NotNullConstraint)
+	tablesTable.SetPrimaryKeys([]string{"schema_id", "id"})
+
+	// columns in declaration order, ordinal_position starts at 1
+	if infoSchema.GetTable("columns") == nil {
+		columnsTable, _ := infoSchema.AddTable("columns")
+		columnsTable.AddColumn("schema_id", types.TYPE_INT, NotNullConstraint)
+		columnsTable.AddColumn("table_id", types.TYPE_INT, NotNullConstraint)
+		columnsTable.AddColumn("name", types.TYPE_TEXT, NotNullConstraint)
+		columnsTable.AddColumn("ordinal_position", types.TYPE_INT, NotNullConstraint)
+		columnsTable.AddColumn("data_type", types.TYPE_TEXT, NoConstraint)
+		columnsTable.AddColumn("not_null", types.TYPE_BOOL, NoConstraint)
+		columnsTable.AddColumn("default_value", types.TYPE_TEXT, NoConstraint)
+		columnsTable.SetPrimaryKeys([]string{"schema_id", "table_id", "name"})
+	}
+}
+
+// AddPublicSchema adds the schema unqualified names resolve to by default,
+// new databases start with it but it can be dropped like any other schema.
+func AddPublicSchema(rootCatalog *RootCatalog) {
+	rootCatalog.Lock()
+	defer rootCatalog.Unlock()
+	if rootCatalog.GetSchema(DEFAULT_SCHEMA_NAME) == nil {
+		rootCatalog.AddSchema(DEFAULT_SCHEMA_NAME)
+	}
 }
+
+const INFORMATION_SCHEMA_NAME = "information_schema"
+
+// schema searched for unqualified object names when the search path is not set
+const DEFAULT_SCHEMA_NAME = "public"
@@ -1,7 +1,9 @@
 package catalog
 
 import (
-	"fmt"
+	"cmp"
+	"maps"
+	"slices"
 	"sync/atomic"
 
 	"github.com/evanxg852000/foxdb/internal/types"
@@ -17,6 +19,9 @@ type Table struct {
 	indexes      map[ObjectId]*Index
 	primaryKeys  []ObjectId
 	nextObjectId atomic.Uint32
+	// shared by the copies of the table, rows inserted through any
+	// catalog version must not get the same row id
+	nextRowId *atomic.Uint64
 }
 
 func NewTable(oid ObjectId, name string) *Table {
@@ -28,9 +33,32 @@ func NewTable(oid ObjectId, name string) *Table {
 		indexNames:  make(map[string]ObjectId),
 		indexes:     make(map[ObjectId]*Index),
 		primaryKeys: make([]ObjectId, 0),
+		nextRowId:   &atomic.Uint64{},
 	}
 }
 
+// Clone copies the table and its columns, the row id sequence is shared
+func (t *Table) Clone() *Table {
+	clone := &Table{
+		id:          t.id,
+		name:        t.name,
+		columnNames: maps.Clone(t.columnNames),
+		columns:     make(map[ObjectId]*Column, len(t.columns)),
+		indexNames:  maps.Clone(t.indexNames),
+		indexes:     make(map[ObjectId]*Index, len(t.indexes)),
+		primaryKeys: slices.Clone(t.primaryKeys),
+		nextRowId:   t.nextRowId,
+	}
+	for oid, column := range t.columns {
+		clone.columns[oid] = column.Clone()
+	}
+	for oid, index := range t.indexes {
+		clone.indexes[oid] = index.Clone()
+	}
+	clone.nextObjectId.Store(t.nextObjectId.Load())
+	return clone
+}
+
 func (t *Table) GetId() ObjectId {
 	return t.id
 }
@@ -41,11 +69,12 @@ func (t *Table) GetName() string {
 
 func (t *Table) AddColumn(name string, dataType types.DataType, constraints Constraint) (*Column, error) {
 	if _, exists := t.columnNames[name]; exists {
-		return nil, fmt.Errorf("column %s already exists", name)
+		return nil, types.NewError(types.ERR_DUPLICATE_COLUMN, "column %s already exists", name)
 	}
 
 	oid := ObjectId(t.nextObjectId.Add(1))
 	column := NewColumn(oid, name, dataType, constraints)
+	column.ordinal = t.nextOrdinal()
 	t.columnNames[column.name] = column.id
 	t.columns[column.id] = column
 	return column, nil
@@ -64,7 +93,7 @@ func (t *Table) GetColumn(name string) *Column {
 func (t *Table) RemoveColumn(name string) (*Column, error) {
 	oid, ok := t.columnNames[name]
 	if !ok {
-		return nil, fmt.Errorf("column %s does not exist", name)
+		return nil, types.NewError(types.ERR_UNDEFINED_COLUMN, "column %s does not exist", name)
 	}
 	column, ok := t.columns[oid]
 	utils.Assert(ok, "column id should exist in columns map")
@@ -73,17 +102,78 @@ func (t *Table) RemoveColumn(name string) (*Column, error) {
 	return column, nil
 }
 
+// re-attaches a removed column, used to undo a failed DROP COLUMN
+func (t *Table) RestoreColumn(column *Column) error {
+	if _, exists := t.columnNames[column.name]; exists {
+		return types.NewError(types.ERR_DUPLICATE_COLUMN, "column %s already exists", column.name)
+	}
+	t.columnNames[column.name] = column.id
+	t.columns[column.id] = column
+	return nil
+}
+
+func (t *Table) RenameColumn(name string, newName string) error {
+	column := t.GetColumn(name)
+	if column == nil {
+		return types.NewError(types.ERR_UNDEFINED_COLUMN, "column %s does not exist", name)
+	}
+	if _, exists := t.columnNames[newName]; exists {
+		return types.NewError(types.ERR_DUPLICATE_COLUMN, "column %s already exists", newName)
+	}
+	delete(t.columnNames, name)
+	column.name = newName
+	t.columnNames[newName] = column.id
+	return nil
+}
+
+// columns are listed by ordinal, which follows the declaration order
 func (t *Table) ListColumns() []*Column {
 	columns := make([]*Column, 0, len(t.columns))
 	for _, column := range t.columns {
 		columns = append(columns, column)
 	}
+	slices.SortFunc(columns, func(a, b *Column) int {
+		return cmp.Compare(a.ordinal, b.ordinal)
+	})
 	return columns
 }
 
+// added columns go after the existing ones, the ordinals of dropped
+// columns are only reused when they were the last ones.
+func (t *Table) nextOrdinal() uint32 {
+	ordinal := uint32(0)
+	for _, column := range t.columns {
+		ordinal = max(ordinal, column.ordinal)
+	}
+	return ordinal + 1
+}
+
+func (t *Table) GetDataSchema() *types.DataSchema {
+	columns := t.ListColumns()
+	dataColumns := make([]types.DataColumn, len(columns))
+	for i, col := range columns {
+		dataColumns[i] = types.DataColumn{
+			Name:     col.GetName(),
+			DataType: col.GetDataType(),
+			Id:       uint32(col.GetId()),
+			Missing:  col.GetMissingValue(),
+		}
+	}
+	return &types.DataSchema{Columns: dataColumns}
+}
+
 func (t *Table) AddIndex(name string, columnNames []string, unique bool) (*Index, error) {
 	if _, exists := t.indexNames[name]; exists {
-		return nil, fmt.Errorf("index %s already exists", name)
+		return nil, types.NewError(types.ERR_DUPLICATE_OBJECT, "index %s already exists", name)
+	}
+
+	if len(columnNames) == 0 {
+		return nil, types.NewError(types.ERR_INVALID_DEFINITION, "index %s must have at least one column", name)
+	}
+	for _, colName := range columnNames {
+		if t.GetColumn(colName) == nil {
+			return nil, types.NewError(types.ERR_UNDEFINED_COLUMN, "column %s does not exist", colName)
+		}
 	}
 
 	oid := ObjectId(t.nextObjectId.Add(1))
@@ -107,7 +197,7 @@ func (t *Table) GetIndex(name string) *Index {
 func (t *Table) RemoveIndex(name string) (*Index, error) {
 	oid, ok := t.indexNames[name]
 	if !ok {
-		return nil, fmt.Errorf("index %s does not exist", name)
+		return nil, types.NewError(types.ERR_UNDEFINED_OBJECT, "index %s does not exist", name)
 	}
 	index, ok := t.indexes[oid]
 	utils.Assert(ok, "index id should exist in indexes map")
@@ -116,11 +206,25 @@ func (t *Table) RemoveIndex(name string) (*Index, error) {
 	return index, nil
 }
 
+// re-attaches a removed index, used to undo a failed DROP INDEX
+func (t *Table) RestoreIndex(index *Index) error {
+	if _, exists := t.indexNames[index.name]; exists {
+		return types.NewError(types.ERR_DUPLICATE_OBJECT, "index %s already exists", index.name)
+	}
+	t.indexNames[index.name] = index.id
+	t.indexes[index.id] = index
+	return nil
+}
+
+// indexes are listed by id, which follows the creation order
 func (t *Table) ListIndexes() []*Index {
 	indexes := make([]*Index, 0, len(t.indexes))
 	for _, index := range t.indexes {
 		indexes = append(indexes, index)
 	}
+	slices.SortFunc(indexes, func(a, b *Index) int {
+		return cmp.Compare(a.id, b.id)
+	})
 	return indexes
 }
 
@@ -132,6 +236,39 @@ func (t *Table) GetPrimaryKeys() []ObjectId {
 	return t.primaryKeys
 }
 
+// returns the positions of the primary key columns in ListColumns
+func (t *Table) GetPrimaryKeyIndexes() []int {
+	return t.GetColumnIndexes(t.primaryKeys)
+}
+
+// maps column ids to their positions in ListColumns
+func (t *Table) GetColumnIndexes(columnIds []ObjectId) []int {
+	columns := t.ListColumns()
+	indexes := make([]int, 0, len(columnIds))
+	for _, colId := range columnIds {
+		for idx, col := range columns {
+			if col.id == colId {
+				indexes = append(indexes, idx)
+				break
+			}
+		}
+	}
+	return indexes
+}
+
+// allocates the hidden row id used as key by tables without primary key
+func (t *Table) NextRowId() uint64 {
+	return t.nextRowId.Add(1)
+}
+
+func (t *Table) GetSequenceValue() uint64 {
+	return t.nextRowId.Load()
+}
+
+func (t *Table) SetSequenceValue(value uint64) {
+	t.nextRowId.Store(value)
+}
+
 func (t *Table) columnIdsFromNames(columnsNames []string) []ObjectId {
 	ids := make([]ObjectId, 0, len(columnsNames))
 	for _, colName := range columnsNames {
@@ -0,0 +1,39 @@
+package catalog
+
+import "github.com/evanxg852000/foxdb/internal/auth"
+
+type User struct {
+	id   ObjectId
+	name string
+	// nil when the user has no password and cannot log in with one
+	credentials *auth.Credentials
+}
+
+func NewUser(id ObjectId, name string) *User {
+	return &User{
+		id:   id,
+		name: name,
+	}
+}
+
+// credentials are replaced rather than changed, the copy can share them
+func (u *User) Clone() *User {
+	clone := *u
+	return &clone
+}
+
+func (u *User) GetId() ObjectId {
+	return u.id
+}
+
+func (u *User) GetName() string {
+	return u.name
+}
+
+func (u *User) GetCredentials() *auth.Credentials {
+	return u.credentials
+}
+
+func (u *User) SetCredentials(credentials *auth.Credentials) {
+	u.credentials = credentials
+}
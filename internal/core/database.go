@@ -3,23 +3,24 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
+	"github.com/dgraph-io/badger/v3"
+	"github.com/evanxg852000/foxdb/internal/auth"
 	"github.com/evanxg852000/foxdb/internal/catalog"
-	"github.com/evanxg852000/foxdb/internal/query/executor"
-	"github.com/evanxg852000/foxdb/internal/query/optimizer"
 	"github.com/evanxg852000/foxdb/internal/query/parser"
-	"github.com/evanxg852000/foxdb/internal/query/planner"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
 	"github.com/evanxg852000/foxdb/internal/storage"
 	"github.com/evanxg852000/foxdb/internal/types"
 	"github.com/evanxg852000/foxdb/internal/utils"
 )
 
 const CONFIG_FILE_NAME = "config.json"
-const CATALOG_FILE_NAME = "catalog.json"
 
 type Config struct{}
 
@@ -27,7 +28,10 @@ type Database struct {
 	path    string
 	configs Config
 	storage *storage.KvStorage
-	catalog *catalog.RootCatalog
+	// catalog is the published catalog version, the lock makes replacing
+	// it and committing the transaction that changed it a single step.
+	catalogLock sync.RWMutex
+	catalog     *catalog.RootCatalog
 }
 
 func Open(path string) (*Database, error) {
@@ -44,20 +48,28 @@ func Open(path string) (*Database, error) {
 		return nil, err
 	}
 
-	err = database.loadCatalog()
+	database.storage, err = storage.NewKvStorage(filepath.Join(path, "data"))
 	if err != nil {
 		return nil, err
 	}
 
-	database.storage, err = storage.NewKvStorage(filepath.Join(path, "data"))
+	err = database.LoadCatalog()
 	if err != nil {
+		database.storage.Close()
 		return nil, err
 	}
 
 	return database, nil
 }
 
+// Run runs sql in a session of its own, with the default search path
 func (db *Database) Run(ctx context.Context, sql string) (*types.DataChunk, error) {
+	return db.NewSession("", "").Run(ctx, sql)
+}
+
+// Parse splits sql into statements, backslash commands
+// are translated to their SQL equivalent first.
+func (db *Database) Parse(sql string) ([]ast.Statement, error) {
 	if strings.HasPrefix(sql, "\\") {
 		convertedSql, err := db.commandToSql(sql)
 		if err != nil {
@@ -69,47 +81,114 @@ func (db *Database) Run(ctx context.Context, sql string) (*types.DataChunk, erro
 	lexer := parser.NewLexer(sql)
 	parser := parser.NewParser(lexer)
 	program := parser.ParseProgram()
-	if program == nil {
+	if len(parser.Errors()) > 0 {
 		messages := strings.Join(parser.Errors(), "\n")
-		return nil, fmt.Errorf("failed to parse SQL: %s\n%s", sql, messages)
+		return nil, types.NewError(types.ERR_SYNTAX_ERROR, "failed to parse SQL: %s\n%s", sql, messages)
 	}
+	return program.Statements, nil
+}
 
-	if len(program.Statements) == 0 {
-		return nil, nil
+// UserCredentials looks a user up, its credentials are nil when it has no password.
+func (db *Database) UserCredentials(name string) (*auth.Credentials, bool) {
+	rootCatalog := db.publishedCatalog()
+	rootCatalog.RLock()
+	defer rootCatalog.RUnlock()
+	user := rootCatalog.GetUser(name)
+	if user == nil {
+		return nil, false
 	}
+	return user.GetCredentials(), true
+}
 
-	planner := planner.NewPlanner(db.catalog)
-	// TODO: support multiple statements
-	logicalPlan, err := planner.Plan(program.Statements[0])
+// LoadCatalog reads the catalog from the storage, a new catalog
+// is created and stored when the database is empty.
+func (db *Database) LoadCatalog() error {
+	catalogData, err := db.storage.Get(types.CATALOG_KEY)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		db.catalog = catalog.NewRootCatalog()
+		catalog.AddInformationSchema(db.catalog)
+		catalog.AddPublicSchema(db.catalog)
+		return db.StoreCatalog()
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	optimizer := optimizer.NewOptimizer(db.catalog, db.getStats())
-	physicalPlan, err := optimizer.Optimize(logicalPlan)
+	rootCatalog := catalog.NewRootCatalog()
+	err = json.Unmarshal(catalogData, rootCatalog)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to load catalog: %w", err)
 	}
 
-	executor := executor.NewExecutor(db.storage, db.catalog, physicalPlan)
-	return executor.Execute(ctx)
-}
-
-func (db *Database) LoadCatalog() error {
+	err = db.recoverRowIdSequences(rootCatalog)
+	if err != nil {
+		return err
+	}
+	catalog.AddInformationSchema(rootCatalog)
+	db.catalogLock.Lock()
+	defer db.catalogLock.Unlock()
+	db.catalog = rootCatalog
 	return nil
 }
 
+// StoreCatalog writes the whole catalog to the storage. DDL statements
+// already store it along with their changes, this is for explicit syncs.
 func (db *Database) StoreCatalog() error {
-	return nil
-}
-
-func (c *Database) Close() error {
-	err := c.storeCatalog()
+	rootCatalog := db.publishedCatalog()
+	rootCatalog.RLock()
+	defer rootCatalog.RUnlock()
+	catalogData, err := json.Marshal(rootCatalog)
 	if err != nil {
 		return err
 	}
+	return db.storage.Set(types.CATALOG_KEY, catalogData)
+}
+
+// publishedCatalog is the catalog version of the latest committed DDL
+func (db *Database) publishedCatalog() *catalog.RootCatalog {
+	db.catalogLock.RLock()
+	defer db.catalogLock.RUnlock()
+	return db.catalog
+}
+
+// snapshot returns the published catalog along with a storage snapshot
+// of the commits that match it
+func (db *Database) snapshot() (*catalog.RootCatalog, *storage.KvStorage) {
+	db.catalogLock.RLock()
+	defer db.catalogLock.RUnlock()
+	return db.catalog, db.storage.Snapshot()
+}
+
+// rowid sequences are not part of the catalog, they restart
+// after the greatest rowid stored for each table.
+func (db *Database) recoverRowIdSequences(rootCatalog *catalog.RootCatalog) error {
+	for _, schema := range rootCatalog.ListSchemas() {
+		for _, table := range schema.ListTables() {
+			if len(table.GetPrimaryKeys()) > 0 {
+				continue
+			}
+
+			prefix := types.TableKeyPrefix(uint32(schema.GetId()), uint32(table.GetId()))
+			lastKey, err := db.storage.LastKey(prefix)
+			if err != nil {
+				return err
+			}
+			if lastKey == nil {
+				continue
+			}
+
+			rowId, err := types.DecodeRowId(lastKey[len(prefix):])
+			if err != nil {
+				return err
+			}
+			table.SetSequenceValue(rowId)
+		}
+	}
+	return nil
+}
 
-	err = c.storeConfig()
+func (c *Database) Close() error {
+	err := c.storeConfig()
 	if err != nil {
 		return err
 	}
@@ -152,45 +231,12 @@ func (db *Database) loadConfig() error {
 	return nil
 }
 
-func (db *Database) storeCatalog() error {
-	catalogFile := filepath.Join(db.path, CATALOG_FILE_NAME)
-	catalogData, err := json.Marshal(db.catalog)
-	if err != nil {
-		return err
-	}
-
-	err = utils.WriteFile(catalogFile, catalogData)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-func (db *Database) loadCatalog() error {
-	catalogFile := filepath.Join(db.path, CATALOG_FILE_NAME)
-	if _, err := os.Stat(catalogFile); os.IsNotExist(err) {
-		db.catalog = catalog.NewRootCatalog()
-		catalog.AddInformationSchema(db.catalog)
-		return nil
-	}
-	catalogData, err := utils.ReadFile(catalogFile)
-	if err != nil {
-		return err
-	}
-
-	err = json.Unmarshal(catalogData, &db.catalog)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
 func (db *Database) commandToSql(command string) (string, error) {
 	switch command {
 	case "\\dt":
-		return "SELECT table_name FROM information_schema.tables;", nil
+		return "SELECT name FROM information_schema.tables;", nil
 	default:
-		return "", fmt.Errorf("unknown command: %s", command)
+		return "", types.NewError(types.ERR_SYNTAX_ERROR, "unknown command: %s", command)
 	}
 }
 
@@ -0,0 +1,1805 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+func setupTestDatabase(t *testing.T, statements ...string) *Database {
+	db, err := Open(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	for _, sql := range statements {
+		_, err := db.Run(context.Background(), sql)
+		require.NoError(t, err, "statement failed: %s", sql)
+	}
+	return db
+}
+
+func queryRows(t *testing.T, db *Database, sql string) [][]any {
+	return sessionRows(t, db.NewSession("", ""), sql)
+}
+
+func sessionRows(t *testing.T, session *Session, sql string) [][]any {
+	chunk, err := session.Run(context.Background(), sql)
+	require.NoError(t, err, "query failed: %s", sql)
+
+	rows := [][]any{}
+	for _, row := range chunk.GetRows() {
+		values := make([]any, len(row.Values))
+		for i, value := range row.Values {
+			values[i] = rawValue(value)
+		}
+		rows = append(rows, values)
+	}
+	return rows
+}
+
+func rawValue(value types.Value) any {
+	switch value.GetDataType() {
+	case types.TYPE_INT:
+		v, _ := value.Int()
+		return v
+	case types.TYPE_FLOAT:
+		v, _ := value.Float()
+		return v
+	case types.TYPE_BOOL:
+		v, _ := value.Bool()
+		return v
+	case types.TYPE_TEXT:
+		v, _ := value.Text()
+		return v
+	}
+	return nil
+}
+
+func TestInsertAndSelect(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, name TEXT NOT NULL, score FLOAT);",
+	)
+
+	chunk, err := db.Run(context.Background(), `INSERT INTO app.users (id, name, score) VALUES (3, "carol", 7.5), (1, "alice", 9), (2, "bob", 4.25);`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), rawValue(chunk.GetRows()[0].Values[0]), "inserted rows count")
+
+	rows := queryRows(t, db, "SELECT * FROM app.users ORDER BY id;")
+	assert.Equal(t, [][]any{
+		{int64(1), "alice", 9.0},
+		{int64(2), "bob", 4.25},
+		{int64(3), "carol", 7.5},
+	}, rows)
+
+	rows = queryRows(t, db, "SELECT name FROM app.users WHERE score > 5 ORDER BY score DESC LIMIT 1;")
+	assert.Equal(t, [][]any{{"alice"}}, rows)
+
+	rows = queryRows(t, db, "SELECT id FROM app.users ORDER BY id LIMIT 5 OFFSET 1;")
+	assert.Equal(t, [][]any{{int64(2)}, {int64(3)}}, rows)
+}
+
+func TestInsertConstraints(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, email TEXT UNIQUE, name TEXT NOT NULL);",
+		`INSERT INTO app.users VALUES (1, "a@fox.db", "alice");`,
+	)
+
+	tests := []struct {
+		name string
+		sql  string
+	}{
+		{"duplicate primary key", `INSERT INTO app.users VALUES (1, "b@fox.db", "bob");`},
+		{"duplicate primary key in statement", `INSERT INTO app.users VALUES (2, "b@fox.db", "bob"), (2, "c@fox.db", "carol");`},
+		{"duplicate unique value", `INSERT INTO app.users VALUES (2, "a@fox.db", "bob");`},
+		{"missing not null value", `INSERT INTO app.users (id, email) VALUES (2, "b@fox.db");`},
+		{"wrong value type", `INSERT INTO app.users VALUES ("2", "b@fox.db", "bob");`},
+		{"unknown column", `INSERT INTO app.users (id, age) VALUES (2, 20);`},
+		{"values count mismatch", `INSERT INTO app.users VALUES (2, "b@fox.db");`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.Run(context.Background(), tt.sql)
+			assert.Error(t, err)
+		})
+	}
+
+	// failed statements must not leave any partial write
+	rows := queryRows(t, db, "SELECT id, name FROM app.users;")
+	assert.Equal(t, [][]any{{int64(1), "alice"}}, rows)
+}
+
+func TestInsertWithoutPrimaryKey(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.events (name TEXT, value INT);",
+		`INSERT INTO app.events VALUES ("click", 1), ("click", 1);`,
+	)
+
+	rows := queryRows(t, db, "SELECT * FROM app.events;")
+	assert.Equal(t, [][]any{{"click", int64(1)}, {"click", int64(1)}}, rows)
+}
+
+func TestScanFollowsPrimaryKeyOrder(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.points (x INT PRIMARY KEY, y FLOAT PRIMARY KEY);",
+		"INSERT INTO app.points VALUES (3, 1.5), (-1, 2), (-20, 0.5), (3, -7.25), (0, 0);",
+	)
+
+	rows := queryRows(t, db, "SELECT * FROM app.points;")
+	assert.Equal(t, [][]any{
+		{int64(-20), 0.5},
+		{int64(-1), 2.0},
+		{int64(0), 0.0},
+		{int64(3), -7.25},
+		{int64(3), 1.5},
+	}, rows)
+}
+
+func TestNullValues(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, email TEXT UNIQUE, score INT, active BOOL);",
+		`INSERT INTO app.users VALUES (1, "a@fox.db", 10, true), (2, NULL, NULL, false), (3, NULL, 3, NULL);`,
+		"INSERT INTO app.users (id) VALUES (4);",
+	)
+
+	rows := queryRows(t, db, "SELECT * FROM app.users ORDER BY id;")
+	assert.Equal(t, [][]any{
+		{int64(1), "a@fox.db", int64(10), true},
+		{int64(2), nil, nil, false},
+		{int64(3), nil, int64(3), nil},
+		{int64(4), nil, nil, nil},
+	}, rows)
+
+	tests := []struct {
+		name     string
+		where    string
+		expected [][]any
+	}{
+		{"is null", "score IS NULL", [][]any{{int64(2)}, {int64(4)}}},
+		{"is not null", "email IS NOT NULL", [][]any{{int64(1)}}},
+		{"comparison with null", "score = NULL", [][]any{}},
+		{"negated comparison", "NOT (score > 5)", [][]any{{int64(3)}}},
+		{"unknown or true", "score > 5 OR score IS NULL", [][]any{{int64(1)}, {int64(2)}, {int64(4)}}},
+		{"unknown and false", "NOT (active AND score > 100)", [][]any{{int64(1)}, {int64(2)}, {int64(3)}}},
+		{"unknown or false", "active OR false", [][]any{{int64(1)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := queryRows(t, db, "SELECT id FROM app.users WHERE "+tt.where+" ORDER BY id;")
+			assert.Equal(t, tt.expected, rows)
+		})
+	}
+
+	// NULLs sort last in ascending order and first in descending order
+	rows = queryRows(t, db, "SELECT id FROM app.users ORDER BY score, id;")
+	assert.Equal(t, [][]any{{int64(3)}, {int64(1)}, {int64(2)}, {int64(4)}}, rows)
+	rows = queryRows(t, db, "SELECT id FROM app.users ORDER BY score DESC, id;")
+	assert.Equal(t, [][]any{{int64(2)}, {int64(4)}, {int64(1)}, {int64(3)}}, rows)
+
+	assert.Equal(t, int64(1), affectedRows(t, db, "UPDATE app.users SET email = NULL, score = NULL WHERE id = 1;"))
+	rows = queryRows(t, db, "SELECT email, score FROM app.users WHERE id = 1;")
+	assert.Equal(t, [][]any{{nil, nil}}, rows)
+
+	_, err := db.Run(context.Background(), "INSERT INTO app.users (email) VALUES (\"e@fox.db\");")
+	assert.ErrorContains(t, err, "not-null constraint")
+}
+
+func TestExpressions(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.items (id INT PRIMARY KEY, name TEXT, price FLOAT, stock INT);",
+		"INSERT INTO app.items VALUES (1, 'pen', 1.5, 10), (2, 'ink', 4.0, 3), (3, 'pad', 2.25, NULL);",
+	)
+
+	rows := queryRows(t, db, "SELECT id FROM app.items WHERE price * stock > 10 ORDER BY id;")
+	assert.Equal(t, [][]any{{int64(1)}, {int64(2)}}, rows)
+
+	assert.Equal(t, int64(3), affectedRows(t, db, "UPDATE app.items SET name = name || '-' || id, stock = stock / 2 + 1;"))
+	rows = queryRows(t, db, "SELECT name, stock FROM app.items ORDER BY id;")
+	assert.Equal(t, [][]any{{"pen-1", int64(6)}, {"ink-2", int64(2)}, {"pad-3", nil}}, rows)
+
+	// the SELECT list is computed for each row
+	rows = queryRows(t, db, "SELECT id + 1, -price, name || 'x', stock > 2 AND NULL, price * stock, id FROM app.items ORDER BY id;")
+	assert.Equal(t, [][]any{
+		{int64(2), -1.5, "pen-1x", nil, 9.0, int64(1)},
+		{int64(3), -4.0, "ink-2x", false, 8.0, int64(2)},
+		{int64(4), -2.25, "pad-3x", nil, nil, int64(3)},
+	}, rows)
+	rows = queryRows(t, db, "SELECT true AND NULL, false AND NULL, NOT (id = 2) FROM app.items WHERE id < 3 ORDER BY id;")
+	assert.Equal(t, [][]any{{nil, false, true}, {nil, false, false}}, rows)
+	chunk, err := db.Run(context.Background(), "SELECT id * 2, name, stock IS NULL FROM app.items;")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"?column?", "name", "?column?"}, chunk.GetColumnNames())
+	assert.Equal(t, types.TYPE_INT, chunk.GetSchema().Columns[0].DataType)
+	assert.Equal(t, types.TYPE_BOOL, chunk.GetSchema().Columns[2].DataType)
+
+	// the computed columns keep the rows selected by the filter
+	chunk, err = db.Run(context.Background(), "SELECT id * 10, price + stock FROM app.items WHERE id <> 2;")
+	require.NoError(t, err)
+	assert.Equal(t, 2, chunk.Len())
+	assert.Equal(t, []types.Value{*types.NewIntValue(10), *types.NewFloatValue(7.5)}, chunk.GetRow(0).Values)
+	assert.Equal(t, []types.Value{*types.NewIntValue(30), {}}, chunk.GetRow(1).Values)
+
+	// operators are type checked before any row is read
+	tests := []struct {
+		sql  string
+		code types.ErrorCode
+	}{
+		{"SELECT name + 1 FROM app.items;", types.ERR_UNDEFINED_FUNCTION},
+		{"SELECT -name FROM app.items;", types.ERR_UNDEFINED_FUNCTION},
+		{"SELECT NOT stock FROM app.items;", types.ERR_DATATYPE_MISMATCH},
+		{"SELECT stock / 0 FROM app.items;", types.ERR_DIVISION_BY_ZERO},
+		{"SELECT id FROM app.items WHERE name + 1 > 0;", types.ERR_UNDEFINED_FUNCTION},
+		{"SELECT id FROM app.items WHERE name = 1;", types.ERR_DATATYPE_MISMATCH},
+		{"SELECT id FROM app.items WHERE stock;", types.ERR_DATATYPE_MISMATCH},
+		{"UPDATE app.items SET stock = stock * 9223372036854775807 WHERE id = 1;", types.ERR_NUMERIC_VALUE_OUT_OF_RANGE},
+		{"DELETE FROM app.items WHERE stock / 0 = 1;", types.ERR_DIVISION_BY_ZERO},
+		{"INSERT INTO app.items VALUES (4, 'cap', 1.0 / 0, 1);", types.ERR_DIVISION_BY_ZERO},
+	}
+	for _, tt := range tests {
+		_, err := db.Run(context.Background(), tt.sql)
+		assert.Equal(t, tt.code, types.GetErrorCode(err), tt.sql)
+	}
+	assert.Len(t, queryRows(t, db, "SELECT id FROM app.items;"), 3)
+}
+
+func TestAlterTableColumns(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, nickname TEXT, score INT DEFAULT 5);",
+		`INSERT INTO app.users (id, nickname) VALUES (1, "al"), (2, "bo");`,
+		"CREATE INDEX users_nickname ON app.users (nickname);",
+	)
+
+	rows := queryRows(t, db, "SELECT * FROM app.users ORDER BY id;")
+	assert.Equal(t, [][]any{{int64(1), "al", int64(5)}, {int64(2), "bo", int64(5)}}, rows)
+
+	// existing records decode the added column with its default
+	_, err := db.Run(context.Background(), "ALTER TABLE app.users ADD COLUMN active BOOL NOT NULL DEFAULT true;")
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "ALTER TABLE app.users ADD COLUMN email TEXT;")
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "ALTER TABLE app.users DROP COLUMN nickname;")
+	require.NoError(t, err)
+	assert.Nil(t, db.catalog.GetSchema("app").GetTable("users").GetIndex("users_nickname"), "index on the dropped column")
+
+	rows = queryRows(t, db, "SELECT * FROM app.users ORDER BY id;")
+	assert.Equal(t, [][]any{{int64(1), int64(5), true, nil}, {int64(2), int64(5), true, nil}}, rows)
+
+	// changing the default does not change the existing records
+	_, err = db.Run(context.Background(), "ALTER TABLE app.users ALTER COLUMN active SET DEFAULT false;")
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "ALTER TABLE app.users ALTER COLUMN score DROP DEFAULT;")
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), `ALTER TABLE app.users RENAME COLUMN email TO contact;`)
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), `INSERT INTO app.users (id, contact) VALUES (3, "c@fox.db");`)
+	require.NoError(t, err)
+
+	rows = queryRows(t, db, "SELECT id, score, active, contact FROM app.users ORDER BY id;")
+	assert.Equal(t, [][]any{
+		{int64(1), int64(5), true, nil},
+		{int64(2), int64(5), true, nil},
+		{int64(3), nil, false, "c@fox.db"},
+	}, rows)
+}
+
+func TestAlterTableConstraints(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, email TEXT, score INT);",
+		`INSERT INTO app.users VALUES (1, "a@fox.db", NULL), (2, NULL, 7);`,
+	)
+
+	tests := []struct {
+		name string
+		sql  string
+	}{
+		{"not null column without default", "ALTER TABLE app.users ADD COLUMN age INT NOT NULL;"},
+		{"unique column with default", "ALTER TABLE app.users ADD COLUMN code INT UNIQUE DEFAULT 1;"},
+		{"primary key column", "ALTER TABLE app.users ADD COLUMN uid INT PRIMARY KEY;"},
+		{"existing column", "ALTER TABLE app.users ADD COLUMN email TEXT;"},
+		{"default of the wrong type", `ALTER TABLE app.users ADD COLUMN age INT DEFAULT "old";`},
+		{"set not null over null values", "ALTER TABLE app.users ALTER COLUMN email SET NOT NULL;"},
+		{"drop not null of primary key", "ALTER TABLE app.users ALTER COLUMN id DROP NOT NULL;"},
+		{"drop primary key column", "ALTER TABLE app.users DROP COLUMN id;"},
+		{"drop unknown column", "ALTER TABLE app.users DROP COLUMN age;"},
+		{"rename to existing column", "ALTER TABLE app.users RENAME COLUMN email TO score;"},
+		{"alter system table", "ALTER TABLE information_schema.tables RENAME TO t;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.Run(context.Background(), tt.sql)
+			assert.Error(t, err)
+		})
+	}
+
+	// failed statements left the table untouched
+	rows := queryRows(t, db, "SELECT * FROM app.users ORDER BY id;")
+	assert.Equal(t, [][]any{{int64(1), "a@fox.db", nil}, {int64(2), nil, int64(7)}}, rows)
+
+	_, err := db.Run(context.Background(), "ALTER TABLE app.users ALTER COLUMN score SET NOT NULL;")
+	assert.Error(t, err)
+	_, err = db.Run(context.Background(), "UPDATE app.users SET score = 0 WHERE score IS NULL;")
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "ALTER TABLE app.users ALTER COLUMN score SET NOT NULL;")
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "INSERT INTO app.users (id) VALUES (3);")
+	assert.ErrorContains(t, err, "not-null constraint")
+
+	_, err = db.Run(context.Background(), "ALTER TABLE app.users ALTER COLUMN score DROP NOT NULL;")
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "INSERT INTO app.users (id) VALUES (3);")
+	require.NoError(t, err)
+}
+
+func TestAlterTablePersistence(t *testing.T) {
+	path := t.TempDir()
+	db, err := Open(path)
+	require.NoError(t, err)
+	for _, sql := range []string{
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, name TEXT);",
+		`INSERT INTO app.users VALUES (1, "alice");`,
+		"ALTER TABLE app.users ADD COLUMN score FLOAT DEFAULT 2;",
+		"ALTER TABLE app.users ALTER COLUMN score SET DEFAULT 3;",
+		"ALTER TABLE app.users RENAME TO members;",
+	} {
+		_, err := db.Run(context.Background(), sql)
+		require.NoError(t, err, "statement failed: %s", sql)
+	}
+	require.NoError(t, db.Close())
+
+	db, err = Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Run(context.Background(), `INSERT INTO app.members (id, name) VALUES (2, "bob");`)
+	require.NoError(t, err)
+	rows := queryRows(t, db, "SELECT * FROM app.members ORDER BY id;")
+	assert.Equal(t, [][]any{{int64(1), "alice", 2.0}, {int64(2), "bob", 3.0}}, rows)
+}
+
+func TestColumnOrder(t *testing.T) {
+	columns := []string{"zeta", "alpha", "mike", "bravo", "yankee", "charlie", "xray", "delta", "echo", "whiskey"}
+	definitions := make([]string, len(columns))
+	values := make([]string, len(columns))
+	expected := make([]any, len(columns))
+	for i, column := range columns {
+		definitions[i] = column + " INT"
+		values[i] = fmt.Sprint(i)
+		expected[i] = int64(i)
+	}
+
+	path := t.TempDir()
+	db, err := Open(path)
+	require.NoError(t, err)
+	for _, sql := range []string{
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.wide (" + strings.Join(definitions, ", ") + ");",
+		"INSERT INTO app.wide VALUES (" + strings.Join(values, ", ") + ");",
+		"CREATE TABLE app.second (id INT);",
+		"CREATE TABLE app.third (id INT);",
+	} {
+		_, err := db.Run(context.Background(), sql)
+		require.NoError(t, err, "statement failed: %s", sql)
+	}
+	require.NoError(t, db.Close())
+
+	db, err = Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	// the order survives reopening the database
+	for range 5 {
+		rows := queryRows(t, db, "SELECT * FROM app.wide;")
+		assert.Equal(t, [][]any{expected}, rows)
+	}
+
+	schemaId := db.catalog.GetSchema("app").GetId()
+	rows := queryRows(t, db, fmt.Sprintf("SELECT name FROM information_schema.tables WHERE schema_id = %d;", schemaId))
+	assert.Equal(t, [][]any{{"wide"}, {"second"}, {"third"}}, rows)
+
+	// added columns go last, after a dropped column is gone
+	_, err = db.Run(context.Background(), "ALTER TABLE app.wide DROP COLUMN zeta;")
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "ALTER TABLE app.wide ADD COLUMN zeta TEXT;")
+	require.NoError(t, err)
+
+	table := db.catalog.GetSchema("app").GetTable("wide")
+	rows = queryRows(t, db, fmt.Sprintf("SELECT name, ordinal_position FROM information_schema.columns WHERE table_id = %d AND schema_id = %d;", table.GetId(), schemaId))
+	expectedColumns := [][]any{}
+	for i, column := range append(columns[1:], "zeta") {
+		expectedColumns = append(expectedColumns, []any{column, int64(i + 1)})
+	}
+	assert.Equal(t, expectedColumns, rows)
+}
+
+func TestInformationSchemaTables(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT);",
+		"CREATE TABLE users (id INT);",
+	)
+	app, public := db.catalog.GetSchema("app"), db.catalog.GetSchema("public")
+	require.Equal(t, app.GetTable("users").GetId(), public.GetTable("users").GetId())
+
+	// rows are keyed by the schema and the table id
+	tables := db.catalog.GetSchema("information_schema").GetTable("tables")
+	assert.Equal(t, []int{2, 0}, tables.GetPrimaryKeyIndexes())
+	rows := queryRows(t, db, "SELECT schema_id, id FROM information_schema.tables WHERE name = 'users' ORDER BY schema_id;")
+	assert.Equal(t, [][]any{
+		{int64(public.GetId()), int64(public.GetTable("users").GetId())},
+		{int64(app.GetId()), int64(app.GetTable("users").GetId())},
+	}, rows)
+}
+
+func affectedRows(t *testing.T, db *Database, sql string) int64 {
+	chunk, err := db.Run(context.Background(), sql)
+	require.NoError(t, err, "statement failed: %s", sql)
+	require.Len(t, chunk.GetRows(), 1)
+	count, err := chunk.GetRows()[0].Values[0].Int()
+	require.NoError(t, err)
+	return count
+}
+
+func TestUpdate(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, email TEXT UNIQUE, score INT NOT NULL);",
+		`INSERT INTO app.users VALUES (1, "a@fox.db", 10), (2, "b@fox.db", 20), (3, "c@fox.db", 30);`,
+	)
+
+	assert.Equal(t, int64(2), affectedRows(t, db, "UPDATE app.users SET score = score * 2 WHERE id >= 2;"))
+	rows := queryRows(t, db, "SELECT id, score FROM app.users ORDER BY id;")
+	assert.Equal(t, [][]any{{int64(1), int64(10)}, {int64(2), int64(40)}, {int64(3), int64(60)}}, rows)
+
+	// shifting every primary key moves records over keys being vacated
+	assert.Equal(t, int64(3), affectedRows(t, db, "UPDATE app.users SET id = id + 1;"))
+	rows = queryRows(t, db, "SELECT id, email FROM app.users ORDER BY id;")
+	assert.Equal(t, [][]any{{int64(2), "a@fox.db"}, {int64(3), "b@fox.db"}, {int64(4), "c@fox.db"}}, rows)
+
+	assert.Equal(t, int64(0), affectedRows(t, db, "UPDATE app.users SET score = 0 WHERE id > 100;"))
+}
+
+func TestUpdateConstraints(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, email TEXT UNIQUE, score INT NOT NULL);",
+		`INSERT INTO app.users VALUES (1, "a@fox.db", 10), (2, "b@fox.db", 20);`,
+	)
+
+	tests := []struct {
+		name string
+		sql  string
+	}{
+		{"primary key collision", "UPDATE app.users SET id = 2 WHERE id = 1;"},
+		{"primary key collision between updated rows", "UPDATE app.users SET id = 5;"},
+		{"unique collision", `UPDATE app.users SET email = "b@fox.db" WHERE id = 1;`},
+		{"not null violation", "UPDATE app.users SET score = NULL;"},
+		{"wrong value type", `UPDATE app.users SET score = "high";`},
+		{"unknown column", "UPDATE app.users SET age = 1;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.Run(context.Background(), tt.sql)
+			assert.Error(t, err)
+		})
+	}
+
+	rows := queryRows(t, db, "SELECT * FROM app.users ORDER BY id;")
+	assert.Equal(t, [][]any{{int64(1), "a@fox.db", int64(10)}, {int64(2), "b@fox.db", int64(20)}}, rows)
+}
+
+func TestDelete(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, score INT);",
+		"INSERT INTO app.users VALUES (1, 10), (2, 20), (3, 30), (4, 40);",
+	)
+
+	assert.Equal(t, int64(2), affectedRows(t, db, "DELETE FROM app.users WHERE score > 10 AND score < 40;"))
+	rows := queryRows(t, db, "SELECT id FROM app.users ORDER BY id;")
+	assert.Equal(t, [][]any{{int64(1)}, {int64(4)}}, rows)
+
+	assert.Equal(t, int64(2), affectedRows(t, db, "DELETE FROM app.users;"))
+	assert.Empty(t, queryRows(t, db, "SELECT id FROM app.users;"))
+}
+
+// reads the entries of an index as a map of index value to primary keys
+func indexEntries(t *testing.T, db *Database, schemaName string, tableName string, indexName string) map[string][]string {
+	schema := db.catalog.GetSchema(schemaName)
+	table := schema.GetTable(tableName)
+	index := table.GetIndex(indexName)
+	require.NotNil(t, index)
+
+	prefix := types.IndexKeyPrefix(uint32(schema.GetId()), uint32(table.GetId()), uint32(index.GetId()))
+	scan := db.storage.Scan(prefix)
+	defer scan.Close()
+
+	entries := map[string][]string{}
+	for ; scan.Valid(); scan.Next() {
+		key, value, err := scan.Item()
+		require.NoError(t, err)
+		indexValue, primaryKey := key[len(prefix):], value
+		if !index.IsUnique() {
+			// the primary key follows the values of the indexed columns
+			rest := indexValue
+			for range index.GetColumnIds() {
+				_, rest, err = types.DecodeKeyValue(rest, false)
+				require.NoError(t, err)
+			}
+			indexValue, primaryKey = indexValue[:len(indexValue)-len(rest)], rest
+		}
+		entries[string(indexValue)] = append(entries[string(indexValue)], string(primaryKey))
+	}
+	return entries
+}
+
+func encodedKey(t *testing.T, values ...types.Value) string {
+	key, err := types.EncodeKey(values)
+	require.NoError(t, err)
+	return string(key)
+}
+
+func TestIndexMaintenance(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, city TEXT, age INT);",
+		`INSERT INTO app.users VALUES (1, "paris", 30), (2, "tokyo", 25);`,
+		"CREATE INDEX users_city ON app.users (city);",
+	)
+	id := func(v int64) string { return encodedKey(t, *types.NewIntValue(v)) }
+	city := func(v string) string { return encodedKey(t, *types.NewTextValue(v)) }
+
+	// backfilled from the existing records
+	assert.Equal(t, map[string][]string{
+		city("paris"): {id(1)},
+		city("tokyo"): {id(2)},
+	}, indexEntries(t, db, "app", "users", "users_city"))
+
+	_, err := db.Run(context.Background(), `INSERT INTO app.users VALUES (3, "paris", 41);`)
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), `UPDATE app.users SET city = "lima", id = 20 WHERE id = 2;`)
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "DELETE FROM app.users WHERE id = 1;")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		city("paris"): {id(3)},
+		city("lima"):  {id(20)},
+	}, indexEntries(t, db, "app", "users", "users_city"))
+
+	_, err = db.Run(context.Background(), "DROP INDEX app.users_city;")
+	require.NoError(t, err)
+	assert.Nil(t, db.catalog.GetSchema("app").GetTable("users").GetIndex("users_city"))
+	_, err = db.Run(context.Background(), "DROP INDEX app.users_city;")
+	assert.Error(t, err)
+}
+
+func TestUniqueIndex(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, email TEXT);",
+		`INSERT INTO app.users VALUES (1, "a@fox.db"), (2, "a@fox.db");`,
+	)
+
+	// existing duplicates prevent the index creation
+	_, err := db.Run(context.Background(), "CREATE UNIQUE INDEX users_email ON app.users (email);")
+	assert.Error(t, err)
+	assert.Nil(t, db.catalog.GetSchema("app").GetTable("users").GetIndex("users_email"))
+
+	_, err = db.Run(context.Background(), `UPDATE app.users SET email = "b@fox.db" WHERE id = 2;`)
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "CREATE UNIQUE INDEX users_email ON app.users (email);")
+	require.NoError(t, err)
+
+	_, err = db.Run(context.Background(), `INSERT INTO app.users VALUES (3, "b@fox.db");`)
+	assert.Error(t, err)
+	_, err = db.Run(context.Background(), `UPDATE app.users SET email = "a@fox.db" WHERE id = 2;`)
+	assert.Error(t, err)
+
+	// swapping keys between records keeps the unique index consistent
+	_, err = db.Run(context.Background(), "UPDATE app.users SET id = 3 - id;")
+	require.NoError(t, err)
+	rows := queryRows(t, db, "SELECT * FROM app.users ORDER BY id;")
+	assert.Equal(t, [][]any{{int64(1), "b@fox.db"}, {int64(2), "a@fox.db"}}, rows)
+	_, err = db.Run(context.Background(), `INSERT INTO app.users VALUES (3, "a@fox.db");`)
+	assert.Error(t, err)
+
+	_, err = db.Run(context.Background(), "CREATE INDEX users_email ON app.users (id);")
+	assert.Error(t, err, "index names are unique")
+	_, err = db.Run(context.Background(), "CREATE INDEX users_missing ON app.users (age);")
+	assert.Error(t, err, "unknown column")
+}
+
+func TestCatalogPersistence(t *testing.T) {
+	path := t.TempDir()
+	db, err := Open(path)
+	require.NoError(t, err)
+	for _, sql := range []string{
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, email TEXT UNIQUE, name TEXT NOT NULL);",
+		"CREATE TABLE app.events (name TEXT, value INT);",
+		"CREATE TABLE app.logs (line TEXT);",
+		"CREATE UNIQUE INDEX users_name ON app.users (name);",
+		`INSERT INTO app.users VALUES (1, "a@fox.db", "alice"), (2, "b@fox.db", "bob");`,
+		`INSERT INTO app.events VALUES ("click", 1), ("view", 2);`,
+		"DROP TABLE app.logs;",
+	} {
+		_, err := db.Run(context.Background(), sql)
+		require.NoError(t, err, "statement failed: %s", sql)
+	}
+	require.NoError(t, db.Close())
+
+	db, err = Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	rows := queryRows(t, db, "SELECT name FROM information_schema.tables WHERE name = \"logs\";")
+	assert.Empty(t, rows)
+	rows = queryRows(t, db, "SELECT id, name FROM app.users ORDER BY id;")
+	assert.Equal(t, [][]any{{int64(1), "alice"}, {int64(2), "bob"}}, rows)
+	assert.Equal(t, map[string][]string{
+		encodedKey(t, *types.NewTextValue("alice")): {encodedKey(t, *types.NewIntValue(1))},
+		encodedKey(t, *types.NewTextValue("bob")):   {encodedKey(t, *types.NewIntValue(2))},
+	}, indexEntries(t, db, "app", "users", "users_name"))
+
+	// constraints and indexes are still enforced
+	_, err = db.Run(context.Background(), `INSERT INTO app.users VALUES (3, "c@fox.db", "alice");`)
+	assert.Error(t, err)
+	_, err = db.Run(context.Background(), `INSERT INTO app.users VALUES (3, "a@fox.db", "carol");`)
+	assert.Error(t, err)
+
+	// rowids keep increasing instead of overwriting existing records
+	_, err = db.Run(context.Background(), `INSERT INTO app.events VALUES ("scroll", 3);`)
+	require.NoError(t, err)
+	rows = queryRows(t, db, "SELECT * FROM app.events;")
+	assert.Equal(t, [][]any{{"click", int64(1)}, {"view", int64(2)}, {"scroll", int64(3)}}, rows)
+
+	// new objects do not reuse the ids of existing ones
+	_, err = db.Run(context.Background(), "CREATE TABLE app.sessions (id INT PRIMARY KEY);")
+	require.NoError(t, err)
+	assert.NotEqual(t, db.catalog.GetSchema("app").GetTable("users").GetId(), db.catalog.GetSchema("app").GetTable("sessions").GetId())
+}
+
+func TestParameters(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, name TEXT, score FLOAT, active BOOL);",
+	)
+	ctx := context.Background()
+	session := db.NewSession("", "")
+
+	prepare := func(sql string) ([]types.DataType, func(values ...types.Value) (*types.DataChunk, error)) {
+		statements, err := db.Parse(sql)
+		require.NoError(t, err)
+		require.Len(t, statements, 1)
+		parameterTypes, err := session.DescribeParameters(statements[0])
+		require.NoError(t, err)
+		return parameterTypes, func(values ...types.Value) (*types.DataChunk, error) {
+			return session.Execute(ctx, statements[0], values)
+		}
+	}
+
+	insertTypes, insert := prepare("INSERT INTO app.users (id, active, name, score) VALUES ($1, $2, $3, $4);")
+	assert.Equal(t, []types.DataType{types.TYPE_INT, types.TYPE_BOOL, types.TYPE_TEXT, types.TYPE_FLOAT}, insertTypes)
+	_, err := insert(*types.NewIntValue(1), *types.NewBoolValue(true), *types.NewTextValue("alice"), *types.NewFloatValue(9.5))
+	require.NoError(t, err)
+	_, err = insert(*types.NewIntValue(2), *types.NewBoolValue(false), *types.NewNullValue(), *types.NewFloatValue(4.25))
+	require.NoError(t, err)
+
+	selectTypes, selectByScore := prepare("SELECT id FROM app.users WHERE score > ? AND active = ? OR name IS NULL;")
+	assert.Equal(t, []types.DataType{types.TYPE_FLOAT, types.TYPE_BOOL}, selectTypes)
+	chunk, err := selectByScore(*types.NewFloatValue(5), *types.NewBoolValue(true))
+	require.NoError(t, err)
+	assert.Len(t, chunk.GetRows(), 2)
+
+	updateTypes, update := prepare("UPDATE app.users SET score = score + $1 WHERE name = $2;")
+	assert.Equal(t, []types.DataType{types.TYPE_FLOAT, types.TYPE_TEXT}, updateTypes)
+	_, err = update(*types.NewFloatValue(0.5), *types.NewTextValue("alice"))
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{10.0}}, queryRows(t, db, "SELECT score FROM app.users WHERE id = 1;"))
+
+	// a parameter whose type nothing tells about is TEXT
+	untypedTypes, _ := prepare("SELECT id FROM app.users WHERE $1 IS NULL;")
+	assert.Equal(t, []types.DataType{types.TYPE_TEXT}, untypedTypes)
+
+	// statements without parameters are not bound, their table may not exist yet
+	for _, sql := range []string{"INSERT INTO app.later VALUES (1);", "UPDATE app.later SET id = 2;", "DELETE FROM app.later;", "SELECT id FROM app.later;"} {
+		statements, err := db.Parse(sql)
+		require.NoError(t, err)
+		parameterTypes, err := session.DescribeParameters(statements[0])
+		require.NoError(t, err, sql)
+		assert.Empty(t, parameterTypes, sql)
+	}
+
+	_, err = update(*types.NewFloatValue(1))
+	assert.ErrorContains(t, err, "bind message supplies 1 parameters, but prepared statement requires 2")
+	assert.Equal(t, types.ERR_PROTOCOL_VIOLATION, types.GetErrorCode(err))
+
+	// binding does not change the prepared statement
+	_, err = update(*types.NewFloatValue(1), *types.NewTextValue("alice"))
+	require.NoError(t, err)
+	assert.Equal(t, [][]any{{11.0}}, queryRows(t, db, "SELECT score FROM app.users WHERE id = 1;"))
+
+	joinTypes, selectJoined := prepare("SELECT u.id FROM app.users u JOIN app.users v ON u.id = v.id AND v.score > $1 WHERE u.name = $2;")
+	assert.Equal(t, []types.DataType{types.TYPE_FLOAT, types.TYPE_TEXT}, joinTypes)
+	chunk, err = selectJoined(*types.NewFloatValue(5), *types.NewTextValue("alice"))
+	require.NoError(t, err)
+	assert.Len(t, chunk.GetRows(), 1)
+
+	groupTypes, selectGrouped := prepare("SELECT active, COUNT(*) FROM app.users WHERE id > $1 GROUP BY active HAVING SUM(score) > $2;")
+	assert.Equal(t, []types.DataType{types.TYPE_INT, types.TYPE_FLOAT}, groupTypes)
+	chunk, err = selectGrouped(*types.NewIntValue(0), *types.NewFloatValue(5))
+	require.NoError(t, err)
+	assert.Len(t, chunk.GetRows(), 1)
+}
+
+func TestUsers(t *testing.T) {
+	path := t.TempDir()
+	db, err := Open(path)
+	require.NoError(t, err)
+	for _, sql := range []string{
+		`CREATE USER alice WITH PASSWORD 'secret';`,
+		"CREATE USER bob;",
+		`ALTER USER bob PASSWORD 'hunter2';`,
+		"CREATE USER carol;",
+		"DROP USER carol;",
+	} {
+		_, err := db.Run(context.Background(), sql)
+		require.NoError(t, err, "statement failed: %s", sql)
+	}
+
+	_, err = db.Run(context.Background(), "CREATE USER alice;")
+	assert.Equal(t, types.ERR_DUPLICATE_OBJECT, types.GetErrorCode(err))
+	_, err = db.Run(context.Background(), "DROP USER carol;")
+	assert.Equal(t, types.ERR_UNDEFINED_OBJECT, types.GetErrorCode(err))
+	_, err = db.Run(context.Background(), `ALTER USER dave PASSWORD 'x';`)
+	assert.Equal(t, types.ERR_UNDEFINED_OBJECT, types.GetErrorCode(err))
+	_, err = db.Run(context.Background(), `CREATE USER dave PASSWORD '';`)
+	assert.Equal(t, types.ERR_INVALID_PARAMETER_VALUE, types.GetErrorCode(err))
+	require.NoError(t, db.Close())
+
+	// users and their credentials are persisted with the catalog
+	db, err = Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	credentials, ok := db.UserCredentials("alice")
+	require.True(t, ok)
+	assert.True(t, credentials.VerifyPassword("secret"))
+	assert.NotContains(t, credentials.Scram+credentials.Md5, "secret")
+
+	credentials, ok = db.UserCredentials("bob")
+	require.True(t, ok)
+	assert.True(t, credentials.VerifyPassword("hunter2"))
+
+	_, ok = db.UserCredentials("carol")
+	assert.False(t, ok)
+
+	// dropping the password keeps the user but it can no longer log in
+	_, err = db.Run(context.Background(), "ALTER USER bob PASSWORD NULL;")
+	require.NoError(t, err)
+	credentials, ok = db.UserCredentials("bob")
+	assert.True(t, ok)
+	assert.Nil(t, credentials)
+}
+
+func TestSearchPath(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE SCHEMA alice;",
+		"CREATE TABLE shared (name TEXT);",
+		`INSERT INTO public.shared VALUES ("public");`,
+	)
+	ctx := context.Background()
+	run := func(session *Session, sql string) {
+		_, err := session.Run(ctx, sql)
+		require.NoError(t, err, "statement failed: %s", sql)
+	}
+
+	// the database of the session is searched before public
+	session := db.NewSession("alice", "app")
+	assert.Equal(t, [][]any{{"app, public"}}, sessionRows(t, session, "SHOW search_path;"))
+	assert.Equal(t, [][]any{{"public"}}, sessionRows(t, session, "SELECT name FROM shared;"))
+	run(session, "CREATE TABLE shared (name TEXT);")
+	run(session, `INSERT INTO shared VALUES ("app");`)
+	run(session, "CREATE INDEX shared_name ON shared (name);")
+	assert.Equal(t, [][]any{{"app"}}, sessionRows(t, session, "SELECT name FROM shared;"))
+	assert.Equal(t, [][]any{{"app"}}, sessionRows(t, session, "SELECT name FROM app.shared;"))
+	assert.NotNil(t, db.catalog.GetSchema("app").GetTable("shared").GetIndex("shared_name"))
+
+	// each session has its own search path
+	assert.Equal(t, [][]any{{"public"}}, queryRows(t, db, "SELECT name FROM shared;"))
+
+	run(session, `SET search_path TO "$user", public;`)
+	assert.Equal(t, [][]any{{"$user, public"}}, sessionRows(t, session, "SHOW search_path;"))
+	run(session, "CREATE TABLE notes (body TEXT);")
+	assert.NotNil(t, db.catalog.GetSchema("alice").GetTable("notes"))
+	assert.Equal(t, [][]any{{"public"}}, sessionRows(t, session, "SELECT name FROM shared;"))
+
+	run(session, "SET search_path = app;")
+	run(session, "DROP INDEX shared_name;")
+	run(session, "DROP TABLE shared;")
+	assert.Nil(t, db.catalog.GetSchema("app").GetTable("shared"))
+	assert.NotNil(t, db.catalog.GetSchema("public").GetTable("shared"))
+
+	// schemas of the search path that do not exist are skipped
+	run(session, "SET search_path = missing;")
+	_, err := session.Run(ctx, "CREATE TABLE orphan (id INT);")
+	assert.Equal(t, types.ERR_INVALID_SCHEMA_NAME, types.GetErrorCode(err))
+	_, err = session.Run(ctx, "SELECT name FROM shared;")
+	assert.Equal(t, types.ERR_UNDEFINED_TABLE, types.GetErrorCode(err))
+	assert.Equal(t, [][]any{{"public"}}, sessionRows(t, session, "SELECT name FROM public.shared;"))
+
+	_, err = session.Run(ctx, "SET unknown_setting = 1;")
+	assert.Equal(t, types.ERR_UNDEFINED_OBJECT, types.GetErrorCode(err))
+	_, err = session.Run(ctx, "SHOW unknown_setting;")
+	assert.Equal(t, types.ERR_UNDEFINED_OBJECT, types.GetErrorCode(err))
+}
+
+func TestSettings(t *testing.T) {
+	db := setupTestDatabase(t)
+	session := db.NewSession("alice", "app")
+	ctx := context.Background()
+	show := func(name string) string {
+		rows := sessionRows(t, session, "SHOW "+name+";")
+		require.Len(t, rows, 1)
+		return rows[0][0].(string)
+	}
+
+	tests := []struct {
+		set      string
+		name     string
+		expected string
+	}{
+		{"SET statement_timeout = 5000;", "statement_timeout", "5s"},
+		{"SET statement_timeout TO '150ms';", "statement_timeout", "150ms"},
+		{"SET statement_timeout = '2min';", "statement_timeout", "2min"},
+		{"SET statement_timeout = 0;", "statement_timeout", "0"},
+		{"SET DateStyle = 'Postgres, DMY';", "datestyle", "Postgres, DMY"},
+		{"SET datestyle = ymd;", "DATESTYLE", "Postgres, YMD"},
+		{"SET client_encoding = 'utf-8';", "client_encoding", "UTF8"},
+		{"SET TimeZone = UTC;", "timezone", "UTC"},
+		{"SET application_name = 'reports';", "application_name", "reports"},
+		{"SET default_transaction_isolation = 'SERIALIZABLE';", "default_transaction_isolation", "serializable"},
+		{"SET search_path = 'a, b', c;", "search_path", "a, b, c"},
+		{"SET SESSION search_path TO DEFAULT;", "search_path", "app, public"},
+		{"RESET datestyle;", "datestyle", "ISO, MDY"},
+	}
+	for _, tt := range tests {
+		_, err := session.Run(ctx, tt.set)
+		require.NoError(t, err, "statement failed: %s", tt.set)
+		assert.Equal(t, tt.expected, show(tt.name), tt.set)
+	}
+
+	_, err := session.Run(ctx, "RESET ALL;")
+	require.NoError(t, err)
+	rows := sessionRows(t, session, "SHOW ALL;")
+	settings := map[string]any{}
+	for _, row := range rows {
+		settings[row[0].(string)] = row[1]
+	}
+	assert.Equal(t, map[string]any{
+		"application_name":              "",
+		"client_encoding":               "UTF8",
+		"DateStyle":                     "ISO, MDY",
+		"default_transaction_isolation": "read committed",
+		"search_path":                   "app, public",
+		"statement_timeout":             "0",
+		"TimeZone":                      "UTC",
+	}, settings)
+
+	for _, sql := range []string{
+		"SET statement_timeout = '-1';",
+		"SET statement_timeout = '5 days';",
+		"SET statement_timeout = 1, 2;",
+		"SET client_encoding = LATIN1;",
+		"SET DateStyle = 'Klingon';",
+		"SET TimeZone = 'Nowhere/Atlantis';",
+		"SET default_transaction_isolation = 'snapshot';",
+	} {
+		_, err := session.Run(ctx, sql)
+		assert.Equal(t, types.ERR_INVALID_PARAMETER_VALUE, types.GetErrorCode(err), sql)
+	}
+	for _, sql := range []string{"SET work_mem = 64;", "SHOW work_mem;", "RESET work_mem;"} {
+		_, err := session.Run(ctx, sql)
+		assert.Equal(t, types.ERR_UNDEFINED_OBJECT, types.GetErrorCode(err), sql)
+	}
+	assert.Equal(t, "0", show("statement_timeout"))
+
+	// settings changed in a transaction are kept when it commits and
+	// restored when it or a savepoint rolls back
+	for _, sql := range []string{
+		"BEGIN;",
+		"SET application_name = 'kept';",
+		"COMMIT;",
+		"BEGIN;",
+		"SET datestyle = 'German';",
+		"SET TRANSACTION ISOLATION LEVEL SERIALIZABLE;",
+		"SAVEPOINT before_reset;",
+		"RESET ALL;",
+		"SET TimeZone = 'Europe/Paris';",
+	} {
+		_, err := session.Run(ctx, sql)
+		require.NoError(t, err, "statement failed: %s", sql)
+	}
+	assert.Equal(t, "", show("application_name"))
+	_, err = session.Run(ctx, "ROLLBACK TO SAVEPOINT before_reset;")
+	require.NoError(t, err)
+	assert.Equal(t, "German, MDY", show("datestyle"))
+	assert.Equal(t, "kept", show("application_name"))
+	assert.Equal(t, "UTC", show("timezone"))
+	_, err = session.Run(ctx, "ROLLBACK;")
+	require.NoError(t, err)
+	assert.Equal(t, "ISO, MDY", show("datestyle"))
+	assert.Equal(t, "kept", show("application_name"))
+}
+
+func TestTransactions(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.accounts (id INT PRIMARY KEY, balance INT NOT NULL);",
+		"INSERT INTO app.accounts VALUES (1, 100), (2, 50);",
+	)
+	ctx := context.Background()
+	session := db.NewSession("", "app")
+	other := db.NewSession("", "app")
+	run := func(s *Session, sql string) error {
+		_, err := s.Run(ctx, sql)
+		return err
+	}
+
+	// writes are only seen by others once committed
+	require.NoError(t, run(session, "BEGIN;"))
+	assert.Equal(t, TRANSACTION_ACTIVE, session.TransactionStatus())
+	require.NoError(t, run(session, "UPDATE accounts SET balance = balance - 30 WHERE id = 1;"))
+	require.NoError(t, run(session, "UPDATE accounts SET balance = balance + 30 WHERE id = 2;"))
+	assert.Equal(t, [][]any{{int64(1), int64(70)}, {int64(2), int64(80)}}, sessionRows(t, session, "SELECT id, balance FROM accounts;"))
+	assert.Equal(t, [][]any{{int64(1), int64(100)}, {int64(2), int64(50)}}, sessionRows(t, other, "SELECT id, balance FROM accounts;"))
+	require.NoError(t, run(session, "COMMIT;"))
+	assert.Equal(t, TRANSACTION_IDLE, session.TransactionStatus())
+	assert.Equal(t, [][]any{{int64(1), int64(70)}, {int64(2), int64(80)}}, sessionRows(t, other, "SELECT id, balance FROM accounts;"))
+
+	// rolled back writes are discarded
+	require.NoError(t, run(session, "START TRANSACTION;"))
+	require.NoError(t, run(session, "INSERT INTO accounts VALUES (3, 10);"))
+	require.NoError(t, run(session, "DELETE FROM accounts WHERE id = 1;"))
+	require.NoError(t, run(session, "ROLLBACK;"))
+	assert.Equal(t, [][]any{{int64(1)}, {int64(2)}}, sessionRows(t, session, "SELECT id FROM accounts;"))
+
+	// an error fails the transaction until it ends, committing it rolls it back
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(session, "INSERT INTO accounts VALUES (3, 10);"))
+	err := run(session, "INSERT INTO accounts VALUES (1, 10);")
+	assert.Equal(t, types.ERR_UNIQUE_VIOLATION, types.GetErrorCode(err))
+	assert.Equal(t, TRANSACTION_FAILED, session.TransactionStatus())
+	err = run(session, "SELECT id FROM accounts;")
+	assert.Equal(t, types.ERR_IN_FAILED_TRANSACTION, types.GetErrorCode(err))
+	require.NoError(t, run(session, "COMMIT;"))
+	assert.Equal(t, TRANSACTION_IDLE, session.TransactionStatus())
+	assert.Equal(t, [][]any{{int64(1)}, {int64(2)}}, sessionRows(t, session, "SELECT id FROM accounts;"))
+
+	// ending a transaction that is not open does nothing
+	require.NoError(t, run(session, "COMMIT;"))
+	require.NoError(t, run(session, "ROLLBACK;"))
+
+	// closing a session rolls back its transaction
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(session, "DELETE FROM accounts;"))
+	session.Close()
+	assert.Equal(t, TRANSACTION_IDLE, session.TransactionStatus())
+	assert.Len(t, sessionRows(t, other, "SELECT id FROM accounts;"), 2)
+}
+
+func TestTransactionConflict(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.counters (id INT PRIMARY KEY, value INT NOT NULL);",
+		"INSERT INTO app.counters VALUES (1, 0);",
+	)
+	ctx := context.Background()
+	first := db.NewSession("", "app")
+	second := db.NewSession("", "app")
+
+	for _, session := range []*Session{first, second} {
+		_, err := session.Run(ctx, "BEGIN;")
+		require.NoError(t, err)
+		_, err = session.Run(ctx, "UPDATE counters SET value = value + 1 WHERE id = 1;")
+		require.NoError(t, err)
+	}
+
+	_, err := first.Run(ctx, "COMMIT;")
+	require.NoError(t, err)
+	_, err = second.Run(ctx, "COMMIT;")
+	assert.Equal(t, types.ERR_SERIALIZATION_FAILURE, types.GetErrorCode(err))
+	assert.Equal(t, TRANSACTION_IDLE, second.TransactionStatus())
+
+	// the update of the first transaction is the only one applied
+	assert.Equal(t, [][]any{{int64(1)}}, sessionRows(t, second, "SELECT value FROM counters;"))
+
+	// statements committed on their own conflict with transactions too
+	_, err = first.Run(ctx, "BEGIN;")
+	require.NoError(t, err)
+	_, err = first.Run(ctx, "SELECT value FROM counters;")
+	require.NoError(t, err)
+	_, err = first.Run(ctx, "UPDATE counters SET value = 10 WHERE id = 1;")
+	require.NoError(t, err)
+	_, err = second.Run(ctx, "UPDATE counters SET value = 20 WHERE id = 1;")
+	require.NoError(t, err)
+	_, err = first.Run(ctx, "COMMIT;")
+	assert.Equal(t, types.ERR_SERIALIZATION_FAILURE, types.GetErrorCode(err))
+	assert.Equal(t, [][]any{{int64(20)}}, sessionRows(t, second, "SELECT value FROM counters;"))
+}
+
+func TestUniqueConflict(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, email TEXT UNIQUE);",
+	)
+	ctx := context.Background()
+	first := db.NewSession("", "app")
+	second := db.NewSession("", "app")
+
+	// neither transaction sees the row of the other one
+	for i, session := range []*Session{first, second} {
+		_, err := session.Run(ctx, "BEGIN;")
+		require.NoError(t, err)
+		_, err = session.Run(ctx, fmt.Sprintf("INSERT INTO users VALUES (%d, 'a@fox.db');", i+1))
+		require.NoError(t, err)
+	}
+
+	_, err := first.Run(ctx, "COMMIT;")
+	require.NoError(t, err)
+	_, err = second.Run(ctx, "COMMIT;")
+	assert.Equal(t, types.ERR_SERIALIZATION_FAILURE, types.GetErrorCode(err))
+	assert.Equal(t, [][]any{{int64(1), "a@fox.db"}}, sessionRows(t, second, "SELECT * FROM users;"))
+}
+
+func TestUniqueColumnEntries(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.users (id INT PRIMARY KEY, email TEXT UNIQUE);",
+		"INSERT INTO app.users VALUES (1, 'a@fox.db'), (2, 'b@fox.db'), (3, NULL), (4, NULL);",
+	)
+
+	// values follow their record and are reused once it is gone
+	_, err := db.Run(context.Background(), `UPDATE app.users SET id = id + 10 WHERE email IS NOT NULL;`)
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), `DELETE FROM app.users WHERE id = 11;`)
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "INSERT INTO app.users VALUES (1, 'a@fox.db');")
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "INSERT INTO app.users VALUES (5, 'b@fox.db');")
+	assert.Equal(t, types.ERR_UNIQUE_VIOLATION, types.GetErrorCode(err))
+
+	// a unique column added with a default holds it for the single record
+	_, err = db.Run(context.Background(), "DELETE FROM app.users WHERE id <> 1;")
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "ALTER TABLE app.users ADD COLUMN code INT UNIQUE DEFAULT 7;")
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "INSERT INTO app.users (id, email) VALUES (2, 'b@fox.db');")
+	assert.Equal(t, types.ERR_UNIQUE_VIOLATION, types.GetErrorCode(err))
+
+	// the entries of a dropped column do not outlive it
+	schema := db.publishedCatalog().GetSchema("app")
+	table := schema.GetTable("users")
+	prefix := types.IndexKeyPrefix(uint32(schema.GetId()), uint32(table.GetId()), uint32(table.GetColumn("code").GetId()))
+	lastKey, err := db.storage.LastKey(prefix)
+	require.NoError(t, err)
+	assert.NotNil(t, lastKey)
+	_, err = db.Run(context.Background(), "ALTER TABLE app.users DROP COLUMN code;")
+	require.NoError(t, err)
+	lastKey, err = db.storage.LastKey(prefix)
+	require.NoError(t, err)
+	assert.Nil(t, lastKey)
+}
+
+func TestTransactionalDDL(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.accounts (id INT PRIMARY KEY, balance INT NOT NULL);",
+		"INSERT INTO app.accounts VALUES (1, 100);",
+	)
+	ctx := context.Background()
+	session := db.NewSession("", "app")
+	other := db.NewSession("", "app")
+	run := func(s *Session, sql string) error {
+		_, err := s.Run(ctx, sql)
+		return err
+	}
+
+	// a rolled back transaction undoes its DDL along with its writes
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(session, "CREATE TABLE logs (id INT, message TEXT);"))
+	require.NoError(t, run(session, "INSERT INTO logs VALUES (1, 'created');"))
+	require.NoError(t, run(session, "ALTER TABLE accounts ADD COLUMN owner TEXT;"))
+	assert.Equal(t, [][]any{{int64(1), "created"}}, sessionRows(t, session, "SELECT id, message FROM logs;"))
+	err := run(other, "SELECT id FROM logs;")
+	assert.Equal(t, types.ERR_UNDEFINED_TABLE, types.GetErrorCode(err))
+	require.NoError(t, run(session, "ROLLBACK;"))
+	err = run(session, "SELECT id FROM logs;")
+	assert.Equal(t, types.ERR_UNDEFINED_TABLE, types.GetErrorCode(err))
+	err = run(session, "SELECT owner FROM accounts;")
+	assert.Equal(t, types.ERR_UNDEFINED_COLUMN, types.GetErrorCode(err))
+
+	// a failed transaction is rolled back with its DDL
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(session, "CREATE TABLE logs (id INT);"))
+	err = run(session, "INSERT INTO accounts VALUES (1, 10);")
+	assert.Equal(t, types.ERR_UNIQUE_VIOLATION, types.GetErrorCode(err))
+	require.NoError(t, run(session, "COMMIT;"))
+	err = run(session, "SELECT id FROM logs;")
+	assert.Equal(t, types.ERR_UNDEFINED_TABLE, types.GetErrorCode(err))
+
+	// committed DDL is published to the other sessions
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(session, "CREATE TABLE logs (id INT);"))
+	require.NoError(t, run(session, "DROP TABLE accounts;"))
+	assert.Len(t, sessionRows(t, other, "SELECT id FROM accounts;"), 1)
+	require.NoError(t, run(session, "COMMIT;"))
+	assert.Empty(t, sessionRows(t, other, "SELECT id FROM logs;"))
+	err = run(other, "SELECT id FROM accounts;")
+	assert.Equal(t, types.ERR_UNDEFINED_TABLE, types.GetErrorCode(err))
+
+	// rolling back to a savepoint undoes the DDL run since
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(session, "CREATE TABLE events (id INT);"))
+	require.NoError(t, run(session, "SAVEPOINT before_drop;"))
+	require.NoError(t, run(session, "DROP TABLE logs;"))
+	require.NoError(t, run(session, "ROLLBACK TO SAVEPOINT before_drop;"))
+	require.NoError(t, run(session, "COMMIT;"))
+	assert.Empty(t, sessionRows(t, other, "SELECT id FROM logs;"))
+	assert.Empty(t, sessionRows(t, other, "SELECT id FROM events;"))
+
+	// repeatable read transactions keep the catalog of their snapshot
+	require.NoError(t, run(other, "BEGIN ISOLATION LEVEL REPEATABLE READ;"))
+	require.NoError(t, run(other, "SELECT id FROM logs;"))
+	require.NoError(t, run(session, "CREATE TABLE metrics (id INT);"))
+	err = run(other, "SELECT id FROM metrics;")
+	assert.Equal(t, types.ERR_UNDEFINED_TABLE, types.GetErrorCode(err))
+	require.NoError(t, run(other, "ROLLBACK;"))
+	assert.Empty(t, sessionRows(t, other, "SELECT id FROM metrics;"))
+
+	// read committed statements see the DDL committed since the transaction began
+	require.NoError(t, run(other, "BEGIN;"))
+	require.NoError(t, run(session, "DROP TABLE metrics;"))
+	err = run(other, "SELECT id FROM metrics;")
+	assert.Equal(t, types.ERR_UNDEFINED_TABLE, types.GetErrorCode(err))
+	require.NoError(t, run(other, "ROLLBACK;"))
+
+	// catalog versions share the row id sequences of their tables
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(session, "CREATE TABLE counters (id INT);"))
+	require.NoError(t, run(session, "INSERT INTO logs VALUES (1);"))
+	require.NoError(t, run(other, "INSERT INTO logs VALUES (2);"))
+	require.NoError(t, run(session, "COMMIT;"))
+	assert.Len(t, sessionRows(t, other, "SELECT id FROM logs;"), 2)
+
+	// concurrent DDL cannot both commit
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(other, "BEGIN;"))
+	require.NoError(t, run(session, "CREATE TABLE audits (id INT);"))
+	require.NoError(t, run(other, "CREATE TABLE reports (id INT);"))
+	require.NoError(t, run(session, "COMMIT;"))
+	err = run(other, "COMMIT;")
+	assert.Equal(t, types.ERR_SERIALIZATION_FAILURE, types.GetErrorCode(err))
+	assert.Empty(t, sessionRows(t, other, "SELECT id FROM audits;"))
+	err = run(other, "SELECT id FROM reports;")
+	assert.Equal(t, types.ERR_UNDEFINED_TABLE, types.GetErrorCode(err))
+
+	// the published catalog is the one stored
+	require.NoError(t, db.LoadCatalog())
+	assert.Empty(t, sessionRows(t, other, "SELECT id FROM audits;"))
+	err = run(other, "SELECT id FROM reports;")
+	assert.Equal(t, types.ERR_UNDEFINED_TABLE, types.GetErrorCode(err))
+}
+
+func TestConcurrentDDL(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.items (id INT PRIMARY KEY, name TEXT);",
+		"INSERT INTO app.items VALUES (1, 'pen');",
+	)
+	ctx := context.Background()
+	session := db.NewSession("", "app")
+	other := db.NewSession("", "app")
+	run := func(s *Session, sql string) error {
+		_, err := s.Run(ctx, sql)
+		return err
+	}
+
+	// a write planned before an index exists cannot commit after it
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(session, "INSERT INTO items VALUES (2, 'ink');"))
+	require.NoError(t, run(other, "CREATE INDEX items_name ON items (name);"))
+	err := run(session, "COMMIT;")
+	assert.Equal(t, types.ERR_SERIALIZATION_FAILURE, types.GetErrorCode(err))
+
+	// an index backfilled without a concurrent write cannot commit after it
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(session, "CREATE INDEX items_id_name ON items (id, name);"))
+	require.NoError(t, run(other, "INSERT INTO items VALUES (3, 'pad');"))
+	err = run(session, "COMMIT;")
+	assert.Equal(t, types.ERR_SERIALIZATION_FAILURE, types.GetErrorCode(err))
+	assert.Equal(t, [][]any{{int64(3)}}, sessionRows(t, other, "SELECT id FROM items WHERE name = 'pad';"))
+
+	// a NULL written concurrently with SET NOT NULL is rejected either way
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(session, "INSERT INTO items VALUES (4, NULL);"))
+	require.NoError(t, run(other, "ALTER TABLE items ALTER COLUMN name SET NOT NULL;"))
+	err = run(session, "COMMIT;")
+	assert.Equal(t, types.ERR_SERIALIZATION_FAILURE, types.GetErrorCode(err))
+	require.NoError(t, run(other, "ALTER TABLE items ALTER COLUMN name DROP NOT NULL;"))
+
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(session, "ALTER TABLE items ALTER COLUMN name SET NOT NULL;"))
+	require.NoError(t, run(other, "INSERT INTO items VALUES (4, NULL);"))
+	err = run(session, "COMMIT;")
+	assert.Equal(t, types.ERR_SERIALIZATION_FAILURE, types.GetErrorCode(err))
+	assert.Equal(t, [][]any{{int64(4)}}, sessionRows(t, other, "SELECT id FROM items WHERE name IS NULL;"))
+
+	// writes to other tables do not conflict with the DDL
+	require.NoError(t, run(other, "CREATE TABLE logs (id INT);"))
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(session, "INSERT INTO logs VALUES (1);"))
+	require.NoError(t, run(other, "DROP INDEX items_name;"))
+	require.NoError(t, run(session, "COMMIT;"))
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.items (id INT PRIMARY KEY, name TEXT, code INT);",
+		"CREATE INDEX items_name ON app.items (name);",
+		"CREATE UNIQUE INDEX items_code ON app.items (code);",
+		"CREATE TABLE app.counters (id INT PRIMARY KEY, n INT);",
+		"INSERT INTO app.counters VALUES (1, 0);",
+	)
+	ctx := context.Background()
+	session := db.NewSession("", "app")
+	other := db.NewSession("", "app")
+	run := func(s *Session, sql string) error {
+		_, err := s.Run(ctx, sql)
+		return err
+	}
+
+	// records sharing a value of a non-unique index are written concurrently
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(other, "BEGIN;"))
+	require.NoError(t, run(session, "INSERT INTO items VALUES (1, 'pen', 1);"))
+	require.NoError(t, run(other, "INSERT INTO items VALUES (2, 'pen', 2);"))
+	require.NoError(t, run(session, "COMMIT;"))
+	require.NoError(t, run(other, "COMMIT;"))
+	assert.Equal(t, [][]any{{int64(1)}, {int64(2)}}, sessionRows(t, session, "SELECT i.id FROM items AS n JOIN items AS i ON i.name = n.name WHERE n.id = 1 ORDER BY i.id;"))
+
+	// the value of a unique index is not
+	require.NoError(t, run(session, "BEGIN;"))
+	require.NoError(t, run(other, "BEGIN;"))
+	require.NoError(t, run(session, "INSERT INTO items VALUES (3, 'ink', 3);"))
+	require.NoError(t, run(other, "INSERT INTO items VALUES (4, 'ink', 3);"))
+	require.NoError(t, run(session, "COMMIT;"))
+	err := run(other, "COMMIT;")
+	assert.Equal(t, types.ERR_SERIALIZATION_FAILURE, types.GetErrorCode(err))
+	assert.Equal(t, [][]any{{int64(1), int64(1)}, {int64(2), int64(2)}, {int64(3), int64(3)}}, sessionRows(t, session, "SELECT n.id, i.id FROM items AS n JOIN items AS i ON i.code = n.code ORDER BY n.id;"))
+
+	// statements outside of a transaction block run again when they
+	// conflict, no increment is lost
+	const workers, increments = 8, 100
+	errs := make(chan error, workers)
+	for range workers {
+		go func() {
+			worker := db.NewSession("", "app")
+			for range increments {
+				if err := run(worker, "UPDATE counters SET n = n + 1 WHERE id = 1;"); err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}()
+	}
+	// every worker ends before the database is closed
+	failures := []error{}
+	for range workers {
+		if err := <-errs; err != nil {
+			failures = append(failures, err)
+		}
+	}
+	require.Empty(t, failures)
+	assert.Equal(t, [][]any{{int64(workers * increments)}}, sessionRows(t, session, "SELECT n FROM counters;"))
+}
+
+func TestSavepoints(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.items (id INT PRIMARY KEY, name TEXT UNIQUE);",
+		"CREATE INDEX items_name ON app.items (name);",
+		"INSERT INTO app.items VALUES (1, 'a');",
+	)
+	ctx := context.Background()
+	session := db.NewSession("", "app")
+	run := func(sql string) error {
+		_, err := session.Run(ctx, sql)
+		return err
+	}
+	ids := func() [][]any {
+		return sessionRows(t, session, "SELECT id, name FROM items;")
+	}
+
+	require.NoError(t, run("BEGIN;"))
+	require.NoError(t, run("INSERT INTO items VALUES (2, 'b');"))
+	require.NoError(t, run("SAVEPOINT first;"))
+	require.NoError(t, run("INSERT INTO items VALUES (3, 'c');"))
+	require.NoError(t, run("UPDATE items SET name = 'z' WHERE id = 1;"))
+	require.NoError(t, run("SAVEPOINT second;"))
+	require.NoError(t, run("DELETE FROM items WHERE id = 2;"))
+	assert.Equal(t, [][]any{{int64(1), "z"}, {int64(3), "c"}}, ids())
+
+	// rolling back to a savepoint keeps it and drops the later ones
+	require.NoError(t, run("ROLLBACK TO SAVEPOINT first;"))
+	assert.Equal(t, [][]any{{int64(1), "a"}, {int64(2), "b"}}, ids())
+	err := run("RELEASE SAVEPOINT second;")
+	assert.Equal(t, types.ERR_INVALID_SAVEPOINT, types.GetErrorCode(err))
+	require.NoError(t, run("ROLLBACK TO first;"))
+
+	// a failed statement is undone by rolling back to the savepoint before it
+	require.NoError(t, run("INSERT INTO items VALUES (3, 'c');"))
+	require.NoError(t, run("SAVEPOINT before_insert;"))
+	err = run("INSERT INTO items VALUES (4, 'd'), (5, 'a');")
+	assert.Equal(t, types.ERR_UNIQUE_VIOLATION, types.GetErrorCode(err))
+	assert.Equal(t, TRANSACTION_FAILED, session.TransactionStatus())
+	err = run("SAVEPOINT other;")
+	assert.Equal(t, types.ERR_IN_FAILED_TRANSACTION, types.GetErrorCode(err))
+	require.NoError(t, run("ROLLBACK TO SAVEPOINT before_insert;"))
+	assert.Equal(t, TRANSACTION_ACTIVE, session.TransactionStatus())
+	require.NoError(t, run("INSERT INTO items VALUES (4, 'd');"))
+
+	// released writes are undone with the savepoint around them
+	require.NoError(t, run("SAVEPOINT outer_sp;"))
+	require.NoError(t, run("SAVEPOINT inner_sp;"))
+	require.NoError(t, run("DELETE FROM items WHERE id = 4;"))
+	require.NoError(t, run("RELEASE inner_sp;"))
+	require.NoError(t, run("ROLLBACK TO outer_sp;"))
+	require.NoError(t, run("COMMIT;"))
+
+	expected := [][]any{{int64(1), "a"}, {int64(2), "b"}, {int64(3), "c"}, {int64(4), "d"}}
+	assert.Equal(t, expected, ids())
+	// the index entries were restored along with the records
+	assert.Equal(t, [][]any{{int64(4)}}, sessionRows(t, session, "SELECT id FROM items WHERE name = 'd';"))
+	assert.Empty(t, sessionRows(t, session, "SELECT id FROM items WHERE name = 'z';"))
+
+	for _, sql := range []string{"SAVEPOINT sp;", "RELEASE SAVEPOINT sp;", "ROLLBACK TO SAVEPOINT sp;"} {
+		err := run(sql)
+		assert.Equal(t, types.ERR_NO_ACTIVE_TRANSACTION, types.GetErrorCode(err), sql)
+	}
+}
+
+func TestIsolationLevels(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE SCHEMA app;",
+		"CREATE TABLE app.doctors (name TEXT PRIMARY KEY, on_call BOOL NOT NULL);",
+	)
+	ctx := context.Background()
+	run := func(s *Session, sql string) error {
+		_, err := s.Run(ctx, sql)
+		return err
+	}
+	alice := db.NewSession("", "app")
+	bob := db.NewSession("", "app")
+
+	// both doctors check that the other one is on call before leaving
+	writeSkew := func(begin string) error {
+		require.NoError(t, run(alice, "UPDATE doctors SET on_call = true;"))
+		for _, session := range []*Session{alice, bob} {
+			require.NoError(t, run(session, begin))
+			rows := sessionRows(t, session, "SELECT name FROM doctors WHERE on_call = true;")
+			require.Len(t, rows, 2)
+		}
+		require.NoError(t, run(alice, "UPDATE doctors SET on_call = false WHERE name = 'alice';"))
+		require.NoError(t, run(bob, "UPDATE doctors SET on_call = false WHERE name = 'bob';"))
+		require.NoError(t, run(alice, "COMMIT;"))
+		return run(bob, "COMMIT;")
+	}
+
+	require.NoError(t, run(alice, "INSERT INTO doctors VALUES ('alice', true), ('bob', true);"))
+	require.NoError(t, writeSkew("BEGIN ISOLATION LEVEL REPEATABLE READ;"))
+	assert.Empty(t, sessionRows(t, alice, "SELECT name FROM doctors WHERE on_call = true;"))
+
+	err := writeSkew("BEGIN ISOLATION LEVEL SERIALIZABLE;")
+	assert.Equal(t, types.ERR_SERIALIZATION_FAILURE, types.GetErrorCode(err))
+	assert.Equal(t, [][]any{{"bob"}}, sessionRows(t, alice, "SELECT name FROM doctors WHERE on_call = true;"))
+
+	// the default level applies to transactions without one
+	require.NoError(t, run(bob, "SET SESSION CHARACTERISTICS AS TRANSACTION ISOLATION LEVEL SERIALIZABLE;"))
+	err = writeSkew("BEGIN;")
+	assert.Equal(t, types.ERR_SERIALIZATION_FAILURE, types.GetErrorCode(err))
+	require.NoError(t, run(bob, "RESET default_transaction_isolation;"))
+
+	// a row inserted where a serializable transaction looked is a conflict
+	require.NoError(t, run(alice, "BEGIN;"))
+	require.NoError(t, run(alice, "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE;"))
+	assert.Empty(t, sessionRows(t, alice, "SELECT name FROM doctors WHERE name = 'carol';"))
+	require.NoError(t, run(alice, "INSERT INTO doctors VALUES ('dave', true);"))
+	require.NoError(t, run(bob, "INSERT INTO doctors VALUES ('carol', true);"))
+	err = run(alice, "COMMIT;")
+	assert.Equal(t, types.ERR_SERIALIZATION_FAILURE, types.GetErrorCode(err))
+
+	// read committed statements see the rows committed since the transaction began
+	require.NoError(t, run(alice, "BEGIN ISOLATION LEVEL READ COMMITTED;"))
+	require.NoError(t, run(bob, "BEGIN ISOLATION LEVEL REPEATABLE READ;"))
+	require.NoError(t, run(alice, "INSERT INTO doctors VALUES ('erin', false);"))
+	require.Len(t, sessionRows(t, bob, "SELECT name FROM doctors;"), 3)
+	require.NoError(t, run(db.NewSession("", "app"), "INSERT INTO doctors VALUES ('frank', false);"))
+	assert.Len(t, sessionRows(t, alice, "SELECT name FROM doctors;"), 5)
+	assert.Len(t, sessionRows(t, bob, "SELECT name FROM doctors;"), 3)
+	require.NoError(t, run(alice, "COMMIT;"))
+	require.NoError(t, run(bob, "COMMIT;"))
+
+	// the level cannot change once the transaction has run a query
+	require.NoError(t, run(alice, "BEGIN;"))
+	sessionRows(t, alice, "SELECT name FROM doctors;")
+	err = run(alice, "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE;")
+	assert.Equal(t, types.ERR_ACTIVE_TRANSACTION, types.GetErrorCode(err))
+	require.NoError(t, run(alice, "ROLLBACK;"))
+}
+
+func TestStreamingRows(t *testing.T) {
+	const rowCount = 2500
+	values := make([]string, rowCount)
+	for i := range values {
+		values[i] = fmt.Sprintf("(%d, %d)", i, i%7)
+	}
+	db := setupTestDatabase(t,
+		"CREATE TABLE items (id INT PRIMARY KEY, bucket INT);",
+		"INSERT INTO items (id, bucket) VALUES "+strings.Join(values, ", ")+";",
+	)
+	ctx := context.Background()
+
+	// readAll reads the rows chunk by chunk, returning the ids and the
+	// size of each chunk
+	readAll := func(rows *Rows) ([]int64, []int) {
+		defer rows.Close()
+		ids, sizes := []int64{}, []int{}
+		for {
+			chunk, err := rows.Next()
+			require.NoError(t, err)
+			if chunk == nil {
+				return ids, sizes
+			}
+			sizes = append(sizes, len(chunk.GetRows()))
+			for _, row := range chunk.GetRows() {
+				ids = append(ids, rawValue(row.Values[0]).(int64))
+			}
+		}
+	}
+
+	session := db.NewSession("", "")
+	rows, err := session.Query(ctx, "SELECT id FROM items;")
+	require.NoError(t, err)
+	assert.Equal(t, "id", rows.Schema().Columns[0].Name)
+	ids, sizes := readAll(rows)
+	assert.Len(t, ids, rowCount)
+	assert.Equal(t, []int{1024, 1024, 452}, sizes)
+
+	// filters and limits span chunk boundaries
+	rows, err = session.Query(ctx, "SELECT id FROM items WHERE bucket = 3 LIMIT 4 OFFSET 150;")
+	require.NoError(t, err)
+	ids, _ = readAll(rows)
+	assert.Equal(t, []int64{1053, 1060, 1067, 1074}, ids)
+
+	rows, err = session.Query(ctx, "SELECT id FROM items ORDER BY id DESC LIMIT 2 OFFSET 1023;")
+	require.NoError(t, err)
+	ids, _ = readAll(rows)
+	assert.Equal(t, []int64{1476, 1475}, ids)
+
+	rows, err = session.Query(ctx, "SELECT id FROM items WHERE id < 0;")
+	require.NoError(t, err)
+	ids, sizes = readAll(rows)
+	assert.Empty(t, ids)
+	assert.Empty(t, sizes)
+	chunk, err := session.Run(ctx, "SELECT id FROM items WHERE id < 0;")
+	require.NoError(t, err)
+	assert.Empty(t, chunk.GetRows())
+
+	// an error met while reading the rows fails the transaction
+	_, err = session.Run(ctx, "BEGIN;")
+	require.NoError(t, err)
+	rows, err = session.Query(ctx, "SELECT id FROM items WHERE 1 / (id - 2000) < 1;")
+	require.NoError(t, err)
+	chunk, err = rows.Next()
+	require.NoError(t, err)
+	assert.Len(t, chunk.GetRows(), 1024)
+	_, err = rows.Next()
+	assert.Equal(t, types.ERR_DIVISION_BY_ZERO, types.GetErrorCode(err))
+	rows.Close()
+	assert.Equal(t, TRANSACTION_FAILED, session.TransactionStatus())
+	_, err = session.Run(ctx, "ROLLBACK;")
+	require.NoError(t, err)
+
+	// rows of a closed result are no longer read
+	rows, err = session.Query(ctx, "SELECT id FROM items;")
+	require.NoError(t, err)
+	_, err = rows.Next()
+	require.NoError(t, err)
+	rows.Close()
+	rows.Close()
+	chunk, err = rows.Next()
+	require.NoError(t, err)
+	assert.Nil(t, chunk)
+	assert.Len(t, queryRows(t, db, "SELECT id FROM items;"), rowCount)
+}
+
+func TestStatementSnapshot(t *testing.T) {
+	const rowCount = 2500
+	values := make([]string, rowCount)
+	for i := range values {
+		values[i] = fmt.Sprintf("(%d, %d)", i, i)
+	}
+	db := setupTestDatabase(t,
+		"CREATE TABLE items (id INT PRIMARY KEY, bucket INT);",
+		"CREATE INDEX items_bucket ON items (bucket);",
+		"INSERT INTO items (id, bucket) VALUES "+strings.Join(values, ", ")+";",
+	)
+	ctx := context.Background()
+
+	// the index lookups of the self join run after the first chunk is read,
+	// they still see the rows as they were when the statement started
+	rows, err := db.NewSession("", "").Query(ctx, "SELECT a.id, b.id FROM items a JOIN items b ON b.bucket = a.id;")
+	require.NoError(t, err)
+	defer rows.Close()
+	chunk, err := rows.Next()
+	require.NoError(t, err)
+	count := chunk.Len()
+
+	writer := db.NewSession("", "")
+	_, err = writer.Run(ctx, "UPDATE items SET bucket = -1 WHERE id >= 2000;")
+	require.NoError(t, err)
+	_, err = writer.Run(ctx, "DELETE FROM items WHERE id >= 1500 AND id < 2000;")
+	require.NoError(t, err)
+
+	for {
+		chunk, err := rows.Next()
+		require.NoError(t, err)
+		if chunk == nil {
+			break
+		}
+		for _, row := range chunk.GetRows() {
+			assert.Equal(t, row.Values[0], row.Values[1])
+		}
+		count += chunk.Len()
+	}
+	assert.Equal(t, rowCount, count)
+	assert.Len(t, queryRows(t, db, "SELECT a.id FROM items a JOIN items b ON b.bucket = a.id;"), 1500)
+}
+
+func TestJoins(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE TABLE authors (id INT PRIMARY KEY, name TEXT);",
+		"CREATE TABLE books (id INT PRIMARY KEY, title TEXT, author_id INT, price FLOAT);",
+		"CREATE INDEX books_author ON books (author_id);",
+		"INSERT INTO authors VALUES (1, 'ann'), (2, 'bob'), (3, 'eve');",
+		"INSERT INTO books VALUES (10, 'go', 1, 20.0), (11, 'sql', 1, 35.5), (12, 'kv', 2, 12.0), (13, 'misc', NULL, 5.0);",
+	)
+
+	tests := []struct {
+		sql      string
+		expected [][]any
+	}{
+		{
+			"SELECT a.name, b.title FROM authors a JOIN books b ON a.id = b.author_id ORDER BY b.id;",
+			[][]any{{"ann", "go"}, {"ann", "sql"}, {"bob", "kv"}},
+		},
+		{
+			"SELECT name, title FROM authors INNER JOIN books ON authors.id = books.author_id AND price > 15 ORDER BY title;",
+			[][]any{{"ann", "go"}, {"ann", "sql"}},
+		},
+		{
+			"SELECT a.name, b.title FROM authors AS a LEFT OUTER JOIN books AS b ON a.id = b.author_id ORDER BY a.id, b.id;",
+			[][]any{{"ann", "go"}, {"ann", "sql"}, {"bob", "kv"}, {"eve", nil}},
+		},
+		{
+			"SELECT a.name, b.title FROM authors a RIGHT JOIN books b ON a.id = b.author_id ORDER BY b.id;",
+			[][]any{{"ann", "go"}, {"ann", "sql"}, {"bob", "kv"}, {nil, "misc"}},
+		},
+		{
+			"SELECT a.name, b.title FROM authors a FULL JOIN books b ON a.id = b.author_id ORDER BY a.id, b.id;",
+			[][]any{{"ann", "go"}, {"ann", "sql"}, {"bob", "kv"}, {"eve", nil}, {nil, "misc"}},
+		},
+		{
+			// arbitrary conditions are joined by a nested loop join
+			"SELECT a.name, b.title FROM authors a JOIN books b ON b.price < a.id * 10 ORDER BY a.id, b.id;",
+			[][]any{{"ann", "misc"}, {"bob", "kv"}, {"bob", "misc"}, {"eve", "go"}, {"eve", "kv"}, {"eve", "misc"}},
+		},
+		{
+			"SELECT a.id, b.id FROM authors a CROSS JOIN authors b WHERE a.id < b.id ORDER BY a.id, b.id;",
+			[][]any{{int64(1), int64(2)}, {int64(1), int64(3)}, {int64(2), int64(3)}},
+		},
+		{
+			"SELECT authors.id, b.id FROM authors, books b WHERE b.author_id = authors.id AND b.price < 15;",
+			[][]any{{int64(2), int64(12)}},
+		},
+		{
+			// the USING column is merged, the columns of both tables stay reachable
+			"SELECT * FROM authors JOIN books USING (id);",
+			[][]any{},
+		},
+		{
+			"SELECT a.*, b.title FROM authors a JOIN books b ON a.id = b.author_id WHERE b.price > 30;",
+			[][]any{{int64(1), "ann", "sql"}},
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, queryRows(t, db, tt.sql), tt.sql)
+	}
+
+	_, err := db.Run(context.Background(), "CREATE TABLE sales (author_id INT, amount INT);")
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "INSERT INTO sales VALUES (1, 5), (3, 7), (4, 9);")
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "ALTER TABLE authors RENAME COLUMN id TO author_id;")
+	require.NoError(t, err)
+
+	rows := queryRows(t, db, "SELECT * FROM authors FULL JOIN sales USING (author_id) ORDER BY author_id;")
+	assert.Equal(t, [][]any{{int64(1), "ann", int64(5)}, {int64(2), "bob", nil}, {int64(3), "eve", int64(7)}, {int64(4), nil, int64(9)}}, rows)
+	rows = queryRows(t, db, "SELECT author_id, authors.author_id, s.author_id FROM authors RIGHT JOIN sales s USING (author_id) ORDER BY amount;")
+	assert.Equal(t, [][]any{{int64(1), int64(1), int64(1)}, {int64(3), int64(3), int64(3)}, {int64(4), nil, int64(4)}}, rows)
+
+	// joins chain from left to right
+	rows = queryRows(t, db, "SELECT a.name, b.title, s.amount FROM authors a JOIN books b ON a.author_id = b.author_id LEFT JOIN sales s ON s.author_id = b.author_id ORDER BY b.id;")
+	assert.Equal(t, [][]any{{"ann", "go", int64(5)}, {"ann", "sql", int64(5)}, {"bob", "kv", nil}}, rows)
+
+	errorTests := []struct {
+		sql  string
+		code types.ErrorCode
+	}{
+		{"SELECT id FROM books JOIN books ON true;", types.ERR_DUPLICATE_ALIAS},
+		{"SELECT id FROM books b JOIN authors b ON true;", types.ERR_DUPLICATE_ALIAS},
+		{"SELECT id FROM books b1 JOIN books b2 ON b1.id = b2.id;", types.ERR_AMBIGUOUS_COLUMN},
+		{"SELECT books.id FROM books b;", types.ERR_UNDEFINED_TABLE},
+		{"SELECT x.* FROM books;", types.ERR_UNDEFINED_TABLE},
+		{"SELECT b.name FROM books b;", types.ERR_UNDEFINED_COLUMN},
+		{"SELECT title FROM books JOIN authors USING (id);", types.ERR_UNDEFINED_COLUMN},
+		{"SELECT title FROM books b JOIN authors a ON b.title;", types.ERR_DATATYPE_MISMATCH},
+		{"SELECT title FROM books b JOIN authors a ON b.title = a.author_id;", types.ERR_DATATYPE_MISMATCH},
+	}
+	for _, tt := range errorTests {
+		_, err := db.Run(context.Background(), tt.sql)
+		assert.Equal(t, tt.code, types.GetErrorCode(err), tt.sql)
+	}
+
+	// an index entry listing a missing record is no match
+	schema := db.publishedCatalog().GetSchema("public")
+	table := schema.GetTable("books")
+	primaryKey, err := types.EncodeKey([]types.Value{*types.NewIntValue(11)})
+	require.NoError(t, err)
+	require.NoError(t, db.storage.Delete(types.TableRecordKey(uint32(schema.GetId()), uint32(table.GetId()), primaryKey)))
+	rows = queryRows(t, db, "SELECT a.name, b.title FROM authors a JOIN books b ON a.author_id = b.author_id ORDER BY b.id;")
+	assert.Equal(t, [][]any{{"ann", "go"}, {"bob", "kv"}}, rows)
+}
+
+func TestGroupBy(t *testing.T) {
+	db := setupTestDatabase(t,
+		"CREATE TABLE authors (id INT PRIMARY KEY, name TEXT);",
+		"CREATE TABLE books (id INT PRIMARY KEY, title TEXT, author_id INT, price FLOAT, pages INT, sold BOOL);",
+		"CREATE TABLE empty (id INT PRIMARY KEY, amount INT);",
+		"INSERT INTO authors VALUES (1, 'ann'), (2, 'bob'), (3, 'eve');",
+		"INSERT INTO books VALUES (10, 'go', 1, 20.0, 300, true), (11, 'sql', 1, 35.5, 300, false), (12, 'kv', 2, 12.0, 150, true), (13, 'misc', NULL, 5.0, NULL, NULL), (14, 'db', 2, 12.0, 200, true);",
+	)
+
+	tests := []struct {
+		sql      string
+		expected [][]any
+	}{
+		{
+			"SELECT author_id, COUNT(*), COUNT(pages), SUM(pages), MIN(price), MAX(title) FROM books GROUP BY author_id ORDER BY author_id;",
+			[][]any{{int64(1), int64(2), int64(2), int64(600), 20.0, "sql"}, {int64(2), int64(2), int64(2), int64(350), 12.0, "kv"}, {nil, int64(1), int64(0), nil, 5.0, "misc"}},
+		},
+		{
+			"SELECT author_id, AVG(price), STRING_AGG(title, ','), BOOL_AND(sold), BOOL_OR(sold) FROM books WHERE author_id IS NOT NULL GROUP BY author_id ORDER BY author_id;",
+			[][]any{{int64(1), 27.75, "go,sql", false, true}, {int64(2), 12.0, "kv,db", true, true}},
+		},
+		{
+			"SELECT COUNT(DISTINCT pages), COUNT(DISTINCT price), SUM(DISTINCT pages) FROM books;",
+			[][]any{{int64(3), int64(4), int64(650)}},
+		},
+		{
+			// without GROUP BY an empty input still gives one row
+			"SELECT COUNT(*), SUM(amount), MAX(amount) FROM empty;",
+			[][]any{{int64(0), nil, nil}},
+		},
+		{
+			"SELECT id FROM empty GROUP BY id;",
+			[][]any{},
+		},
+		{
+			"SELECT author_id, COUNT(*) FROM books GROUP BY author_id HAVING COUNT(*) > 1 AND SUM(pages) < 500;",
+			[][]any{{int64(2), int64(2)}},
+		},
+		{
+			"SELECT author_id FROM books WHERE author_id IS NOT NULL GROUP BY author_id ORDER BY SUM(price) DESC;",
+			[][]any{{int64(1)}, {int64(2)}},
+		},
+		{
+			// the primary key makes each row its own group
+			"SELECT id, title, SUM(price) FROM books GROUP BY id, title HAVING id < 12;",
+			[][]any{{int64(10), "go", 20.0}, {int64(11), "sql", 35.5}},
+		},
+		{
+			"SELECT a.name, COUNT(b.id) FROM authors a LEFT JOIN books b ON a.id = b.author_id GROUP BY a.name ORDER BY a.name;",
+			[][]any{{"ann", int64(2)}, {"bob", int64(2)}, {"eve", int64(0)}},
+		},
+		{
+			"SELECT MAX(pages) FROM books GROUP BY price > 15 ORDER BY price > 15;",
+			[][]any{{int64(200)}, {int64(300)}},
+		},
+		{
+			"SELECT COUNT(*) FROM books HAVING COUNT(*) > 10;",
+			[][]any{},
+		},
+		{
+			"SELECT author_id * 10, SUM(pages) + 1, MAX(price) - MIN(price) FROM books WHERE author_id IS NOT NULL GROUP BY author_id ORDER BY author_id;",
+			[][]any{{int64(10), int64(601), 15.5}, {int64(20), int64(351), 0.0}},
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, queryRows(t, db, tt.sql), tt.sql)
+	}
+
+	// groups span several chunks of input and of output
+	values := make([]string, 2500)
+	for i := range values {
+		values[i] = fmt.Sprintf("(%d, %d)", i, i%7)
+	}
+	_, err := db.Run(context.Background(), "CREATE TABLE items (id INT PRIMARY KEY, bucket INT);")
+	require.NoError(t, err)
+	_, err = db.Run(context.Background(), "INSERT INTO items VALUES "+strings.Join(values, ", ")+";")
+	require.NoError(t, err)
+	rows := queryRows(t, db, "SELECT bucket, COUNT(*) FROM items GROUP BY bucket ORDER BY bucket;")
+	assert.Len(t, rows, 7)
+	assert.Equal(t, []any{int64(0), int64(358)}, rows[0])
+	assert.Equal(t, []any{int64(6), int64(357)}, rows[6])
+	rows = queryRows(t, db, "SELECT id, COUNT(*), MAX(bucket) FROM items GROUP BY id;")
+	assert.Len(t, rows, 2500)
+	assert.Equal(t, []any{int64(2499), int64(1), int64(0)}, rows[2499])
+
+	chunk, err := db.Run(context.Background(), "SELECT author_id, COUNT(*), SUM(pages) FROM books GROUP BY author_id;")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"author_id", "count", "sum"}, chunk.GetColumnNames())
+
+	errorTests := []struct {
+		sql  string
+		code types.ErrorCode
+	}{
+		{"SELECT title, COUNT(*) FROM books GROUP BY author_id;", types.ERR_GROUPING_ERROR},
+		{"SELECT title FROM books HAVING COUNT(*) > 1;", types.ERR_GROUPING_ERROR},
+		{"SELECT author_id FROM books GROUP BY author_id ORDER BY title;", types.ERR_GROUPING_ERROR},
+		{"SELECT id FROM books WHERE COUNT(*) > 1;", types.ERR_GROUPING_ERROR},
+		{"SELECT SUM(COUNT(*)) FROM books;", types.ERR_GROUPING_ERROR},
+		{"SELECT COUNT(*) FROM books GROUP BY COUNT(*);", types.ERR_GROUPING_ERROR},
+		{"SELECT SUM(title) FROM books;", types.ERR_UNDEFINED_FUNCTION},
+		{"SELECT STRING_AGG(title) FROM books;", types.ERR_UNDEFINED_FUNCTION},
+		{"SELECT COUNT(DISTINCT *) FROM books;", types.ERR_SYNTAX_ERROR},
+		{"SELECT nope, COUNT(*) FROM books GROUP BY author_id;", types.ERR_UNDEFINED_COLUMN},
+		{"SELECT COUNT(*) FROM books GROUP BY nope;", types.ERR_UNDEFINED_COLUMN},
+	}
+	for _, tt := range errorTests {
+		_, err := db.Run(context.Background(), tt.sql)
+		assert.Equal(t, tt.code, types.GetErrorCode(err), tt.sql)
+	}
+}
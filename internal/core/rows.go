@@ -0,0 +1,115 @@
+package core
+
+import (
+	"context"
+	"errors"
+
+	"github.com/evanxg852000/foxdb/internal/query/executor"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// Rows is the result of a statement, its rows are produced a chunk at a
+// time as they are read. Rows must be closed before the session runs
+// another statement.
+type Rows struct {
+	session  *Session
+	executor *executor.Executor
+	// result produced before the rows were returned, by the statements
+	// that do not run a plan
+	result *types.DataChunk
+	schema *types.DataSchema
+	ctx    context.Context
+	// cancel releases the statement timeout, timeout tells whether one is set
+	cancel  context.CancelFunc
+	timeout bool
+	// snapshot is read by a statement running outside of a transaction
+	snapshot *storage.KvStorage
+}
+
+func newResultRows(result *types.DataChunk) *Rows {
+	rows := &Rows{result: result}
+	if result != nil {
+		rows.schema = result.GetSchema()
+	}
+	return rows
+}
+
+// Schema describes the rows, nil for statements that do not return rows
+func (r *Rows) Schema() *types.DataSchema {
+	return r.schema
+}
+
+// Next returns the following chunk of rows, nil once they are all read.
+// An error fails the session transaction like an error of the statement.
+func (r *Rows) Next() (*types.DataChunk, error) {
+	if r.executor == nil {
+		result := r.result
+		r.result = nil
+		return result, nil
+	}
+
+	chunk, err := r.executor.Next(r.ctx)
+	if err != nil {
+		err = statementError(err, r.timeout)
+		if r.session.txn != nil {
+			r.session.failed = true
+		}
+		return nil, err
+	}
+	return chunk, nil
+}
+
+// Close releases the resources held to read the rows, it can be called
+// more than once.
+func (r *Rows) Close() {
+	if r.executor != nil {
+		r.executor.Close()
+		r.executor = nil
+	}
+	if r.snapshot != nil {
+		r.snapshot.Rollback()
+		r.snapshot = nil
+	}
+	if r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+	r.result = nil
+}
+
+// All reads the remaining rows in a single chunk then closes the rows, the
+// chunk is nil for statements that do not return rows.
+func (r *Rows) All() (*types.DataChunk, error) {
+	defer r.Close()
+
+	var result *types.DataChunk
+	if r.schema != nil {
+		result = types.NewChunk(r.schema)
+	}
+	for {
+		chunk, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if chunk == nil {
+			return result, nil
+		}
+		// the rows are copied, chunks may share them with the operators
+		// producing them
+		if result == nil {
+			result = types.NewChunk(chunk.GetSchema())
+		}
+		for _, row := range chunk.GetRows() {
+			result.AppendRow(row)
+		}
+	}
+}
+
+// statementError reports a statement stopped by its timeout as canceled
+func statementError(err error, timeout bool) error {
+	if errors.Is(err, context.DeadlineExceeded) && timeout {
+		return types.NewError(types.ERR_QUERY_CANCELED, "canceling statement due to statement timeout")
+	}
+	return err
+}
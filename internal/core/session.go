@@ -0,0 +1,586 @@
+package core
+
+import (
+	"context"
+	"maps"
+	"strings"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/executor"
+	"github.com/evanxg852000/foxdb/internal/query/optimizer"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/query/planner"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// the search path entry standing for the schema named after the session user
+const SEARCH_PATH_USER = "$user"
+
+// Session is the state of a client connection, statements of a session
+// look their unqualified names up in its search path.
+type Session struct {
+	db       *Database
+	userName string
+	// value of each setting as SHOW prints it, defaults are the values
+	// RESET goes back to
+	settings map[string]string
+	defaults map[string]string
+	// txn is the transaction opened by BEGIN, statements run in a
+	// transaction of their own when there is none.
+	txn *storage.KvStorage
+	// a failed transaction ignores statements until it ends
+	failed bool
+	// the isolation level can only change before the first query
+	queried bool
+	// catalog is the catalog version the transaction sees, base is the
+	// published version it started from. DDL statements replace catalog
+	// with a changed copy, published when the transaction commits.
+	catalog *catalog.RootCatalog
+	base    *catalog.RootCatalog
+	// settings when the transaction began, like in Postgres SET is undone
+	// when the transaction rolls back
+	txnSettings map[string]string
+	// catalog version and settings of each open savepoint, innermost last
+	savepoints []catalogSavepoint
+}
+
+type catalogSavepoint struct {
+	name     string
+	catalog  *catalog.RootCatalog
+	settings map[string]string
+}
+
+// TransactionStatus is the state of the session transaction, the values
+// are the ones the wire protocol reports in ReadyForQuery.
+type TransactionStatus byte
+
+const (
+	TRANSACTION_IDLE   TransactionStatus = 'I'
+	TRANSACTION_ACTIVE TransactionStatus = 'T'
+	TRANSACTION_FAILED TransactionStatus = 'E'
+)
+
+// NewSession starts a session for userName, a database requested by the
+// client maps to the schema searched first.
+func (db *Database) NewSession(userName string, databaseName string) *Session {
+	session := &Session{
+		db:       db,
+		userName: userName,
+		settings: make(map[string]string, len(settings)),
+		defaults: make(map[string]string, len(settings)),
+	}
+	for _, setting := range settings {
+		session.defaults[setting.name] = setting.defaultValue
+	}
+
+	searchPath := catalog.DEFAULT_SCHEMA_NAME
+	if databaseName != "" && databaseName != catalog.DEFAULT_SCHEMA_NAME {
+		searchPath = databaseName + ", " + searchPath
+	}
+	session.defaults[SEARCH_PATH_SETTING] = searchPath
+	maps.Copy(session.settings, session.defaults)
+	return session
+}
+
+// Run runs the first statement of sql and gathers all the rows it returns
+func (s *Session) Run(ctx context.Context, sql string) (*types.DataChunk, error) {
+	rows, err := s.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	return rows.All()
+}
+
+// Describe returns the schema of the rows a statement produces without
+// running it, nil for statements that do not return rows.
+func (s *Session) Describe(stmt ast.Statement) (*types.DataSchema, error) {
+	switch stmt := stmt.(type) {
+	case *ast.ShowStatement:
+		if strings.EqualFold(stmt.Name, SHOW_ALL) {
+			return showAllSchema, nil
+		}
+		setting, err := lookupSetting(stmt.Name)
+		if err != nil {
+			return nil, err
+		}
+		return settingSchema(setting.name), nil
+	case *ast.SelectStatement:
+		planner := planner.NewPlanner(s.currentCatalog(), s.resolvedSearchPath())
+		logicalPlan, err := planner.Plan(stmt)
+		if err != nil {
+			return nil, err
+		}
+		return logicalPlan.GetSchema(), nil
+	default:
+		return nil, nil
+	}
+}
+
+// DescribeParameters returns the type inferred for each parameter of a statement.
+func (s *Session) DescribeParameters(stmt ast.Statement) ([]types.DataType, error) {
+	planner := planner.NewPlanner(s.currentCatalog(), s.resolvedSearchPath())
+	return planner.ParameterTypes(stmt)
+}
+
+// Execute runs a single statement and gathers all the rows it returns
+func (s *Session) Execute(ctx context.Context, stmt ast.Statement, parameters []types.Value) (*types.DataChunk, error) {
+	rows, err := s.QueryStatement(ctx, stmt, parameters)
+	if err != nil {
+		return nil, err
+	}
+	return rows.All()
+}
+
+// Query runs the first statement of sql and returns its rows as they are read
+func (s *Session) Query(ctx context.Context, sql string) (*Rows, error) {
+	statements, err := s.db.Parse(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(statements) == 0 {
+		return newResultRows(nil), nil
+	}
+
+	// TODO: support multiple statements
+	return s.QueryStatement(ctx, statements[0], nil)
+}
+
+// QueryStatement binds parameters to a single statement then plans and
+// runs it, session statements are handled by the session itself. An error
+// inside a transaction fails it, the statements that follow are rejected
+// until the transaction ends.
+func (s *Session) QueryStatement(ctx context.Context, stmt ast.Statement, parameters []types.Value) (*Rows, error) {
+	if stmt, ok := stmt.(*ast.TransactionStatement); ok {
+		if err := s.runTransactionStatement(stmt); err != nil {
+			return nil, err
+		}
+		return newResultRows(nil), nil
+	}
+	if s.failed {
+		return nil, failedTransactionError()
+	}
+	if s.txn == nil && (isUtility(stmt) || isWrite(stmt)) {
+		chunk, err := s.executeImplicit(ctx, stmt, parameters)
+		if err != nil {
+			return nil, err
+		}
+		return newResultRows(chunk), nil
+	}
+
+	rows, err := s.query(ctx, stmt, parameters)
+	if err != nil && s.txn != nil {
+		s.failed = true
+	}
+	return rows, err
+}
+
+// executeImplicit runs a DDL or DML statement outside of a transaction
+// block in a transaction of its own, committing it publishes the catalog
+// like COMMIT. DML statements get a snapshot taken with the catalog they
+// are planned from, like in a transaction block. A DML statement failing
+// on a concurrent write runs again on the latest commits, as Postgres
+// re-checks the rows it writes under read committed instead of failing.
+func (s *Session) executeImplicit(ctx context.Context, stmt ast.Statement, parameters []types.Value) (*types.DataChunk, error) {
+	for {
+		chunk, err := s.executeInTransaction(ctx, stmt, parameters)
+		if !isWrite(stmt) || types.GetErrorCode(err) != types.ERR_SERIALIZATION_FAILURE || ctx.Err() != nil {
+			return chunk, err
+		}
+	}
+}
+
+func (s *Session) executeInTransaction(ctx context.Context, stmt ast.Statement, parameters []types.Value) (*types.DataChunk, error) {
+	s.begin(storage.READ_COMMITTED)
+	rows, err := s.query(ctx, stmt, parameters)
+	var chunk *types.DataChunk
+	if err == nil {
+		chunk, err = rows.All()
+	}
+	if err != nil {
+		s.rollback()
+		return nil, err
+	}
+	return chunk, s.commit()
+}
+
+// query opens the plan of a statement, its rows are produced as the
+// returned Rows are read.
+func (s *Session) query(ctx context.Context, stmt ast.Statement, parameters []types.Value) (*Rows, error) {
+	switch stmt := stmt.(type) {
+	case *ast.SetStatement:
+		return newResultRows(nil), s.Set(stmt.Name, stmt.Values)
+	case *ast.ResetStatement:
+		return newResultRows(nil), s.Reset(stmt.Name)
+	case *ast.ShowStatement:
+		chunk, err := s.show(stmt.Name)
+		return newResultRows(chunk), err
+	}
+
+	if s.txn != nil {
+		s.queried = true
+		if err := s.startStatement(); err != nil {
+			return nil, err
+		}
+	}
+
+	stmt, err := planner.BindParameters(stmt, parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	// the catalog version seen by the transaction is never changed, DDL
+	// statements change a copy that the transaction sees once they succeed
+	rows := &Rows{session: s, ctx: ctx}
+	rootCatalog, storage := s.currentCatalog(), s.txn
+	if s.txn == nil {
+		// every operator of the statement reads the same snapshot
+		rootCatalog, rows.snapshot = s.db.snapshot()
+		storage = rows.snapshot
+	}
+	if isUtility(stmt) {
+		rootCatalog = rootCatalog.Clone()
+	}
+
+	planner := planner.NewPlanner(rootCatalog, s.resolvedSearchPath())
+	logicalPlan, err := planner.Plan(stmt)
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+
+	optimizer := optimizer.NewOptimizer(rootCatalog, s.db.getStats())
+	physicalPlan, err := optimizer.Optimize(logicalPlan)
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+
+	// the timeout covers reading the rows, it is released when they are closed
+	rows.schema = physicalPlan.GetSchema()
+	// the setting is valid, SET checked it
+	if timeout, _ := parseTimeout(s.settings[STATEMENT_TIMEOUT_SETTING]); timeout > 0 {
+		rows.ctx, rows.cancel = context.WithTimeout(ctx, timeout)
+		rows.timeout = true
+	}
+
+	rows.executor = executor.NewExecutor(storage, rootCatalog, physicalPlan)
+	if err := rows.executor.Open(rows.ctx); err != nil {
+		rows.Close()
+		return nil, statementError(err, rows.timeout)
+	}
+	if s.txn != nil {
+		s.catalog = rootCatalog
+	}
+	return rows, nil
+}
+
+// currentCatalog is the catalog version statements of the session see
+func (s *Session) currentCatalog() *catalog.RootCatalog {
+	if s.txn != nil {
+		return s.catalog
+	}
+	return s.db.publishedCatalog()
+}
+
+// begin opens the session transaction, its snapshot and catalog version
+// are taken together so that they never disagree.
+func (s *Session) begin(level storage.IsolationLevel) {
+	s.db.catalogLock.RLock()
+	defer s.db.catalogLock.RUnlock()
+	s.txn = s.db.storage.Begin(level)
+	s.catalog, s.base = s.db.catalog, s.db.catalog
+	s.txnSettings = maps.Clone(s.settings)
+}
+
+// startStatement moves a read committed transaction to the latest commits,
+// it keeps the catalog version it staged if it ran DDL statements.
+func (s *Session) startStatement() error {
+	s.db.catalogLock.RLock()
+	defer s.db.catalogLock.RUnlock()
+	if err := s.txn.StartStatement(); err != nil {
+		return err
+	}
+	if s.txn.IsolationLevel() == storage.READ_COMMITTED && s.catalog == s.base {
+		s.catalog, s.base = s.db.catalog, s.db.catalog
+	}
+	return nil
+}
+
+// commit ends the session transaction and publishes the catalog version
+// it staged. Concurrent DDL statements all write the stored catalog, the
+// transactions that committed after the snapshot of another one make it
+// fail with a serialization failure. A failed commit restores the settings
+// like a rollback.
+func (s *Session) commit() error {
+	txn, rootCatalog, staged, settings := s.txn, s.catalog, s.catalog != s.base, s.txnSettings
+	s.end()
+	if err := s.commitTxn(txn, rootCatalog, staged); err != nil {
+		s.settings = settings
+		return err
+	}
+	return nil
+}
+
+func (s *Session) commitTxn(txn *storage.KvStorage, rootCatalog *catalog.RootCatalog, staged bool) error {
+	if !staged {
+		return txn.Commit()
+	}
+
+	s.db.catalogLock.Lock()
+	defer s.db.catalogLock.Unlock()
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+	s.db.catalog = rootCatalog
+	return nil
+}
+
+// rollback ends the session transaction, the catalog version it staged is
+// dropped along with its writes and the settings it changed are restored.
+func (s *Session) rollback() {
+	txn, settings := s.txn, s.txnSettings
+	s.end()
+	txn.Rollback()
+	s.settings = settings
+}
+
+func (s *Session) end() {
+	s.txn, s.failed, s.queried = nil, false, false
+	s.catalog, s.base, s.savepoints, s.txnSettings = nil, nil, nil, nil
+}
+
+// runTransactionStatement begins, commits or rolls back the session
+// transaction. Committing a failed transaction rolls it back, a
+// transaction that is already open or not open is left as is.
+func (s *Session) runTransactionStatement(stmt *ast.TransactionStatement) error {
+	switch stmt.Action {
+	case ast.TRANSACTION_BEGIN:
+		if s.txn == nil {
+			level := stmt.IsolationLevel
+			if level == "" {
+				level = s.settings[DEFAULT_ISOLATION_SETTING]
+			}
+			s.begin(isolationLevels[level])
+		}
+		return nil
+	case ast.TRANSACTION_SET:
+		return s.setIsolationLevel(stmt.IsolationLevel)
+	case ast.TRANSACTION_SAVEPOINT, ast.TRANSACTION_RELEASE, ast.TRANSACTION_ROLLBACK_TO:
+		return s.runSavepointStatement(stmt)
+	}
+	if s.txn == nil {
+		return nil
+	}
+
+	if stmt.Action == ast.TRANSACTION_ROLLBACK || s.failed {
+		s.rollback()
+		return nil
+	}
+	return s.commit()
+}
+
+// setIsolationLevel restarts the open transaction with another isolation
+// level, it has no effect outside of a transaction.
+func (s *Session) setIsolationLevel(level string) error {
+	if s.txn == nil {
+		return nil
+	}
+	if s.failed {
+		return failedTransactionError()
+	}
+	if s.queried {
+		s.failed = true
+		return types.NewError(types.ERR_ACTIVE_TRANSACTION, "SET TRANSACTION ISOLATION LEVEL must be called before any query")
+	}
+	// the settings changed before the restart stay changed
+	settings, txnSettings := s.settings, s.txnSettings
+	s.rollback()
+	s.begin(isolationLevels[level])
+	s.settings, s.txnSettings = settings, txnSettings
+	return nil
+}
+
+// runSavepointStatement sets, releases or rolls back to a savepoint. Rolling
+// back to a savepoint recovers a failed transaction, an error in any of them
+// fails it.
+func (s *Session) runSavepointStatement(stmt *ast.TransactionStatement) error {
+	if s.txn == nil {
+		statement := map[ast.TransactionAction]string{
+			ast.TRANSACTION_SAVEPOINT:   "SAVEPOINT",
+			ast.TRANSACTION_RELEASE:     "RELEASE SAVEPOINT",
+			ast.TRANSACTION_ROLLBACK_TO: "ROLLBACK TO SAVEPOINT",
+		}[stmt.Action]
+		return types.NewError(types.ERR_NO_ACTIVE_TRANSACTION, "%s can only be used in transaction blocks", statement)
+	}
+	if s.failed && stmt.Action != ast.TRANSACTION_ROLLBACK_TO {
+		return failedTransactionError()
+	}
+
+	var err error
+	switch stmt.Action {
+	case ast.TRANSACTION_SAVEPOINT:
+		s.txn.Savepoint(stmt.Savepoint)
+		s.savepoints = append(s.savepoints, catalogSavepoint{name: stmt.Savepoint, catalog: s.catalog, settings: maps.Clone(s.settings)})
+	case ast.TRANSACTION_RELEASE:
+		if err = s.txn.Release(stmt.Savepoint); err == nil {
+			s.savepoints = s.savepoints[:s.findSavepoint(stmt.Savepoint)]
+		}
+	case ast.TRANSACTION_ROLLBACK_TO:
+		if err = s.txn.RollbackTo(stmt.Savepoint); err == nil {
+			idx := s.findSavepoint(stmt.Savepoint)
+			s.catalog = s.savepoints[idx].catalog
+			s.settings = maps.Clone(s.savepoints[idx].settings)
+			s.savepoints = s.savepoints[:idx+1]
+		}
+	}
+	s.failed = err != nil
+	return err
+}
+
+// findSavepoint returns the position of the latest savepoint named name,
+// the storage transaction has already checked that it exists.
+func (s *Session) findSavepoint(name string) int {
+	for i := len(s.savepoints) - 1; i >= 0; i-- {
+		if s.savepoints[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func failedTransactionError() error {
+	return types.NewError(types.ERR_IN_FAILED_TRANSACTION, "current transaction is aborted, commands ignored until end of transaction block")
+}
+
+// TransactionStatus tells whether the session is in a transaction
+func (s *Session) TransactionStatus() TransactionStatus {
+	switch {
+	case s.failed:
+		return TRANSACTION_FAILED
+	case s.txn != nil:
+		return TRANSACTION_ACTIVE
+	default:
+		return TRANSACTION_IDLE
+	}
+}
+
+// Close rolls back the transaction left open by the session
+func (s *Session) Close() {
+	if s.txn != nil {
+		s.rollback()
+	}
+}
+
+// utility statements change the catalog
+func isUtility(stmt ast.Statement) bool {
+	switch stmt.(type) {
+	case *ast.CreateSchemaStatement, *ast.DropSchemaStatement,
+		*ast.CreateTableStatement, *ast.DropTableStatement, *ast.AlterTableStatement,
+		*ast.CreateIndexStatement, *ast.DropIndexStatement,
+		*ast.CreateUserStatement, *ast.DropUserStatement, *ast.AlterUserStatement:
+		return true
+	default:
+		return false
+	}
+}
+
+// statements writing the records of a table
+func isWrite(stmt ast.Statement) bool {
+	switch stmt.(type) {
+	case *ast.InsertStatement, *ast.UpdateStatement, *ast.DeleteStatement:
+		return true
+	default:
+		return false
+	}
+}
+
+// Set changes a setting for the rest of the session, a change made in a
+// transaction is undone when it rolls back.
+func (s *Session) Set(name string, values []string) error {
+	setting, err := lookupSetting(name)
+	if err != nil {
+		return err
+	}
+	value, err := setting.normalize(s.settings[setting.name], values)
+	if err != nil {
+		return err
+	}
+	s.settings[setting.name] = value
+	return nil
+}
+
+// Reset sets a setting back to its default, ALL resets every setting
+func (s *Session) Reset(name string) error {
+	if strings.EqualFold(name, SHOW_ALL) {
+		maps.Copy(s.settings, s.defaults)
+		return nil
+	}
+	setting, err := lookupSetting(name)
+	if err != nil {
+		return err
+	}
+	s.settings[setting.name] = s.defaults[setting.name]
+	return nil
+}
+
+// ReportedSettings returns the settings wire clients are told about
+func (s *Session) ReportedSettings() map[string]string {
+	reported := map[string]string{}
+	for _, setting := range settings {
+		if setting.reported {
+			reported[setting.name] = s.settings[setting.name]
+		}
+	}
+	return reported
+}
+
+// SHOW ALL and RESET ALL apply to every setting
+const SHOW_ALL = "all"
+
+var showAllSchema = &types.DataSchema{Columns: []types.DataColumn{
+	{Name: "name", DataType: types.TYPE_TEXT},
+	{Name: "setting", DataType: types.TYPE_TEXT},
+	{Name: "description", DataType: types.TYPE_TEXT},
+}}
+
+func (s *Session) show(name string) (*types.DataChunk, error) {
+	if strings.EqualFold(name, SHOW_ALL) {
+		chunk := types.NewChunk(showAllSchema)
+		for _, setting := range settings {
+			chunk.AppendRow(types.DataRow{Values: []types.Value{
+				*types.NewTextValue(setting.name),
+				*types.NewTextValue(s.settings[setting.name]),
+				*types.NewTextValue(setting.description),
+			}})
+		}
+		return chunk, nil
+	}
+
+	setting, err := lookupSetting(name)
+	if err != nil {
+		return nil, err
+	}
+	row := types.DataRow{Values: []types.Value{*types.NewTextValue(s.settings[setting.name])}}
+	return types.NewWith(settingSchema(setting.name), []types.DataRow{row}), nil
+}
+
+func settingSchema(name string) *types.DataSchema {
+	return &types.DataSchema{Columns: []types.DataColumn{{Name: name, DataType: types.TYPE_TEXT}}}
+}
+
+// resolvedSearchPath is the search path with $user replaced by the user name
+func (s *Session) resolvedSearchPath() []string {
+	searchPath := []string{}
+	for _, schemaName := range listValues([]string{s.settings[SEARCH_PATH_SETTING]}) {
+		if schemaName == SEARCH_PATH_USER {
+			if s.userName == "" {
+				continue
+			}
+			schemaName = s.userName
+		}
+		searchPath = append(searchPath, schemaName)
+	}
+	return searchPath
+}
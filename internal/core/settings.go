@@ -0,0 +1,244 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// setting is a configuration parameter a session can SET, SHOW and RESET
+type setting struct {
+	// name as SHOW ALL prints it, names are looked up case insensitively
+	name        string
+	description string
+	// reported settings are sent to wire clients whenever they change
+	reported     bool
+	defaultValue string
+	// normalize checks the values of a SET and returns the setting as SHOW
+	// prints it, current is the value it replaces.
+	normalize func(current string, values []string) (string, error)
+}
+
+// settings sorted by name, search_path defaults to the database of the session
+var settings = []*setting{
+	{
+		name:        "application_name",
+		description: "Sets the application name to be reported in statistics and logs.",
+		reported:    true,
+		normalize:   singleValue,
+	},
+	{
+		name:         "client_encoding",
+		description:  "Sets the client's character set encoding.",
+		reported:     true,
+		defaultValue: "UTF8",
+		normalize:    normalizeEncoding,
+	},
+	{
+		name:         "DateStyle",
+		description:  "Sets the display format for date and time values.",
+		reported:     true,
+		defaultValue: "ISO, MDY",
+		normalize:    normalizeDateStyle,
+	},
+	{
+		name:         DEFAULT_ISOLATION_SETTING,
+		description:  "Sets the transaction isolation level of each new transaction.",
+		defaultValue: "read committed",
+		normalize:    normalizeIsolationLevel,
+	},
+	{
+		name:        SEARCH_PATH_SETTING,
+		description: "Sets the schema search order for names that are not schema-qualified.",
+		normalize:   normalizeSearchPath,
+	},
+	{
+		name:         STATEMENT_TIMEOUT_SETTING,
+		description:  "Sets the maximum allowed duration of any statement.",
+		defaultValue: "0",
+		normalize:    normalizeTimeout,
+	},
+	{
+		name:         "TimeZone",
+		description:  "Sets the time zone for displaying and interpreting time stamps.",
+		reported:     true,
+		defaultValue: "UTC",
+		normalize:    normalizeTimeZone,
+	},
+}
+
+const (
+	DEFAULT_ISOLATION_SETTING = "default_transaction_isolation"
+	SEARCH_PATH_SETTING       = "search_path"
+	STATEMENT_TIMEOUT_SETTING = "statement_timeout"
+)
+
+func lookupSetting(name string) (*setting, error) {
+	idx := slices.IndexFunc(settings, func(s *setting) bool {
+		return strings.EqualFold(s.name, name)
+	})
+	if idx < 0 {
+		return nil, types.NewError(types.ERR_UNDEFINED_OBJECT, "unrecognized configuration parameter %q", name)
+	}
+	return settings[idx], nil
+}
+
+func invalidValue(name string, value string) error {
+	return types.NewError(types.ERR_INVALID_PARAMETER_VALUE, "invalid value for parameter %q: %q", name, value)
+}
+
+func singleValue(current string, values []string) (string, error) {
+	if len(values) != 1 {
+		return "", types.NewError(types.ERR_INVALID_PARAMETER_VALUE, "setting takes only one argument")
+	}
+	return values[0], nil
+}
+
+// listValues splits values on commas, `SET x = 'a, b'` is the same as `SET x = a, b`
+func listValues(values []string) []string {
+	list := []string{}
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// only UTF8 is supported, under any of its names
+func normalizeEncoding(current string, values []string) (string, error) {
+	value, err := singleValue(current, values)
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToUpper(strings.NewReplacer("-", "", "_", "").Replace(value)) {
+	case "UTF8", "UNICODE":
+		return "UTF8", nil
+	default:
+		return "", invalidValue("client_encoding", value)
+	}
+}
+
+// the output style and the field order can be set separately, the part
+// that is not given keeps its current value
+func normalizeDateStyle(current string, values []string) (string, error) {
+	style, order, _ := strings.Cut(current, ", ")
+	for _, value := range listValues(values) {
+		switch strings.ToUpper(value) {
+		case "ISO":
+			style = "ISO"
+		case "SQL":
+			style = "SQL"
+		case "POSTGRES":
+			style = "Postgres"
+		case "GERMAN":
+			style = "German"
+		case "DMY", "EURO", "EUROPEAN":
+			order = "DMY"
+		case "MDY", "US", "NONEURO", "NONEUROPEAN":
+			order = "MDY"
+		case "YMD":
+			order = "YMD"
+		default:
+			return "", invalidValue("DateStyle", value)
+		}
+	}
+	return style + ", " + order, nil
+}
+
+// read uncommitted is accepted and behaves as read committed
+var isolationLevels = map[string]storage.IsolationLevel{
+	"read uncommitted": storage.READ_COMMITTED,
+	"read committed":   storage.READ_COMMITTED,
+	"repeatable read":  storage.REPEATABLE_READ,
+	"serializable":     storage.SERIALIZABLE,
+}
+
+func normalizeIsolationLevel(current string, values []string) (string, error) {
+	value, err := singleValue(current, values)
+	if err != nil {
+		return "", err
+	}
+	level := strings.ToLower(value)
+	if _, ok := isolationLevels[level]; !ok {
+		return "", invalidValue(DEFAULT_ISOLATION_SETTING, value)
+	}
+	return level, nil
+}
+
+func normalizeSearchPath(current string, values []string) (string, error) {
+	return strings.Join(listValues(values), ", "), nil
+}
+
+// a timeout is in milliseconds unless it has a unit, 0 disables it
+func normalizeTimeout(current string, values []string) (string, error) {
+	value, err := singleValue(current, values)
+	if err != nil {
+		return "", err
+	}
+	timeout, err := parseTimeout(value)
+	if err != nil {
+		return "", invalidValue(STATEMENT_TIMEOUT_SETTING, value)
+	}
+	return formatTimeout(timeout), nil
+}
+
+var timeoutUnits = []struct {
+	name     string
+	duration time.Duration
+}{{"h", time.Hour}, {"min", time.Minute}, {"s", time.Second}, {"ms", time.Millisecond}}
+
+func parseTimeout(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	number := strings.TrimRightFunc(value, unicode.IsLetter)
+	amount, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if amount < 0 {
+		return 0, fmt.Errorf("negative timeout")
+	}
+
+	unitName := value[len(number):]
+	if unitName == "" {
+		return time.Duration(amount) * time.Millisecond, nil
+	}
+	for _, unit := range timeoutUnits {
+		if unit.name == unitName {
+			return time.Duration(amount) * unit.duration, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown unit %s", unitName)
+}
+
+// formatTimeout uses the largest unit the timeout is a whole number of
+func formatTimeout(timeout time.Duration) string {
+	if timeout == 0 {
+		return "0"
+	}
+	for _, unit := range timeoutUnits {
+		if timeout%unit.duration == 0 {
+			return fmt.Sprintf("%d%s", timeout/unit.duration, unit.name)
+		}
+	}
+	return fmt.Sprintf("%dms", timeout.Milliseconds())
+}
+
+func normalizeTimeZone(current string, values []string) (string, error) {
+	value, err := singleValue(current, values)
+	if err != nil {
+		return "", err
+	}
+	if _, err := time.LoadLocation(value); err != nil || value == "" || value == "Local" {
+		return "", invalidValue("TimeZone", value)
+	}
+	return value, nil
+}
@@ -23,6 +23,20 @@ func NewExecutor(storage *storage.KvStorage, catalog *catalog.RootCatalog, plan
 	}
 }
 
-func (e *Executor) Execute(ctx context.Context) (*types.DataChunk, error) {
-	return e.plan.Execute(ctx, e.catalog, e.storage)
+// Open prepares the plan, its rows are then pulled with Next until it
+// returns nil. Close must be called once done, even after an error.
+func (e *Executor) Open(ctx context.Context) error {
+	return e.plan.Open(ctx, e.catalog, e.storage)
+}
+
+func (e *Executor) Next(ctx context.Context) (*types.DataChunk, error) {
+	return e.plan.Next(ctx)
+}
+
+func (e *Executor) Close() {
+	e.plan.Close()
+}
+
+func (e *Executor) GetSchema() *types.DataSchema {
+	return e.plan.GetSchema()
 }
@@ -0,0 +1,387 @@
+package expression
+
+import (
+	"math"
+	"strings"
+
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// Aggregate functions compute a value from all the rows of a group. Their
+// arguments are evaluated for each row of the group and accumulated in an
+// AggregateState, rows whose first argument is NULL are skipped.
+
+// the aggregate functions and the number of arguments they take
+var aggregateFunctions = map[string]int{
+	"COUNT":      1,
+	"SUM":        1,
+	"AVG":        1,
+	"MIN":        1,
+	"MAX":        1,
+	"STRING_AGG": 2,
+	"BOOL_AND":   1,
+	"BOOL_OR":    1,
+}
+
+// AggregateName returns the upper case name of the aggregate function a
+// call is to, empty when it is not an aggregate.
+func AggregateName(call *ast.CallExpr) string {
+	function, ok := call.Function.(*ast.IdentifierExpr)
+	if !ok || function.Table != "" {
+		return ""
+	}
+	name := strings.ToUpper(function.Value)
+	if _, ok := aggregateFunctions[name]; !ok {
+		return ""
+	}
+	return name
+}
+
+// ContainsAggregate tells whether an aggregate function is called in expr
+func ContainsAggregate(expr ast.Expression) bool {
+	switch e := expr.(type) {
+	case *ast.CallExpr:
+		if AggregateName(e) != "" {
+			return true
+		}
+		for _, arg := range e.Args {
+			if ContainsAggregate(arg) {
+				return true
+			}
+		}
+	case *ast.PrefixExpr:
+		return ContainsAggregate(e.Right)
+	case *ast.InfixExpr:
+		return ContainsAggregate(e.Left) || ContainsAggregate(e.Right)
+	case *ast.IsNullExpr:
+		return ContainsAggregate(e.Expr)
+	}
+	return false
+}
+
+// AggregateType returns the type of the result of an aggregate function
+// given the type of its first argument, zero when it is not known.
+func AggregateType(name string, argType types.DataType) types.DataType {
+	switch name {
+	case "COUNT":
+		return types.TYPE_INT
+	case "AVG":
+		return types.TYPE_FLOAT
+	case "STRING_AGG":
+		return types.TYPE_TEXT
+	case "BOOL_AND", "BOOL_OR":
+		return types.TYPE_BOOL
+	}
+	return argType
+}
+
+// Aggregate is a call to an aggregate function bound to the schema of the
+// rows it aggregates.
+type Aggregate struct {
+	name string
+	// nil for COUNT(*) which counts the rows
+	args     []BoundExpr
+	distinct bool
+	dataType types.DataType
+}
+
+// BindAggregate binds the arguments of a call to an aggregate function and
+// checks their types.
+func BindAggregate(call *ast.CallExpr, schema *types.DataSchema) (*Aggregate, error) {
+	name := AggregateName(call)
+	aggregate := &Aggregate{name: name, distinct: call.Distinct}
+	if len(call.Args) == 1 {
+		if star, ok := call.Args[0].(*ast.StarExpr); ok && star.Table == "" && name == "COUNT" {
+			if call.Distinct {
+				return nil, types.NewError(types.ERR_SYNTAX_ERROR, "DISTINCT is not allowed with COUNT(*)")
+			}
+			aggregate.dataType = types.TYPE_INT
+			return aggregate, nil
+		}
+	}
+
+	argTypes := make([]string, len(call.Args))
+	for i, arg := range call.Args {
+		if ContainsAggregate(arg) {
+			return nil, types.NewError(types.ERR_GROUPING_ERROR, "aggregate function calls cannot be nested")
+		}
+		bound, err := Bind(arg, schema)
+		if err != nil {
+			return nil, err
+		}
+		aggregate.args = append(aggregate.args, bound)
+		argTypes[i] = bound.DataType().String()
+	}
+	if !aggregate.accepts() {
+		return nil, types.NewError(types.ERR_UNDEFINED_FUNCTION, "function %s(%s) does not exist", strings.ToLower(name), strings.Join(argTypes, ", "))
+	}
+	aggregate.dataType = AggregateType(name, aggregate.args[0].DataType())
+	return aggregate, nil
+}
+
+// accepts tells whether the function is defined for the types of its arguments
+func (a *Aggregate) accepts() bool {
+	if len(a.args) != aggregateFunctions[a.name] {
+		return false
+	}
+	for _, arg := range a.args {
+		dataType := arg.DataType()
+		if dataType == 0 {
+			continue
+		}
+		switch a.name {
+		case "SUM", "AVG":
+			if !isNumeric(dataType) {
+				return false
+			}
+		case "STRING_AGG":
+			if dataType != types.TYPE_TEXT {
+				return false
+			}
+		case "BOOL_AND", "BOOL_OR":
+			if dataType != types.TYPE_BOOL {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (a *Aggregate) DataType() types.DataType {
+	return a.dataType
+}
+
+// EvaluateArgs computes the arguments of the function for all the rows of
+// a chunk, the values of a row are then read at its position.
+func (a *Aggregate) EvaluateArgs(chunk *types.DataChunk) ([]*types.Vector, error) {
+	vectors := make([]*types.Vector, len(a.args))
+	for i, arg := range a.args {
+		var err error
+		if vectors[i], err = arg.EvaluateVector(chunk); err != nil {
+			return nil, err
+		}
+	}
+	return vectors, nil
+}
+
+// AggregateState accumulates the values of a group
+type AggregateState interface {
+	// Add accumulates the arguments of a row
+	Add(args []types.Value) error
+	// Result is the value of the function over the rows added so far
+	Result() types.Value
+}
+
+// NewState returns the state of the function for a new group
+func (a *Aggregate) NewState() AggregateState {
+	var state AggregateState
+	switch a.name {
+	case "COUNT":
+		state = &countState{}
+	case "SUM":
+		state = &sumState{dataType: a.dataType}
+	case "AVG":
+		state = &avgState{}
+	case "MIN", "MAX":
+		state = &extremumState{max: a.name == "MAX"}
+	case "STRING_AGG":
+		state = &stringAggState{}
+	default:
+		state = &boolState{or: a.name == "BOOL_OR"}
+	}
+	if a.distinct {
+		return &distinctState{state: state, seen: map[string]bool{}}
+	}
+	return state
+}
+
+type countState struct {
+	count int64
+}
+
+// COUNT(*) gives no arguments, every row is counted
+func (s *countState) Add(args []types.Value) error {
+	if len(args) == 0 || !args[0].IsNull() {
+		s.count++
+	}
+	return nil
+}
+
+func (s *countState) Result() types.Value {
+	return *types.NewIntValue(s.count)
+}
+
+// sums INT values as INT, failing on overflow, and other ones as FLOAT
+type sumState struct {
+	dataType types.DataType
+	ints     int64
+	floats   float64
+	seen     bool
+}
+
+func (s *sumState) Add(args []types.Value) error {
+	if args[0].IsNull() {
+		return nil
+	}
+	s.seen = true
+	if s.dataType == types.TYPE_INT {
+		v, _ := args[0].Int()
+		if (v > 0 && s.ints > math.MaxInt64-v) || (v < 0 && s.ints < math.MinInt64-v) {
+			return errIntegerOutOfRange()
+		}
+		s.ints += v
+		return nil
+	}
+	s.floats += asFloat(args[0])
+	return nil
+}
+
+func (s *sumState) Result() types.Value {
+	switch {
+	case !s.seen:
+		return types.Value{}
+	case s.dataType == types.TYPE_INT:
+		return *types.NewIntValue(s.ints)
+	default:
+		return *types.NewFloatValue(s.floats)
+	}
+}
+
+type avgState struct {
+	sum   float64
+	count int64
+}
+
+func (s *avgState) Add(args []types.Value) error {
+	if !args[0].IsNull() {
+		s.sum += asFloat(args[0])
+		s.count++
+	}
+	return nil
+}
+
+func (s *avgState) Result() types.Value {
+	if s.count == 0 {
+		return types.Value{}
+	}
+	return *types.NewFloatValue(s.sum / float64(s.count))
+}
+
+// the lowest value for MIN, the greatest one for MAX
+type extremumState struct {
+	max   bool
+	value types.Value
+}
+
+func (s *extremumState) Add(args []types.Value) error {
+	if args[0].IsNull() {
+		return nil
+	}
+	if s.value.IsNull() {
+		s.value = args[0]
+		return nil
+	}
+	result, err := args[0].Compare(&s.value)
+	if err != nil {
+		return err
+	}
+	if (s.max && result > 0) || (!s.max && result < 0) {
+		s.value = args[0]
+	}
+	return nil
+}
+
+func (s *extremumState) Result() types.Value {
+	return s.value
+}
+
+// the delimiter of a row goes before its value, a NULL delimiter is empty
+type stringAggState struct {
+	builder strings.Builder
+	seen    bool
+}
+
+func (s *stringAggState) Add(args []types.Value) error {
+	if args[0].IsNull() {
+		return nil
+	}
+	if s.seen && !args[1].IsNull() {
+		delimiter, _ := args[1].Text()
+		s.builder.WriteString(delimiter)
+	}
+	value, _ := args[0].Text()
+	s.builder.WriteString(value)
+	s.seen = true
+	return nil
+}
+
+func (s *stringAggState) Result() types.Value {
+	if !s.seen {
+		return types.Value{}
+	}
+	return *types.NewTextValue(s.builder.String())
+}
+
+type boolState struct {
+	or    bool
+	value bool
+	seen  bool
+}
+
+func (s *boolState) Add(args []types.Value) error {
+	if args[0].IsNull() {
+		return nil
+	}
+	v, _ := args[0].Bool()
+	if !s.seen {
+		s.value, s.seen = v, true
+	} else if s.or {
+		s.value = s.value || v
+	} else {
+		s.value = s.value && v
+	}
+	return nil
+}
+
+func (s *boolState) Result() types.Value {
+	if !s.seen {
+		return types.Value{}
+	}
+	return *types.NewBoolValue(s.value)
+}
+
+// passes the rows of a DISTINCT aggregate to its state the first time
+// their value is seen.
+type distinctState struct {
+	state AggregateState
+	seen  map[string]bool
+}
+
+func (s *distinctState) Add(args []types.Value) error {
+	if args[0].IsNull() {
+		return nil
+	}
+	key, err := types.EncodeKey(args[:1])
+	if err != nil {
+		return err
+	}
+	if s.seen[string(key)] {
+		return nil
+	}
+	s.seen[string(key)] = true
+	return s.state.Add(args)
+}
+
+func (s *distinctState) Result() types.Value {
+	return s.state.Result()
+}
+
+func asFloat(value types.Value) float64 {
+	if value.GetDataType() == types.TYPE_INT {
+		v, _ := value.Int()
+		return float64(v)
+	}
+	v, _ := value.Float()
+	return v
+}
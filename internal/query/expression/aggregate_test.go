@@ -0,0 +1,94 @@
+package expression
+
+import (
+	"math"
+	"testing"
+
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// aggregate adds each row of args to a new state of the call and returns
+// its result
+func aggregate(t *testing.T, sql string, args [][]types.Value) (types.Value, error) {
+	call, ok := parseExpression(t, sql).(*ast.CallExpr)
+	require.True(t, ok, sql)
+	bound, err := BindAggregate(call, testSchema)
+	require.NoError(t, err, sql)
+
+	state := bound.NewState()
+	for _, row := range args {
+		if err := state.Add(row); err != nil {
+			return types.Value{}, err
+		}
+	}
+	return state.Result(), nil
+}
+
+func TestAggregateStates(t *testing.T) {
+	null := *types.NewNullValue()
+	ints := func(values ...int64) [][]types.Value {
+		rows := make([][]types.Value, len(values))
+		for i, v := range values {
+			rows[i] = []types.Value{*types.NewIntValue(v)}
+		}
+		return rows
+	}
+	texts := [][]types.Value{
+		{*types.NewTextValue("a"), *types.NewTextValue(",")},
+		{null, *types.NewTextValue(";")},
+		{*types.NewTextValue("b"), null},
+		{*types.NewTextValue("c"), *types.NewTextValue("-")},
+	}
+
+	tests := []struct {
+		sql      string
+		args     [][]types.Value
+		expected types.Value
+	}{
+		{"COUNT(*)", [][]types.Value{{}, {}}, *types.NewIntValue(2)},
+		{"COUNT(id)", append(ints(1, 2), []types.Value{null}), *types.NewIntValue(2)},
+		{"COUNT(DISTINCT id)", ints(1, 2, 1, 2, 3), *types.NewIntValue(3)},
+		{"SUM(id)", ints(1, 2, 3), *types.NewIntValue(6)},
+		{"SUM(DISTINCT id)", ints(4, 4, 1), *types.NewIntValue(5)},
+		{"SUM(id)", nil, null},
+		{"AVG(id)", ints(1, 2), *types.NewFloatValue(1.5)},
+		{"MIN(id)", append(ints(3, 1), []types.Value{null}), *types.NewIntValue(1)},
+		{"MAX(name)", [][]types.Value{{*types.NewTextValue("ant")}, {*types.NewTextValue("fox")}}, *types.NewTextValue("fox")},
+		// the delimiter goes before the value of its row
+		{"STRING_AGG(name, ',')", texts, *types.NewTextValue("ab-c")},
+		{"BOOL_AND(active)", [][]types.Value{{*types.NewBoolValue(true)}, {null}, {*types.NewBoolValue(false)}}, *types.NewBoolValue(false)},
+		{"BOOL_OR(active)", [][]types.Value{{*types.NewBoolValue(false)}, {null}}, *types.NewBoolValue(false)},
+		{"BOOL_OR(active)", [][]types.Value{{null}}, null},
+	}
+	for _, tt := range tests {
+		result, err := aggregate(t, tt.sql, tt.args)
+		require.NoError(t, err, tt.sql)
+		assert.Equal(t, tt.expected, result, tt.sql)
+	}
+
+	_, err := aggregate(t, "SUM(id)", ints(math.MaxInt64, 1))
+	assert.Equal(t, types.ERR_NUMERIC_VALUE_OUT_OF_RANGE, types.GetErrorCode(err))
+}
+
+func TestBindAggregateErrors(t *testing.T) {
+	tests := []struct {
+		sql  string
+		code types.ErrorCode
+	}{
+		{"SUM(name)", types.ERR_UNDEFINED_FUNCTION},
+		{"AVG(active)", types.ERR_UNDEFINED_FUNCTION},
+		{"BOOL_AND(id)", types.ERR_UNDEFINED_FUNCTION},
+		{"STRING_AGG(name)", types.ERR_UNDEFINED_FUNCTION},
+		{"COUNT(id, name)", types.ERR_UNDEFINED_FUNCTION},
+		{"MAX(MIN(id))", types.ERR_GROUPING_ERROR},
+		{"COUNT(DISTINCT *)", types.ERR_SYNTAX_ERROR},
+		{"MIN(nope)", types.ERR_UNDEFINED_COLUMN},
+	}
+	for _, tt := range tests {
+		_, err := BindAggregate(parseExpression(t, tt.sql).(*ast.CallExpr), testSchema)
+		assert.Equal(t, tt.code, types.GetErrorCode(err), tt.sql)
+	}
+}
@@ -0,0 +1,156 @@
+package expression
+
+import (
+	"strings"
+
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// BoundExpr is an expression ready to be evaluated against the rows of the
+// schema it was bound to, its columns are resolved to their position in the
+// row and its operators are checked against the types of their operands.
+type BoundExpr interface {
+	// the type of the values produced, zero when it is not known until the
+	// expression is evaluated, as for NULL
+	DataType() types.DataType
+	Evaluate(row types.DataRow) (*types.Value, error)
+	// EvaluateVector computes the values of all the rows of a chunk at once
+	EvaluateVector(chunk *types.DataChunk) (*types.Vector, error)
+}
+
+// Bind resolves the columns referenced by expr in schema and type checks
+// its operators. INT operands are promoted to FLOAT when mixed with FLOAT
+// ones, NULL is accepted by every operator.
+func Bind(expr ast.Expression, schema *types.DataSchema) (BoundExpr, error) {
+	switch e := expr.(type) {
+	case *ast.IntegerLiteralExpr:
+		return &literal{value: types.NewIntValue(e.Value)}, nil
+	case *ast.FloatLiteralExpr:
+		return &literal{value: types.NewFloatValue(e.Value)}, nil
+	case *ast.StringLiteralExpr:
+		return &literal{value: types.NewTextValue(e.Value)}, nil
+	case *ast.BooleanLiteralExpr:
+		return &literal{value: types.NewBoolValue(e.Value)}, nil
+	case *ast.NullLiteralExpr:
+		return &literal{value: types.NewNullValue()}, nil
+	case *ast.ParameterExpr:
+		return &parameter{index: e.Index}, nil
+	case *ast.IdentifierExpr:
+		idx, err := schema.ResolveColumn(e.Table, e.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &columnRef{index: idx, dataType: schema.Columns[idx].DataType}, nil
+	case *ast.PrefixExpr:
+		operand, err := Bind(e.Right, schema)
+		if err != nil {
+			return nil, err
+		}
+		return bindPrefix(strings.ToUpper(e.Operator), operand)
+	case *ast.InfixExpr:
+		left, err := Bind(e.Left, schema)
+		if err != nil {
+			return nil, err
+		}
+		right, err := Bind(e.Right, schema)
+		if err != nil {
+			return nil, err
+		}
+		return bindInfix(strings.ToUpper(e.Operator), left, right)
+	case *ast.IsNullExpr:
+		operand, err := Bind(e.Expr, schema)
+		if err != nil {
+			return nil, err
+		}
+		return &isNull{operand: operand, not: e.Not}, nil
+	case *ast.CallExpr:
+		// aggregates are computed by the plan grouping the rows, expressions
+		// evaluated after it refer to their result as to a column
+		if AggregateName(e) != "" {
+			return nil, types.NewError(types.ERR_GROUPING_ERROR, "aggregate function %s is not allowed here", e.ToExprString())
+		}
+		return nil, types.NewError(types.ERR_UNDEFINED_FUNCTION, "function %s does not exist", e.Function.ToExprString())
+	case *ast.StarExpr:
+		return nil, types.NewError(types.ERR_SYNTAX_ERROR, "%s is only allowed in the SELECT list and in COUNT(*)", e.ToExprString())
+	}
+	return nil, types.NewError(types.ERR_FEATURE_NOT_SUPPORTED, "unsupported expression: %T", expr)
+}
+
+// BindPredicate binds the condition of a clause such as WHERE, it must be BOOL
+func BindPredicate(expr ast.Expression, schema *types.DataSchema, clause string) (BoundExpr, error) {
+	bound, err := Bind(expr, schema)
+	if err != nil {
+		return nil, err
+	}
+	if dataType := bound.DataType(); dataType != 0 && dataType != types.TYPE_BOOL {
+		return nil, types.NewError(types.ERR_DATATYPE_MISMATCH, "argument of %s must be type BOOL, not type %s", clause, dataType)
+	}
+	return bound, nil
+}
+
+func bindPrefix(operator string, operand BoundExpr) (BoundExpr, error) {
+	dataType := operand.DataType()
+	switch operator {
+	case "-":
+		if dataType != 0 && !isNumeric(dataType) {
+			return nil, types.NewError(types.ERR_UNDEFINED_FUNCTION, "operator %s is not defined for %s", operator, dataType)
+		}
+		return &negation{operand: operand}, nil
+	case "NOT":
+		if dataType != 0 && dataType != types.TYPE_BOOL {
+			return nil, types.NewError(types.ERR_DATATYPE_MISMATCH, "argument of NOT must be type BOOL, not type %s", dataType)
+		}
+		return &not{operand: operand}, nil
+	}
+	return nil, types.NewError(types.ERR_FEATURE_NOT_SUPPORTED, "unsupported operator: %s", operator)
+}
+
+func bindInfix(operator string, left BoundExpr, right BoundExpr) (BoundExpr, error) {
+	leftType, rightType := left.DataType(), right.DataType()
+	switch operator {
+	case "AND", "OR":
+		for _, dataType := range []types.DataType{leftType, rightType} {
+			if dataType != 0 && dataType != types.TYPE_BOOL {
+				return nil, types.NewError(types.ERR_DATATYPE_MISMATCH, "operator %s expects BOOL operands", operator)
+			}
+		}
+		return &logical{operator: operator, left: left, right: right}, nil
+	case "=", "!=", "<>", "<", "<=", ">", ">=":
+		if leftType != 0 && rightType != 0 && leftType != rightType && !(isNumeric(leftType) && isNumeric(rightType)) {
+			return nil, types.NewError(types.ERR_DATATYPE_MISMATCH, "cannot compare %s with %s", leftType, rightType)
+		}
+		return &comparison{operator: operator, left: left, right: right}, nil
+	case "+", "-", "*", "/":
+		if (leftType != 0 && !isNumeric(leftType)) || (rightType != 0 && !isNumeric(rightType)) {
+			return nil, types.NewError(types.ERR_UNDEFINED_FUNCTION, "operator %s is not defined for %s and %s", operator, leftType, rightType)
+		}
+		return &arithmetic{operator: operator, left: left, right: right, dataType: promote(leftType, rightType)}, nil
+	case "||":
+		// one side has to be TEXT, the other one is converted to it
+		if leftType != 0 && rightType != 0 && leftType != types.TYPE_TEXT && rightType != types.TYPE_TEXT {
+			return nil, types.NewError(types.ERR_UNDEFINED_FUNCTION, "operator %s is not defined for %s and %s", operator, leftType, rightType)
+		}
+		return &concatenation{left: left, right: right}, nil
+	}
+	return nil, types.NewError(types.ERR_FEATURE_NOT_SUPPORTED, "unsupported operator: %s", operator)
+}
+
+// promote returns the type of the result of an arithmetic operator, an
+// operand of unknown type takes the type of the other one.
+func promote(left types.DataType, right types.DataType) types.DataType {
+	switch {
+	case left == 0:
+		return right
+	case right == 0:
+		return left
+	case left == types.TYPE_FLOAT || right == types.TYPE_FLOAT:
+		return types.TYPE_FLOAT
+	default:
+		return types.TYPE_INT
+	}
+}
+
+func isNumeric(dataType types.DataType) bool {
+	return dataType == types.TYPE_INT || dataType == types.TYPE_FLOAT
+}
@@ -0,0 +1,253 @@
+package expression
+
+import (
+	"math"
+	"testing"
+
+	"github.com/evanxg852000/foxdb/internal/query/parser"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSchema = &types.DataSchema{Columns: []types.DataColumn{
+	{Name: "id", DataType: types.TYPE_INT},
+	{Name: "price", DataType: types.TYPE_FLOAT},
+	{Name: "name", DataType: types.TYPE_TEXT},
+	{Name: "active", DataType: types.TYPE_BOOL},
+	{Name: "score", DataType: types.TYPE_INT},
+}}
+
+var testRow = types.DataRow{Values: []types.Value{
+	*types.NewIntValue(7),
+	*types.NewFloatValue(2.5),
+	*types.NewTextValue("fox"),
+	*types.NewBoolValue(true),
+	*types.NewNullValue(),
+}}
+
+// parseExpression parses sql as the WHERE clause of a query
+func parseExpression(t *testing.T, sql string) ast.Expression {
+	p := parser.NewParser(parser.NewLexer("SELECT id FROM t WHERE " + sql + ";"))
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors(), sql)
+	return program.Statements[0].(*ast.SelectStatement).WhereClause
+}
+
+func TestBindAndEvaluate(t *testing.T) {
+	tests := []struct {
+		expr     string
+		dataType types.DataType
+		expected *types.Value
+	}{
+		{"id + 3", types.TYPE_INT, types.NewIntValue(10)},
+		{"id / 2", types.TYPE_INT, types.NewIntValue(3)},
+		{"id * price", types.TYPE_FLOAT, types.NewFloatValue(17.5)},
+		{"-price + 1", types.TYPE_FLOAT, types.NewFloatValue(-1.5)},
+		{"id + score", types.TYPE_INT, types.NewNullValue()},
+		{"id + NULL", types.TYPE_INT, types.NewNullValue()},
+		{"id > price", types.TYPE_BOOL, types.NewBoolValue(true)},
+		{"name = 'fox'", types.TYPE_BOOL, types.NewBoolValue(true)},
+		{"score = NULL", types.TYPE_BOOL, types.NewNullValue()},
+		{"active AND score > 1", types.TYPE_BOOL, types.NewNullValue()},
+		{"NOT active OR score > 1", types.TYPE_BOOL, types.NewNullValue()},
+		{"active OR score > 1", types.TYPE_BOOL, types.NewBoolValue(true)},
+		{"NOT active AND score > 1", types.TYPE_BOOL, types.NewBoolValue(false)},
+		{"score IS NULL", types.TYPE_BOOL, types.NewBoolValue(true)},
+		{"name || '-' || id", types.TYPE_TEXT, types.NewTextValue("fox-7")},
+		{"'on: ' || active", types.TYPE_TEXT, types.NewTextValue("on: true")},
+		{"name || NULL", types.TYPE_TEXT, types.NewNullValue()},
+		{"NULL", 0, types.NewNullValue()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			bound, err := Bind(parseExpression(t, tt.expr), testSchema)
+			require.NoError(t, err)
+			assert.Equal(t, tt.dataType, bound.DataType())
+
+			value, err := bound.Evaluate(testRow)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+
+			// evaluating a chunk gives the same value for its selected rows
+			chunk := types.NewWith(testSchema, []types.DataRow{testRow, testRow, testRow}).Select([]int{0, 2})
+			vector, err := bound.EvaluateVector(chunk)
+			require.NoError(t, err)
+			assert.Equal(t, *tt.expected, vector.Get(0))
+			assert.Equal(t, *tt.expected, vector.Get(2))
+			assert.True(t, vector.IsNull(1))
+		})
+	}
+}
+
+func TestEvaluateSelection(t *testing.T) {
+	rows := make([]types.DataRow, 200)
+	for i := range rows {
+		score := *types.NewNullValue()
+		if i%5 != 0 {
+			score = *types.NewIntValue(int64(i % 5))
+		}
+		rows[i] = types.DataRow{Values: []types.Value{
+			*types.NewIntValue(int64(i - 100)),
+			*types.NewFloatValue(float64(i) / 4),
+			*types.NewTextValue(string(rune('a' + i%26))),
+			*types.NewBoolValue(i%2 == 0),
+			score,
+		}}
+	}
+	chunk := types.NewWith(testSchema, rows)
+
+	tests := []struct {
+		expr     string
+		expected []int
+	}{
+		{"id = 3", []int{103}},
+		{"id > 95 AND price < 49.5", []int{196, 197}},
+		{"name = 'c' AND active", []int{2, 28, 54, 80, 106, 132, 158, 184}},
+		{"score IS NULL AND id < -80", []int{0, 5, 10, 15}},
+		{"score > 3 AND id > 80", []int{184, 189, 194, 199}},
+		{"NOT (score < 4) AND id > 80", []int{184, 189, 194, 199}},
+		{"id + score = 104", []int{}},
+		{"-id * 2 > 196 OR id = 99", []int{0, 1, 199}},
+		{"NULL", []int{}},
+		// the rows the left operand decides never evaluate the right one
+		{"id <> 0 AND 100 / id >= 50", []int{101, 102}},
+		{"id = 0 OR 1 / id = 1", []int{100, 101}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			bound, err := BindPredicate(parseExpression(t, tt.expr), testSchema, "WHERE")
+			require.NoError(t, err)
+			selection, err := EvaluateSelection(bound, chunk)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, selection)
+
+			// the selection of a filtered chunk is evaluated again
+			selection, err = EvaluateSelection(bound, chunk.Select(selection))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, selection)
+		})
+	}
+
+	bound, err := Bind(parseExpression(t, "10 / (id - 50) > 0"), testSchema)
+	require.NoError(t, err)
+	_, err = EvaluateSelection(bound, chunk)
+	assert.Equal(t, types.ERR_DIVISION_BY_ZERO, types.GetErrorCode(err))
+	_, err = EvaluateSelection(bound, chunk.Slice(0, 150))
+	assert.NoError(t, err)
+}
+
+func TestEvaluateProjection(t *testing.T) {
+	rows := []types.DataRow{testRow, testRow, testRow}
+	rows[1] = types.DataRow{Values: []types.Value{
+		*types.NewIntValue(0),
+		*types.NewFloatValue(1),
+		*types.NewTextValue("owl"),
+		*types.NewBoolValue(false),
+		*types.NewIntValue(4),
+	}}
+	chunk := types.NewWith(testSchema, rows).Select([]int{0, 2})
+
+	exprs := []BoundExpr{}
+	for _, sql := range []string{"name", "id * price", "score + 1", "NOT active", "NULL"} {
+		bound, err := Bind(parseExpression(t, sql), testSchema)
+		require.NoError(t, err)
+		exprs = append(exprs, bound)
+	}
+	columns, err := EvaluateProjection(exprs, chunk)
+	require.NoError(t, err)
+	require.Len(t, columns, 5)
+
+	// column references share the vectors of the chunk
+	assert.Same(t, chunk.GetColumn(2), columns[0])
+	for _, i := range []int{0, 2} {
+		assert.Equal(t, []types.Value{
+			*types.NewTextValue("fox"),
+			*types.NewFloatValue(17.5),
+			*types.NewNullValue(),
+			*types.NewBoolValue(false),
+			*types.NewNullValue(),
+		}, []types.Value{columns[0].Get(i), columns[1].Get(i), columns[2].Get(i), columns[3].Get(i), columns[4].Get(i)})
+	}
+	// the rows left out by the selection are not computed
+	assert.True(t, columns[1].IsNull(1))
+	assert.True(t, columns[2].IsNull(1))
+
+	bound, err := Bind(parseExpression(t, "price / id"), testSchema)
+	require.NoError(t, err)
+	_, err = EvaluateProjection([]BoundExpr{bound}, chunk)
+	assert.NoError(t, err)
+	_, err = EvaluateProjection([]BoundExpr{bound}, chunk.Select([]int{1}))
+	assert.Equal(t, types.ERR_DIVISION_BY_ZERO, types.GetErrorCode(err))
+}
+
+func TestBindErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		code types.ErrorCode
+	}{
+		{"missing = 1", types.ERR_UNDEFINED_COLUMN},
+		{"name + 1", types.ERR_UNDEFINED_FUNCTION},
+		{"-name", types.ERR_UNDEFINED_FUNCTION},
+		{"id || 1", types.ERR_UNDEFINED_FUNCTION},
+		{"name = 1", types.ERR_DATATYPE_MISMATCH},
+		{"active < id", types.ERR_DATATYPE_MISMATCH},
+		{"id AND active", types.ERR_DATATYPE_MISMATCH},
+		{"NOT name", types.ERR_DATATYPE_MISMATCH},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Bind(parseExpression(t, tt.expr), testSchema)
+			assert.Equal(t, tt.code, types.GetErrorCode(err))
+		})
+	}
+
+	_, err := BindPredicate(parseExpression(t, "id + 1"), testSchema, "WHERE")
+	assert.Equal(t, types.ERR_DATATYPE_MISMATCH, types.GetErrorCode(err))
+	assert.EqualError(t, err, "argument of WHERE must be type BOOL, not type INT")
+	_, err = BindPredicate(parseExpression(t, "NULL"), testSchema, "WHERE")
+	assert.NoError(t, err)
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	intValue := func(v int64) *types.Value { return types.NewIntValue(v) }
+	tests := []struct {
+		name     string
+		operator string
+		left     *types.Value
+		right    *types.Value
+		code     types.ErrorCode
+	}{
+		{"int division by zero", "/", intValue(1), intValue(0), types.ERR_DIVISION_BY_ZERO},
+		{"float division by zero", "/", types.NewFloatValue(1), intValue(0), types.ERR_DIVISION_BY_ZERO},
+		{"addition overflow", "+", intValue(math.MaxInt64), intValue(1), types.ERR_NUMERIC_VALUE_OUT_OF_RANGE},
+		{"subtraction overflow", "-", intValue(math.MinInt64), intValue(1), types.ERR_NUMERIC_VALUE_OUT_OF_RANGE},
+		{"multiplication overflow", "*", intValue(math.MaxInt64 / 2), intValue(3), types.ERR_NUMERIC_VALUE_OUT_OF_RANGE},
+		{"negative multiplication overflow", "*", intValue(-1), intValue(math.MinInt64), types.ERR_NUMERIC_VALUE_OUT_OF_RANGE},
+		{"division overflow", "/", intValue(math.MinInt64), intValue(-1), types.ERR_NUMERIC_VALUE_OUT_OF_RANGE},
+		{"float overflow", "*", types.NewFloatValue(math.MaxFloat64), types.NewFloatValue(2), types.ERR_NUMERIC_VALUE_OUT_OF_RANGE},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bound, err := bindInfix(tt.operator, &literal{value: tt.left}, &literal{value: tt.right})
+			require.NoError(t, err)
+			_, err = bound.Evaluate(types.DataRow{})
+			assert.Equal(t, tt.code, types.GetErrorCode(err))
+		})
+	}
+
+	// the extremes are still in range
+	bound, err := bindInfix("-", &literal{value: intValue(-1)}, &literal{value: intValue(math.MaxInt64)})
+	require.NoError(t, err)
+	value, err := bound.Evaluate(types.DataRow{})
+	require.NoError(t, err)
+	assert.Equal(t, intValue(math.MinInt64), value)
+
+	_, err = (&negation{operand: &literal{value: intValue(math.MinInt64)}}).Evaluate(types.DataRow{})
+	assert.Equal(t, types.ERR_NUMERIC_VALUE_OUT_OF_RANGE, types.GetErrorCode(err))
+}
@@ -0,0 +1,357 @@
+package expression
+
+import (
+	"math"
+
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// Evaluate binds expr then computes its value for a row laid out as described
+// by schema, expressions evaluated for many rows should be bound once.
+func Evaluate(expr ast.Expression, schema *types.DataSchema, row types.DataRow) (*types.Value, error) {
+	bound, err := Bind(expr, schema)
+	if err != nil {
+		return nil, err
+	}
+	return bound.Evaluate(row)
+}
+
+// EvaluatePredicate evaluates a boolean expression, NULL is treated as false.
+func EvaluatePredicate(expr BoundExpr, row types.DataRow) (bool, error) {
+	value, err := expr.Evaluate(row)
+	if err != nil {
+		return false, err
+	}
+	if value.IsNull() {
+		return false, nil
+	}
+	return value.Bool()
+}
+
+type literal struct {
+	value *types.Value
+}
+
+func (l *literal) DataType() types.DataType {
+	return l.value.GetDataType()
+}
+
+func (l *literal) Evaluate(row types.DataRow) (*types.Value, error) {
+	return l.value, nil
+}
+
+// parameters are replaced by their value before statements run, they are
+// only bound when describing a statement.
+type parameter struct {
+	index int
+}
+
+func (p *parameter) DataType() types.DataType {
+	return 0
+}
+
+func (p *parameter) Evaluate(row types.DataRow) (*types.Value, error) {
+	return nil, types.NewError(types.ERR_PROTOCOL_VIOLATION, "no value supplied for parameter $%d", p.index)
+}
+
+type columnRef struct {
+	index    int
+	dataType types.DataType
+}
+
+func (c *columnRef) DataType() types.DataType {
+	return c.dataType
+}
+
+func (c *columnRef) Evaluate(row types.DataRow) (*types.Value, error) {
+	return &row.Values[c.index], nil
+}
+
+type isNull struct {
+	operand BoundExpr
+	not     bool
+}
+
+func (n *isNull) DataType() types.DataType {
+	return types.TYPE_BOOL
+}
+
+func (n *isNull) Evaluate(row types.DataRow) (*types.Value, error) {
+	value, err := n.operand.Evaluate(row)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewBoolValue(value.IsNull() != n.not), nil
+}
+
+type negation struct {
+	operand BoundExpr
+}
+
+func (n *negation) DataType() types.DataType {
+	return n.operand.DataType()
+}
+
+func (n *negation) Evaluate(row types.DataRow) (*types.Value, error) {
+	value, err := n.operand.Evaluate(row)
+	if err != nil || value.IsNull() {
+		return value, err
+	}
+	switch value.GetDataType() {
+	case types.TYPE_INT:
+		v, _ := value.Int()
+		if v == math.MinInt64 {
+			return nil, errIntegerOutOfRange()
+		}
+		return types.NewIntValue(-v), nil
+	case types.TYPE_FLOAT:
+		v, _ := value.Float()
+		return types.NewFloatValue(-v), nil
+	}
+	return nil, types.NewError(types.ERR_UNDEFINED_FUNCTION, "operator - is not defined for %s", value.GetDataType())
+}
+
+type not struct {
+	operand BoundExpr
+}
+
+func (n *not) DataType() types.DataType {
+	return types.TYPE_BOOL
+}
+
+func (n *not) Evaluate(row types.DataRow) (*types.Value, error) {
+	value, err := n.operand.Evaluate(row)
+	if err != nil || value.IsNull() {
+		return value, err
+	}
+	v, err := value.Bool()
+	if err != nil {
+		return nil, types.NewError(types.ERR_DATATYPE_MISMATCH, "argument of NOT must be type BOOL, not type %s", value.GetDataType())
+	}
+	return types.NewBoolValue(!v), nil
+}
+
+// AND and OR follow the SQL three-valued logic: NULL stands for an unknown
+// boolean, so FALSE AND NULL is FALSE and TRUE OR NULL is TRUE.
+type logical struct {
+	operator string
+	left     BoundExpr
+	right    BoundExpr
+}
+
+func (l *logical) DataType() types.DataType {
+	return types.TYPE_BOOL
+}
+
+func (l *logical) Evaluate(row types.DataRow) (*types.Value, error) {
+	// the value deciding the result whatever the other operand is
+	dominant := l.operator == "OR"
+	unknown := false
+	for _, operand := range []BoundExpr{l.left, l.right} {
+		value, err := operand.Evaluate(row)
+		if err != nil {
+			return nil, err
+		}
+		if value.IsNull() {
+			unknown = true
+			continue
+		}
+		v, err := value.Bool()
+		if err != nil {
+			return nil, types.NewError(types.ERR_DATATYPE_MISMATCH, "operator %s expects BOOL operands", l.operator)
+		}
+		if v == dominant {
+			return types.NewBoolValue(dominant), nil
+		}
+	}
+	if unknown {
+		return types.NewNullValue(), nil
+	}
+	return types.NewBoolValue(!dominant), nil
+}
+
+type comparison struct {
+	operator string
+	left     BoundExpr
+	right    BoundExpr
+}
+
+func (c *comparison) DataType() types.DataType {
+	return types.TYPE_BOOL
+}
+
+func (c *comparison) Evaluate(row types.DataRow) (*types.Value, error) {
+	left, right, err := evaluateOperands(c.left, c.right, row)
+	if err != nil {
+		return nil, err
+	}
+	if left.IsNull() || right.IsNull() {
+		return types.NewNullValue(), nil
+	}
+	result, err := left.Compare(right)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewBoolValue(compareResult(c.operator, result)), nil
+}
+
+func compareResult(operator string, result int) bool {
+	switch operator {
+	case "=":
+		return result == 0
+	case "!=", "<>":
+		return result != 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	default:
+		return result >= 0
+	}
+}
+
+// INT operands give an INT, the result must fit in 64 bits. An INT mixed
+// with a FLOAT is converted to FLOAT first.
+type arithmetic struct {
+	operator string
+	left     BoundExpr
+	right    BoundExpr
+	dataType types.DataType
+}
+
+func (a *arithmetic) DataType() types.DataType {
+	return a.dataType
+}
+
+func (a *arithmetic) Evaluate(row types.DataRow) (*types.Value, error) {
+	left, right, err := evaluateOperands(a.left, a.right, row)
+	if err != nil {
+		return nil, err
+	}
+	if left.IsNull() || right.IsNull() {
+		return types.NewNullValue(), nil
+	}
+
+	if left.GetDataType() == types.TYPE_INT && right.GetDataType() == types.TYPE_INT {
+		l, _ := left.Int()
+		r, _ := right.Int()
+		result, err := intArithmetic(a.operator, l, r)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewIntValue(result), nil
+	}
+	l, lok := toFloat(left)
+	r, rok := toFloat(right)
+	if !lok || !rok {
+		return nil, types.NewError(types.ERR_UNDEFINED_FUNCTION, "operator %s is not defined for %s and %s", a.operator, left.GetDataType(), right.GetDataType())
+	}
+	result, err := floatArithmetic(a.operator, l, r)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewFloatValue(result), nil
+}
+
+func intArithmetic(operator string, l int64, r int64) (int64, error) {
+	var result int64
+	switch operator {
+	case "+":
+		result = l + r
+		if (r > 0 && result < l) || (r < 0 && result > l) {
+			return 0, errIntegerOutOfRange()
+		}
+	case "-":
+		result = l - r
+		if (r > 0 && result > l) || (r < 0 && result < l) {
+			return 0, errIntegerOutOfRange()
+		}
+	case "*":
+		result = l * r
+		if l != 0 && (result/l != r || (l == -1 && r == math.MinInt64)) {
+			return 0, errIntegerOutOfRange()
+		}
+	default:
+		if r == 0 {
+			return 0, types.NewError(types.ERR_DIVISION_BY_ZERO, "division by zero")
+		}
+		if l == math.MinInt64 && r == -1 {
+			return 0, errIntegerOutOfRange()
+		}
+		result = l / r
+	}
+	return result, nil
+}
+
+func floatArithmetic(operator string, l float64, r float64) (float64, error) {
+	var result float64
+	switch operator {
+	case "+":
+		result = l + r
+	case "-":
+		result = l - r
+	case "*":
+		result = l * r
+	default:
+		if r == 0 {
+			return 0, types.NewError(types.ERR_DIVISION_BY_ZERO, "division by zero")
+		}
+		result = l / r
+	}
+	if math.IsInf(result, 0) && !math.IsInf(l, 0) && !math.IsInf(r, 0) {
+		return 0, types.NewError(types.ERR_NUMERIC_VALUE_OUT_OF_RANGE, "value out of range: overflow")
+	}
+	return result, nil
+}
+
+func toFloat(value *types.Value) (float64, bool) {
+	switch value.GetDataType() {
+	case types.TYPE_INT:
+		v, _ := value.Int()
+		return float64(v), true
+	case types.TYPE_FLOAT:
+		v, _ := value.Float()
+		return v, true
+	}
+	return 0, false
+}
+
+func errIntegerOutOfRange() error {
+	return types.NewError(types.ERR_NUMERIC_VALUE_OUT_OF_RANGE, "integer out of range")
+}
+
+// the operand that is not TEXT is converted to its text representation
+type concatenation struct {
+	left  BoundExpr
+	right BoundExpr
+}
+
+func (c *concatenation) DataType() types.DataType {
+	return types.TYPE_TEXT
+}
+
+func (c *concatenation) Evaluate(row types.DataRow) (*types.Value, error) {
+	left, right, err := evaluateOperands(c.left, c.right, row)
+	if err != nil {
+		return nil, err
+	}
+	if left.IsNull() || right.IsNull() {
+		return types.NewNullValue(), nil
+	}
+	return types.NewTextValue(left.String() + right.String()), nil
+}
+
+func evaluateOperands(left BoundExpr, right BoundExpr, row types.DataRow) (*types.Value, *types.Value, error) {
+	l, err := left.Evaluate(row)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := right.Evaluate(row)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, r, nil
+}
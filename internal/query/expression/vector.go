@@ -0,0 +1,330 @@
+package expression
+
+import (
+	"cmp"
+	"math"
+	"strings"
+
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// Expressions are evaluated over whole chunks by EvaluateVector. The vector
+// it returns is indexed like the vectors of the chunk, only the positions
+// of the rows of the chunk hold a value. The operators loop over the typed
+// slices of their operands so that values are never boxed.
+
+// EvaluateSelection returns the positions of the rows of chunk for which a
+// boolean expression is true, NULL is treated as false.
+func EvaluateSelection(expr BoundExpr, chunk *types.DataChunk) ([]int, error) {
+	result, err := expr.EvaluateVector(chunk)
+	if err != nil {
+		return nil, err
+	}
+
+	selection := make([]int, 0, chunk.Len())
+	if result.GetDataType() != types.TYPE_BOOL {
+		return selection, nil
+	}
+	values := result.Bools()
+	for _, i := range chunk.GetSelection() {
+		if !result.IsNull(i) && values[i] {
+			selection = append(selection, i)
+		}
+	}
+	return selection, nil
+}
+
+// EvaluateProjection returns the vectors of the columns a list of
+// expressions computes over the rows of chunk. They are indexed like the
+// vectors of chunk so its selection applies to them, a column reference
+// shares the vector of the chunk.
+func EvaluateProjection(exprs []BoundExpr, chunk *types.DataChunk) ([]*types.Vector, error) {
+	columns := make([]*types.Vector, len(exprs))
+	for i, expr := range exprs {
+		column, err := expr.EvaluateVector(chunk)
+		if err != nil {
+			return nil, err
+		}
+		columns[i] = column
+	}
+	return columns, nil
+}
+
+func (l *literal) EvaluateVector(chunk *types.DataChunk) (*types.Vector, error) {
+	result := types.NewNullVector(l.value.GetDataType(), chunk.VectorLen())
+	if l.value.IsNull() {
+		return result, nil
+	}
+	for _, i := range chunk.GetSelection() {
+		switch l.value.GetDataType() {
+		case types.TYPE_INT:
+			v, _ := l.value.Int()
+			result.SetInt(i, v)
+		case types.TYPE_FLOAT:
+			v, _ := l.value.Float()
+			result.SetFloat(i, v)
+		case types.TYPE_BOOL:
+			v, _ := l.value.Bool()
+			result.SetBool(i, v)
+		case types.TYPE_TEXT:
+			v, _ := l.value.Text()
+			result.SetText(i, v)
+		}
+	}
+	return result, nil
+}
+
+func (p *parameter) EvaluateVector(chunk *types.DataChunk) (*types.Vector, error) {
+	return nil, types.NewError(types.ERR_PROTOCOL_VIOLATION, "no value supplied for parameter $%d", p.index)
+}
+
+func (c *columnRef) EvaluateVector(chunk *types.DataChunk) (*types.Vector, error) {
+	return chunk.GetColumn(c.index), nil
+}
+
+func (n *isNull) EvaluateVector(chunk *types.DataChunk) (*types.Vector, error) {
+	operand, err := n.operand.EvaluateVector(chunk)
+	if err != nil {
+		return nil, err
+	}
+	result := types.NewNullVector(types.TYPE_BOOL, chunk.VectorLen())
+	for _, i := range chunk.GetSelection() {
+		result.SetBool(i, operand.IsNull(i) != n.not)
+	}
+	return result, nil
+}
+
+func (n *negation) EvaluateVector(chunk *types.DataChunk) (*types.Vector, error) {
+	operand, err := n.operand.EvaluateVector(chunk)
+	if err != nil {
+		return nil, err
+	}
+	result := types.NewNullVector(operand.GetDataType(), chunk.VectorLen())
+	switch operand.GetDataType() {
+	case types.TYPE_INT:
+		values := operand.Ints()
+		for _, i := range chunk.GetSelection() {
+			if operand.IsNull(i) {
+				continue
+			}
+			if values[i] == math.MinInt64 {
+				return nil, errIntegerOutOfRange()
+			}
+			result.SetInt(i, -values[i])
+		}
+	case types.TYPE_FLOAT:
+		values := operand.Floats()
+		for _, i := range chunk.GetSelection() {
+			if !operand.IsNull(i) {
+				result.SetFloat(i, -values[i])
+			}
+		}
+	case 0:
+	default:
+		return nil, types.NewError(types.ERR_UNDEFINED_FUNCTION, "operator - is not defined for %s", operand.GetDataType())
+	}
+	return result, nil
+}
+
+func (n *not) EvaluateVector(chunk *types.DataChunk) (*types.Vector, error) {
+	operand, err := n.operand.EvaluateVector(chunk)
+	if err != nil {
+		return nil, err
+	}
+	result := types.NewNullVector(types.TYPE_BOOL, chunk.VectorLen())
+	switch operand.GetDataType() {
+	case types.TYPE_BOOL:
+		values := operand.Bools()
+		for _, i := range chunk.GetSelection() {
+			if !operand.IsNull(i) {
+				result.SetBool(i, !values[i])
+			}
+		}
+	case 0:
+	default:
+		return nil, types.NewError(types.ERR_DATATYPE_MISMATCH, "argument of NOT must be type BOOL, not type %s", operand.GetDataType())
+	}
+	return result, nil
+}
+
+// the right operand is only evaluated for the rows the left one does not
+// decide, as when evaluating a single row.
+func (l *logical) EvaluateVector(chunk *types.DataChunk) (*types.Vector, error) {
+	dominant := l.operator == "OR"
+	left, err := l.left.EvaluateVector(chunk)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.checkOperand(left); err != nil {
+		return nil, err
+	}
+
+	result := types.NewNullVector(types.TYPE_BOOL, chunk.VectorLen())
+	undecided := make([]int, 0, chunk.Len())
+	for _, i := range chunk.GetSelection() {
+		if !left.IsNull(i) && left.Bools()[i] == dominant {
+			result.SetBool(i, dominant)
+		} else {
+			undecided = append(undecided, i)
+		}
+	}
+	if len(undecided) == 0 {
+		return result, nil
+	}
+
+	right, err := l.right.EvaluateVector(chunk.Select(undecided))
+	if err != nil {
+		return nil, err
+	}
+	if err := l.checkOperand(right); err != nil {
+		return nil, err
+	}
+	for _, i := range undecided {
+		switch {
+		case !right.IsNull(i) && right.Bools()[i] == dominant:
+			result.SetBool(i, dominant)
+		case !left.IsNull(i) && !right.IsNull(i):
+			result.SetBool(i, !dominant)
+		}
+	}
+	return result, nil
+}
+
+func (l *logical) checkOperand(operand *types.Vector) error {
+	if dataType := operand.GetDataType(); dataType != 0 && dataType != types.TYPE_BOOL {
+		return types.NewError(types.ERR_DATATYPE_MISMATCH, "operator %s expects BOOL operands", l.operator)
+	}
+	return nil
+}
+
+func (c *comparison) EvaluateVector(chunk *types.DataChunk) (*types.Vector, error) {
+	left, right, err := evaluateVectorOperands(c.left, c.right, chunk)
+	if err != nil {
+		return nil, err
+	}
+	result := types.NewNullVector(types.TYPE_BOOL, chunk.VectorLen())
+	leftType, rightType := left.GetDataType(), right.GetDataType()
+	if leftType == 0 || rightType == 0 {
+		return result, nil
+	}
+
+	var compare func(i int) int
+	switch {
+	case leftType == types.TYPE_INT && rightType == types.TYPE_INT:
+		l, r := left.Ints(), right.Ints()
+		compare = func(i int) int { return cmp.Compare(l[i], r[i]) }
+	case isNumeric(leftType) && isNumeric(rightType):
+		compare = func(i int) int { return cmp.Compare(floatAt(left, i), floatAt(right, i)) }
+	case leftType != rightType:
+		return nil, types.NewError(types.ERR_DATATYPE_MISMATCH, "cannot compare %s with %s", leftType, rightType)
+	case leftType == types.TYPE_TEXT:
+		l, r := left.Texts(), right.Texts()
+		compare = func(i int) int { return strings.Compare(l[i], r[i]) }
+	default:
+		l, r := left.Bools(), right.Bools()
+		compare = func(i int) int { return compareBools(l[i], r[i]) }
+	}
+
+	for _, i := range chunk.GetSelection() {
+		if !left.IsNull(i) && !right.IsNull(i) {
+			result.SetBool(i, compareResult(c.operator, compare(i)))
+		}
+	}
+	return result, nil
+}
+
+func (a *arithmetic) EvaluateVector(chunk *types.DataChunk) (*types.Vector, error) {
+	left, right, err := evaluateVectorOperands(a.left, a.right, chunk)
+	if err != nil {
+		return nil, err
+	}
+	leftType, rightType := left.GetDataType(), right.GetDataType()
+	if leftType == 0 || rightType == 0 {
+		return types.NewNullVector(a.dataType, chunk.VectorLen()), nil
+	}
+	if !isNumeric(leftType) || !isNumeric(rightType) {
+		return nil, types.NewError(types.ERR_UNDEFINED_FUNCTION, "operator %s is not defined for %s and %s", a.operator, leftType, rightType)
+	}
+
+	if leftType == types.TYPE_INT && rightType == types.TYPE_INT {
+		result := types.NewNullVector(types.TYPE_INT, chunk.VectorLen())
+		l, r := left.Ints(), right.Ints()
+		for _, i := range chunk.GetSelection() {
+			if left.IsNull(i) || right.IsNull(i) {
+				continue
+			}
+			value, err := intArithmetic(a.operator, l[i], r[i])
+			if err != nil {
+				return nil, err
+			}
+			result.SetInt(i, value)
+		}
+		return result, nil
+	}
+
+	result := types.NewNullVector(types.TYPE_FLOAT, chunk.VectorLen())
+	for _, i := range chunk.GetSelection() {
+		if left.IsNull(i) || right.IsNull(i) {
+			continue
+		}
+		value, err := floatArithmetic(a.operator, floatAt(left, i), floatAt(right, i))
+		if err != nil {
+			return nil, err
+		}
+		result.SetFloat(i, value)
+	}
+	return result, nil
+}
+
+func (c *concatenation) EvaluateVector(chunk *types.DataChunk) (*types.Vector, error) {
+	left, right, err := evaluateVectorOperands(c.left, c.right, chunk)
+	if err != nil {
+		return nil, err
+	}
+	result := types.NewNullVector(types.TYPE_TEXT, chunk.VectorLen())
+	for _, i := range chunk.GetSelection() {
+		if left.IsNull(i) || right.IsNull(i) {
+			continue
+		}
+		result.SetText(i, textAt(left, i)+textAt(right, i))
+	}
+	return result, nil
+}
+
+func evaluateVectorOperands(left BoundExpr, right BoundExpr, chunk *types.DataChunk) (*types.Vector, *types.Vector, error) {
+	l, err := left.EvaluateVector(chunk)
+	if err != nil {
+		return nil, nil, err
+	}
+	r, err := right.EvaluateVector(chunk)
+	if err != nil {
+		return nil, nil, err
+	}
+	return l, r, nil
+}
+
+// floatAt reads the value i of an INT or FLOAT vector as a FLOAT
+func floatAt(vector *types.Vector, i int) float64 {
+	if vector.GetDataType() == types.TYPE_INT {
+		return float64(vector.Ints()[i])
+	}
+	return vector.Floats()[i]
+}
+
+func textAt(vector *types.Vector, i int) string {
+	if vector.GetDataType() == types.TYPE_TEXT {
+		return vector.Texts()[i]
+	}
+	return vector.Get(i).String()
+}
+
+func compareBools(left bool, right bool) int {
+	switch {
+	case left == right:
+		return 0
+	case !left:
+		return -1
+	default:
+		return 1
+	}
+}
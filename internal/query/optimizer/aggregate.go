@@ -0,0 +1,68 @@
+package optimizer
+
+import (
+	"slices"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/optimizer/physical"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/query/planner/logical"
+)
+
+// buildAggregate picks the operator of an aggregate, a stream aggregate when
+// its input comes sorted on the group keys and a hash aggregate otherwise.
+func (o *Optimizer) buildAggregate(plan *logical.AggregatePlan) (PhysicalPlan, error) {
+	child, err := o.buildPhysicalPlan(plan.Child)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.GroupBy) > 0 && sortedOnKeys(plan.Child, plan.GroupBy) {
+		return physical.NewStreamAggregate(child, plan.GroupBy, plan.Aggregates, plan.GetSchema()), nil
+	}
+	return physical.NewHashAggregate(child, plan.GroupBy, plan.Aggregates, plan.GetSchema()), nil
+}
+
+// sortedOnKeys tells whether the rows with the same group keys come one
+// after the other. A table scan reads its rows in primary key order, they
+// are grouped together when the keys are the leading primary key columns
+// or include all of them. Filters keep the order of their input.
+func sortedOnKeys(plan logical.LogicalPlan, groupBy []ast.Expression) bool {
+	for {
+		filter, ok := plan.(*logical.FilterPlan)
+		if !ok {
+			break
+		}
+		plan = filter.Child
+	}
+	scan, ok := plan.(*logical.ScanPlan)
+	if !ok || scan.Schema.GetName() == catalog.INFORMATION_SCHEMA_NAME {
+		return false
+	}
+	primaryKeys := scan.Table.GetPrimaryKeyIndexes()
+	if len(primaryKeys) == 0 {
+		return false
+	}
+
+	keyColumns := []int{}
+	for _, key := range groupBy {
+		identifier, ok := key.(*ast.IdentifierExpr)
+		if !ok {
+			return false
+		}
+		idx, err := scan.GetSchema().ResolveColumn(identifier.Table, identifier.Value)
+		if err != nil {
+			return false
+		}
+		if !slices.Contains(keyColumns, idx) {
+			keyColumns = append(keyColumns, idx)
+		}
+	}
+
+	for i, idx := range primaryKeys {
+		if !slices.Contains(keyColumns, idx) {
+			// the keys must be exactly the primary key columns before this one
+			return i == len(keyColumns)
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package optimizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/optimizer/physical"
+	"github.com/evanxg852000/foxdb/internal/query/parser"
+	"github.com/evanxg852000/foxdb/internal/query/planner"
+	"github.com/evanxg852000/foxdb/internal/query/planner/logical"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+func TestAggregateOperator(t *testing.T) {
+	root := catalog.NewRootCatalog()
+	schema, err := root.AddSchema("public")
+	require.NoError(t, err)
+	for _, name := range []string{"sales", "events"} {
+		table, err := schema.AddTable(name)
+		require.NoError(t, err)
+		for _, column := range []string{"region", "day", "amount"} {
+			_, err = table.AddColumn(column, types.TYPE_INT, catalog.Constraint{})
+			require.NoError(t, err)
+		}
+	}
+	schema.GetTable("sales").SetPrimaryKeys([]string{"region", "day"})
+
+	tests := []struct {
+		sql      string
+		expected PhysicalPlan
+	}{
+		{"SELECT region, SUM(amount) FROM sales GROUP BY region;", &physical.StreamAggregate{}},
+		{"SELECT day, region, SUM(amount) FROM sales GROUP BY day, region;", &physical.StreamAggregate{}},
+		{"SELECT region, day, amount, COUNT(*) FROM sales GROUP BY amount, day, region;", &physical.StreamAggregate{}},
+		{"SELECT s.region, COUNT(*) FROM sales s WHERE amount > 10 GROUP BY s.region;", &physical.StreamAggregate{}},
+		// the rows of a group are not read one after the other
+		{"SELECT day, SUM(amount) FROM sales GROUP BY day;", &physical.HashAggregate{}},
+		{"SELECT region, amount, COUNT(*) FROM sales GROUP BY region, amount;", &physical.HashAggregate{}},
+		{"SELECT COUNT(*) FROM sales GROUP BY region + 1;", &physical.HashAggregate{}},
+		{"SELECT region, COUNT(*) FROM events GROUP BY region;", &physical.HashAggregate{}},
+		{"SELECT s.region, COUNT(*) FROM sales s JOIN events e ON s.day = e.day GROUP BY s.region;", &physical.HashAggregate{}},
+		{"SELECT COUNT(*) FROM sales;", &physical.HashAggregate{}},
+	}
+	optimizer := NewOptimizer(root, nil)
+	for _, tt := range tests {
+		program := parser.NewParser(parser.NewLexer(tt.sql)).ParseProgram()
+		require.Len(t, program.Statements, 1, tt.sql)
+		plan, err := planner.NewPlanner(root, []string{"public"}).Plan(program.Statements[0])
+		require.NoError(t, err, tt.sql)
+
+		aggregatePlan := plan.(*logical.ProjectionPlan).Child.(*logical.AggregatePlan)
+		aggregate, err := optimizer.buildAggregate(aggregatePlan)
+		require.NoError(t, err, tt.sql)
+		assert.IsType(t, tt.expected, aggregate, tt.sql)
+	}
+}
@@ -0,0 +1,143 @@
+package optimizer
+
+import (
+	"strings"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/optimizer/physical"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/query/planner/logical"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// buildJoin picks the operator of a join. The equalities between a left and
+// a right column are its keys, an index nested loop join is used when an
+// index of the right table covers them, a hash join for the other joins
+// with keys and a nested loop join for the rest.
+func (o *Optimizer) buildJoin(plan *logical.JoinPlan) (PhysicalPlan, error) {
+	left, err := o.buildPhysicalPlan(plan.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	leftSchema, rightSchema := plan.Left.GetSchema(), plan.Right.GetSchema()
+	merged := make([]physical.JoinKey, len(plan.Using))
+	for i, pair := range plan.Using {
+		merged[i] = physical.JoinKey{Left: pair.Left, Right: pair.Right}
+	}
+	condition, err := splitJoinCondition(plan.Condition, leftSchema, rightSchema)
+	if err != nil {
+		return nil, err
+	}
+	condition.Keys = append(merged, condition.Keys...)
+
+	if scan, ok := plan.Right.(*logical.ScanPlan); ok && (plan.Type == ast.JOIN_INNER || plan.Type == ast.JOIN_LEFT) &&
+		scan.Schema.GetName() != catalog.INFORMATION_SCHEMA_NAME {
+		if index, indexKeys := coveringIndex(scan.Table, condition.Keys, leftSchema, rightSchema); index != nil {
+			return physical.NewIndexNestedLoopJoin(plan.Type, left, scan.Schema, scan.Table, index, rightSchema, indexKeys, condition, merged, plan.GetSchema()), nil
+		}
+	}
+
+	right, err := o.buildPhysicalPlan(plan.Right)
+	if err != nil {
+		return nil, err
+	}
+	if len(condition.Keys) > 0 {
+		return physical.NewHashJoin(plan.Type, left, right, condition, merged, plan.GetSchema()), nil
+	}
+	return physical.NewNestedLoopJoin(plan.Type, left, right, condition, merged, plan.GetSchema()), nil
+}
+
+// splitJoinCondition takes the equalities between a left and a right column
+// out of the AND-ed terms of an ON clause, the other terms remain in the
+// predicate of the join which is bound to the columns of both sides.
+func splitJoinCondition(on ast.Expression, leftSchema *types.DataSchema, rightSchema *types.DataSchema) (physical.JoinCondition, error) {
+	condition := physical.JoinCondition{}
+	if on == nil {
+		return condition, nil
+	}
+
+	columns := append(append([]types.DataColumn{}, leftSchema.Columns...), rightSchema.Columns...)
+	schema := &types.DataSchema{Columns: columns}
+	width := len(leftSchema.Columns)
+	var predicate ast.Expression
+	for _, term := range conjuncts(on) {
+		if key, ok := joinKey(term, schema, width); ok {
+			condition.Keys = append(condition.Keys, key)
+			continue
+		}
+		if predicate == nil {
+			predicate = term
+		} else {
+			predicate = &ast.InfixExpr{Left: predicate, Operator: "AND", Right: term}
+		}
+	}
+	if predicate == nil {
+		return condition, nil
+	}
+
+	bound, err := expression.BindPredicate(predicate, schema, "JOIN/ON")
+	if err != nil {
+		return physical.JoinCondition{}, err
+	}
+	condition.Predicate = bound
+	return condition, nil
+}
+
+func conjuncts(expr ast.Expression) []ast.Expression {
+	if infix, ok := expr.(*ast.InfixExpr); ok && strings.ToUpper(infix.Operator) == "AND" {
+		return append(conjuncts(infix.Left), conjuncts(infix.Right)...)
+	}
+	return []ast.Expression{expr}
+}
+
+// joinKey recognizes `left_column = right_column`, in either order
+func joinKey(term ast.Expression, schema *types.DataSchema, width int) (physical.JoinKey, bool) {
+	infix, ok := term.(*ast.InfixExpr)
+	if !ok || infix.Operator != "=" {
+		return physical.JoinKey{}, false
+	}
+	left, lok := infix.Left.(*ast.IdentifierExpr)
+	right, rok := infix.Right.(*ast.IdentifierExpr)
+	if !lok || !rok {
+		return physical.JoinKey{}, false
+	}
+	l, err := schema.ResolveColumn(left.Table, left.Value)
+	if err != nil {
+		return physical.JoinKey{}, false
+	}
+	r, err := schema.ResolveColumn(right.Table, right.Value)
+	if err != nil {
+		return physical.JoinKey{}, false
+	}
+
+	switch {
+	case l < width && r >= width:
+		return physical.JoinKey{Left: l, Right: r - width}, true
+	case r < width && l >= width:
+		return physical.JoinKey{Left: r, Right: l - width}, true
+	}
+	return physical.JoinKey{}, false
+}
+
+// coveringIndex finds an index of the right table of a join whose columns
+// are all join keys of the same type on both sides, it returns the left
+// columns giving the value of each column of the index.
+func coveringIndex(table *catalog.Table, keys []physical.JoinKey, leftSchema *types.DataSchema, rightSchema *types.DataSchema) (*catalog.Index, []int) {
+	for _, index := range table.ListIndexes() {
+		indexKeys := []int{}
+		for _, column := range table.GetColumnIndexes(index.GetColumnIds()) {
+			for _, key := range keys {
+				if key.Right == column && leftSchema.Columns[key.Left].DataType == rightSchema.Columns[column].DataType {
+					indexKeys = append(indexKeys, key.Left)
+					break
+				}
+			}
+		}
+		if len(indexKeys) == len(index.GetColumnIds()) {
+			return index, indexKeys
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,80 @@
+package optimizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/optimizer/physical"
+	"github.com/evanxg852000/foxdb/internal/query/parser"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/query/planner"
+	"github.com/evanxg852000/foxdb/internal/query/planner/logical"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+func TestJoinOperator(t *testing.T) {
+	root := catalog.NewRootCatalog()
+	schema, err := root.AddSchema("public")
+	require.NoError(t, err)
+	for _, name := range []string{"authors", "books"} {
+		table, err := schema.AddTable(name)
+		require.NoError(t, err)
+		_, err = table.AddColumn("id", types.TYPE_INT, catalog.Constraint{})
+		require.NoError(t, err)
+		_, err = table.AddColumn("ref", types.TYPE_INT, catalog.Constraint{})
+		require.NoError(t, err)
+		_, err = table.AddColumn("price", types.TYPE_FLOAT, catalog.Constraint{})
+		require.NoError(t, err)
+	}
+	_, err = schema.GetTable("books").AddIndex("books_ref", []string{"ref", "id"}, false)
+	require.NoError(t, err)
+
+	tests := []struct {
+		sql      string
+		expected PhysicalPlan
+		// the equalities of the ON clause taken as keys
+		keys int
+	}{
+		{"SELECT * FROM authors a JOIN books b ON a.id = b.ref AND b.id = a.ref;", &physical.IndexNestedLoopJoin{}, 2},
+		{"SELECT * FROM authors a LEFT JOIN books b ON b.ref = a.id AND a.ref = b.id AND a.price > 1;", &physical.IndexNestedLoopJoin{}, 2},
+		// the index covers the keys but the unmatched right rows are needed
+		{"SELECT * FROM authors a RIGHT JOIN books b ON a.id = b.ref AND b.id = a.ref;", &physical.HashJoin{}, 2},
+		// the index is not covered, or not of the right table
+		{"SELECT * FROM authors a JOIN books b ON a.id = b.ref;", &physical.HashJoin{}, 1},
+		{"SELECT * FROM books b JOIN authors a ON a.id = b.ref AND b.id = a.ref;", &physical.HashJoin{}, 2},
+		// an INT column is not looked up in an index of FLOAT values
+		{"SELECT * FROM authors a JOIN books b ON a.price = b.ref AND b.id = a.ref;", &physical.HashJoin{}, 2},
+		{"SELECT * FROM authors JOIN books USING (id);", &physical.HashJoin{}, 0},
+		{"SELECT * FROM authors a JOIN books b ON a.id < b.ref;", &physical.NestedLoopJoin{}, 0},
+		{"SELECT * FROM authors a JOIN books b ON a.id = a.ref;", &physical.NestedLoopJoin{}, 0},
+		{"SELECT * FROM authors CROSS JOIN books;", &physical.NestedLoopJoin{}, 0},
+	}
+	optimizer := NewOptimizer(root, nil)
+	for _, tt := range tests {
+		program := parser.NewParser(parser.NewLexer(tt.sql)).ParseProgram()
+		require.Len(t, program.Statements, 1, tt.sql)
+		plan, err := planner.NewPlanner(root, []string{"public"}).Plan(program.Statements[0])
+		require.NoError(t, err, tt.sql)
+
+		joinPlan := plan.(*logical.ProjectionPlan).Child.(*logical.JoinPlan)
+		join, err := optimizer.buildJoin(joinPlan)
+		require.NoError(t, err, tt.sql)
+		assert.IsType(t, tt.expected, join, tt.sql)
+
+		condition, err := splitJoinCondition(joinPlan.Condition, joinPlan.Left.GetSchema(), joinPlan.Right.GetSchema())
+		require.NoError(t, err, tt.sql)
+		assert.Len(t, condition.Keys, tt.keys, tt.sql)
+	}
+
+	// the rest of the ON clause is bound when the join is built
+	program := parser.NewParser(parser.NewLexer("SELECT * FROM authors a JOIN books b ON a.id = b.ref;")).ParseProgram()
+	plan, err := planner.NewPlanner(root, []string{"public"}).Plan(program.Statements[0])
+	require.NoError(t, err)
+	joinPlan := plan.(*logical.ProjectionPlan).Child.(*logical.JoinPlan)
+	on := &ast.InfixExpr{Left: joinPlan.Condition, Operator: "AND", Right: &ast.IdentifierExpr{Table: "b", Value: "price"}}
+	_, err = optimizer.buildJoin(logical.NewJoinPlan(joinPlan.Type, joinPlan.Left, joinPlan.Right, on, nil))
+	assert.Equal(t, types.ERR_DATATYPE_MISMATCH, types.GetErrorCode(err))
+}
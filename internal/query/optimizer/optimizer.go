@@ -1,20 +1,15 @@
 package optimizer
 
 import (
-	"context"
+	"fmt"
 
 	"github.com/evanxg852000/foxdb/internal/catalog"
 	"github.com/evanxg852000/foxdb/internal/query/optimizer/physical"
 	"github.com/evanxg852000/foxdb/internal/query/planner"
 	"github.com/evanxg852000/foxdb/internal/query/planner/logical"
-	"github.com/evanxg852000/foxdb/internal/storage"
-	"github.com/evanxg852000/foxdb/internal/types"
 )
 
-type PhysicalPlan interface {
-	Execute(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) (*types.DataChunk, error)
-	GetSchema() *types.DataSchema
-}
+type PhysicalPlan = physical.PhysicalPlan
 
 type Optimizer struct {
 	catalog *catalog.RootCatalog
@@ -31,12 +26,73 @@ func NewOptimizer(catalog *catalog.RootCatalog, stats map[string]interface{}) *O
 func (o *Optimizer) Optimize(logicalPlan planner.LogicalPlan) (PhysicalPlan, error) {
 	//handle utility statements
 	switch plan := logicalPlan.(type) {
-	case *logical.CreateSchemaPlan:
+	case *logical.CreateSchemaPlan, *logical.CreateTablePlan, *logical.DropTablePlan,
+		*logical.AlterTablePlan, *logical.CreateIndexPlan, *logical.DropIndexPlan,
+		*logical.CreateUserPlan, *logical.DropUserPlan, *logical.AlterUserPlan:
 		return physical.NewUtilityPlan(plan), nil
 	}
 
 	//TODO: implement a full optimization process
-	schema := o.catalog.GetSchema("todo")
-	table := schema.GetTable("todo")
-	return physical.NewScan(table), nil
+	return o.buildPhysicalPlan(logicalPlan)
+}
+
+// maps every logical operator to its physical counterpart
+func (o *Optimizer) buildPhysicalPlan(logicalPlan planner.LogicalPlan) (PhysicalPlan, error) {
+	switch plan := logicalPlan.(type) {
+	case *logical.ScanPlan:
+		if plan.Schema.GetName() == catalog.INFORMATION_SCHEMA_NAME {
+			return physical.NewSystemScan(plan.Table, plan.GetSchema()), nil
+		}
+		return physical.NewScan(plan.Schema, plan.Table, plan.GetSchema()), nil
+
+	case *logical.JoinPlan:
+		return o.buildJoin(plan)
+
+	case *logical.AggregatePlan:
+		return o.buildAggregate(plan)
+
+	case *logical.FilterPlan:
+		child, err := o.buildPhysicalPlan(plan.Child)
+		if err != nil {
+			return nil, err
+		}
+		return physical.NewFilter(child, plan.Predicate), nil
+
+	case *logical.SortPlan:
+		child, err := o.buildPhysicalPlan(plan.Child)
+		if err != nil {
+			return nil, err
+		}
+		return physical.NewSort(child, plan.OrderBy), nil
+
+	case *logical.LimitPlan:
+		child, err := o.buildPhysicalPlan(plan.Child)
+		if err != nil {
+			return nil, err
+		}
+		return physical.NewLimit(child, plan.Limit, plan.Offset), nil
+
+	case *logical.InsertPlan:
+		return physical.NewInsert(plan.Schema, plan.Table, plan.ColumnIndexes, plan.Values), nil
+
+	case *logical.UpdatePlan:
+		assignments := make([]physical.Assignment, len(plan.Assignments))
+		for i, assignment := range plan.Assignments {
+			assignments[i] = physical.Assignment{ColumnIndex: assignment.ColumnIndex, Value: assignment.Value}
+		}
+		return physical.NewUpdate(plan.Schema, plan.Table, assignments, plan.Predicate), nil
+
+	case *logical.DeletePlan:
+		return physical.NewDelete(plan.Schema, plan.Table, plan.Predicate), nil
+
+	case *logical.ProjectionPlan:
+		child, err := o.buildPhysicalPlan(plan.Child)
+		if err != nil {
+			return nil, err
+		}
+		return physical.NewProjection(child, plan.Exprs, plan.GetSchema()), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported logical plan: %T", logicalPlan)
+	}
 }
@@ -0,0 +1,261 @@
+package physical
+
+import (
+	"context"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// aggregation is the logic shared by the aggregate operators, they differ
+// by how they find the group of a row. The rows of the operators are the
+// group keys followed by the results of the aggregates.
+type aggregation struct {
+	child      PhysicalPlan
+	groupBy    []ast.Expression
+	aggregates []*ast.CallExpr
+	schema     *types.DataSchema
+
+	// bound when the plan is opened
+	keys  []expression.BoundExpr
+	calls []*expression.Aggregate
+	// rows of the operator not produced yet
+	pending []types.DataRow
+	done    bool
+}
+
+// group holds the key values and the aggregate states of a group
+type group struct {
+	keys   []types.Value
+	states []expression.AggregateState
+}
+
+func newAggregation(child PhysicalPlan, groupBy []ast.Expression, aggregates []*ast.CallExpr, schema *types.DataSchema) aggregation {
+	return aggregation{
+		child:      child,
+		groupBy:    groupBy,
+		aggregates: aggregates,
+		schema:     schema,
+	}
+}
+
+func (a *aggregation) open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	input := a.child.GetSchema()
+	a.keys = make([]expression.BoundExpr, len(a.groupBy))
+	for i, key := range a.groupBy {
+		var err error
+		if a.keys[i], err = expression.Bind(key, input); err != nil {
+			return err
+		}
+	}
+	a.calls = make([]*expression.Aggregate, len(a.aggregates))
+	for i, call := range a.aggregates {
+		var err error
+		if a.calls[i], err = expression.BindAggregate(call, input); err != nil {
+			return err
+		}
+	}
+	a.pending, a.done = nil, false
+	return a.child.Open(ctx, catalog, storage)
+}
+
+func (a *aggregation) newGroup(keys []types.Value) *group {
+	g := &group{keys: keys, states: make([]expression.AggregateState, len(a.calls))}
+	for i, call := range a.calls {
+		g.states[i] = call.NewState()
+	}
+	return g
+}
+
+// accumulate adds the rows of a chunk to their group, groupOf returns the
+// group of the rows with the given encoded key.
+func (a *aggregation) accumulate(chunk *types.DataChunk, groupOf func(key string, values []types.Value) *group) error {
+	keyVectors := make([]*types.Vector, len(a.keys))
+	for i, key := range a.keys {
+		var err error
+		if keyVectors[i], err = key.EvaluateVector(chunk); err != nil {
+			return err
+		}
+	}
+	argVectors := make([][]*types.Vector, len(a.calls))
+	for i, call := range a.calls {
+		var err error
+		if argVectors[i], err = call.EvaluateArgs(chunk); err != nil {
+			return err
+		}
+	}
+
+	for _, pos := range chunk.GetSelection() {
+		values := make([]types.Value, len(keyVectors))
+		for i, vector := range keyVectors {
+			values[i] = vector.Get(pos)
+		}
+		key, err := types.EncodeKey(values)
+		if err != nil {
+			return err
+		}
+		g := groupOf(string(key), values)
+		for i, vectors := range argVectors {
+			args := make([]types.Value, len(vectors))
+			for j, vector := range vectors {
+				args[j] = vector.Get(pos)
+			}
+			if err := g.states[i].Add(args); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *group) row() types.DataRow {
+	values := make([]types.Value, 0, len(g.keys)+len(g.states))
+	values = append(values, g.keys...)
+	for _, state := range g.states {
+		values = append(values, state.Result())
+	}
+	return types.DataRow{Values: values}
+}
+
+// next hands out a chunk of the pending rows, nil once there are none
+func (a *aggregation) next() *types.DataChunk {
+	if len(a.pending) == 0 {
+		return nil
+	}
+	n := min(len(a.pending), CHUNK_SIZE)
+	chunk := types.NewWith(a.schema, a.pending[:n])
+	a.pending = a.pending[n:]
+	return chunk
+}
+
+func (a *aggregation) Close() {
+	a.pending = nil
+	a.child.Close()
+}
+
+func (a *aggregation) GetSchema() *types.DataSchema {
+	return a.schema
+}
+
+// HashAggregate groups rows in a hash table, it reads its whole input
+// before producing the groups in the order they were first seen.
+type HashAggregate struct {
+	aggregation
+}
+
+func NewHashAggregate(child PhysicalPlan, groupBy []ast.Expression, aggregates []*ast.CallExpr, schema *types.DataSchema) *HashAggregate {
+	return &HashAggregate{aggregation: newAggregation(child, groupBy, aggregates, schema)}
+}
+
+func (h *HashAggregate) Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	return h.open(ctx, catalog, storage)
+}
+
+func (h *HashAggregate) Next(ctx context.Context) (*types.DataChunk, error) {
+	if !h.done {
+		if err := h.aggregate(ctx); err != nil {
+			return nil, err
+		}
+		h.done = true
+	}
+	return h.next(), nil
+}
+
+func (h *HashAggregate) aggregate(ctx context.Context) error {
+	positions := map[string]int{}
+	groups := []*group{}
+	groupOf := func(key string, values []types.Value) *group {
+		pos, ok := positions[key]
+		if !ok {
+			pos = len(groups)
+			positions[key] = pos
+			groups = append(groups, h.newGroup(values))
+		}
+		return groups[pos]
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		input, err := h.child.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if input == nil {
+			break
+		}
+		if err := h.accumulate(input, groupOf); err != nil {
+			return err
+		}
+	}
+
+	// without GROUP BY all the rows form one group, even when there are none
+	if len(groups) == 0 && len(h.groupBy) == 0 {
+		groups = append(groups, h.newGroup(nil))
+	}
+	h.pending = make([]types.DataRow, len(groups))
+	for i, g := range groups {
+		h.pending[i] = g.row()
+	}
+	return nil
+}
+
+// StreamAggregate groups rows that come sorted on the group keys, a group
+// is complete as soon as a row with another key is read so only one group
+// is held at a time.
+type StreamAggregate struct {
+	aggregation
+	current    *group
+	currentKey string
+}
+
+func NewStreamAggregate(child PhysicalPlan, groupBy []ast.Expression, aggregates []*ast.CallExpr, schema *types.DataSchema) *StreamAggregate {
+	return &StreamAggregate{aggregation: newAggregation(child, groupBy, aggregates, schema)}
+}
+
+func (s *StreamAggregate) Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	s.current, s.currentKey = nil, ""
+	return s.open(ctx, catalog, storage)
+}
+
+// Next reads the input until a chunk of complete groups is ready
+func (s *StreamAggregate) Next(ctx context.Context) (*types.DataChunk, error) {
+	groupOf := func(key string, values []types.Value) *group {
+		if s.current == nil || key != s.currentKey {
+			if s.current != nil {
+				s.pending = append(s.pending, s.current.row())
+			}
+			s.current, s.currentKey = s.newGroup(values), key
+		}
+		return s.current
+	}
+
+	for len(s.pending) < CHUNK_SIZE && !s.done {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		input, err := s.child.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if input == nil {
+			s.done = true
+			if s.current == nil && len(s.groupBy) == 0 {
+				s.current = s.newGroup(nil)
+			}
+			if s.current != nil {
+				s.pending = append(s.pending, s.current.row())
+				s.current = nil
+			}
+			break
+		}
+		if err := s.accumulate(input, groupOf); err != nil {
+			return nil, err
+		}
+	}
+	return s.next(), nil
+}
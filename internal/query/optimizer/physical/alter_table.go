@@ -0,0 +1,254 @@
+package physical
+
+import (
+	"slices"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/query/planner/logical"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// An ALTER TABLE action changes the catalog in memory and returns the work
+// to do in the transaction persisting it, either a validation of the
+// existing records or the removal of stale data, along with the function
+// undoing the catalog change when that transaction fails.
+type alterAction func(schema *catalog.Schema, table *catalog.Table, plan *logical.AlterTablePlan) (func(txn *kvTxn) error, func(), error)
+
+var alterActions = map[ast.AlterTableAction]alterAction{
+	ast.ALTER_ADD_COLUMN:    addColumn,
+	ast.ALTER_DROP_COLUMN:   dropColumn,
+	ast.ALTER_RENAME_COLUMN: renameColumn,
+	ast.ALTER_RENAME_TABLE:  renameTable,
+	ast.ALTER_SET_NOT_NULL:  setNotNull,
+	ast.ALTER_DROP_NOT_NULL: dropNotNull,
+	ast.ALTER_SET_DEFAULT:   setDefault,
+	ast.ALTER_DROP_DEFAULT:  setDefault,
+}
+
+func alterTable(rootCatalog *catalog.RootCatalog, storage *storage.KvStorage, plan *logical.AlterTablePlan) (*types.DataChunk, error) {
+	rootCatalog.Lock()
+	defer rootCatalog.Unlock()
+	schema := rootCatalog.GetSchema(plan.SchemaName)
+	if schema == nil {
+		return nil, types.NewError(types.ERR_INVALID_SCHEMA_NAME, "schema %s does not exist", plan.SchemaName)
+	}
+	if schema.GetName() == catalog.INFORMATION_SCHEMA_NAME {
+		return nil, types.NewError(types.ERR_INSUFFICIENT_PRIVILEGE, "cannot alter system table %s", plan.TableName)
+	}
+
+	table := schema.GetTable(plan.TableName)
+	if table == nil {
+		return nil, types.NewError(types.ERR_UNDEFINED_TABLE, "table %s.%s does not exist", plan.SchemaName, plan.TableName)
+	}
+
+	action, ok := alterActions[plan.Action]
+	if !ok {
+		return nil, types.NewError(types.ERR_FEATURE_NOT_SUPPORTED, "unsupported ALTER TABLE action")
+	}
+	work, undo, err := action(schema, table, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	err = storage.Batch(func(txn *kvTxn) error {
+		if err := changeTable(txn, schema, table); err != nil {
+			return err
+		}
+		if work != nil {
+			if err := work(txn); err != nil {
+				return err
+			}
+		}
+		return storeCatalog(txn, rootCatalog)
+	})
+	if err != nil {
+		undo()
+		return nil, err
+	}
+	return nil, nil
+}
+
+// Existing records are not rewritten, they decode the new column with its
+// missing value which is the default at the time the column is added.
+func addColumn(schema *catalog.Schema, table *catalog.Table, plan *logical.AlterTablePlan) (func(txn *kvTxn) error, func(), error) {
+	column, err := table.AddColumn(plan.ColumnName, plan.Column.GetDataType(), plan.Column.GetConstraints())
+	if err != nil {
+		return nil, nil, err
+	}
+	undo := func() { table.RemoveColumn(plan.ColumnName) }
+
+	value, err := evaluateDefault(plan.Default, column)
+	if err != nil {
+		undo()
+		return nil, nil, err
+	}
+	column.SetDefault(value)
+	column.SetMissingValue(value)
+
+	constraints := column.GetConstraints()
+	checkNotNull := constraints.NotNull && value.IsNull()
+	checkUnique := constraints.Unique && !value.IsNull()
+	if !checkNotNull && !checkUnique {
+		return nil, undo, nil
+	}
+
+	// every existing record gets the same value for the new column, its
+	// unique entry is written for the first one and rejects the others
+	writer := newTableWriter(schema, table)
+	idx := writer.dataSchema.GetColumnIndex(column.GetName())
+	validate := func(txn *kvTxn) error {
+		return writer.scan(txn, func(key []byte, record *types.Record) error {
+			if checkNotNull {
+				return types.NewError(types.ERR_NOT_NULL_VIOLATION, "column %s of table %s contains null values", column.GetName(), table.GetName())
+			}
+			return writer.addIndexEntry(txn, newUniqueColumnWriter(column, idx), record, writer.primaryKeyOf(key))
+		})
+	}
+	return validate, undo, nil
+}
+
+// Values of the dropped column are left in the records and skipped when
+// decoding them, the indexes covering the column and its unique entries
+// are dropped with it.
+func dropColumn(schema *catalog.Schema, table *catalog.Table, plan *logical.AlterTablePlan) (func(txn *kvTxn) error, func(), error) {
+	column := table.GetColumn(plan.ColumnName)
+	if column == nil {
+		return nil, nil, types.NewError(types.ERR_UNDEFINED_COLUMN, "column %s of table %s does not exist", plan.ColumnName, table.GetName())
+	}
+	if slices.Contains(table.GetPrimaryKeys(), column.GetId()) {
+		return nil, nil, types.NewError(types.ERR_INVALID_DEFINITION, "cannot drop primary key column %s", column.GetName())
+	}
+	if len(table.ListColumns()) == 1 {
+		return nil, nil, types.NewError(types.ERR_INVALID_DEFINITION, "cannot drop the last column of table %s", table.GetName())
+	}
+
+	droppedIndexes := []*catalog.Index{}
+	for _, index := range table.ListIndexes() {
+		if slices.Contains(index.GetColumnIds(), column.GetId()) {
+			table.RemoveIndex(index.GetName())
+			droppedIndexes = append(droppedIndexes, index)
+		}
+	}
+	table.RemoveColumn(column.GetName())
+
+	undo := func() {
+		table.RestoreColumn(column)
+		for _, index := range droppedIndexes {
+			table.RestoreIndex(index)
+		}
+	}
+	deleteEntries := func(txn *kvTxn) error {
+		if column.GetConstraints().Unique {
+			prefix := types.IndexKeyPrefix(uint32(schema.GetId()), uint32(table.GetId()), uint32(column.GetId()))
+			if err := deletePrefix(txn, prefix); err != nil {
+				return err
+			}
+		}
+		for _, index := range droppedIndexes {
+			prefix := types.IndexKeyPrefix(uint32(schema.GetId()), uint32(table.GetId()), uint32(index.GetId()))
+			if err := deletePrefix(txn, prefix); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return deleteEntries, undo, nil
+}
+
+func renameColumn(schema *catalog.Schema, table *catalog.Table, plan *logical.AlterTablePlan) (func(txn *kvTxn) error, func(), error) {
+	if err := table.RenameColumn(plan.ColumnName, plan.NewName); err != nil {
+		return nil, nil, err
+	}
+	return nil, func() { table.RenameColumn(plan.NewName, plan.ColumnName) }, nil
+}
+
+func renameTable(schema *catalog.Schema, table *catalog.Table, plan *logical.AlterTablePlan) (func(txn *kvTxn) error, func(), error) {
+	if err := schema.RenameTable(plan.TableName, plan.NewName); err != nil {
+		return nil, nil, err
+	}
+	return nil, func() { schema.RenameTable(plan.NewName, plan.TableName) }, nil
+}
+
+func setNotNull(schema *catalog.Schema, table *catalog.Table, plan *logical.AlterTablePlan) (func(txn *kvTxn) error, func(), error) {
+	column := table.GetColumn(plan.ColumnName)
+	if column == nil {
+		return nil, nil, types.NewError(types.ERR_UNDEFINED_COLUMN, "column %s of table %s does not exist", plan.ColumnName, table.GetName())
+	}
+
+	writer := newTableWriter(schema, table)
+	idx := writer.dataSchema.GetColumnIndex(column.GetName())
+	constraints := column.GetConstraints()
+	constraints.NotNull = true
+	undo := updateConstraints(column, constraints)
+
+	validate := func(txn *kvTxn) error {
+		return writer.scan(txn, func(key []byte, record *types.Record) error {
+			if value, _ := record.GetValue(uint(idx)); value == nil {
+				return types.NewError(types.ERR_NOT_NULL_VIOLATION, "column %s of table %s contains null values", column.GetName(), table.GetName())
+			}
+			return nil
+		})
+	}
+	return validate, undo, nil
+}
+
+func dropNotNull(schema *catalog.Schema, table *catalog.Table, plan *logical.AlterTablePlan) (func(txn *kvTxn) error, func(), error) {
+	column := table.GetColumn(plan.ColumnName)
+	if column == nil {
+		return nil, nil, types.NewError(types.ERR_UNDEFINED_COLUMN, "column %s of table %s does not exist", plan.ColumnName, table.GetName())
+	}
+	if slices.Contains(table.GetPrimaryKeys(), column.GetId()) {
+		return nil, nil, types.NewError(types.ERR_INVALID_DEFINITION, "column %s is in a primary key", column.GetName())
+	}
+
+	constraints := column.GetConstraints()
+	constraints.NotNull = false
+	return nil, updateConstraints(column, constraints), nil
+}
+
+// only affects the records inserted from now on
+func setDefault(schema *catalog.Schema, table *catalog.Table, plan *logical.AlterTablePlan) (func(txn *kvTxn) error, func(), error) {
+	column := table.GetColumn(plan.ColumnName)
+	if column == nil {
+		return nil, nil, types.NewError(types.ERR_UNDEFINED_COLUMN, "column %s of table %s does not exist", plan.ColumnName, table.GetName())
+	}
+
+	value, err := evaluateDefault(plan.Default, column)
+	if err != nil {
+		return nil, nil, err
+	}
+	previous := column.GetDefault()
+	column.SetDefault(value)
+	return nil, func() { column.SetDefault(previous) }, nil
+}
+
+// sets the constraints of a column and returns the function restoring them
+func updateConstraints(column *catalog.Column, constraints catalog.Constraint) func() {
+	previous := column.GetConstraints()
+	column.SetConstraints(constraints)
+	return func() { column.SetConstraints(previous) }
+}
+
+// evaluates a constant default expression, a nil expression stands for NULL
+func evaluateDefault(expr ast.Expression, column *catalog.Column) (types.Value, error) {
+	if expr == nil {
+		return types.Value{}, nil
+	}
+
+	value, err := expression.Evaluate(expr, &types.DataSchema{}, types.DataRow{})
+	if err != nil {
+		return types.Value{}, err
+	}
+	if value.IsNull() {
+		return types.Value{}, nil
+	}
+
+	value, err = coerceValue(value, types.DataColumn{Name: column.GetName(), DataType: column.GetDataType()})
+	if err != nil {
+		return types.Value{}, err
+	}
+	return *value, nil
+}
@@ -0,0 +1,85 @@
+package physical
+
+import (
+	"context"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// Removes the records matching the predicate in a single transaction.
+type Delete struct {
+	tableWriter
+	singleResult
+	predicate ast.Expression
+}
+
+func NewDelete(schema *catalog.Schema, table *catalog.Table, predicate ast.Expression) *Delete {
+	return &Delete{
+		tableWriter: newTableWriter(schema, table),
+		predicate:   predicate,
+	}
+}
+
+func (d *Delete) Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	result, err := d.execute(ctx, storage)
+	d.result = result
+	return err
+}
+
+func (d *Delete) execute(ctx context.Context, storage *storage.KvStorage) (*types.DataChunk, error) {
+	predicate, err := bindPredicate(d.predicate, d.dataSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	count := 0
+	err = storage.Batch(func(txn *kvTxn) error {
+		d.guardVersion(txn)
+		type deletion struct {
+			key    []byte
+			record *types.Record
+		}
+
+		deletions := []deletion{}
+		err := d.scan(txn, func(key []byte, record *types.Record) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if predicate != nil {
+				match, err := expression.EvaluatePredicate(predicate, record.ToRow())
+				if err != nil || !match {
+					return err
+				}
+			}
+			deletions = append(deletions, deletion{key: key, record: record})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, deletion := range deletions {
+			if err := txn.Delete(deletion.key); err != nil {
+				return err
+			}
+			if err := d.removeIndexEntries(txn, deletion.record, d.primaryKeyOf(deletion.key)); err != nil {
+				return err
+			}
+		}
+		count = len(deletions)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return types.NewCountChunk(count), nil
+}
+
+func (d *Delete) GetSchema() *types.DataSchema {
+	return types.COUNT_SCHEMA
+}
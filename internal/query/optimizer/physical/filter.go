@@ -0,0 +1,68 @@
+package physical
+
+import (
+	"context"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+type Filter struct {
+	child     PhysicalPlan
+	predicate ast.Expression
+	bound     expression.BoundExpr
+}
+
+func NewFilter(child PhysicalPlan, predicate ast.Expression) *Filter {
+	return &Filter{
+		child:     child,
+		predicate: predicate,
+	}
+}
+
+func (f *Filter) Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	predicate, err := bindPredicate(f.predicate, f.GetSchema())
+	if err != nil {
+		return err
+	}
+	f.bound = predicate
+	return f.child.Open(ctx, catalog, storage)
+}
+
+// Next pulls chunks from the child until one of them has matching rows
+func (f *Filter) Next(ctx context.Context) (*types.DataChunk, error) {
+	for {
+		input, err := f.child.Next(ctx)
+		if err != nil || input == nil {
+			return nil, err
+		}
+
+		// the matching rows are selected, the vectors are not copied
+		selection, err := expression.EvaluateSelection(f.bound, input)
+		if err != nil {
+			return nil, err
+		}
+		if len(selection) > 0 {
+			return input.Select(selection), nil
+		}
+	}
+}
+
+func (f *Filter) Close() {
+	f.child.Close()
+}
+
+func (f *Filter) GetSchema() *types.DataSchema {
+	return f.child.GetSchema()
+}
+
+// bindPredicate binds the WHERE clause of a plan, nil when it has none
+func bindPredicate(predicate ast.Expression, schema *types.DataSchema) (expression.BoundExpr, error) {
+	if predicate == nil {
+		return nil, nil
+	}
+	return expression.BindPredicate(predicate, schema, "WHERE")
+}
@@ -0,0 +1,119 @@
+package physical
+
+import (
+	"context"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// Writes all the rows of an INSERT statement in a single transaction,
+// either every row is inserted or none is.
+type Insert struct {
+	tableWriter
+	singleResult
+	columnIndexes []int
+	values        [][]ast.Expression
+}
+
+func NewInsert(schema *catalog.Schema, table *catalog.Table, columnIndexes []int, values [][]ast.Expression) *Insert {
+	return &Insert{
+		tableWriter:   newTableWriter(schema, table),
+		columnIndexes: columnIndexes,
+		values:        values,
+	}
+}
+
+func (i *Insert) Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	result, err := i.execute(ctx, storage)
+	i.result = result
+	return err
+}
+
+func (i *Insert) execute(ctx context.Context, storage *storage.KvStorage) (*types.DataChunk, error) {
+	err := storage.Batch(func(txn *kvTxn) error {
+		i.guardVersion(txn)
+		for _, row := range i.values {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			record, err := i.buildRecord(row)
+			if err != nil {
+				return err
+			}
+
+			key, err := i.recordKey(record)
+			if err != nil {
+				return err
+			}
+
+			if len(i.primaryKeys) > 0 {
+				if err := checkPrimaryKeyAbsent(txn, key, i.table); err != nil {
+					return err
+				}
+			}
+
+			data, err := record.Encode()
+			if err != nil {
+				return err
+			}
+			if err := txn.Set(key, data); err != nil {
+				return err
+			}
+
+			if err := i.addIndexEntries(txn, record, i.primaryKeyOf(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return types.NewCountChunk(len(i.values)), nil
+}
+
+func (i *Insert) GetSchema() *types.DataSchema {
+	return types.COUNT_SCHEMA
+}
+
+// omitted columns take their default value
+func (i *Insert) buildRecord(row []ast.Expression) (*types.Record, error) {
+	record := types.NewRecord(i.dataSchema)
+	for idx, value := range i.defaults {
+		if err := record.SetValue(uint(idx), value); err != nil {
+			return nil, err
+		}
+	}
+
+	noColumns := &types.DataSchema{}
+	for pos, expr := range row {
+		idx := i.columnIndexes[pos]
+
+		value, err := expression.Evaluate(expr, noColumns, types.DataRow{})
+		if err != nil {
+			return nil, err
+		}
+		if value.IsNull() {
+			record.ClearValue(uint(idx))
+			continue
+		}
+
+		value, err = coerceValue(value, i.dataSchema.Columns[idx])
+		if err != nil {
+			return nil, err
+		}
+		if err := record.SetValue(uint(idx), *value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := i.checkNotNull(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
@@ -0,0 +1,465 @@
+package physical
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// JoinKey pairs a column of the left input of a join with a column of its
+// right input, rows match when their values are equal and not NULL.
+type JoinKey struct {
+	Left  int
+	Right int
+}
+
+// JoinCondition is what rows of both inputs of a join must meet to match:
+// the equality of their keys and a predicate bound to the left columns
+// followed by the right ones, nil when there is none.
+type JoinCondition struct {
+	Keys      []JoinKey
+	Predicate expression.BoundExpr
+}
+
+// join is the logic shared by the join operators, they differ by how they
+// find the right rows that may match a left row. The left input is read a
+// chunk at a time, the right rows are held in inner.
+type join struct {
+	joinType  ast.JoinType
+	left      PhysicalPlan
+	condition JoinCondition
+	// the USING columns, their merged value starts the rows of the join
+	merged      []JoinKey
+	rightSchema *types.DataSchema
+	schema      *types.DataSchema
+	// lookup returns the positions in inner of the candidates for a left row
+	lookup func(ctx context.Context, row types.DataRow) ([]int, error)
+
+	inner []types.DataRow
+	// the inner rows that matched, kept for RIGHT and FULL joins
+	matched  []bool
+	input    *types.DataChunk
+	position int
+	done     bool
+	// rows of the join not produced yet
+	pending []types.DataRow
+}
+
+func newJoin(joinType ast.JoinType, left PhysicalPlan, rightSchema *types.DataSchema, condition JoinCondition, merged []JoinKey, schema *types.DataSchema) join {
+	return join{
+		joinType:    joinType,
+		left:        left,
+		condition:   condition,
+		merged:      merged,
+		rightSchema: rightSchema,
+		schema:      schema,
+	}
+}
+
+func (j *join) open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	j.inner, j.matched, j.pending = nil, nil, nil
+	j.input, j.position, j.done = nil, 0, false
+	return j.left.Open(ctx, catalog, storage)
+}
+
+// keepsUnmatched tells whether the rows of a side without match are produced
+func (j *join) keepsUnmatched(left bool) bool {
+	if left {
+		return j.joinType == ast.JOIN_LEFT || j.joinType == ast.JOIN_FULL
+	}
+	return j.joinType == ast.JOIN_RIGHT || j.joinType == ast.JOIN_FULL
+}
+
+// Next probes the left rows until a chunk of joined rows is ready, the
+// unmatched right rows of RIGHT and FULL joins come once the left input
+// is exhausted.
+func (j *join) Next(ctx context.Context) (*types.DataChunk, error) {
+	for len(j.pending) < CHUNK_SIZE && !j.done {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if j.input == nil || j.position == j.input.Len() {
+			input, err := j.left.Next(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if input == nil {
+				j.done = true
+				j.addUnmatchedInner()
+				break
+			}
+			j.input, j.position = input, 0
+			continue
+		}
+
+		row := j.input.GetRow(j.position)
+		j.position++
+		if err := j.probe(ctx, row); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(j.pending) == 0 {
+		return nil, nil
+	}
+	n := min(len(j.pending), CHUNK_SIZE)
+	chunk := types.NewWith(j.schema, j.pending[:n])
+	j.pending = j.pending[n:]
+	return chunk, nil
+}
+
+func (j *join) probe(ctx context.Context, row types.DataRow) error {
+	candidates, err := j.lookup(ctx, row)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, pos := range candidates {
+		match, err := j.matches(row, j.inner[pos])
+		if err != nil {
+			return err
+		}
+		if !match {
+			continue
+		}
+		found = true
+		if j.matched != nil {
+			j.matched[pos] = true
+		}
+		j.pending = append(j.pending, j.joinRows(row, j.inner[pos]))
+	}
+
+	if !found && j.keepsUnmatched(true) {
+		j.pending = append(j.pending, j.joinRows(row, nullRow(len(j.rightSchema.Columns))))
+	}
+	return nil
+}
+
+func (j *join) addUnmatchedInner() {
+	if !j.keepsUnmatched(false) {
+		return
+	}
+	leftNulls := nullRow(len(j.left.GetSchema().Columns))
+	for pos, row := range j.inner {
+		if !j.matched[pos] {
+			j.pending = append(j.pending, j.joinRows(leftNulls, row))
+		}
+	}
+}
+
+func (j *join) matches(left types.DataRow, right types.DataRow) (bool, error) {
+	for _, key := range j.condition.Keys {
+		l, r := left.Values[key.Left], right.Values[key.Right]
+		if l.IsNull() || r.IsNull() {
+			return false, nil
+		}
+		result, err := l.Compare(&r)
+		if err != nil || result != 0 {
+			return false, err
+		}
+	}
+
+	if j.condition.Predicate == nil {
+		return true, nil
+	}
+	values := make([]types.Value, 0, len(left.Values)+len(right.Values))
+	values = append(append(values, left.Values...), right.Values...)
+	return expression.EvaluatePredicate(j.condition.Predicate, types.DataRow{Values: values})
+}
+
+// joinRows builds a row of the join, the merged value of a USING column is
+// the one of the left row unless it is NULL.
+func (j *join) joinRows(left types.DataRow, right types.DataRow) types.DataRow {
+	values := make([]types.Value, 0, len(j.schema.Columns))
+	for _, key := range j.merged {
+		value := left.Values[key.Left]
+		if value.IsNull() {
+			value = right.Values[key.Right]
+		}
+		values = append(values, value)
+	}
+	values = append(values, left.Values...)
+	values = append(values, right.Values...)
+	return types.DataRow{Values: values}
+}
+
+// loadInner reads all the rows of the right input of a join
+func (j *join) loadInner(ctx context.Context, right PhysicalPlan) error {
+	j.inner = nil
+	for {
+		chunk, err := right.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if chunk == nil {
+			break
+		}
+		j.inner = append(j.inner, chunk.GetRows()...)
+	}
+	if j.keepsUnmatched(false) {
+		j.matched = make([]bool, len(j.inner))
+	}
+	return nil
+}
+
+func (j *join) close() {
+	j.inner, j.matched, j.pending, j.input = nil, nil, nil, nil
+	j.left.Close()
+}
+
+func (j *join) GetSchema() *types.DataSchema {
+	return j.schema
+}
+
+func nullRow(length int) types.DataRow {
+	return types.DataRow{Values: make([]types.Value, length)}
+}
+
+// NestedLoopJoin compares each left row with all the right rows, it joins
+// on any condition.
+type NestedLoopJoin struct {
+	join
+	right PhysicalPlan
+	// the positions of all the right rows
+	all []int
+}
+
+func NewNestedLoopJoin(joinType ast.JoinType, left PhysicalPlan, right PhysicalPlan, condition JoinCondition, merged []JoinKey, schema *types.DataSchema) *NestedLoopJoin {
+	j := &NestedLoopJoin{
+		join:  newJoin(joinType, left, right.GetSchema(), condition, merged, schema),
+		right: right,
+	}
+	j.lookup = func(ctx context.Context, row types.DataRow) ([]int, error) {
+		return j.all, nil
+	}
+	return j
+}
+
+func (j *NestedLoopJoin) Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	if err := j.open(ctx, catalog, storage); err != nil {
+		return err
+	}
+	if err := j.right.Open(ctx, catalog, storage); err != nil {
+		return err
+	}
+	if err := j.loadInner(ctx, j.right); err != nil {
+		return err
+	}
+	j.all = make([]int, len(j.inner))
+	for i := range j.all {
+		j.all[i] = i
+	}
+	return nil
+}
+
+func (j *NestedLoopJoin) Close() {
+	j.all = nil
+	j.close()
+	j.right.Close()
+}
+
+// HashJoin joins on the equality of keys, the right rows are put in a hash
+// table by key value which each left row then looks its key up in.
+type HashJoin struct {
+	join
+	right PhysicalPlan
+	// keys mixing INT and FLOAT columns are hashed as FLOAT
+	floatKeys []bool
+	table     map[string][]int
+}
+
+func NewHashJoin(joinType ast.JoinType, left PhysicalPlan, right PhysicalPlan, condition JoinCondition, merged []JoinKey, schema *types.DataSchema) *HashJoin {
+	j := &HashJoin{
+		join:  newJoin(joinType, left, right.GetSchema(), condition, merged, schema),
+		right: right,
+	}
+	leftColumns, rightColumns := left.GetSchema().Columns, right.GetSchema().Columns
+	j.floatKeys = make([]bool, len(condition.Keys))
+	for i, key := range condition.Keys {
+		j.floatKeys[i] = leftColumns[key.Left].DataType != rightColumns[key.Right].DataType
+	}
+	j.lookup = func(ctx context.Context, row types.DataRow) ([]int, error) {
+		key, err := j.hashKey(row, true)
+		if err != nil || key == nil {
+			return nil, err
+		}
+		return j.table[string(key)], nil
+	}
+	return j
+}
+
+func (j *HashJoin) Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	if err := j.open(ctx, catalog, storage); err != nil {
+		return err
+	}
+	if err := j.right.Open(ctx, catalog, storage); err != nil {
+		return err
+	}
+	if err := j.loadInner(ctx, j.right); err != nil {
+		return err
+	}
+
+	j.table = make(map[string][]int)
+	for pos, row := range j.inner {
+		key, err := j.hashKey(row, false)
+		if err != nil {
+			return err
+		}
+		if key != nil {
+			j.table[string(key)] = append(j.table[string(key)], pos)
+		}
+	}
+	return nil
+}
+
+// hashKey encodes the key values of a row of a side of the join, nil when
+// one of them is NULL as such rows match nothing.
+func (j *HashJoin) hashKey(row types.DataRow, left bool) ([]byte, error) {
+	values := make([]types.Value, len(j.condition.Keys))
+	for i, key := range j.condition.Keys {
+		if left {
+			values[i] = row.Values[key.Left]
+		} else {
+			values[i] = row.Values[key.Right]
+		}
+		if values[i].IsNull() {
+			return nil, nil
+		}
+		if j.floatKeys[i] && values[i].GetDataType() == types.TYPE_INT {
+			v, _ := values[i].Int()
+			values[i] = *types.NewFloatValue(float64(v))
+		}
+	}
+	return types.EncodeKey(values)
+}
+
+func (j *HashJoin) Close() {
+	j.table = nil
+	j.close()
+	j.right.Close()
+}
+
+// IndexNestedLoopJoin reads the right rows matching each left row through
+// an index of the right table covering the join keys, the right table is
+// never scanned.
+type IndexNestedLoopJoin struct {
+	join
+	schemaId catalog.ObjectId
+	tableId  catalog.ObjectId
+	indexId  catalog.ObjectId
+	unique   bool
+	// the left columns giving the value of each column of the index
+	indexKeys []int
+	storage   *storage.KvStorage
+	decoder   *types.RecordDecoder
+}
+
+func NewIndexNestedLoopJoin(joinType ast.JoinType, left PhysicalPlan, schema *catalog.Schema, table *catalog.Table, index *catalog.Index, rightSchema *types.DataSchema, indexKeys []int, condition JoinCondition, merged []JoinKey, joinSchema *types.DataSchema) *IndexNestedLoopJoin {
+	j := &IndexNestedLoopJoin{
+		join:      newJoin(joinType, left, rightSchema, condition, merged, joinSchema),
+		schemaId:  schema.GetId(),
+		tableId:   table.GetId(),
+		indexId:   index.GetId(),
+		unique:    index.IsUnique(),
+		indexKeys: indexKeys,
+	}
+	j.lookup = j.fetch
+	return j
+}
+
+func (j *IndexNestedLoopJoin) Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	decoder, err := types.NewRecordDecoder(j.rightSchema)
+	if err != nil {
+		return err
+	}
+	j.storage, j.decoder = storage, decoder
+	return j.open(ctx, catalog, storage)
+}
+
+// fetch reads the records listed by the index entry of the key of a left
+// row, they replace the inner rows.
+func (j *IndexNestedLoopJoin) fetch(ctx context.Context, row types.DataRow) ([]int, error) {
+	j.inner = j.inner[:0]
+	values := make([]types.Value, len(j.indexKeys))
+	for i, idx := range j.indexKeys {
+		values[i] = row.Values[idx]
+		if values[i].IsNull() {
+			return nil, nil
+		}
+	}
+	indexValue, err := types.EncodeKey(values)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaId, tableId := uint32(j.schemaId), uint32(j.tableId)
+	primaryKeys, err := j.indexedKeys(types.IndexEntryKey(schemaId, tableId, uint32(j.indexId), indexValue))
+	if err != nil {
+		return nil, err
+	}
+
+	// the records are decoded in a chunk, the join compares its rows
+	records := types.NewChunk(j.rightSchema)
+	for _, primaryKey := range primaryKeys {
+		data, err := j.storage.Get(types.TableRecordKey(schemaId, tableId, primaryKey))
+		// an entry listing a record that is not there is no match
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := j.decoder.DecodeInto(data, records); err != nil {
+			return nil, err
+		}
+	}
+
+	j.inner = records.GetRows()
+	positions := make([]int, len(j.inner))
+	for i := range positions {
+		positions[i] = i
+	}
+	return positions, nil
+}
+
+// indexedKeys returns the primary keys of the records an index lists under
+// entryKey, the entry of a unique index holds the key of its record while
+// the entries of other indexes end with it.
+func (j *IndexNestedLoopJoin) indexedKeys(entryKey []byte) ([][]byte, error) {
+	if j.unique {
+		primaryKey, err := j.storage.Get(entryKey)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{primaryKey}, nil
+	}
+
+	scan := j.storage.Scan(entryKey)
+	defer scan.Close()
+	primaryKeys := [][]byte{}
+	for ; scan.Valid(); scan.Next() {
+		key, _, err := scan.Item()
+		if err != nil {
+			return nil, err
+		}
+		primaryKeys = append(primaryKeys, bytes.Clone(key[len(entryKey):]))
+	}
+	return primaryKeys, nil
+}
+
+func (j *IndexNestedLoopJoin) Close() {
+	j.storage, j.decoder = nil, nil
+	j.close()
+}
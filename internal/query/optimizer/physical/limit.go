@@ -0,0 +1,64 @@
+package physical
+
+import (
+	"context"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// Limit stops pulling rows from its child once it has produced enough of
+// them, the rest of the input is never read.
+type Limit struct {
+	child  PhysicalPlan
+	limit  *uint64
+	offset uint64
+	// rows skipped and produced so far
+	skipped  uint64
+	produced uint64
+}
+
+func NewLimit(child PhysicalPlan, limit *uint64, offset uint64) *Limit {
+	return &Limit{
+		child:  child,
+		limit:  limit,
+		offset: offset,
+	}
+}
+
+func (l *Limit) Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	l.skipped, l.produced = 0, 0
+	return l.child.Open(ctx, catalog, storage)
+}
+
+func (l *Limit) Next(ctx context.Context) (*types.DataChunk, error) {
+	for l.limit == nil || l.produced < *l.limit {
+		input, err := l.child.Next(ctx)
+		if err != nil || input == nil {
+			return nil, err
+		}
+
+		count := uint64(input.Len())
+		start := min(l.offset-l.skipped, count)
+		l.skipped += start
+		end := count
+		if l.limit != nil {
+			end = min(end, start+*l.limit-l.produced)
+		}
+		if start == end {
+			continue
+		}
+		l.produced += end - start
+		return input.Slice(int(start), int(end)), nil
+	}
+	return nil, nil
+}
+
+func (l *Limit) Close() {
+	l.child.Close()
+}
+
+func (l *Limit) GetSchema() *types.DataSchema {
+	return l.child.GetSchema()
+}
@@ -0,0 +1,40 @@
+package physical
+
+import (
+	"context"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// PhysicalPlan is an iterator over the rows of a plan. Open prepares it,
+// each call to Next returns the following chunk of at most CHUNK_SIZE rows
+// and nil once they are all produced, Close releases what Open acquired.
+// Operators pull the rows of their children as they need them so that a
+// plan does not hold more than a few chunks in memory, unless it has to
+// see all its input first as a sort does.
+type PhysicalPlan interface {
+	Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error
+	Next(ctx context.Context) (*types.DataChunk, error)
+	Close()
+	GetSchema() *types.DataSchema
+}
+
+// the number of rows operators produce at a time
+const CHUNK_SIZE = 1024
+
+// singleResult gives the iterator interface to the plans producing their
+// whole result when they are opened, such as DML statements returning the
+// number of rows they changed.
+type singleResult struct {
+	result *types.DataChunk
+}
+
+func (r *singleResult) Next(ctx context.Context) (*types.DataChunk, error) {
+	result := r.result
+	r.result = nil
+	return result, nil
+}
+
+func (r *singleResult) Close() {}
@@ -0,0 +1,54 @@
+package physical
+
+import (
+	"context"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// computes the columns of a SELECT from the rows of its child, exprs are
+// bound to the schema of the child.
+type Projection struct {
+	child  PhysicalPlan
+	exprs  []expression.BoundExpr
+	schema *types.DataSchema
+}
+
+func NewProjection(child PhysicalPlan, exprs []expression.BoundExpr, schema *types.DataSchema) *Projection {
+	return &Projection{
+		child:  child,
+		exprs:  exprs,
+		schema: schema,
+	}
+}
+
+func (p *Projection) Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	return p.child.Open(ctx, catalog, storage)
+}
+
+// Next evaluates the expressions over the chunks of the child, the output
+// keeps the selection of the child chunk.
+func (p *Projection) Next(ctx context.Context) (*types.DataChunk, error) {
+	input, err := p.child.Next(ctx)
+	if err != nil || input == nil {
+		return nil, err
+	}
+
+	selection := input.GetSelection()
+	columns, err := expression.EvaluateProjection(p.exprs, input)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewVectorChunk(p.schema, columns, input.VectorLen(), selection), nil
+}
+
+func (p *Projection) Close() {
+	p.child.Close()
+}
+
+func (p *Projection) GetSchema() *types.DataSchema {
+	return p.schema
+}
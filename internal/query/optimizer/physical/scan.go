@@ -8,28 +8,63 @@ import (
 	"github.com/evanxg852000/foxdb/internal/types"
 )
 
+// full table scan over the `t_{schemaId}{tableId}_` key range, the records
+// are read from the storage one chunk at a time. dataSchema describes the
+// records with their columns qualified as in the query.
 type Scan struct {
-	schema *types.DataSchema
+	schemaId catalog.ObjectId
+	tableId  catalog.ObjectId
+	schema   *types.DataSchema
+	decoder  *types.RecordDecoder
+	scan     *storage.KvScan
 }
 
-func NewScan(table *catalog.Table) *Scan {
-	columns := make([]types.DataColumn, len(table.ListColumns()))
-	for i, col := range table.ListColumns() {
-		columns[i] = types.DataColumn{
-			Name:     col.GetName(),
-			DataType: col.GetDataType(),
-		}
+func NewScan(schema *catalog.Schema, table *catalog.Table, dataSchema *types.DataSchema) *Scan {
+	return &Scan{
+		schemaId: schema.GetId(),
+		tableId:  table.GetId(),
+		schema:   dataSchema,
 	}
-	schema := &types.DataSchema{
-		Columns: columns,
+}
+
+func (s *Scan) Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	decoder, err := types.NewRecordDecoder(s.schema)
+	if err != nil {
+		return err
 	}
-	return &Scan{
-		schema: schema,
+	s.decoder = decoder
+	s.scan = storage.Scan(types.TableKeyPrefix(uint32(s.schemaId), uint32(s.tableId)))
+	return nil
+}
+
+func (s *Scan) Next(ctx context.Context) (*types.DataChunk, error) {
+	chunk := types.NewChunk(s.schema)
+	for ; s.scan.Valid() && chunk.Len() < CHUNK_SIZE; s.scan.Next() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		_, value, err := s.scan.Item()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.decoder.DecodeInto(value, chunk); err != nil {
+			return nil, err
+		}
 	}
+
+	if chunk.Len() == 0 {
+		return nil, nil
+	}
+	return chunk, nil
 }
 
-func (s *Scan) Execute(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) (*types.DataChunk, error) {
-	return types.NewChunk(s.schema), nil
+func (s *Scan) Close() {
+	if s.scan != nil {
+		s.scan.Close()
+		s.scan = nil
+	}
 }
 
 func (s *Scan) GetSchema() *types.DataSchema {
@@ -0,0 +1,127 @@
+package physical
+
+import (
+	"context"
+	"slices"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// in memory sort of all the rows produced by its child
+type Sort struct {
+	child   PhysicalPlan
+	orderBy []ast.SortExpr
+	// bound when the plan is opened
+	sortKeys []expression.BoundExpr
+	// the sorted rows not produced yet
+	rows   []types.DataRow
+	sorted bool
+}
+
+func NewSort(child PhysicalPlan, orderBy []ast.SortExpr) *Sort {
+	return &Sort{
+		child:   child,
+		orderBy: orderBy,
+	}
+}
+
+func (s *Sort) Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	s.sortKeys = make([]expression.BoundExpr, len(s.orderBy))
+	for i, sortExpr := range s.orderBy {
+		var err error
+		if s.sortKeys[i], err = expression.Bind(sortExpr.Expr, s.GetSchema()); err != nil {
+			return err
+		}
+	}
+	s.rows = nil
+	s.sorted = false
+	return s.child.Open(ctx, catalog, storage)
+}
+
+// Next sorts the whole input on its first call, then hands the sorted rows
+// out one chunk at a time.
+func (s *Sort) Next(ctx context.Context) (*types.DataChunk, error) {
+	if !s.sorted {
+		if err := s.sort(ctx); err != nil {
+			return nil, err
+		}
+		s.sorted = true
+	}
+	if len(s.rows) == 0 {
+		return nil, nil
+	}
+
+	n := min(len(s.rows), CHUNK_SIZE)
+	chunk := types.NewWith(s.GetSchema(), s.rows[:n])
+	s.rows = s.rows[n:]
+	return chunk, nil
+}
+
+func (s *Sort) sort(ctx context.Context) error {
+	// evaluate the sort keys once per row before sorting
+	type sortEntry struct {
+		keys []*types.Value
+		row  types.DataRow
+	}
+	var entries []sortEntry
+	for {
+		input, err := s.child.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if input == nil {
+			break
+		}
+		for _, row := range input.GetRows() {
+			keys := make([]*types.Value, len(s.sortKeys))
+			for i, sortKey := range s.sortKeys {
+				keys[i], err = sortKey.Evaluate(row)
+				if err != nil {
+					return err
+				}
+			}
+			entries = append(entries, sortEntry{keys: keys, row: row})
+		}
+	}
+
+	var sortErr error
+	slices.SortStableFunc(entries, func(a, b sortEntry) int {
+		for i, sortExpr := range s.orderBy {
+			result, err := a.keys[i].Compare(b.keys[i])
+			if err != nil {
+				sortErr = err
+				return 0
+			}
+			if result == 0 {
+				continue
+			}
+			if !sortExpr.Ascending {
+				return -result
+			}
+			return result
+		}
+		return 0
+	})
+	if sortErr != nil {
+		return sortErr
+	}
+
+	s.rows = make([]types.DataRow, len(entries))
+	for i, entry := range entries {
+		s.rows[i] = entry.row
+	}
+	return nil
+}
+
+func (s *Sort) Close() {
+	s.rows = nil
+	s.child.Close()
+}
+
+func (s *Sort) GetSchema() *types.DataSchema {
+	return s.child.GetSchema()
+}
@@ -0,0 +1,81 @@
+package physical
+
+import (
+	"context"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// Scans the information_schema tables. Those tables have no stored
+// records, their rows are generated from the catalog at execution time.
+type SystemScan struct {
+	tableName string
+	schema    *types.DataSchema
+	singleResult
+}
+
+func NewSystemScan(table *catalog.Table, dataSchema *types.DataSchema) *SystemScan {
+	return &SystemScan{
+		tableName: table.GetName(),
+		schema:    dataSchema,
+	}
+}
+
+func (s *SystemScan) Open(ctx context.Context, rootCatalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	s.result = s.generate(rootCatalog)
+	return nil
+}
+
+func (s *SystemScan) generate(rootCatalog *catalog.RootCatalog) *types.DataChunk {
+	rootCatalog.RLock()
+	defer rootCatalog.RUnlock()
+
+	chunk := types.NewChunk(s.schema)
+	switch s.tableName {
+	case "schemas":
+		for _, schema := range rootCatalog.ListSchemas() {
+			chunk.AppendRow(types.DataRow{Values: []types.Value{
+				*types.NewIntValue(int64(schema.GetId())),
+				*types.NewTextValue(schema.GetName()),
+			}})
+		}
+	case "tables":
+		for _, schema := range rootCatalog.ListSchemas() {
+			for _, table := range schema.ListTables() {
+				chunk.AppendRow(types.DataRow{Values: []types.Value{
+					*types.NewIntValue(int64(table.GetId())),
+					*types.NewTextValue(table.GetName()),
+					*types.NewIntValue(int64(schema.GetId())),
+					*types.NewIntValue(int64(table.GetSequenceValue())),
+				}})
+			}
+		}
+	case "columns":
+		for _, schema := range rootCatalog.ListSchemas() {
+			for _, table := range schema.ListTables() {
+				for position, column := range table.ListColumns() {
+					defaultValue := column.GetDefault()
+					if !defaultValue.IsNull() {
+						defaultValue = *types.NewTextValue(defaultValue.String())
+					}
+					chunk.AppendRow(types.DataRow{Values: []types.Value{
+						*types.NewIntValue(int64(schema.GetId())),
+						*types.NewIntValue(int64(table.GetId())),
+						*types.NewTextValue(column.GetName()),
+						*types.NewIntValue(int64(position + 1)),
+						*types.NewTextValue(column.GetDataType().String()),
+						*types.NewBoolValue(column.GetConstraints().NotNull),
+						defaultValue,
+					}})
+				}
+			}
+		}
+	}
+	return chunk
+}
+
+func (s *SystemScan) GetSchema() *types.DataSchema {
+	return s.schema
+}
@@ -0,0 +1,286 @@
+package physical
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// Shared logic of the statements writing the records of a table:
+// key computation, constraints checking and scanning inside a transaction.
+type tableWriter struct {
+	schemaId    catalog.ObjectId
+	table       *catalog.Table
+	dataSchema  *types.DataSchema
+	constraints []catalog.Constraint
+	defaults    []types.Value
+	primaryKeys []int
+	indexes     []indexWriter
+}
+
+// a secondary index and the positions of the columns it covers. A UNIQUE
+// column is an implicit unique index keyed by the column id, the ids of
+// the columns and indexes of a table never overlap.
+type indexWriter struct {
+	id      catalog.ObjectId
+	unique  bool
+	columns []int
+	// names the violated constraint in errors
+	constraint string
+}
+
+// the transaction of Batch functions, the storage parameter of the
+// plans hides the package name
+type kvTxn = storage.KvTxn
+
+func newTableWriter(schema *catalog.Schema, table *catalog.Table) tableWriter {
+	columns := table.ListColumns()
+	constraints := make([]catalog.Constraint, len(columns))
+	defaults := make([]types.Value, len(columns))
+	for idx, col := range columns {
+		constraints[idx] = col.GetConstraints()
+		defaults[idx] = col.GetDefault()
+	}
+
+	primaryKeys := table.GetPrimaryKeyIndexes()
+	indexes := []indexWriter{}
+	for idx, column := range columns {
+		if isUniqueColumn(column, idx, primaryKeys) {
+			indexes = append(indexes, newUniqueColumnWriter(column, idx))
+		}
+	}
+	for _, index := range table.ListIndexes() {
+		indexes = append(indexes, newIndexWriter(table, index))
+	}
+
+	return tableWriter{
+		schemaId:    schema.GetId(),
+		table:       table,
+		dataSchema:  table.GetDataSchema(),
+		constraints: constraints,
+		defaults:    defaults,
+		primaryKeys: primaryKeys,
+		indexes:     indexes,
+	}
+}
+
+func newIndexWriter(table *catalog.Table, index *catalog.Index) indexWriter {
+	return indexWriter{
+		id:         index.GetId(),
+		unique:     index.IsUnique(),
+		columns:    table.GetColumnIndexes(index.GetColumnIds()),
+		constraint: "unique index " + index.GetName(),
+	}
+}
+
+// the entries of a UNIQUE column at position idx of the table
+func newUniqueColumnWriter(column *catalog.Column, idx int) indexWriter {
+	return indexWriter{
+		id:         column.GetId(),
+		unique:     true,
+		columns:    []int{idx},
+		constraint: "unique constraint on column " + column.GetName(),
+	}
+}
+
+// a unique column that is the whole primary key is already checked by
+// the record key
+func isUniqueColumn(column *catalog.Column, idx int, primaryKeys []int) bool {
+	if !column.GetConstraints().Unique {
+		return false
+	}
+	return len(primaryKeys) != 1 || primaryKeys[0] != idx
+}
+
+func (w *tableWriter) keyPrefix() []byte {
+	return types.TableKeyPrefix(uint32(w.schemaId), uint32(w.table.GetId()))
+}
+
+// guardVersion makes a statement writing the table fail when a DDL
+// statement changing the table commits after its snapshot, its plan was
+// built from the previous definition of the table.
+func (w *tableWriter) guardVersion(txn *kvTxn) {
+	txn.GuardKey(types.TableVersionKey(uint32(w.schemaId), uint32(w.table.GetId())))
+}
+
+// Computes the key of a record from its primary key values. Tables
+// without primary key are keyed by a newly allocated hidden row id.
+func (w *tableWriter) recordKey(record *types.Record) ([]byte, error) {
+	if len(w.primaryKeys) == 0 {
+		rowId := types.EncodeRowId(w.table.NextRowId())
+		return types.TableRecordKey(uint32(w.schemaId), uint32(w.table.GetId()), rowId), nil
+	}
+
+	keyValues := make([]types.Value, 0, len(w.primaryKeys))
+	for _, idx := range w.primaryKeys {
+		value, _ := record.GetValue(uint(idx))
+		keyValues = append(keyValues, *value)
+	}
+
+	primaryKey, err := types.EncodeKey(keyValues)
+	if err != nil {
+		return nil, err
+	}
+	return types.TableRecordKey(uint32(w.schemaId), uint32(w.table.GetId()), primaryKey), nil
+}
+
+// strips the table prefix from a record key
+func (w *tableWriter) primaryKeyOf(key []byte) []byte {
+	return key[len(w.keyPrefix()):]
+}
+
+func (w *tableWriter) addIndexEntries(txn *kvTxn, record *types.Record, primaryKey []byte) error {
+	for _, index := range w.indexes {
+		if err := w.addIndexEntry(txn, index, record, primaryKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *tableWriter) removeIndexEntries(txn *kvTxn, record *types.Record, primaryKey []byte) error {
+	for _, index := range w.indexes {
+		if err := w.removeIndexEntry(txn, index, record, primaryKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// adds the entry of a record to an index. The entry of a unique index is
+// keyed by the indexed value and holds the primary key, concurrent
+// transactions writing the same value conflict on it. The entries of other
+// indexes are keyed by the value followed by the primary key, concurrent
+// writes of a value do not conflict.
+func (w *tableWriter) addIndexEntry(txn *kvTxn, index indexWriter, record *types.Record, primaryKey []byte) error {
+	entryKey, err := w.indexEntryKey(index, record)
+	if err != nil || entryKey == nil {
+		return err
+	}
+	if !index.unique {
+		return txn.Set(append(entryKey, primaryKey...), nil)
+	}
+
+	indexedKey, err := loadIndexedKey(txn, entryKey)
+	if err != nil {
+		return err
+	}
+	if indexedKey != nil && !bytes.Equal(indexedKey, primaryKey) {
+		return types.NewError(types.ERR_UNIQUE_VIOLATION, "duplicate key value violates %s", index.constraint)
+	}
+	return txn.Set(entryKey, primaryKey)
+}
+
+func (w *tableWriter) removeIndexEntry(txn *kvTxn, index indexWriter, record *types.Record, primaryKey []byte) error {
+	entryKey, err := w.indexEntryKey(index, record)
+	if err != nil || entryKey == nil {
+		return err
+	}
+	if !index.unique {
+		return txn.Delete(append(entryKey, primaryKey...))
+	}
+
+	indexedKey, err := loadIndexedKey(txn, entryKey)
+	if err != nil || !bytes.Equal(indexedKey, primaryKey) {
+		return err
+	}
+	return txn.Delete(entryKey)
+}
+
+// returns nil for records with a NULL indexed value, those are not indexed
+func (w *tableWriter) indexEntryKey(index indexWriter, record *types.Record) ([]byte, error) {
+	values := make([]types.Value, 0, len(index.columns))
+	for _, idx := range index.columns {
+		value, _ := record.GetValue(uint(idx))
+		if value == nil || value.IsNull() {
+			return nil, nil
+		}
+		values = append(values, *value)
+	}
+
+	indexValue, err := types.EncodeKey(values)
+	if err != nil {
+		return nil, err
+	}
+	return types.IndexEntryKey(uint32(w.schemaId), uint32(w.table.GetId()), uint32(index.id), indexValue), nil
+}
+
+// returns the primary key held by the entry of a unique index, nil when
+// there is none
+func loadIndexedKey(txn *kvTxn, entryKey []byte) ([]byte, error) {
+	item, err := txn.Get(entryKey)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item.ValueCopy(nil)
+}
+
+// primary key columns are implicitly NOT NULL
+func (w *tableWriter) checkNotNull(record *types.Record) error {
+	for idx, column := range w.dataSchema.Columns {
+		value, _ := record.GetValue(uint(idx))
+		if value != nil {
+			continue
+		}
+		if w.constraints[idx].NotNull || slices.Contains(w.primaryKeys, idx) {
+			return types.NewError(types.ERR_NOT_NULL_VIOLATION, "null value in column %s violates not-null constraint", column.Name)
+		}
+	}
+	return nil
+}
+
+// calls fn for every record of the table visible to the transaction
+func (w *tableWriter) scan(txn *kvTxn, fn func(key []byte, record *types.Record) error) error {
+	prefix := w.keyPrefix()
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		data, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+
+		record := types.NewRecord(w.dataSchema)
+		if err := record.Decode(data); err != nil {
+			return err
+		}
+		if err := fn(item.KeyCopy(nil), record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// converts a value to the type of the column it is stored in
+func coerceValue(value *types.Value, column types.DataColumn) (*types.Value, error) {
+	if value.GetDataType() == column.DataType {
+		return value, nil
+	}
+	if value.GetDataType() == types.TYPE_INT && column.DataType == types.TYPE_FLOAT {
+		v, _ := value.Int()
+		return types.NewFloatValue(float64(v)), nil
+	}
+	return nil, types.NewError(types.ERR_DATATYPE_MISMATCH, "column %s is of type %s but expression is of type %s", column.Name, column.DataType, value.GetDataType())
+}
+
+func checkPrimaryKeyAbsent(txn *kvTxn, key []byte, table *catalog.Table) error {
+	_, err := txn.Get(key)
+	if err == nil {
+		return types.NewError(types.ERR_UNIQUE_VIOLATION, "duplicate key value violates primary key constraint of table %s", table.GetName())
+	}
+	if !errors.Is(err, badger.ErrKeyNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package physical
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+type Assignment struct {
+	ColumnIndex int
+	Value       ast.Expression
+}
+
+// Rewrites the records matching the predicate in a single transaction.
+// Records whose primary key changes are moved to their new key.
+type Update struct {
+	tableWriter
+	singleResult
+	assignments []Assignment
+	predicate   ast.Expression
+}
+
+func NewUpdate(schema *catalog.Schema, table *catalog.Table, assignments []Assignment, predicate ast.Expression) *Update {
+	return &Update{
+		tableWriter: newTableWriter(schema, table),
+		assignments: assignments,
+		predicate:   predicate,
+	}
+}
+
+func (u *Update) Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	result, err := u.execute(ctx, storage)
+	u.result = result
+	return err
+}
+
+func (u *Update) execute(ctx context.Context, storage *storage.KvStorage) (*types.DataChunk, error) {
+	predicate, err := bindPredicate(u.predicate, u.dataSchema)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]expression.BoundExpr, len(u.assignments))
+	for i, assignment := range u.assignments {
+		if values[i], err = expression.Bind(assignment.Value, u.dataSchema); err != nil {
+			return nil, err
+		}
+	}
+
+	type change struct {
+		oldKey    []byte
+		newKey    []byte
+		oldRecord *types.Record
+		record    *types.Record
+	}
+
+	changes := []change{}
+	err = storage.Batch(func(txn *kvTxn) error {
+		u.guardVersion(txn)
+		err := u.scan(txn, func(key []byte, record *types.Record) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			row := record.ToRow()
+			if predicate != nil {
+				match, err := expression.EvaluatePredicate(predicate, row)
+				if err != nil || !match {
+					return err
+				}
+			}
+
+			newRecord, err := u.buildRecord(row, values)
+			if err != nil {
+				return err
+			}
+
+			newKey := key
+			if len(u.primaryKeys) > 0 {
+				newKey, err = u.recordKey(newRecord)
+				if err != nil {
+					return err
+				}
+			}
+			changes = append(changes, change{oldKey: key, newKey: newKey, oldRecord: record, record: newRecord})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// remove all the moved records and old index entries first so
+		// that keys and indexed values can be swapped between records
+		for _, c := range changes {
+			if err := u.removeIndexEntries(txn, c.oldRecord, u.primaryKeyOf(c.oldKey)); err != nil {
+				return err
+			}
+			if !bytes.Equal(c.oldKey, c.newKey) {
+				if err := txn.Delete(c.oldKey); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, c := range changes {
+			if !bytes.Equal(c.oldKey, c.newKey) {
+				if err := checkPrimaryKeyAbsent(txn, c.newKey, u.table); err != nil {
+					return err
+				}
+			}
+
+			data, err := c.record.Encode()
+			if err != nil {
+				return err
+			}
+			if err := txn.Set(c.newKey, data); err != nil {
+				return err
+			}
+			if err := u.addIndexEntries(txn, c.record, u.primaryKeyOf(c.newKey)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return types.NewCountChunk(len(changes)), nil
+}
+
+func (u *Update) GetSchema() *types.DataSchema {
+	return types.COUNT_SCHEMA
+}
+
+// builds the new version of a record from the bound values of the
+// assignments, they see the old values
+func (u *Update) buildRecord(row types.DataRow, values []expression.BoundExpr) (*types.Record, error) {
+	record := types.NewRecord(u.dataSchema)
+	for idx, value := range row.Values {
+		if value.IsNull() {
+			continue
+		}
+		if err := record.SetValue(uint(idx), value); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, assignment := range u.assignments {
+		value, err := values[i].Evaluate(row)
+		if err != nil {
+			return nil, err
+		}
+		if value.IsNull() {
+			record.ClearValue(uint(assignment.ColumnIndex))
+			continue
+		}
+
+		value, err = coerceValue(value, u.dataSchema.Columns[assignment.ColumnIndex])
+		if err != nil {
+			return nil, err
+		}
+		if err := record.SetValue(uint(assignment.ColumnIndex), *value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := u.checkNotNull(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
@@ -0,0 +1,88 @@
+package physical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/auth"
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/storage"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// Passwords are hashed before they reach the catalog, only
+// their SCRAM verifier and MD5 hash are ever persisted.
+
+func createUser(rootCatalog *catalog.RootCatalog, storage *storage.KvStorage, name string, password *string) (*types.DataChunk, error) {
+	credentials, err := hashPassword(name, password)
+	if err != nil {
+		return nil, err
+	}
+
+	rootCatalog.Lock()
+	defer rootCatalog.Unlock()
+	user, err := rootCatalog.AddUser(name)
+	if err != nil {
+		return nil, err
+	}
+	user.SetCredentials(credentials)
+
+	err = storage.Batch(func(txn *kvTxn) error {
+		return storeCatalog(txn, rootCatalog)
+	})
+	if err != nil {
+		rootCatalog.RemoveUser(name)
+		return nil, err
+	}
+	return nil, nil
+}
+
+func dropUser(rootCatalog *catalog.RootCatalog, storage *storage.KvStorage, name string) (*types.DataChunk, error) {
+	rootCatalog.Lock()
+	defer rootCatalog.Unlock()
+	user, err := rootCatalog.RemoveUser(name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = storage.Batch(func(txn *kvTxn) error {
+		return storeCatalog(txn, rootCatalog)
+	})
+	if err != nil {
+		rootCatalog.RestoreUser(user)
+		return nil, err
+	}
+	return nil, nil
+}
+
+func alterUser(rootCatalog *catalog.RootCatalog, storage *storage.KvStorage, name string, password *string) (*types.DataChunk, error) {
+	credentials, err := hashPassword(name, password)
+	if err != nil {
+		return nil, err
+	}
+
+	rootCatalog.Lock()
+	defer rootCatalog.Unlock()
+	user := rootCatalog.GetUser(name)
+	if user == nil {
+		return nil, types.NewError(types.ERR_UNDEFINED_OBJECT, "user %s does not exist", name)
+	}
+	previous := user.GetCredentials()
+	user.SetCredentials(credentials)
+
+	err = storage.Batch(func(txn *kvTxn) error {
+		return storeCatalog(txn, rootCatalog)
+	})
+	if err != nil {
+		user.SetCredentials(previous)
+		return nil, err
+	}
+	return nil, nil
+}
+
+func hashPassword(name string, password *string) (*auth.Credentials, error) {
+	if password == nil {
+		return nil, nil
+	}
+	if *password == "" {
+		return nil, types.NewError(types.ERR_INVALID_PARAMETER_VALUE, "password of user %s cannot be empty", name)
+	}
+	return auth.NewCredentials(name, *password)
+}
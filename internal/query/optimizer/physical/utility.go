@@ -2,7 +2,9 @@ package physical
 
 import (
 	"context"
+	"encoding/json"
 
+	"github.com/dgraph-io/badger/v3"
 	"github.com/evanxg852000/foxdb/internal/catalog"
 	"github.com/evanxg852000/foxdb/internal/query/planner"
 	"github.com/evanxg852000/foxdb/internal/query/planner/logical"
@@ -14,6 +16,7 @@ import (
 // This is just like a wrapper around logical plan that
 // should be executed as is without any optimization
 type UtilityPlan struct {
+	singleResult
 	logicalPlan planner.LogicalPlan
 }
 
@@ -27,26 +30,274 @@ func (p *UtilityPlan) GetSchema() *types.DataSchema {
 	return p.logicalPlan.GetSchema()
 }
 
-func (p *UtilityPlan) Execute(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) (*types.DataChunk, error) {
+func (p *UtilityPlan) Open(ctx context.Context, catalog *catalog.RootCatalog, storage *storage.KvStorage) error {
+	result, err := p.execute(catalog, storage)
+	p.result = result
+	return err
+}
+
+func (p *UtilityPlan) execute(catalog *catalog.RootCatalog, storage *storage.KvStorage) (*types.DataChunk, error) {
 	//TODO: complete execution logic for utility plans
 	switch plan := p.logicalPlan.(type) {
 	case *logical.CreateSchemaPlan:
-		return createSchema(catalog, plan.SchemaName, plan.IfNotExists)
+		return createSchema(catalog, storage, plan.SchemaName, plan.IfNotExists)
+	case *logical.CreateTablePlan:
+		return createTable(catalog, storage, plan)
+	case *logical.DropTablePlan:
+		return dropTable(catalog, storage, plan.SchemaName, plan.TableName)
+	case *logical.AlterTablePlan:
+		return alterTable(catalog, storage, plan)
+	case *logical.CreateIndexPlan:
+		return createIndex(catalog, storage, plan)
+	case *logical.DropIndexPlan:
+		return dropIndex(catalog, storage, plan.SchemaName, plan.IndexName)
+	case *logical.CreateUserPlan:
+		return createUser(catalog, storage, plan.UserName, plan.Password)
+	case *logical.DropUserPlan:
+		return dropUser(catalog, storage, plan.UserName)
+	case *logical.AlterUserPlan:
+		return alterUser(catalog, storage, plan.UserName, plan.Password)
 	}
 	return nil, nil
 }
 
-func createSchema(catalog *catalog.RootCatalog, name string, safe bool) (*types.DataChunk, error) {
-	catalog.Lock()
-	defer catalog.Unlock()
+// Catalog changes are applied in memory first, then persisted in the same
+// transaction as the related data changes. When that transaction fails
+// the in memory change is undone so that both stay in sync. Sessions run
+// the plans on a copy of the catalog, published when their transaction
+// commits.
+
+func createSchema(rootCatalog *catalog.RootCatalog, storage *storage.KvStorage, name string, safe bool) (*types.DataChunk, error) {
+	rootCatalog.Lock()
+	defer rootCatalog.Unlock()
 	if safe {
-		if schema := catalog.GetSchema(name); schema != nil {
+		if schema := rootCatalog.GetSchema(name); schema != nil {
+			return nil, nil
+		}
+	}
+
+	if _, err := rootCatalog.AddSchema(name); err != nil {
+		return nil, err
+	}
+
+	err := storage.Batch(func(txn *kvTxn) error {
+		return storeCatalog(txn, rootCatalog)
+	})
+	if err != nil {
+		rootCatalog.RemoveSchema(name)
+		return nil, err
+	}
+	return nil, nil
+}
+
+func createTable(rootCatalog *catalog.RootCatalog, storage *storage.KvStorage, plan *logical.CreateTablePlan) (*types.DataChunk, error) {
+	rootCatalog.Lock()
+	defer rootCatalog.Unlock()
+	schema := rootCatalog.GetSchema(plan.SchemaName)
+	if schema == nil {
+		return nil, types.NewError(types.ERR_INVALID_SCHEMA_NAME, "schema %s does not exist", plan.SchemaName)
+	}
+
+	if plan.IfNotExists {
+		if table := schema.GetTable(plan.TableName); table != nil {
 			return nil, nil
 		}
 	}
 
-	if _, err := catalog.AddSchema(name); err != nil {
+	table, err := schema.AddTable(plan.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = defineTable(table, plan)
+	if err == nil {
+		err = storage.Batch(func(txn *kvTxn) error {
+			return storeCatalog(txn, rootCatalog)
+		})
+	}
+	if err != nil {
+		schema.RemoveTable(plan.TableName)
 		return nil, err
 	}
 	return nil, nil
 }
+
+func defineTable(table *catalog.Table, plan *logical.CreateTablePlan) error {
+	for i, column := range plan.Columns {
+		added, err := table.AddColumn(column.GetName(), column.GetDataType(), column.GetConstraints())
+		if err != nil {
+			return err
+		}
+
+		value, err := evaluateDefault(plan.Defaults[i], added)
+		if err != nil {
+			return err
+		}
+		added.SetDefault(value)
+	}
+
+	for _, columnName := range plan.PrimaryKeys {
+		if table.GetColumn(columnName) == nil {
+			return types.NewError(types.ERR_UNDEFINED_COLUMN, "primary key column %s does not exist", columnName)
+		}
+	}
+	table.SetPrimaryKeys(plan.PrimaryKeys)
+	return nil
+}
+
+func dropTable(rootCatalog *catalog.RootCatalog, storage *storage.KvStorage, schemaName string, tableName string) (*types.DataChunk, error) {
+	rootCatalog.Lock()
+	defer rootCatalog.Unlock()
+	schema := rootCatalog.GetSchema(schemaName)
+	if schema == nil {
+		return nil, types.NewError(types.ERR_INVALID_SCHEMA_NAME, "schema %s does not exist", schemaName)
+	}
+	if schema.GetName() == catalog.INFORMATION_SCHEMA_NAME {
+		return nil, types.NewError(types.ERR_INSUFFICIENT_PRIVILEGE, "cannot drop system table %s", tableName)
+	}
+
+	table, err := schema.RemoveTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaId, tableId := uint32(schema.GetId()), uint32(table.GetId())
+	err = storage.Batch(func(txn *kvTxn) error {
+		if err := changeTable(txn, schema, table); err != nil {
+			return err
+		}
+		// the version key goes with the table
+		if err := txn.Delete(types.TableVersionKey(schemaId, tableId)); err != nil {
+			return err
+		}
+		if err := deletePrefix(txn, types.TableKeyPrefix(schemaId, tableId)); err != nil {
+			return err
+		}
+		if err := deletePrefix(txn, types.TableIndexesKeyPrefix(schemaId, tableId)); err != nil {
+			return err
+		}
+		return storeCatalog(txn, rootCatalog)
+	})
+	if err != nil {
+		schema.RestoreTable(table)
+		return nil, err
+	}
+	return nil, nil
+}
+
+// registers the index and backfills it from the existing records
+func createIndex(rootCatalog *catalog.RootCatalog, storage *storage.KvStorage, plan *logical.CreateIndexPlan) (*types.DataChunk, error) {
+	rootCatalog.Lock()
+	defer rootCatalog.Unlock()
+	schema := rootCatalog.GetSchema(plan.SchemaName)
+	if schema == nil {
+		return nil, types.NewError(types.ERR_INVALID_SCHEMA_NAME, "schema %s does not exist", plan.SchemaName)
+	}
+	if schema.GetName() == catalog.INFORMATION_SCHEMA_NAME {
+		return nil, types.NewError(types.ERR_INSUFFICIENT_PRIVILEGE, "cannot create index on system table %s", plan.TableName)
+	}
+
+	table := schema.GetTable(plan.TableName)
+	if table == nil {
+		return nil, types.NewError(types.ERR_UNDEFINED_TABLE, "table %s.%s does not exist", plan.SchemaName, plan.TableName)
+	}
+	if _, index := schema.FindIndex(plan.IndexName); index != nil {
+		return nil, types.NewError(types.ERR_DUPLICATE_OBJECT, "index %s already exists", plan.IndexName)
+	}
+
+	index, err := table.AddIndex(plan.IndexName, plan.Columns, plan.Unique)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := newTableWriter(schema, table)
+	indexWriter := newIndexWriter(table, index)
+	err = storage.Batch(func(txn *kvTxn) error {
+		if err := changeTable(txn, schema, table); err != nil {
+			return err
+		}
+		err := writer.scan(txn, func(key []byte, record *types.Record) error {
+			return writer.addIndexEntry(txn, indexWriter, record, writer.primaryKeyOf(key))
+		})
+		if err != nil {
+			return err
+		}
+		return storeCatalog(txn, rootCatalog)
+	})
+	if err != nil {
+		table.RemoveIndex(plan.IndexName)
+		return nil, err
+	}
+	return nil, nil
+}
+
+func dropIndex(rootCatalog *catalog.RootCatalog, storage *storage.KvStorage, schemaName string, indexName string) (*types.DataChunk, error) {
+	rootCatalog.Lock()
+	defer rootCatalog.Unlock()
+	schema := rootCatalog.GetSchema(schemaName)
+	if schema == nil {
+		return nil, types.NewError(types.ERR_INVALID_SCHEMA_NAME, "schema %s does not exist", schemaName)
+	}
+
+	table, index := schema.FindIndex(indexName)
+	if index == nil {
+		return nil, types.NewError(types.ERR_UNDEFINED_OBJECT, "index %s does not exist", indexName)
+	}
+	if _, err := table.RemoveIndex(indexName); err != nil {
+		return nil, err
+	}
+
+	prefix := types.IndexKeyPrefix(uint32(schema.GetId()), uint32(table.GetId()), uint32(index.GetId()))
+	err := storage.Batch(func(txn *kvTxn) error {
+		if err := changeTable(txn, schema, table); err != nil {
+			return err
+		}
+		if err := deletePrefix(txn, prefix); err != nil {
+			return err
+		}
+		return storeCatalog(txn, rootCatalog)
+	})
+	if err != nil {
+		table.RestoreIndex(index)
+		return nil, err
+	}
+	return nil, nil
+}
+
+// writes the catalog under its reserved key, the catalog lock must be held
+func storeCatalog(txn *kvTxn, rootCatalog *catalog.RootCatalog) error {
+	data, err := json.Marshal(rootCatalog)
+	if err != nil {
+		return err
+	}
+	return txn.Set(types.CATALOG_KEY, data)
+}
+
+// changeTable serializes a DDL statement with the statements writing the
+// table concurrently, whichever commits last fails. The DDL statement
+// depends on the records it may have missed and writes the table version
+// the other statements depend on.
+func changeTable(txn *kvTxn, schema *catalog.Schema, table *catalog.Table) error {
+	schemaId, tableId := uint32(schema.GetId()), uint32(table.GetId())
+	txn.GuardPrefix(types.TableKeyPrefix(schemaId, tableId))
+	return txn.Set(types.TableVersionKey(schemaId, tableId), nil)
+}
+
+func deletePrefix(txn *kvTxn, prefix []byte) error {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = prefix
+	it := txn.NewIterator(opts)
+	keys := [][]byte{}
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		keys = append(keys, it.Item().KeyCopy(nil))
+	}
+	it.Close()
+
+	for _, key := range keys {
+		if err := txn.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -12,11 +12,13 @@ type Expression interface {
 }
 
 type IdentifierExpr struct {
+	// Table qualifies a column name, it is empty when the name is not qualified
+	Table string
 	Value string
 }
 
 func (ie *IdentifierExpr) ToExprString() string {
-	return ie.Value
+	return qualifiedName(ie.Table, ie.Value)
 }
 
 type StringLiteralExpr struct {
@@ -50,6 +52,16 @@ func (nle *NullLiteralExpr) ToExprString() string {
 	return "NULL"
 }
 
+// ParameterExpr is the placeholder of the Index-th (from 1) bound parameter,
+// written $n or ? where question marks are numbered in order.
+type ParameterExpr struct {
+	Index int
+}
+
+func (pe *ParameterExpr) ToExprString() string {
+	return fmt.Sprintf("$%d", pe.Index)
+}
+
 type BooleanLiteralExpr struct {
 	Value bool
 }
@@ -73,6 +85,13 @@ type SortExpr struct {
 	Ascending bool
 }
 
+func (se *SortExpr) ToExprString() string {
+	if se.Ascending {
+		return se.Expr.ToExprString() + " ASC"
+	}
+	return se.Expr.ToExprString() + " DESC"
+}
+
 type PrefixExpr struct {
 	Operator string
 	Right    Expression
@@ -92,9 +111,24 @@ func (be *InfixExpr) ToExprString() string {
 	return "(" + be.Left.ToExprString() + " " + be.Operator + " " + be.Right.ToExprString() + ")"
 }
 
+// IsNullExpr is `Expr IS NULL` or `Expr IS NOT NULL` when Not is set
+type IsNullExpr struct {
+	Expr Expression
+	Not  bool
+}
+
+func (ine *IsNullExpr) ToExprString() string {
+	if ine.Not {
+		return "(" + ine.Expr.ToExprString() + " IS NOT NULL)"
+	}
+	return "(" + ine.Expr.ToExprString() + " IS NULL)"
+}
+
 type CallExpr struct {
 	Function Expression
 	Args     []Expression
+	// Distinct is set by `fn(DISTINCT x)`, the function only sees distinct values
+	Distinct bool
 }
 
 func (ce *CallExpr) ToExprString() string {
@@ -102,9 +136,22 @@ func (ce *CallExpr) ToExprString() string {
 	for _, arg := range ce.Args {
 		args = append(args, arg.ToExprString())
 	}
+	if ce.Distinct {
+		return ce.Function.ToExprString() + "(DISTINCT " + strings.Join(args, ", ") + ")"
+	}
 	return ce.Function.ToExprString() + "(" + strings.Join(args, ", ") + ")"
 }
 
+// StarExpr stands for all the columns, or all the columns of Table when it
+// is set, as in `SELECT *` and `COUNT(*)`.
+type StarExpr struct {
+	Table string
+}
+
+func (se *StarExpr) ToExprString() string {
+	return qualifiedName(se.Table, "*")
+}
+
 type Statement interface {
 	ToStmtString() string
 }
@@ -144,9 +191,28 @@ type ColumnDef struct {
 	Name       string
 	DataType   types.DataType // int, float, bool, text
 	Constraint Constraint
+	Default    Expression
+}
+
+func (cd *ColumnDef) ToDefString() string {
+	def := cd.Name + " " + cd.DataType.String()
+	if cd.Constraint.PrimaryKey {
+		def += " PRIMARY KEY"
+	}
+	if cd.Constraint.Unique {
+		def += " UNIQUE"
+	}
+	if cd.Constraint.NotNull {
+		def += " NOT NULL"
+	}
+	if cd.Default != nil {
+		def += " DEFAULT " + cd.Default.ToExprString()
+	}
+	return def
 }
 
 type CreateTableStatement struct {
+	SchemaName  string
 	TableName   string
 	Columns     []ColumnDef
 	IfNotExists bool
@@ -154,19 +220,9 @@ type CreateTableStatement struct {
 }
 
 func (cts *CreateTableStatement) ToStmtString() string {
-	stmt := "CREATE TABLE " + cts.TableName + " ("
+	stmt := "CREATE TABLE " + qualifiedName(cts.SchemaName, cts.TableName) + " ("
 	for i, col := range cts.Columns {
-		stmt += col.Name + " " + col.DataType.String()
-		if col.Constraint.PrimaryKey {
-			stmt += " PRIMARY KEY"
-		}
-		if col.Constraint.Unique {
-			stmt += " UNIQUE"
-		}
-		if col.Constraint.NotNull {
-			stmt += " NOT NULL"
-		}
-
+		stmt += col.ToDefString()
 		if i < len(cts.Columns)-1 {
 			stmt += ", "
 		}
@@ -181,25 +237,384 @@ func (cts *CreateTableStatement) ToStmtString() string {
 }
 
 type DropTableStatement struct {
-	TableName string
+	SchemaName string
+	TableName  string
 }
 
 func (dts *DropTableStatement) ToStmtString() string {
-	return "DROP TABLE " + dts.TableName + ";"
+	return "DROP TABLE " + qualifiedName(dts.SchemaName, dts.TableName) + ";"
+}
+
+type AlterTableAction int
+
+const (
+	ALTER_ADD_COLUMN AlterTableAction = iota + 1
+	ALTER_DROP_COLUMN
+	ALTER_RENAME_COLUMN
+	ALTER_RENAME_TABLE
+	ALTER_SET_NOT_NULL
+	ALTER_DROP_NOT_NULL
+	ALTER_SET_DEFAULT
+	ALTER_DROP_DEFAULT
+)
+
+// AlterTableStatement holds a single action, the fields used depend on it:
+// Column for ADD COLUMN, NewName for the renames, Default for SET DEFAULT
+// and ColumnName for every action on an existing column.
+type AlterTableStatement struct {
+	SchemaName string
+	TableName  string
+	Action     AlterTableAction
+	ColumnName string
+	Column     ColumnDef
+	NewName    string
+	Default    Expression
+}
+
+func (ats *AlterTableStatement) ToStmtString() string {
+	stmt := "ALTER TABLE " + qualifiedName(ats.SchemaName, ats.TableName) + " "
+	switch ats.Action {
+	case ALTER_ADD_COLUMN:
+		stmt += "ADD COLUMN " + ats.Column.ToDefString()
+	case ALTER_DROP_COLUMN:
+		stmt += "DROP COLUMN " + ats.ColumnName
+	case ALTER_RENAME_COLUMN:
+		stmt += "RENAME COLUMN " + ats.ColumnName + " TO " + ats.NewName
+	case ALTER_RENAME_TABLE:
+		stmt += "RENAME TO " + ats.NewName
+	case ALTER_SET_NOT_NULL:
+		stmt += "ALTER COLUMN " + ats.ColumnName + " SET NOT NULL"
+	case ALTER_DROP_NOT_NULL:
+		stmt += "ALTER COLUMN " + ats.ColumnName + " DROP NOT NULL"
+	case ALTER_SET_DEFAULT:
+		stmt += "ALTER COLUMN " + ats.ColumnName + " SET DEFAULT " + ats.Default.ToExprString()
+	case ALTER_DROP_DEFAULT:
+		stmt += "ALTER COLUMN " + ats.ColumnName + " DROP DEFAULT"
+	}
+	return stmt + ";"
+}
+
+// Password is nil when the user has none, it is never printed back
+type CreateUserStatement struct {
+	UserName string
+	Password *string
+}
+
+func (cus *CreateUserStatement) ToStmtString() string {
+	return "CREATE USER " + cus.UserName + passwordClause(cus.Password) + ";"
+}
+
+type DropUserStatement struct {
+	UserName string
+}
+
+func (dus *DropUserStatement) ToStmtString() string {
+	return "DROP USER " + dus.UserName + ";"
+}
+
+// AlterUserStatement sets the password, nil removes it
+type AlterUserStatement struct {
+	UserName string
+	Password *string
+}
+
+func (aus *AlterUserStatement) ToStmtString() string {
+	return "ALTER USER " + aus.UserName + passwordClause(aus.Password) + ";"
+}
+
+func passwordClause(password *string) string {
+	if password == nil {
+		return " PASSWORD NULL"
+	}
+	return " PASSWORD \"********\""
+}
+
+// SetStatement changes a setting of the session, values are kept as written
+type SetStatement struct {
+	Name   string
+	Values []string
+}
+
+func (ss *SetStatement) ToStmtString() string {
+	values := make([]string, len(ss.Values))
+	for i, value := range ss.Values {
+		values[i] = "\"" + value + "\""
+	}
+	return "SET " + ss.Name + " = " + strings.Join(values, ", ") + ";"
+}
+
+// ShowStatement prints a setting, or all of them when Name is "all"
+type ShowStatement struct {
+	Name string
+}
+
+func (ss *ShowStatement) ToStmtString() string {
+	return "SHOW " + ss.Name + ";"
+}
+
+// ResetStatement sets a setting back to its default, or all of them when Name is "all"
+type ResetStatement struct {
+	Name string
+}
+
+func (rs *ResetStatement) ToStmtString() string {
+	return "RESET " + rs.Name + ";"
+}
+
+type TransactionAction int
+
+const (
+	TRANSACTION_BEGIN TransactionAction = iota + 1
+	TRANSACTION_COMMIT
+	TRANSACTION_ROLLBACK
+	TRANSACTION_SAVEPOINT
+	TRANSACTION_RELEASE
+	TRANSACTION_ROLLBACK_TO
+	// SET TRANSACTION changes the isolation level of the open transaction
+	TRANSACTION_SET
+)
+
+// TransactionStatement starts or ends the explicit transaction of a
+// session, Savepoint is the name used by the savepoint actions. The
+// IsolationLevel of BEGIN and SET TRANSACTION is lower case, such as
+// "repeatable read", and empty when BEGIN has none.
+type TransactionStatement struct {
+	Action         TransactionAction
+	Savepoint      string
+	IsolationLevel string
+}
+
+func (ts *TransactionStatement) ToStmtString() string {
+	switch ts.Action {
+	case TRANSACTION_BEGIN:
+		if ts.IsolationLevel != "" {
+			return "BEGIN ISOLATION LEVEL " + strings.ToUpper(ts.IsolationLevel) + ";"
+		}
+		return "BEGIN;"
+	case TRANSACTION_SET:
+		return "SET TRANSACTION ISOLATION LEVEL " + strings.ToUpper(ts.IsolationLevel) + ";"
+	case TRANSACTION_COMMIT:
+		return "COMMIT;"
+	case TRANSACTION_SAVEPOINT:
+		return "SAVEPOINT " + ts.Savepoint + ";"
+	case TRANSACTION_RELEASE:
+		return "RELEASE SAVEPOINT " + ts.Savepoint + ";"
+	case TRANSACTION_ROLLBACK_TO:
+		return "ROLLBACK TO SAVEPOINT " + ts.Savepoint + ";"
+	default:
+		return "ROLLBACK;"
+	}
+}
+
+type CreateIndexStatement struct {
+	IndexName  string
+	SchemaName string
+	TableName  string
+	Columns    []string
+	Unique     bool
+}
+
+func (cis *CreateIndexStatement) ToStmtString() string {
+	stmt := "CREATE "
+	if cis.Unique {
+		stmt += "UNIQUE "
+	}
+	stmt += "INDEX " + cis.IndexName + " ON " + qualifiedName(cis.SchemaName, cis.TableName)
+	stmt += " (" + strings.Join(cis.Columns, ", ") + ");"
+	return stmt
+}
+
+type DropIndexStatement struct {
+	SchemaName string
+	IndexName  string
+}
+
+func (dis *DropIndexStatement) ToStmtString() string {
+	return "DROP INDEX " + qualifiedName(dis.SchemaName, dis.IndexName) + ";"
 }
 
 type InsertStatement struct {
-	TableName string
-	Columns   []string
-	Values    [][]Expression
+	SchemaName string
+	TableName  string
+	Columns    []string
+	Values     [][]Expression
+}
+
+func (is *InsertStatement) ToStmtString() string {
+	stmt := "INSERT INTO " + qualifiedName(is.SchemaName, is.TableName)
+	if len(is.Columns) > 0 {
+		stmt += " (" + strings.Join(is.Columns, ", ") + ")"
+	}
+
+	rows := make([]string, 0, len(is.Values))
+	for _, row := range is.Values {
+		values := make([]string, 0, len(row))
+		for _, value := range row {
+			values = append(values, value.ToExprString())
+		}
+		rows = append(rows, "("+strings.Join(values, ", ")+")")
+	}
+	stmt += " VALUES " + strings.Join(rows, ", ") + ";"
+	return stmt
+}
+
+type Assignment struct {
+	Column string
+	Value  Expression
+}
+
+type UpdateStatement struct {
+	SchemaName  string
+	TableName   string
+	Assignments []Assignment
+	WhereClause Expression
+}
+
+func (us *UpdateStatement) ToStmtString() string {
+	assignments := make([]string, 0, len(us.Assignments))
+	for _, assignment := range us.Assignments {
+		assignments = append(assignments, assignment.Column+" = "+assignment.Value.ToExprString())
+	}
+
+	stmt := "UPDATE " + qualifiedName(us.SchemaName, us.TableName) + " SET " + strings.Join(assignments, ", ")
+	if us.WhereClause != nil {
+		stmt += " WHERE " + us.WhereClause.ToExprString()
+	}
+	stmt += ";"
+	return stmt
+}
+
+type DeleteStatement struct {
+	SchemaName  string
+	TableName   string
+	WhereClause Expression
+}
+
+func (ds *DeleteStatement) ToStmtString() string {
+	stmt := "DELETE FROM " + qualifiedName(ds.SchemaName, ds.TableName)
+	if ds.WhereClause != nil {
+		stmt += " WHERE " + ds.WhereClause.ToExprString()
+	}
+	stmt += ";"
+	return stmt
 }
 
 type SelectStatement struct {
-	Columns     []string
-	FromClause  string
+	Columns    []Expression
+	SchemaName string
+	FromClause string
+	// Alias names the FROM clause table in the query, empty when it has none
+	Alias string
+	// tables joined to the FROM clause table, in order
+	Joins       []JoinClause
 	WhereClause Expression
 	GroupBy     []Expression
+	Having      Expression
 	OrderBy     []SortExpr
-	Limit       uint64
+	Limit       *uint64
 	Offset      uint64
 }
+
+func (ss *SelectStatement) ToStmtString() string {
+	stmt := "SELECT " + expressionList(ss.Columns)
+	stmt += " FROM " + tableReference(ss.SchemaName, ss.FromClause, ss.Alias)
+	for _, join := range ss.Joins {
+		stmt += " " + join.ToExprString()
+	}
+	if ss.WhereClause != nil {
+		stmt += " WHERE " + ss.WhereClause.ToExprString()
+	}
+	if len(ss.GroupBy) > 0 {
+		stmt += " GROUP BY " + expressionList(ss.GroupBy)
+	}
+	if ss.Having != nil {
+		stmt += " HAVING " + ss.Having.ToExprString()
+	}
+
+	if len(ss.OrderBy) > 0 {
+		sortExprs := make([]string, 0, len(ss.OrderBy))
+		for _, sortExpr := range ss.OrderBy {
+			sortExprs = append(sortExprs, sortExpr.ToExprString())
+		}
+		stmt += " ORDER BY " + strings.Join(sortExprs, ", ")
+	}
+
+	if ss.Limit != nil {
+		stmt += fmt.Sprintf(" LIMIT %d", *ss.Limit)
+	}
+	if ss.Offset > 0 {
+		stmt += fmt.Sprintf(" OFFSET %d", ss.Offset)
+	}
+	stmt += ";"
+	return stmt
+}
+
+type JoinType int
+
+const (
+	JOIN_INNER JoinType = iota
+	JOIN_LEFT
+	JOIN_RIGHT
+	JOIN_FULL
+	JOIN_CROSS
+)
+
+func (jt JoinType) String() string {
+	switch jt {
+	case JOIN_LEFT:
+		return "LEFT JOIN"
+	case JOIN_RIGHT:
+		return "RIGHT JOIN"
+	case JOIN_FULL:
+		return "FULL JOIN"
+	case JOIN_CROSS:
+		return "CROSS JOIN"
+	default:
+		return "JOIN"
+	}
+}
+
+// JoinClause joins a table to the tables that precede it in a FROM clause
+type JoinClause struct {
+	Type       JoinType
+	SchemaName string
+	TableName  string
+	Alias      string
+	// On is the join condition, nil for CROSS joins and the joins on the
+	// USING columns
+	On    Expression
+	Using []string
+}
+
+func (jc *JoinClause) ToExprString() string {
+	clause := jc.Type.String() + " " + tableReference(jc.SchemaName, jc.TableName, jc.Alias)
+	if jc.On != nil {
+		clause += " ON " + jc.On.ToExprString()
+	}
+	if len(jc.Using) > 0 {
+		clause += " USING (" + strings.Join(jc.Using, ", ") + ")"
+	}
+	return clause
+}
+
+func expressionList(exprs []Expression) string {
+	list := make([]string, len(exprs))
+	for i, expr := range exprs {
+		list[i] = expr.ToExprString()
+	}
+	return strings.Join(list, ", ")
+}
+
+func tableReference(schemaName string, tableName string, alias string) string {
+	if alias == "" {
+		return qualifiedName(schemaName, tableName)
+	}
+	return qualifiedName(schemaName, tableName) + " AS " + alias
+}
+
+func qualifiedName(schemaName string, objectName string) string {
+	if schemaName == "" {
+		return objectName
+	}
+	return schemaName + "." + objectName
+}
@@ -1,6 +1,10 @@
 package parser
 
-import "github.com/evanxg852000/foxdb/internal/query/parser/token"
+import (
+	"strings"
+
+	"github.com/evanxg852000/foxdb/internal/query/parser/token"
+)
 
 type Lexer struct {
 	input string
@@ -84,10 +88,27 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.POUND, l.ch)
 	case '?':
 		tok = newToken(token.QUESTION, l.ch)
+	case '$':
+		if !isDigit(l.peekChar()) {
+			tok = newToken(token.ILLEGAL, l.ch)
+			break
+		}
+		l.consumeChar()
+		tok.Literal = l.readDigits()
+		tok.Type = token.PARAM
+		return tok
 	case '&':
 		tok = newToken(token.AMPERSAND, l.ch)
-	case '"':
-		tok.Literal = l.readString()
+	case '|':
+		if l.peekChar() == '|' {
+			ch := l.ch
+			l.consumeChar()
+			tok = newToken(token.CONCAT, string(ch)+string(l.ch))
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+	case '"', '\'':
+		tok.Literal = l.readString(l.ch)
 		tok.Type = token.STRING
 	case 0:
 		tok.Literal = ""
@@ -132,14 +153,22 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
-func (l *Lexer) readString() string {
+// readString reads a literal quoted with quote, inside it
+// a doubled quote stands for the quote character itself.
+func (l *Lexer) readString(quote byte) string {
 	l.consumeChar() // skip opening quote
-	start := l.curr
-	for l.ch != '"' && l.ch != 0 {
+	var str strings.Builder
+	for l.ch != 0 {
+		if l.ch == quote {
+			if l.peekChar() != quote {
+				break
+			}
+			l.consumeChar()
+		}
+		str.WriteByte(l.ch)
 		l.consumeChar()
 	}
-	str := l.input[start:l.curr]
-	return str
+	return str.String()
 }
 
 func (l *Lexer) readIdentifier() string {
@@ -150,11 +179,17 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[start:l.curr]
 }
 
-func (l *Lexer) readNumber() string {
+func (l *Lexer) readDigits() string {
 	start := l.curr
 	for isDigit(l.ch) {
 		l.consumeChar()
 	}
+	return l.input[start:l.curr]
+}
+
+func (l *Lexer) readNumber() string {
+	start := l.curr
+	l.readDigits()
 	if l.ch == '.' {
 		l.consumeChar()
 		for isDigit(l.ch) {
@@ -49,7 +49,7 @@ func TestLexerNextToken(t *testing.T) {
 }
 
 func TestLexerComparisonOperators(t *testing.T) {
-	input := `<= >= != <>`
+	input := `<= >= != <> ||`
 
 	tests := []struct {
 		expectedType    token.TokenType
@@ -59,6 +59,7 @@ func TestLexerComparisonOperators(t *testing.T) {
 		{token.GT_EQ, ">="},
 		{token.NOT_EQ, "!="},
 		{token.NOT_EQ, "<>"},
+		{token.CONCAT, "||"},
 		{token.EOF, ""},
 	}
 
@@ -143,7 +144,7 @@ func TestLexerNumbers(t *testing.T) {
 }
 
 func TestLexerStrings(t *testing.T) {
-	input := `"hello" "world with spaces" "special !@#$ chars" ""`
+	input := `"hello" "world with spaces" "special !@#$ chars" "" 'single' 'it''s' "say ""hi"""`
 
 	tests := []struct {
 		expectedType    token.TokenType
@@ -153,6 +154,9 @@ func TestLexerStrings(t *testing.T) {
 		{token.STRING, "world with spaces"},
 		{token.STRING, "special !@#$ chars"},
 		{token.STRING, ""},
+		{token.STRING, "single"},
+		{token.STRING, "it's"},
+		{token.STRING, `say "hi"`},
 		{token.EOF, ""},
 	}
 
@@ -219,13 +223,13 @@ func TestLexerComplexSQL(t *testing.T) {
 	}{
 		{token.SELECT, "select"},
 		{token.ASTERISK, "*"},
-		{token.IDENT, "FROM"},
+		{token.FROM, "FROM"},
 		{token.IDENT, "users"},
-		{token.IDENT, "WHERE"},
+		{token.WHERE, "WHERE"},
 		{token.IDENT, "age"},
 		{token.GT_EQ, ">="},
 		{token.INT, "18"},
-		{token.IDENT, "AND"},
+		{token.AND, "AND"},
 		{token.IDENT, "name"},
 		{token.NOT_EQ, "!="},
 		{token.STRING, "admin"},
@@ -293,6 +297,35 @@ func TestLexerSingleCharacter(t *testing.T) {
 	}
 }
 
+func TestLexerParameters(t *testing.T) {
+	input := `id = $1 AND name = $12 OR ? $`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "id"},
+		{token.EQ, "="},
+		{token.PARAM, "1"},
+		{token.AND, "AND"},
+		{token.IDENT, "name"},
+		{token.EQ, "="},
+		{token.PARAM, "12"},
+		{token.OR, "OR"},
+		{token.QUESTION, "?"},
+		{token.ILLEGAL, "$"},
+		{token.EOF, ""},
+	}
+
+	l := NewLexer(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+		assert.Equal(t, tt.expectedType, tok.Type, "test[%d] - unexpected token type", i)
+		assert.Equal(t, tt.expectedLiteral, tok.Literal, "test[%d] - unexpected token literal", i)
+	}
+}
+
 func TestLexerUnterminatedString(t *testing.T) {
 	input := `"unterminated string`
 	l := NewLexer(input)
@@ -344,8 +377,8 @@ func TestLookupIdentifier(t *testing.T) {
 		{"foo", token.IDENT},
 		{"bar", token.IDENT},
 		{"variable_name", token.IDENT},
-		{"TRUE", token.IDENT},   // case sensitive
-		{"SELECT", token.IDENT}, // case sensitive
+		{"TRUE", token.TRUE},     // case insensitive
+		{"SELECT", token.SELECT}, // case insensitive
 	}
 
 	for _, tt := range tests {
@@ -20,8 +20,26 @@ func parsePrefixExpression(p *Parser) ast.Expression {
 	return expression
 }
 
+// a column name, possibly qualified by its table as in `table.column`, or
+// all the columns of a table as in `table.*`
 func parseIdentifier(p *Parser) ast.Expression {
-	return &ast.IdentifierExpr{Value: p.currentToken.Literal}
+	identifier := &ast.IdentifierExpr{Value: p.currentToken.Literal}
+	if p.peekTokenIs(token.DOT) {
+		p.nextToken() // move to '.'
+		if p.peekTokenIs(token.ASTERISK) {
+			p.nextToken() // move to '*'
+			return &ast.StarExpr{Table: identifier.Value}
+		}
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		identifier.Table, identifier.Value = identifier.Value, p.currentToken.Literal
+	}
+	return identifier
+}
+
+func parseStar(p *Parser) ast.Expression {
+	return &ast.StarExpr{}
 }
 
 func parseLiteralValue(p *Parser) ast.Expression {
@@ -54,6 +72,28 @@ func parseLiteralValue(p *Parser) ast.Expression {
 	return nil
 }
 
+// $n placeholders carry their index, ? placeholders are numbered in order
+func parseParameter(p *Parser) ast.Expression {
+	numbered := p.currentTokenIs(token.PARAM)
+	if p.parameterCount > 0 && numbered != p.numbered {
+		p.errors = append(p.errors, "cannot mix $n and ? parameters")
+		return nil
+	}
+	p.numbered = numbered
+	p.parameterCount++
+
+	if !numbered {
+		return &ast.ParameterExpr{Index: p.parameterCount}
+	}
+
+	index, err := strconv.Atoi(p.currentToken.Literal)
+	if err != nil || index < 1 {
+		p.errors = append(p.errors, "invalid parameter $"+p.currentToken.Literal)
+		return nil
+	}
+	return &ast.ParameterExpr{Index: index}
+}
+
 func parseGroupedExpression(p *Parser) ast.Expression {
 	p.nextToken()
 
@@ -81,8 +121,28 @@ func parseInfixExpression(p *Parser, left ast.Expression) ast.Expression {
 	return expression
 }
 
+func parseIsNullExpression(p *Parser, left ast.Expression) ast.Expression {
+	expression := &ast.IsNullExpr{Expr: left}
+	if p.peekTokenIs(token.NOT) {
+		p.nextToken()
+		expression.Not = true
+	}
+
+	if !p.expectPeek(token.NULL) {
+		return nil
+	}
+	return expression
+}
+
 func parseCallExpression(p *Parser, function ast.Expression) ast.Expression {
 	exp := &ast.CallExpr{Function: function}
+	if p.peekWordIs("DISTINCT") {
+		p.nextToken() // move to 'DISTINCT'
+		exp.Distinct = true
+	}
 	exp.Args = p.parseExpressionList(token.RPAREN)
+	if exp.Args == nil {
+		return nil
+	}
 	return exp
 }
@@ -2,6 +2,9 @@ package parser
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
 	"github.com/evanxg852000/foxdb/internal/query/parser/token"
@@ -13,7 +16,8 @@ const (
 	_ int = iota
 	LOWEST
 	AND_OR      // AND, OR
-	COMP        // ==, !=, <, >=, >, <=
+	COMP        // ==, !=, <, >=, >, <=, IS
+	CONCAT      // ||
 	SUM         // +, -
 	PRODUCT     // *, /
 	PREFIX      // -x, !x
@@ -30,10 +34,13 @@ var precedencesTable = map[token.TokenType]int{
 	token.LT_EQ:    COMP,
 	token.GT:       COMP,
 	token.GT_EQ:    COMP,
+	token.IS:       COMP,
+	token.CONCAT:   CONCAT,
 	token.PLUS:     SUM,
 	token.MINUS:    SUM,
 	token.ASTERISK: PRODUCT,
 	token.SLASH:    PRODUCT,
+	token.LPAREN:   CALL,
 }
 
 type prefixParseFn func(p *Parser) ast.Expression
@@ -46,6 +53,9 @@ type Parser struct {
 	peekToken      token.Token
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+	// placeholders seen so far, they are either all $n or all ?
+	parameterCount int
+	numbered       bool
 }
 
 func NewParser(lexer *Lexer) *Parser {
@@ -67,11 +77,15 @@ func NewParser(lexer *Lexer) *Parser {
 	parser.prefixParseFns[token.MINUS] = parsePrefixExpression
 	parser.prefixParseFns[token.NOT] = parsePrefixExpression
 	parser.prefixParseFns[token.LPAREN] = parseGroupedExpression
+	parser.prefixParseFns[token.PARAM] = parseParameter
+	parser.prefixParseFns[token.QUESTION] = parseParameter
+	parser.prefixParseFns[token.ASTERISK] = parseStar
 
 	parser.infixParseFns[token.PLUS] = parseInfixExpression
 	parser.infixParseFns[token.MINUS] = parseInfixExpression
 	parser.infixParseFns[token.ASTERISK] = parseInfixExpression
 	parser.infixParseFns[token.SLASH] = parseInfixExpression
+	parser.infixParseFns[token.CONCAT] = parseInfixExpression
 	parser.infixParseFns[token.EQ] = parseInfixExpression
 	parser.infixParseFns[token.NOT_EQ] = parseInfixExpression
 	parser.infixParseFns[token.LT] = parseInfixExpression
@@ -80,6 +94,7 @@ func NewParser(lexer *Lexer) *Parser {
 	parser.infixParseFns[token.GT_EQ] = parseInfixExpression
 	parser.infixParseFns[token.AND] = parseInfixExpression
 	parser.infixParseFns[token.OR] = parseInfixExpression
+	parser.infixParseFns[token.IS] = parseIsNullExpression
 	parser.infixParseFns[token.LPAREN] = parseCallExpression
 
 	// Read two tokens, so currentToken and peekToken are both set
@@ -151,14 +166,29 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseCreateStatement()
 	case token.DROP:
 		return p.parseDropStatement()
-	// case token.INSERT:
-	// 	return p.parseInsertStatement()
-	// case token.SELECT:
-	// 	return p.parseSelectStatement()
-	// case token.UPDATE:
-	// 	return p.parseUpdateStatement()
-	// case token.DELETE:
-	// 	return p.parseDeleteStatement()
+	case token.INSERT:
+		return p.parseInsertStatement()
+	case token.SELECT:
+		return p.parseSelectStatement()
+	case token.UPDATE:
+		return p.parseUpdateStatement()
+	case token.DELETE:
+		return p.parseDeleteStatement()
+	case token.ALTER:
+		return p.parseAlterStatement()
+	case token.SET:
+		return p.parseSetStatement()
+	case token.IDENT:
+		if p.currentWordIs("SHOW") {
+			return p.parseShowStatement()
+		}
+		if p.currentWordIs("RESET") {
+			return p.parseResetStatement()
+		}
+		if action, ok := transactionActions[strings.ToUpper(p.currentToken.Literal)]; ok {
+			return p.parseTransactionStatement(action)
+		}
+		fallthrough
 	default:
 		p.errors = append(p.errors, fmt.Sprintf("unknown statement: %s", p.currentToken.Type))
 		return nil
@@ -173,9 +203,21 @@ func (p *Parser) parseCreateStatement() ast.Statement {
 	case token.TABLE:
 		return p.parseCreateTableStatement()
 	case token.INDEX:
-		return p.parseCreateIndexStatement()
+		return p.parseCreateIndexStatement(false)
+	case token.UNIQUE:
+		p.nextToken() // consume 'UNIQUE'
+		if !p.currentTokenIs(token.INDEX) {
+			p.currentTokenError(token.INDEX)
+			return nil
+		}
+		return p.parseCreateIndexStatement(true)
+	case token.IDENT:
+		if p.currentWordIs("USER") {
+			return p.parseCreateUserStatement()
+		}
+		fallthrough
 	default:
-		p.errors = append(p.errors, fmt.Sprintf("expected SCHEMA, TABLE, or INDEX after CREATE, got %s instead", p.currentToken.Type))
+		p.errors = append(p.errors, fmt.Sprintf("expected SCHEMA, TABLE, INDEX or USER after CREATE, got %s instead", p.currentToken.Type))
 		return nil
 	}
 }
@@ -227,73 +269,24 @@ func (p *Parser) parseCreateTableStatement() ast.Statement {
 		p.errors = append(p.errors, fmt.Sprintf("expected table name after CREATE TABLE, got %s instead", p.currentToken.Type))
 		return nil
 	}
-	tableName := p.currentToken.Literal
-	p.nextToken() // consume table name
+	schemaName, tableName, ok := p.parseQualifiedName()
+	if !ok {
+		return nil
+	}
 
 	if !p.currentTokenIs(token.LPAREN) {
 		p.errors = append(p.errors, fmt.Sprintf("expected '(' after table name, got %s instead", p.currentToken.Type))
 		return nil
 	}
+	p.nextToken() // consume '('
 
 	columns := []ast.ColumnDef{}
 
 	for p.currentToken.Type != token.RPAREN {
-		if p.currentToken.Type != token.IDENT {
-			p.errors = append(p.errors, fmt.Sprintf("expected column name, got %s instead", p.currentToken.Type))
-			return nil
-		}
-		columnName := p.currentToken.Literal
-		p.nextToken() // consume column name
-
-		var dataType types.DataType
-		switch p.currentToken.Type {
-		case token.INT_TYPE:
-			dataType = types.TYPE_INT
-		case token.FLOAT_TYPE:
-			dataType = types.TYPE_FLOAT
-		case token.BOOL_TYPE:
-			dataType = types.TYPE_BOOL
-		case token.TEXT_TYPE:
-			dataType = types.TYPE_TEXT
-		default:
-			p.errors = append(p.errors, fmt.Sprintf("expected data type for column %s, got %s instead", columnName, p.currentToken.Type))
+		columnDef, ok := p.parseColumnDef()
+		if !ok {
 			return nil
 		}
-		p.nextToken() // consume data type
-
-		constraint := ast.Constraint{}
-		for p.currentToken.Type != token.COMMA && p.currentToken.Type != token.RPAREN {
-			if p.currentToken.Type == token.PRIMARY {
-				p.nextToken() // consume PRIMARY
-				if p.currentToken.Type != token.KEY {
-					p.errors = append(p.errors, fmt.Sprintf("expected KEY after PRIMARY, got %s instead", p.currentToken.Type))
-					return nil
-				}
-				constraint.PrimaryKey = true
-				p.nextToken() // consume KEY
-			}
-
-			if p.currentToken.Type == token.NOT {
-				p.nextToken() // consume NOT
-				if p.currentToken.Type != token.NULL {
-					p.errors = append(p.errors, fmt.Sprintf("expected NULL after NOT, got %s instead", p.currentToken.Type))
-					return nil
-				}
-				constraint.NotNull = true
-				p.nextToken() // consume NULL
-			}
-
-			if p.currentToken.Type == token.UNIQUE {
-				constraint.Unique = true
-				p.nextToken() // consume UNIQUE
-			}
-		}
-
-		columnDef := ast.ColumnDef{
-			Name:       columnName,
-			DataType:   dataType,
-			Constraint: constraint,
-		}
 		columns = append(columns, columnDef)
 
 		if p.currentToken.Type == token.COMMA {
@@ -308,6 +301,7 @@ func (p *Parser) parseCreateTableStatement() ast.Statement {
 		p.errors = append(p.errors, fmt.Sprintf("expected closing parenthesis, got %s instead", p.currentToken.Type))
 		return nil
 	}
+	p.nextToken() // consume ')'
 
 	if !p.currentTokenIs(token.SEMICOLON) {
 		p.errors = append(p.errors, fmt.Sprintf("expected SEMICOLON after CREATE TABLE, got %s instead", p.currentToken.Type))
@@ -315,13 +309,146 @@ func (p *Parser) parseCreateTableStatement() ast.Statement {
 	}
 
 	return &ast.CreateTableStatement{
-		TableName: tableName,
-		Columns:   columns,
+		SchemaName: schemaName,
+		TableName:  tableName,
+		Columns:    columns,
 	}
 }
 
-func (p *Parser) parseCreateIndexStatement() ast.Statement {
-	panic("CREATE INDEX not implemented yet")
+// parses `name type [constraints...]` and leaves the parser on the
+// token following the column definition.
+func (p *Parser) parseColumnDef() (ast.ColumnDef, bool) {
+	if p.currentToken.Type != token.IDENT {
+		p.errors = append(p.errors, fmt.Sprintf("expected column name, got %s instead", p.currentToken.Type))
+		return ast.ColumnDef{}, false
+	}
+	columnDef := ast.ColumnDef{Name: p.currentToken.Literal}
+	p.nextToken() // consume column name
+
+	switch p.currentToken.Type {
+	case token.INT_TYPE:
+		columnDef.DataType = types.TYPE_INT
+	case token.FLOAT_TYPE:
+		columnDef.DataType = types.TYPE_FLOAT
+	case token.BOOL_TYPE:
+		columnDef.DataType = types.TYPE_BOOL
+	case token.TEXT_TYPE:
+		columnDef.DataType = types.TYPE_TEXT
+	default:
+		p.errors = append(p.errors, fmt.Sprintf("expected data type for column %s, got %s instead", columnDef.Name, p.currentToken.Type))
+		return ast.ColumnDef{}, false
+	}
+	p.nextToken() // consume data type
+
+	for !p.currentTokenIs(token.COMMA) && !p.currentTokenIs(token.RPAREN) && !p.currentTokenIs(token.SEMICOLON) {
+		switch p.currentToken.Type {
+		case token.PRIMARY:
+			p.nextToken() // consume PRIMARY
+			if p.currentToken.Type != token.KEY {
+				p.errors = append(p.errors, fmt.Sprintf("expected KEY after PRIMARY, got %s instead", p.currentToken.Type))
+				return ast.ColumnDef{}, false
+			}
+			columnDef.Constraint.PrimaryKey = true
+			p.nextToken() // consume KEY
+		case token.NOT:
+			p.nextToken() // consume NOT
+			if p.currentToken.Type != token.NULL {
+				p.errors = append(p.errors, fmt.Sprintf("expected NULL after NOT, got %s instead", p.currentToken.Type))
+				return ast.ColumnDef{}, false
+			}
+			columnDef.Constraint.NotNull = true
+			p.nextToken() // consume NULL
+		case token.UNIQUE:
+			columnDef.Constraint.Unique = true
+			p.nextToken() // consume UNIQUE
+		case token.DEFAULT:
+			p.nextToken() // consume DEFAULT
+			columnDef.Default = p.parseExpression(LOWEST)
+			if columnDef.Default == nil {
+				return ast.ColumnDef{}, false
+			}
+			p.nextToken() // consume last token of the expression
+		default:
+			p.errors = append(p.errors, fmt.Sprintf("unexpected %s in definition of column %s", p.currentToken.Type, columnDef.Name))
+			return ast.ColumnDef{}, false
+		}
+	}
+	return columnDef, true
+}
+
+func (p *Parser) parseCreateIndexStatement(unique bool) ast.Statement {
+	p.nextToken() // consume 'INDEX'
+
+	if !p.currentTokenIs(token.IDENT) {
+		p.errors = append(p.errors, fmt.Sprintf("expected index name after CREATE INDEX, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	indexName := p.currentToken.Literal
+	p.nextToken() // consume index name
+
+	if !p.currentTokenIs(token.ON) {
+		p.currentTokenError(token.ON)
+		return nil
+	}
+	p.nextToken() // consume 'ON'
+
+	if !p.currentTokenIs(token.IDENT) {
+		p.errors = append(p.errors, fmt.Sprintf("expected table name after ON, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	schemaName, tableName, ok := p.parseQualifiedName()
+	if !ok {
+		return nil
+	}
+
+	columns, ok := p.parseColumnNameList()
+	if !ok {
+		return nil
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after CREATE INDEX, got %s instead", p.currentToken.Type))
+		return nil
+	}
+
+	return &ast.CreateIndexStatement{
+		IndexName:  indexName,
+		SchemaName: schemaName,
+		TableName:  tableName,
+		Columns:    columns,
+		Unique:     unique,
+	}
+}
+
+// parses `(col1, col2, ...)` and leaves the parser on the token following ')'
+func (p *Parser) parseColumnNameList() ([]string, bool) {
+	if !p.currentTokenIs(token.LPAREN) {
+		p.currentTokenError(token.LPAREN)
+		return nil, false
+	}
+	p.nextToken() // consume '('
+
+	columns := []string{}
+	for {
+		if !p.currentTokenIs(token.IDENT) {
+			p.errors = append(p.errors, fmt.Sprintf("expected column name, got %s instead", p.currentToken.Type))
+			return nil, false
+		}
+		columns = append(columns, p.currentToken.Literal)
+		p.nextToken() // consume column name
+
+		if !p.currentTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken() // consume ','
+	}
+
+	if !p.currentTokenIs(token.RPAREN) {
+		p.currentTokenError(token.RPAREN)
+		return nil, false
+	}
+	p.nextToken() // consume ')'
+	return columns, true
 }
 
 func (p *Parser) parseDropStatement() ast.Statement {
@@ -331,8 +458,13 @@ func (p *Parser) parseDropStatement() ast.Statement {
 		return p.parseDropTableStatement()
 	case token.INDEX:
 		return p.parseDropIndexStatement()
+	case token.IDENT:
+		if p.currentWordIs("USER") {
+			return p.parseDropUserStatement()
+		}
+		fallthrough
 	default:
-		p.errors = append(p.errors, fmt.Sprintf("expected TABLE after DROP, got %s instead", p.currentToken.Type))
+		p.errors = append(p.errors, fmt.Sprintf("expected TABLE, INDEX or USER after DROP, got %s instead", p.currentToken.Type))
 		return nil
 	}
 }
@@ -343,14 +475,10 @@ func (p *Parser) parseDropTableStatement() ast.Statement {
 		p.errors = append(p.errors, fmt.Sprintf("expected table name after DROP TABLE, got %s instead", p.currentToken.Type))
 		return nil
 	}
-
-	if !p.currentTokenIs(token.IDENT) {
-		p.errors = append(p.errors, fmt.Sprintf("expected table name after DROP TABLE, got %s instead", p.currentToken.Type))
+	schemaName, tableName, ok := p.parseQualifiedName()
+	if !ok {
 		return nil
 	}
-	tableName := p.currentToken.Literal
-
-	p.nextToken() // consume table name
 
 	if !p.currentTokenIs(token.SEMICOLON) {
 		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after DROP TABLE, got %s instead", p.currentToken.Type))
@@ -358,12 +486,950 @@ func (p *Parser) parseDropTableStatement() ast.Statement {
 	}
 
 	return &ast.DropTableStatement{
-		TableName: tableName,
+		SchemaName: schemaName,
+		TableName:  tableName,
 	}
 }
 
 func (p *Parser) parseDropIndexStatement() ast.Statement {
-	panic("DROP INDEX not implemented yet")
+	p.nextToken() // consume 'INDEX'
+	if !p.currentTokenIs(token.IDENT) {
+		p.errors = append(p.errors, fmt.Sprintf("expected index name after DROP INDEX, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	schemaName, indexName, ok := p.parseQualifiedName()
+	if !ok {
+		return nil
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after DROP INDEX, got %s instead", p.currentToken.Type))
+		return nil
+	}
+
+	return &ast.DropIndexStatement{
+		SchemaName: schemaName,
+		IndexName:  indexName,
+	}
+}
+
+func (p *Parser) parseAlterStatement() ast.Statement {
+	p.nextToken() // consume 'ALTER'
+	if p.currentWordIs("USER") {
+		return p.parseAlterUserStatement()
+	}
+	if !p.currentTokenIs(token.TABLE) {
+		p.currentTokenError(token.TABLE)
+		return nil
+	}
+	return p.parseAlterTableStatement()
+}
+
+func (p *Parser) parseAlterTableStatement() ast.Statement {
+	p.nextToken() // consume 'TABLE'
+
+	if !p.currentTokenIs(token.IDENT) {
+		p.errors = append(p.errors, fmt.Sprintf("expected table name after ALTER TABLE, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	schemaName, tableName, ok := p.parseQualifiedName()
+	if !ok {
+		return nil
+	}
+	stmt := &ast.AlterTableStatement{SchemaName: schemaName, TableName: tableName}
+
+	switch p.currentToken.Type {
+	case token.ADD:
+		p.nextToken() // consume 'ADD'
+		p.skipToken(token.COLUMN)
+		stmt.Action = ast.ALTER_ADD_COLUMN
+		stmt.Column, ok = p.parseColumnDef()
+		if !ok {
+			return nil
+		}
+	case token.DROP:
+		p.nextToken() // consume 'DROP'
+		p.skipToken(token.COLUMN)
+		stmt.Action = ast.ALTER_DROP_COLUMN
+		if stmt.ColumnName, ok = p.parseName(); !ok {
+			return nil
+		}
+	case token.RENAME:
+		p.nextToken() // consume 'RENAME'
+		if p.currentTokenIs(token.TO) {
+			stmt.Action = ast.ALTER_RENAME_TABLE
+		} else {
+			p.skipToken(token.COLUMN)
+			stmt.Action = ast.ALTER_RENAME_COLUMN
+			if stmt.ColumnName, ok = p.parseName(); !ok {
+				return nil
+			}
+		}
+		if !p.currentTokenIs(token.TO) {
+			p.currentTokenError(token.TO)
+			return nil
+		}
+		p.nextToken() // consume 'TO'
+		if stmt.NewName, ok = p.parseName(); !ok {
+			return nil
+		}
+	case token.ALTER:
+		p.nextToken() // consume 'ALTER'
+		p.skipToken(token.COLUMN)
+		if stmt.ColumnName, ok = p.parseName(); !ok {
+			return nil
+		}
+		if !p.parseAlterColumnAction(stmt) {
+			return nil
+		}
+	default:
+		p.errors = append(p.errors, fmt.Sprintf("expected ADD, DROP, RENAME or ALTER after table name, got %s instead", p.currentToken.Type))
+		return nil
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after ALTER TABLE, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	return stmt
+}
+
+// parses `SET NOT NULL`, `DROP NOT NULL`, `SET DEFAULT expr` or `DROP DEFAULT`
+func (p *Parser) parseAlterColumnAction(stmt *ast.AlterTableStatement) bool {
+	if !p.currentTokenIs(token.SET) && !p.currentTokenIs(token.DROP) {
+		p.errors = append(p.errors, fmt.Sprintf("expected SET or DROP after column name, got %s instead", p.currentToken.Type))
+		return false
+	}
+	set := p.currentTokenIs(token.SET)
+	p.nextToken() // consume 'SET' or 'DROP'
+
+	switch p.currentToken.Type {
+	case token.NOT:
+		if !p.expectPeek(token.NULL) {
+			return false
+		}
+		p.nextToken() // consume 'NULL'
+		stmt.Action = ast.ALTER_DROP_NOT_NULL
+		if set {
+			stmt.Action = ast.ALTER_SET_NOT_NULL
+		}
+	case token.DEFAULT:
+		p.nextToken() // consume 'DEFAULT'
+		stmt.Action = ast.ALTER_DROP_DEFAULT
+		if set {
+			stmt.Action = ast.ALTER_SET_DEFAULT
+			stmt.Default = p.parseExpression(LOWEST)
+			if stmt.Default == nil {
+				return false
+			}
+			p.nextToken() // consume last token of the expression
+		}
+	default:
+		p.errors = append(p.errors, fmt.Sprintf("expected NOT NULL or DEFAULT, got %s instead", p.currentToken.Type))
+		return false
+	}
+	return true
+}
+
+func (p *Parser) parseCreateUserStatement() ast.Statement {
+	p.nextToken() // consume 'USER'
+	userName, ok := p.parseName()
+	if !ok {
+		return nil
+	}
+
+	stmt := &ast.CreateUserStatement{UserName: userName}
+	if !p.currentTokenIs(token.SEMICOLON) {
+		if stmt.Password, ok = p.parsePasswordClause(); !ok {
+			return nil
+		}
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after CREATE USER, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	return stmt
+}
+
+func (p *Parser) parseDropUserStatement() ast.Statement {
+	p.nextToken() // consume 'USER'
+	userName, ok := p.parseName()
+	if !ok {
+		return nil
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after DROP USER, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	return &ast.DropUserStatement{UserName: userName}
+}
+
+func (p *Parser) parseAlterUserStatement() ast.Statement {
+	p.nextToken() // consume 'USER'
+	userName, ok := p.parseName()
+	if !ok {
+		return nil
+	}
+
+	stmt := &ast.AlterUserStatement{UserName: userName}
+	if stmt.Password, ok = p.parsePasswordClause(); !ok {
+		return nil
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after ALTER USER, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	return stmt
+}
+
+// parses `[WITH] PASSWORD 'secret' | NULL`, a NULL password is returned as nil
+func (p *Parser) parsePasswordClause() (*string, bool) {
+	if p.currentWordIs("WITH") {
+		p.nextToken() // consume 'WITH'
+	}
+	if !p.currentWordIs("PASSWORD") {
+		p.errors = append(p.errors, fmt.Sprintf("expected PASSWORD, got %s instead", p.currentToken.Type))
+		return nil, false
+	}
+	p.nextToken() // consume 'PASSWORD'
+
+	switch p.currentToken.Type {
+	case token.NULL:
+		p.nextToken() // consume 'NULL'
+		return nil, true
+	case token.STRING:
+		password := p.currentToken.Literal
+		p.nextToken() // consume password
+		return &password, true
+	default:
+		p.errors = append(p.errors, fmt.Sprintf("expected password string or NULL, got %s instead", p.currentToken.Type))
+		return nil, false
+	}
+}
+
+// parses `SET [SESSION] name {= | TO} {value [, ...] | DEFAULT}`, setting
+// names are case insensitive and setting DEFAULT is the same as RESET
+func (p *Parser) parseSetStatement() ast.Statement {
+	p.nextToken() // consume 'SET'
+	if p.currentWordIs("TRANSACTION") {
+		return p.parseSetTransactionStatement()
+	}
+	if p.currentWordIs("SESSION") {
+		p.nextToken() // consume 'SESSION'
+		if p.currentWordIs("CHARACTERISTICS") {
+			return p.parseSetSessionCharacteristicsStatement()
+		}
+	}
+	name, ok := p.parseName()
+	if !ok {
+		return nil
+	}
+	if !p.currentTokenIs(token.EQ) && !p.currentTokenIs(token.TO) {
+		p.errors = append(p.errors, fmt.Sprintf("expected = or TO after SET %s, got %s instead", name, p.currentToken.Type))
+		return nil
+	}
+	p.nextToken() // consume '=' or 'TO'
+
+	if p.currentTokenIs(token.DEFAULT) {
+		p.nextToken() // consume 'DEFAULT'
+		if !p.currentTokenIs(token.SEMICOLON) {
+			p.errors = append(p.errors, fmt.Sprintf("expected semicolon after SET, got %s instead", p.currentToken.Type))
+			return nil
+		}
+		return &ast.ResetStatement{Name: strings.ToLower(name)}
+	}
+
+	stmt := &ast.SetStatement{Name: strings.ToLower(name)}
+	for {
+		switch p.currentToken.Type {
+		case token.IDENT, token.STRING, token.INT, token.FLOAT, token.TRUE, token.FALSE, token.ON:
+			stmt.Values = append(stmt.Values, p.currentToken.Literal)
+		default:
+			p.errors = append(p.errors, fmt.Sprintf("expected value for SET %s, got %s instead", name, p.currentToken.Type))
+			return nil
+		}
+		p.nextToken() // consume value
+
+		if !p.currentTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken() // consume ','
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after SET, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	return stmt
+}
+
+// parses `SET TRANSACTION ISOLATION LEVEL level`
+func (p *Parser) parseSetTransactionStatement() ast.Statement {
+	p.nextToken() // consume 'TRANSACTION'
+	level, ok := p.parseIsolationLevel()
+	if !ok {
+		return nil
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) && !p.currentTokenIs(token.EOF) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after SET TRANSACTION, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	return &ast.TransactionStatement{Action: ast.TRANSACTION_SET, IsolationLevel: level}
+}
+
+// parses `SET SESSION CHARACTERISTICS AS TRANSACTION ISOLATION LEVEL level`,
+// the same as setting default_transaction_isolation
+func (p *Parser) parseSetSessionCharacteristicsStatement() ast.Statement {
+	for _, word := range []string{"CHARACTERISTICS", "AS", "TRANSACTION"} {
+		if !p.currentWordIs(word) {
+			p.errors = append(p.errors, fmt.Sprintf("expected %s, got %s instead", word, p.currentToken.Type))
+			return nil
+		}
+		p.nextToken() // consume word
+	}
+	level, ok := p.parseIsolationLevel()
+	if !ok {
+		return nil
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after SET SESSION CHARACTERISTICS, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	return &ast.SetStatement{Name: "default_transaction_isolation", Values: []string{level}}
+}
+
+func (p *Parser) parseShowStatement() ast.Statement {
+	p.nextToken() // consume 'SHOW'
+	name, ok := p.parseName()
+	if !ok {
+		return nil
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after SHOW, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	return &ast.ShowStatement{Name: strings.ToLower(name)}
+}
+
+func (p *Parser) parseResetStatement() ast.Statement {
+	p.nextToken() // consume 'RESET'
+	name, ok := p.parseName()
+	if !ok {
+		return nil
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after RESET, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	return &ast.ResetStatement{Name: strings.ToLower(name)}
+}
+
+// the words starting a transaction statement, START is followed by TRANSACTION
+var transactionActions = map[string]ast.TransactionAction{
+	"BEGIN":     ast.TRANSACTION_BEGIN,
+	"START":     ast.TRANSACTION_BEGIN,
+	"COMMIT":    ast.TRANSACTION_COMMIT,
+	"END":       ast.TRANSACTION_COMMIT,
+	"ROLLBACK":  ast.TRANSACTION_ROLLBACK,
+	"ABORT":     ast.TRANSACTION_ROLLBACK,
+	"SAVEPOINT": ast.TRANSACTION_SAVEPOINT,
+	"RELEASE":   ast.TRANSACTION_RELEASE,
+}
+
+// parses `{BEGIN | START TRANSACTION} [WORK | TRANSACTION] [ISOLATION LEVEL level]`,
+// `{COMMIT | END | ABORT} [WORK | TRANSACTION]`,
+// `ROLLBACK [WORK | TRANSACTION] [TO [SAVEPOINT] name]`, `SAVEPOINT name`
+// and `RELEASE [SAVEPOINT] name`
+func (p *Parser) parseTransactionStatement(action ast.TransactionAction) ast.Statement {
+	start := p.currentWordIs("START")
+	rollback := p.currentWordIs("ROLLBACK")
+	p.nextToken() // consume the statement word
+	if start && !p.currentWordIs("TRANSACTION") {
+		p.errors = append(p.errors, fmt.Sprintf("expected TRANSACTION after START, got %s instead", p.currentToken.Type))
+		return nil
+	}
+
+	stmt := &ast.TransactionStatement{Action: action}
+	switch action {
+	case ast.TRANSACTION_SAVEPOINT, ast.TRANSACTION_RELEASE:
+		if action == ast.TRANSACTION_RELEASE && p.currentWordIs("SAVEPOINT") {
+			p.nextToken() // consume 'SAVEPOINT'
+		}
+		name, ok := p.parseName()
+		if !ok {
+			return nil
+		}
+		stmt.Savepoint = name
+	default:
+		if p.currentWordIs("WORK") || p.currentWordIs("TRANSACTION") {
+			p.nextToken() // consume 'WORK' or 'TRANSACTION'
+		}
+		if action == ast.TRANSACTION_BEGIN && p.currentWordIs("ISOLATION") {
+			level, ok := p.parseIsolationLevel()
+			if !ok {
+				return nil
+			}
+			stmt.IsolationLevel = level
+		}
+		if rollback && p.currentTokenIs(token.TO) {
+			p.nextToken() // consume 'TO'
+			if p.currentWordIs("SAVEPOINT") {
+				p.nextToken() // consume 'SAVEPOINT'
+			}
+			name, ok := p.parseName()
+			if !ok {
+				return nil
+			}
+			stmt.Action, stmt.Savepoint = ast.TRANSACTION_ROLLBACK_TO, name
+		}
+	}
+
+	// drivers send them without the final semicolon
+	if !p.currentTokenIs(token.SEMICOLON) && !p.currentTokenIs(token.EOF) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after transaction statement, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	return stmt
+}
+
+var isolationLevels = [][]string{
+	{"SERIALIZABLE"},
+	{"REPEATABLE", "READ"},
+	{"READ", "COMMITTED"},
+	{"READ", "UNCOMMITTED"},
+}
+
+// parses `ISOLATION LEVEL level` and returns the level in lower case
+func (p *Parser) parseIsolationLevel() (string, bool) {
+	for _, word := range []string{"ISOLATION", "LEVEL"} {
+		if !p.currentWordIs(word) {
+			p.errors = append(p.errors, fmt.Sprintf("expected %s, got %s instead", word, p.currentToken.Type))
+			return "", false
+		}
+		p.nextToken() // consume word
+	}
+
+	for _, words := range isolationLevels {
+		if !p.currentWordIs(words[0]) || (len(words) > 1 && !p.peekWordIs(words[1])) {
+			continue
+		}
+		for range words {
+			p.nextToken() // consume level words
+		}
+		return strings.ToLower(strings.Join(words, " ")), true
+	}
+	p.errors = append(p.errors, fmt.Sprintf("expected isolation level, got %s instead", p.currentToken.Literal))
+	return "", false
+}
+
+func (p *Parser) parseInsertStatement() ast.Statement {
+	p.nextToken() // consume 'INSERT'
+	if !p.currentTokenIs(token.INTO) {
+		p.currentTokenError(token.INTO)
+		return nil
+	}
+	p.nextToken() // consume 'INTO'
+
+	if !p.currentTokenIs(token.IDENT) {
+		p.errors = append(p.errors, fmt.Sprintf("expected table name after INSERT INTO, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	schemaName, tableName, ok := p.parseQualifiedName()
+	if !ok {
+		return nil
+	}
+
+	columns := []string{}
+	if p.currentTokenIs(token.LPAREN) {
+		columns, ok = p.parseColumnNameList()
+		if !ok {
+			return nil
+		}
+	}
+
+	if !p.currentTokenIs(token.VALUES) {
+		p.currentTokenError(token.VALUES)
+		return nil
+	}
+	p.nextToken() // consume 'VALUES'
+
+	rows := [][]ast.Expression{}
+	for {
+		if !p.currentTokenIs(token.LPAREN) {
+			p.currentTokenError(token.LPAREN)
+			return nil
+		}
+
+		values := p.parseExpressionList(token.RPAREN)
+		if values == nil {
+			return nil
+		}
+		rows = append(rows, values)
+		p.nextToken() // consume ')'
+
+		if !p.currentTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken() // consume ','
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after INSERT, got %s instead", p.currentToken.Type))
+		return nil
+	}
+
+	return &ast.InsertStatement{
+		SchemaName: schemaName,
+		TableName:  tableName,
+		Columns:    columns,
+		Values:     rows,
+	}
+}
+
+func (p *Parser) parseUpdateStatement() ast.Statement {
+	p.nextToken() // consume 'UPDATE'
+
+	if !p.currentTokenIs(token.IDENT) {
+		p.errors = append(p.errors, fmt.Sprintf("expected table name after UPDATE, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	schemaName, tableName, ok := p.parseQualifiedName()
+	if !ok {
+		return nil
+	}
+
+	if !p.currentTokenIs(token.SET) {
+		p.currentTokenError(token.SET)
+		return nil
+	}
+	p.nextToken() // consume 'SET'
+
+	assignments := []ast.Assignment{}
+	for {
+		if !p.currentTokenIs(token.IDENT) {
+			p.errors = append(p.errors, fmt.Sprintf("expected column name in SET, got %s instead", p.currentToken.Type))
+			return nil
+		}
+		columnName := p.currentToken.Literal
+		if !p.expectPeek(token.EQ) {
+			return nil
+		}
+		p.nextToken() // consume '='
+
+		value := p.parseExpression(LOWEST)
+		if value == nil {
+			return nil
+		}
+		p.nextToken() // consume last token of the expression
+		assignments = append(assignments, ast.Assignment{Column: columnName, Value: value})
+
+		if !p.currentTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken() // consume ','
+	}
+
+	whereClause, ok := p.parseWhereClause()
+	if !ok {
+		return nil
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after UPDATE, got %s instead", p.currentToken.Type))
+		return nil
+	}
+
+	return &ast.UpdateStatement{
+		SchemaName:  schemaName,
+		TableName:   tableName,
+		Assignments: assignments,
+		WhereClause: whereClause,
+	}
+}
+
+func (p *Parser) parseDeleteStatement() ast.Statement {
+	p.nextToken() // consume 'DELETE'
+	if !p.currentTokenIs(token.FROM) {
+		p.currentTokenError(token.FROM)
+		return nil
+	}
+	p.nextToken() // consume 'FROM'
+
+	if !p.currentTokenIs(token.IDENT) {
+		p.errors = append(p.errors, fmt.Sprintf("expected table name after DELETE FROM, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	schemaName, tableName, ok := p.parseQualifiedName()
+	if !ok {
+		return nil
+	}
+
+	whereClause, ok := p.parseWhereClause()
+	if !ok {
+		return nil
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after DELETE, got %s instead", p.currentToken.Type))
+		return nil
+	}
+
+	return &ast.DeleteStatement{
+		SchemaName:  schemaName,
+		TableName:   tableName,
+		WhereClause: whereClause,
+	}
+}
+
+// parses an optional `WHERE expr` clause
+func (p *Parser) parseWhereClause() (ast.Expression, bool) {
+	if !p.currentTokenIs(token.WHERE) {
+		return nil, true
+	}
+	p.nextToken() // consume 'WHERE'
+
+	expr := p.parseExpression(LOWEST)
+	if expr == nil {
+		return nil, false
+	}
+	p.nextToken() // consume last token of the expression
+	return expr, true
+}
+
+func (p *Parser) parseSelectStatement() ast.Statement {
+	p.nextToken() // consume 'SELECT'
+
+	columns := []ast.Expression{}
+	for {
+		column := p.parseExpression(LOWEST)
+		if column == nil {
+			return nil
+		}
+		columns = append(columns, column)
+		p.nextToken() // consume last token of the expression
+
+		if !p.currentTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken() // consume ','
+	}
+
+	if !p.currentTokenIs(token.FROM) {
+		p.currentTokenError(token.FROM)
+		return nil
+	}
+	p.nextToken() // consume 'FROM'
+
+	if !p.currentTokenIs(token.IDENT) {
+		p.errors = append(p.errors, fmt.Sprintf("expected table name after FROM, got %s instead", p.currentToken.Type))
+		return nil
+	}
+	schemaName, tableName, ok := p.parseQualifiedName()
+	if !ok {
+		return nil
+	}
+
+	stmt := &ast.SelectStatement{
+		Columns:    columns,
+		SchemaName: schemaName,
+		FromClause: tableName,
+	}
+	if stmt.Alias, ok = p.parseAlias(); !ok {
+		return nil
+	}
+	for {
+		join, ok := p.parseJoinClause()
+		if !ok {
+			return nil
+		}
+		if join == nil {
+			break
+		}
+		stmt.Joins = append(stmt.Joins, *join)
+	}
+
+	stmt.WhereClause, ok = p.parseWhereClause()
+	if !ok {
+		return nil
+	}
+
+	if p.currentWordIs("GROUP") {
+		p.nextToken() // consume 'GROUP'
+		if !p.currentTokenIs(token.BY) {
+			p.currentTokenError(token.BY)
+			return nil
+		}
+		p.nextToken() // consume 'BY'
+
+		for {
+			expr := p.parseExpression(LOWEST)
+			if expr == nil {
+				return nil
+			}
+			stmt.GroupBy = append(stmt.GroupBy, expr)
+			p.nextToken() // consume last token of the expression
+
+			if !p.currentTokenIs(token.COMMA) {
+				break
+			}
+			p.nextToken() // consume ','
+		}
+	}
+
+	if p.currentWordIs("HAVING") {
+		p.nextToken() // consume 'HAVING'
+		stmt.Having = p.parseExpression(LOWEST)
+		if stmt.Having == nil {
+			return nil
+		}
+		p.nextToken() // consume last token of the expression
+	}
+
+	if p.currentTokenIs(token.ORDER) {
+		p.nextToken() // consume 'ORDER'
+		if !p.currentTokenIs(token.BY) {
+			p.currentTokenError(token.BY)
+			return nil
+		}
+		p.nextToken() // consume 'BY'
+
+		for {
+			expr := p.parseExpression(LOWEST)
+			if expr == nil {
+				return nil
+			}
+			p.nextToken() // consume last token of the expression
+
+			sortExpr := ast.SortExpr{Expr: expr, Ascending: true}
+			if p.currentTokenIs(token.ASC) {
+				p.nextToken() // consume 'ASC'
+			} else if p.currentTokenIs(token.DESC) {
+				sortExpr.Ascending = false
+				p.nextToken() // consume 'DESC'
+			}
+			stmt.OrderBy = append(stmt.OrderBy, sortExpr)
+
+			if !p.currentTokenIs(token.COMMA) {
+				break
+			}
+			p.nextToken() // consume ','
+		}
+	}
+
+	if p.currentTokenIs(token.LIMIT) {
+		p.nextToken() // consume 'LIMIT'
+		limit, ok := p.parseUnsignedInteger()
+		if !ok {
+			return nil
+		}
+		stmt.Limit = &limit
+	}
+
+	if p.currentTokenIs(token.OFFSET) {
+		p.nextToken() // consume 'OFFSET'
+		offset, ok := p.parseUnsignedInteger()
+		if !ok {
+			return nil
+		}
+		stmt.Offset = offset
+	}
+
+	if !p.currentTokenIs(token.SEMICOLON) {
+		p.errors = append(p.errors, fmt.Sprintf("expected semicolon after SELECT, got %s instead", p.currentToken.Type))
+		return nil
+	}
+
+	return stmt
+}
+
+// words following a table name that has no alias in a FROM clause
+var clauseWords = []string{"JOIN", "INNER", "LEFT", "RIGHT", "FULL", "CROSS", "USING", "GROUP", "HAVING"}
+
+var outerJoinTypes = map[string]ast.JoinType{
+	"LEFT":  ast.JOIN_LEFT,
+	"RIGHT": ast.JOIN_RIGHT,
+	"FULL":  ast.JOIN_FULL,
+}
+
+// parses the optional `[AS] alias` following a table name
+func (p *Parser) parseAlias() (string, bool) {
+	if p.currentWordIs("AS") {
+		p.nextToken() // consume 'AS'
+		return p.parseName()
+	}
+	if !p.currentTokenIs(token.IDENT) || slices.ContainsFunc(clauseWords, p.currentWordIs) {
+		return "", true
+	}
+	return p.parseName()
+}
+
+// parses a join of the FROM clause, it returns nil when the clause has no
+// more joins. A comma separated table is a CROSS join.
+//
+//	[INNER] JOIN table [[AS] alias] { ON condition | USING (column, ...) }
+//	{ LEFT | RIGHT | FULL } [OUTER] JOIN table [[AS] alias] { ON condition | USING (column, ...) }
+//	CROSS JOIN table [[AS] alias]
+func (p *Parser) parseJoinClause() (*ast.JoinClause, bool) {
+	join := &ast.JoinClause{Type: ast.JOIN_INNER}
+	switch {
+	case p.currentTokenIs(token.COMMA):
+		join.Type = ast.JOIN_CROSS
+		p.nextToken() // consume ','
+	case p.currentWordIs("JOIN"), p.currentWordIs("INNER"), p.currentWordIs("CROSS"):
+		if p.currentWordIs("CROSS") {
+			join.Type = ast.JOIN_CROSS
+		}
+		if !p.currentWordIs("JOIN") {
+			p.nextToken() // consume 'INNER' or 'CROSS'
+		}
+		if !p.parseJoinWord() {
+			return nil, false
+		}
+	case p.currentWordIs("LEFT"), p.currentWordIs("RIGHT"), p.currentWordIs("FULL"):
+		join.Type = outerJoinTypes[strings.ToUpper(p.currentToken.Literal)]
+		p.nextToken() // consume 'LEFT', 'RIGHT' or 'FULL'
+		if p.currentWordIs("OUTER") {
+			p.nextToken() // consume 'OUTER'
+		}
+		if !p.parseJoinWord() {
+			return nil, false
+		}
+	default:
+		return nil, true
+	}
+
+	if !p.currentTokenIs(token.IDENT) {
+		p.errors = append(p.errors, fmt.Sprintf("expected table name after %s, got %s instead", join.Type, p.currentToken.Type))
+		return nil, false
+	}
+	var ok bool
+	join.SchemaName, join.TableName, ok = p.parseQualifiedName()
+	if !ok {
+		return nil, false
+	}
+	if join.Alias, ok = p.parseAlias(); !ok {
+		return nil, false
+	}
+	if join.Type == ast.JOIN_CROSS {
+		return join, true
+	}
+
+	switch {
+	case p.currentTokenIs(token.ON):
+		p.nextToken() // consume 'ON'
+		join.On = p.parseExpression(LOWEST)
+		if join.On == nil {
+			return nil, false
+		}
+		p.nextToken() // consume last token of the expression
+	case p.currentWordIs("USING"):
+		p.nextToken() // consume 'USING'
+		if !p.currentTokenIs(token.LPAREN) {
+			p.currentTokenError(token.LPAREN)
+			return nil, false
+		}
+		p.nextToken() // consume '('
+		for {
+			name, ok := p.parseName()
+			if !ok {
+				return nil, false
+			}
+			join.Using = append(join.Using, name)
+			if !p.currentTokenIs(token.COMMA) {
+				break
+			}
+			p.nextToken() // consume ','
+		}
+		if !p.currentTokenIs(token.RPAREN) {
+			p.currentTokenError(token.RPAREN)
+			return nil, false
+		}
+		p.nextToken() // consume ')'
+	default:
+		p.errors = append(p.errors, fmt.Sprintf("expected ON or USING after %s %s, got %s instead", join.Type, join.TableName, p.currentToken.Type))
+		return nil, false
+	}
+	return join, true
+}
+
+func (p *Parser) parseJoinWord() bool {
+	if !p.currentWordIs("JOIN") {
+		p.errors = append(p.errors, fmt.Sprintf("expected JOIN, got %s instead", p.currentToken.Type))
+		return false
+	}
+	p.nextToken() // consume 'JOIN'
+	return true
+}
+
+// parses an optionally schema qualified name `[schema.]name`
+// and leaves the parser on the token following it.
+func (p *Parser) parseQualifiedName() (string, string, bool) {
+	schemaName := ""
+	name := p.currentToken.Literal
+	p.nextToken() // consume name
+
+	if p.currentTokenIs(token.DOT) {
+		p.nextToken() // consume '.'
+		if !p.currentTokenIs(token.IDENT) {
+			p.errors = append(p.errors, fmt.Sprintf("expected name after '.', got %s instead", p.currentToken.Type))
+			return "", "", false
+		}
+		schemaName = name
+		name = p.currentToken.Literal
+		p.nextToken() // consume name
+	}
+	return schemaName, name, true
+}
+
+// parses a single identifier and leaves the parser on the token following it
+func (p *Parser) parseName() (string, bool) {
+	if !p.currentTokenIs(token.IDENT) {
+		p.currentTokenError(token.IDENT)
+		return "", false
+	}
+	name := p.currentToken.Literal
+	p.nextToken() // consume name
+	return name, true
+}
+
+// USER, PASSWORD, WITH, SHOW, RESET, SESSION, the transaction words, the
+// join words, GROUP, HAVING and DISTINCT are only keywords where a statement
+// expects them, elsewhere they remain usable as names.
+func (p *Parser) currentWordIs(word string) bool {
+	return p.currentTokenIs(token.IDENT) && strings.EqualFold(p.currentToken.Literal, word)
+}
+
+func (p *Parser) peekWordIs(word string) bool {
+	return p.peekTokenIs(token.IDENT) && strings.EqualFold(p.peekToken.Literal, word)
+}
+
+// consumes the current token when it is of the given optional type
+func (p *Parser) skipToken(t token.TokenType) {
+	if p.currentTokenIs(t) {
+		p.nextToken()
+	}
+}
+
+func (p *Parser) parseUnsignedInteger() (uint64, bool) {
+	if !p.currentTokenIs(token.INT) {
+		p.currentTokenError(token.INT)
+		return 0, false
+	}
+
+	value, err := strconv.ParseUint(p.currentToken.Literal, 10, 64)
+	if err != nil {
+		p.errors = append(p.errors, "could not parse integer literal: "+err.Error())
+		return 0, false
+	}
+	p.nextToken() // consume integer
+	return value, true
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
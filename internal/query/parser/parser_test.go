@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
 )
 
 func TestParseCreateSchemaStatement(t *testing.T) {
@@ -211,3 +212,817 @@ func TestParseCreateSchemaStatementSpecificErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCreateTableStatement(t *testing.T) {
+	input := "CREATE TABLE app.users (id INT PRIMARY KEY, name TEXT NOT NULL, email TEXT UNIQUE, score FLOAT DEFAULT -1.5 NOT NULL);"
+	lexer := NewLexer(input)
+	parser := NewParser(lexer)
+	program := parser.ParseProgram()
+
+	require.Empty(t, parser.Errors(), "Unexpected parsing errors: %v", parser.Errors())
+	require.Len(t, program.Statements, 1)
+
+	stmt, ok := program.Statements[0].(*ast.CreateTableStatement)
+	require.True(t, ok, "Statement is not a CreateTableStatement, got %T", program.Statements[0])
+	assert.Equal(t, "app", stmt.SchemaName)
+	assert.Equal(t, "users", stmt.TableName)
+	require.Len(t, stmt.Columns, 4)
+	assert.True(t, stmt.Columns[0].Constraint.PrimaryKey)
+	assert.True(t, stmt.Columns[1].Constraint.NotNull)
+	assert.True(t, stmt.Columns[2].Constraint.Unique)
+	assert.Equal(t, "score", stmt.Columns[3].Name)
+	assert.Equal(t, "score FLOAT NOT NULL DEFAULT (-1.500000)", stmt.Columns[3].ToDefString())
+}
+
+func TestParseSelectStatement(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Select all",
+			input:    "SELECT * FROM users;",
+			expected: "SELECT * FROM users;",
+		},
+		{
+			name:     "Qualified table and column list",
+			input:    "SELECT id, name FROM app.users;",
+			expected: "SELECT id, name FROM app.users;",
+		},
+		{
+			name:     "Where clause",
+			input:    "SELECT id FROM app.users WHERE age >= 18 AND name != \"admin\";",
+			expected: "SELECT id FROM app.users WHERE ((age >= 18) AND (name != \"admin\"));",
+		},
+		{
+			name:     "Null checks",
+			input:    "SELECT id FROM users WHERE email IS NULL OR age IS NOT NULL;",
+			expected: "SELECT id FROM users WHERE ((email IS NULL) OR (age IS NOT NULL));",
+		},
+		{
+			name:     "Concatenation binds tighter than comparisons",
+			input:    "SELECT id FROM users WHERE first || ' ' || last = 'a' || id + 1;",
+			expected: "SELECT id FROM users WHERE (((first || \" \") || last) = (\"a\" || (id + 1)));",
+		},
+		{
+			name:        "Invalid null check",
+			input:       "SELECT id FROM users WHERE email IS 5;",
+			expectError: true,
+		},
+		{
+			name:     "Order by, limit and offset",
+			input:    "SELECT * FROM users ORDER BY age DESC, name LIMIT 10 OFFSET 5;",
+			expected: "SELECT * FROM users ORDER BY age DESC, name ASC LIMIT 10 OFFSET 5;",
+		},
+		{
+			name:     "Limit zero",
+			input:    "SELECT * FROM users LIMIT 0;",
+			expected: "SELECT * FROM users LIMIT 0;",
+		},
+		{
+			name:     "Qualified columns and aliases",
+			input:    "SELECT u.id, o.* FROM app.users AS u JOIN orders o ON u.id = o.user_id WHERE o.total > 10;",
+			expected: "SELECT u.id, o.* FROM app.users AS u JOIN orders AS o ON (u.id = o.user_id) WHERE (o.total > 10);",
+		},
+		{
+			name:     "Join types",
+			input:    "SELECT * FROM a INNER JOIN b ON a.id = b.id LEFT OUTER JOIN c USING (id, name) RIGHT JOIN d ON true FULL JOIN e USING (id) CROSS JOIN f, g;",
+			expected: "SELECT * FROM a JOIN b ON (a.id = b.id) LEFT JOIN c USING (id, name) RIGHT JOIN d ON true FULL JOIN e USING (id) CROSS JOIN f CROSS JOIN g;",
+		},
+		{
+			name:     "Join words remain names elsewhere",
+			input:    "SELECT left FROM full AS cross WHERE cross.left = 1;",
+			expected: "SELECT left FROM full AS cross WHERE (cross.left = 1);",
+		},
+		{
+			name:     "Group by with aggregates",
+			input:    "SELECT dept, COUNT(*), count(DISTINCT name), string_agg(name, ',') FROM users GROUP BY dept HAVING SUM(score) > 10 ORDER BY dept;",
+			expected: "SELECT dept, COUNT(*), count(DISTINCT name), string_agg(name, \",\") FROM users GROUP BY dept HAVING (SUM(score) > 10) ORDER BY dept ASC;",
+		},
+		{
+			name:     "Group by expressions",
+			input:    "SELECT MAX(u.score) FROM users u GROUP BY u.dept, u.score > 1;",
+			expected: "SELECT MAX(u.score) FROM users AS u GROUP BY u.dept, (u.score > 1);",
+		},
+		{
+			name:     "Having without group by",
+			input:    "SELECT COUNT(*) FROM users HAVING COUNT(*) > 1;",
+			expected: "SELECT COUNT(*) FROM users HAVING (COUNT(*) > 1);",
+		},
+		{
+			name:     "Grouping words remain names elsewhere",
+			input:    "SELECT group, having FROM distinct WHERE group = 1;",
+			expected: "SELECT group, having FROM distinct WHERE (group = 1);",
+		},
+		{
+			name:        "Group without by",
+			input:       "SELECT dept FROM users GROUP dept;",
+			expectError: true,
+		},
+		{
+			name:        "Unclosed call",
+			input:       "SELECT COUNT(DISTINCT id FROM users;",
+			expectError: true,
+		},
+		{
+			name:        "Join without condition",
+			input:       "SELECT * FROM a JOIN b;",
+			expectError: true,
+		},
+		{
+			name:        "Outer join without JOIN",
+			input:       "SELECT * FROM a LEFT b ON a.id = b.id;",
+			expectError: true,
+		},
+		{
+			name:        "Empty USING list",
+			input:       "SELECT * FROM a JOIN b USING ();",
+			expectError: true,
+		},
+		{
+			name:        "Missing column after qualifier",
+			input:       "SELECT id FROM a WHERE a. = 1;",
+			expectError: true,
+		},
+		{
+			name:        "Missing FROM",
+			input:       "SELECT id users;",
+			expectError: true,
+		},
+		{
+			name:        "Missing table name",
+			input:       "SELECT id FROM;",
+			expectError: true,
+		},
+		{
+			name:        "Missing BY after ORDER",
+			input:       "SELECT id FROM users ORDER id;",
+			expectError: true,
+		},
+		{
+			name:        "Non integer limit",
+			input:       "SELECT id FROM users LIMIT ten;",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer(tt.input)
+			parser := NewParser(lexer)
+			program := parser.ParseProgram()
+
+			if tt.expectError {
+				assert.NotEmpty(t, parser.Errors(), "Expected parsing errors but got none")
+				return
+			}
+
+			require.Empty(t, parser.Errors(), "Unexpected parsing errors: %v", parser.Errors())
+			require.Len(t, program.Statements, 1, "Expected exactly 1 statement")
+
+			stmt, ok := program.Statements[0].(*ast.SelectStatement)
+			require.True(t, ok, "Statement is not a SelectStatement, got %T", program.Statements[0])
+			assert.Equal(t, tt.expected, stmt.ToStmtString())
+		})
+	}
+}
+
+func TestParseInsertStatement(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Single row without column list",
+			input:    "INSERT INTO users VALUES (1, \"alice\");",
+			expected: "INSERT INTO users VALUES (1, \"alice\");",
+		},
+		{
+			name:     "Multiple rows with column list",
+			input:    "INSERT INTO app.users (id, name, score) VALUES (1, \"alice\", 1.5), (2, \"bob\", -3);",
+			expected: "INSERT INTO app.users (id, name, score) VALUES (1, \"alice\", 1.500000), (2, \"bob\", (-3));",
+		},
+		{
+			name:        "Missing INTO",
+			input:       "INSERT users VALUES (1);",
+			expectError: true,
+		},
+		{
+			name:        "Missing VALUES",
+			input:       "INSERT INTO users (id) (1);",
+			expectError: true,
+		},
+		{
+			name:        "Unclosed values",
+			input:       "INSERT INTO users VALUES (1, 2;",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer(tt.input)
+			parser := NewParser(lexer)
+			program := parser.ParseProgram()
+
+			if tt.expectError {
+				assert.NotEmpty(t, parser.Errors(), "Expected parsing errors but got none")
+				return
+			}
+
+			require.Empty(t, parser.Errors(), "Unexpected parsing errors: %v", parser.Errors())
+			require.Len(t, program.Statements, 1, "Expected exactly 1 statement")
+
+			stmt, ok := program.Statements[0].(*ast.InsertStatement)
+			require.True(t, ok, "Statement is not an InsertStatement, got %T", program.Statements[0])
+			assert.Equal(t, tt.expected, stmt.ToStmtString())
+		})
+	}
+}
+
+func TestParseUpdateAndDeleteStatements(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Update all rows",
+			input:    "UPDATE users SET score = 0;",
+			expected: "UPDATE users SET score = 0;",
+		},
+		{
+			name:     "Update with where clause",
+			input:    "UPDATE app.users SET score = score + 1, name = \"bob\" WHERE id = 2;",
+			expected: "UPDATE app.users SET score = (score + 1), name = \"bob\" WHERE (id = 2);",
+		},
+		{
+			name:     "Delete all rows",
+			input:    "DELETE FROM users;",
+			expected: "DELETE FROM users;",
+		},
+		{
+			name:     "Delete with where clause",
+			input:    "DELETE FROM app.users WHERE id >= 10 OR name = \"bob\";",
+			expected: "DELETE FROM app.users WHERE ((id >= 10) OR (name = \"bob\"));",
+		},
+		{
+			name:        "Update without SET",
+			input:       "UPDATE users score = 1;",
+			expectError: true,
+		},
+		{
+			name:        "Update assignment without value",
+			input:       "UPDATE users SET score;",
+			expectError: true,
+		},
+		{
+			name:        "Delete without FROM",
+			input:       "DELETE users;",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer(tt.input)
+			parser := NewParser(lexer)
+			program := parser.ParseProgram()
+
+			if tt.expectError {
+				assert.NotEmpty(t, parser.Errors(), "Expected parsing errors but got none")
+				return
+			}
+
+			require.Empty(t, parser.Errors(), "Unexpected parsing errors: %v", parser.Errors())
+			require.Len(t, program.Statements, 1, "Expected exactly 1 statement")
+			assert.Equal(t, tt.expected, program.Statements[0].ToStmtString())
+		})
+	}
+}
+
+func TestParseIndexStatements(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Create index",
+			input:    "CREATE INDEX users_name ON users (name);",
+			expected: "CREATE INDEX users_name ON users (name);",
+		},
+		{
+			name:     "Create unique multi column index",
+			input:    "CREATE UNIQUE INDEX users_name_email ON app.users (name, email);",
+			expected: "CREATE UNIQUE INDEX users_name_email ON app.users (name, email);",
+		},
+		{
+			name:     "Drop index",
+			input:    "DROP INDEX app.users_name;",
+			expected: "DROP INDEX app.users_name;",
+		},
+		{
+			name:        "Create index without ON",
+			input:       "CREATE INDEX users_name users (name);",
+			expectError: true,
+		},
+		{
+			name:        "Create index without columns",
+			input:       "CREATE INDEX users_name ON users ();",
+			expectError: true,
+		},
+		{
+			name:        "Unique without INDEX",
+			input:       "CREATE UNIQUE TABLE users (id INT);",
+			expectError: true,
+		},
+		{
+			name:        "Drop index without name",
+			input:       "DROP INDEX;",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer(tt.input)
+			parser := NewParser(lexer)
+			program := parser.ParseProgram()
+
+			if tt.expectError {
+				assert.NotEmpty(t, parser.Errors(), "Expected parsing errors but got none")
+				return
+			}
+
+			require.Empty(t, parser.Errors(), "Unexpected parsing errors: %v", parser.Errors())
+			require.Len(t, program.Statements, 1, "Expected exactly 1 statement")
+			assert.Equal(t, tt.expected, program.Statements[0].ToStmtString())
+		})
+	}
+}
+
+func TestParseAlterTableStatement(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Add column",
+			input:    "ALTER TABLE app.users ADD COLUMN age INT NOT NULL DEFAULT 18;",
+			expected: "ALTER TABLE app.users ADD COLUMN age INT NOT NULL DEFAULT 18;",
+		},
+		{
+			name:     "Add column without COLUMN keyword",
+			input:    "ALTER TABLE users ADD email TEXT UNIQUE;",
+			expected: "ALTER TABLE users ADD COLUMN email TEXT UNIQUE;",
+		},
+		{
+			name:     "Drop column",
+			input:    "ALTER TABLE users DROP COLUMN age;",
+			expected: "ALTER TABLE users DROP COLUMN age;",
+		},
+		{
+			name:     "Rename column",
+			input:    "ALTER TABLE users RENAME name TO full_name;",
+			expected: "ALTER TABLE users RENAME COLUMN name TO full_name;",
+		},
+		{
+			name:     "Rename table",
+			input:    "ALTER TABLE app.users RENAME TO members;",
+			expected: "ALTER TABLE app.users RENAME TO members;",
+		},
+		{
+			name:     "Set not null",
+			input:    "ALTER TABLE users ALTER COLUMN name SET NOT NULL;",
+			expected: "ALTER TABLE users ALTER COLUMN name SET NOT NULL;",
+		},
+		{
+			name:     "Drop not null",
+			input:    "ALTER TABLE users ALTER name DROP NOT NULL;",
+			expected: "ALTER TABLE users ALTER COLUMN name DROP NOT NULL;",
+		},
+		{
+			name:     "Set default",
+			input:    "ALTER TABLE users ALTER COLUMN name SET DEFAULT \"anonymous\";",
+			expected: "ALTER TABLE users ALTER COLUMN name SET DEFAULT \"anonymous\";",
+		},
+		{
+			name:     "Drop default",
+			input:    "ALTER TABLE users ALTER COLUMN name DROP DEFAULT;",
+			expected: "ALTER TABLE users ALTER COLUMN name DROP DEFAULT;",
+		},
+		{
+			name:        "Missing action",
+			input:       "ALTER TABLE users;",
+			expectError: true,
+		},
+		{
+			name:        "Rename without new name",
+			input:       "ALTER TABLE users RENAME name TO;",
+			expectError: true,
+		},
+		{
+			name:        "Add column without type",
+			input:       "ALTER TABLE users ADD COLUMN age;",
+			expectError: true,
+		},
+		{
+			name:        "Set without target",
+			input:       "ALTER TABLE users ALTER COLUMN name SET;",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer(tt.input)
+			parser := NewParser(lexer)
+			program := parser.ParseProgram()
+
+			if tt.expectError {
+				assert.NotEmpty(t, parser.Errors(), "Expected parsing errors but got none")
+				return
+			}
+
+			require.Empty(t, parser.Errors(), "Unexpected parsing errors: %v", parser.Errors())
+			require.Len(t, program.Statements, 1, "Expected exactly 1 statement")
+			assert.Equal(t, tt.expected, program.Statements[0].ToStmtString())
+		})
+	}
+}
+
+func TestParseParameters(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "Numbered parameters",
+			input:    "SELECT id FROM users WHERE id = $2 AND name = $1;",
+			expected: "SELECT id FROM users WHERE ((id = $2) AND (name = $1));",
+		},
+		{
+			name:     "Question marks are numbered in order",
+			input:    "INSERT INTO users VALUES (?, ?), (?, ?);",
+			expected: "INSERT INTO users VALUES ($1, $2), ($3, $4);",
+		},
+		{
+			name:     "Parameters in assignments",
+			input:    "UPDATE users SET score = score + $1 WHERE id = $2;",
+			expected: "UPDATE users SET score = (score + $1) WHERE (id = $2);",
+		},
+		{
+			name:        "Mixed placeholders",
+			input:       "SELECT id FROM users WHERE id = $1 AND name = ?;",
+			expectError: true,
+		},
+		{
+			name:        "Parameter zero",
+			input:       "SELECT id FROM users WHERE id = $0;",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer(tt.input)
+			parser := NewParser(lexer)
+			program := parser.ParseProgram()
+
+			if tt.expectError {
+				assert.NotEmpty(t, parser.Errors(), "Expected parsing errors but got none")
+				return
+			}
+
+			require.Empty(t, parser.Errors(), "Unexpected parsing errors: %v", parser.Errors())
+			require.Len(t, program.Statements, 1, "Expected exactly 1 statement")
+			assert.Equal(t, tt.expected, program.Statements[0].ToStmtString())
+		})
+	}
+}
+
+func TestParseUserStatements(t *testing.T) {
+	secret := "s3cr'et"
+	tests := []struct {
+		name        string
+		input       string
+		expected    ast.Statement
+		expectError bool
+	}{
+		{
+			name:     "Create user with password",
+			input:    "CREATE USER alice WITH PASSWORD 's3cr''et';",
+			expected: &ast.CreateUserStatement{UserName: "alice", Password: &secret},
+		},
+		{
+			name:     "Create user without password",
+			input:    "create user bob;",
+			expected: &ast.CreateUserStatement{UserName: "bob"},
+		},
+		{
+			name:     "Alter user password",
+			input:    "ALTER USER alice PASSWORD 's3cr''et';",
+			expected: &ast.AlterUserStatement{UserName: "alice", Password: &secret},
+		},
+		{
+			name:     "Remove user password",
+			input:    "ALTER USER alice WITH PASSWORD NULL;",
+			expected: &ast.AlterUserStatement{UserName: "alice"},
+		},
+		{
+			name:     "Drop user",
+			input:    "DROP USER alice;",
+			expected: &ast.DropUserStatement{UserName: "alice"},
+		},
+		{
+			name:     "Password is still a valid column name",
+			input:    "CREATE TABLE accounts (user TEXT, password TEXT);",
+			expected: &ast.CreateTableStatement{TableName: "accounts", Columns: []ast.ColumnDef{{Name: "user", DataType: types.TYPE_TEXT}, {Name: "password", DataType: types.TYPE_TEXT}}},
+		},
+		{
+			name:        "Alter user without password",
+			input:       "ALTER USER alice;",
+			expectError: true,
+		},
+		{
+			name:        "Password is not a string",
+			input:       "CREATE USER alice PASSWORD 42;",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer(tt.input)
+			parser := NewParser(lexer)
+			program := parser.ParseProgram()
+
+			if tt.expectError {
+				assert.NotEmpty(t, parser.Errors(), "Expected parsing errors but got none")
+				return
+			}
+
+			require.Empty(t, parser.Errors(), "Unexpected parsing errors: %v", parser.Errors())
+			require.Len(t, program.Statements, 1, "Expected exactly 1 statement")
+			assert.Equal(t, tt.expected, program.Statements[0])
+		})
+	}
+
+	stmt := &ast.CreateUserStatement{UserName: "alice", Password: &secret}
+	assert.NotContains(t, stmt.ToStmtString(), secret, "passwords must not be printed")
+}
+
+func TestParseSettingStatements(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    ast.Statement
+		expectError bool
+	}{
+		{
+			name:     "Set search path",
+			input:    "SET search_path = app, public;",
+			expected: &ast.SetStatement{Name: "search_path", Values: []string{"app", "public"}},
+		},
+		{
+			name:     "Set with TO and quoted values",
+			input:    `SET Search_Path TO "$user", 'public';`,
+			expected: &ast.SetStatement{Name: "search_path", Values: []string{"$user", "public"}},
+		},
+		{
+			name:     "Set a number",
+			input:    "SET statement_timeout = 5000;",
+			expected: &ast.SetStatement{Name: "statement_timeout", Values: []string{"5000"}},
+		},
+		{
+			name:     "Set session to default",
+			input:    "SET SESSION DateStyle TO DEFAULT;",
+			expected: &ast.ResetStatement{Name: "datestyle"},
+		},
+		{
+			name:     "Show",
+			input:    "SHOW search_path;",
+			expected: &ast.ShowStatement{Name: "search_path"},
+		},
+		{
+			name:     "Show all",
+			input:    "SHOW ALL;",
+			expected: &ast.ShowStatement{Name: "all"},
+		},
+		{
+			name:     "Reset",
+			input:    "RESET statement_timeout;",
+			expected: &ast.ResetStatement{Name: "statement_timeout"},
+		},
+		{
+			name:     "Reset all",
+			input:    "reset all;",
+			expected: &ast.ResetStatement{Name: "all"},
+		},
+		{
+			name:     "Show is still a valid table name",
+			input:    "DROP TABLE show;",
+			expected: &ast.DropTableStatement{TableName: "show"},
+		},
+		{
+			name:        "Set without value",
+			input:       "SET search_path =;",
+			expectError: true,
+		},
+		{
+			name:        "Set without equal sign",
+			input:       "SET search_path public;",
+			expectError: true,
+		},
+		{
+			name:        "Show without name",
+			input:       "SHOW;",
+			expectError: true,
+		},
+		{
+			name:        "Default among values",
+			input:       "SET search_path = app, DEFAULT;",
+			expectError: true,
+		},
+		{
+			name:        "Reset without name",
+			input:       "RESET;",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer(tt.input)
+			parser := NewParser(lexer)
+			program := parser.ParseProgram()
+
+			if tt.expectError {
+				assert.NotEmpty(t, parser.Errors(), "Expected parsing errors but got none")
+				return
+			}
+
+			require.Empty(t, parser.Errors(), "Unexpected parsing errors: %v", parser.Errors())
+			require.Len(t, program.Statements, 1, "Expected exactly 1 statement")
+			assert.Equal(t, tt.expected, program.Statements[0])
+		})
+	}
+}
+
+func TestParseTransactionStatements(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    ast.Statement
+		expectError bool
+	}{
+		{
+			name:     "Begin",
+			input:    "BEGIN;",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_BEGIN},
+		},
+		{
+			name:     "Begin transaction",
+			input:    "begin transaction;",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_BEGIN},
+		},
+		{
+			name:     "Start transaction",
+			input:    "START TRANSACTION;",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_BEGIN},
+		},
+		{
+			name:     "Commit work",
+			input:    "COMMIT WORK;",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_COMMIT},
+		},
+		{
+			name:     "Commit without semicolon",
+			input:    "commit",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_COMMIT},
+		},
+		{
+			name:     "End",
+			input:    "END;",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_COMMIT},
+		},
+		{
+			name:     "Rollback",
+			input:    "ROLLBACK;",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_ROLLBACK},
+		},
+		{
+			name:     "Abort transaction",
+			input:    "ABORT TRANSACTION;",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_ROLLBACK},
+		},
+		{
+			name:     "Savepoint",
+			input:    "SAVEPOINT before_update;",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_SAVEPOINT, Savepoint: "before_update"},
+		},
+		{
+			name:     "Release savepoint",
+			input:    "RELEASE SAVEPOINT sp1;",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_RELEASE, Savepoint: "sp1"},
+		},
+		{
+			name:     "Release",
+			input:    "release sp1",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_RELEASE, Savepoint: "sp1"},
+		},
+		{
+			name:     "Rollback to savepoint",
+			input:    "ROLLBACK TO SAVEPOINT sp1;",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_ROLLBACK_TO, Savepoint: "sp1"},
+		},
+		{
+			name:     "Rollback work to",
+			input:    "ROLLBACK WORK TO sp1;",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_ROLLBACK_TO, Savepoint: "sp1"},
+		},
+		{
+			name:     "Begin isolation level",
+			input:    "BEGIN ISOLATION LEVEL SERIALIZABLE;",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_BEGIN, IsolationLevel: "serializable"},
+		},
+		{
+			name:     "Start transaction isolation level",
+			input:    "start transaction isolation level repeatable read;",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_BEGIN, IsolationLevel: "repeatable read"},
+		},
+		{
+			name:     "Set transaction",
+			input:    "SET TRANSACTION ISOLATION LEVEL READ COMMITTED;",
+			expected: &ast.TransactionStatement{Action: ast.TRANSACTION_SET, IsolationLevel: "read committed"},
+		},
+		{
+			name:     "Set session characteristics",
+			input:    "SET SESSION CHARACTERISTICS AS TRANSACTION ISOLATION LEVEL READ UNCOMMITTED;",
+			expected: &ast.SetStatement{Name: "default_transaction_isolation", Values: []string{"read uncommitted"}},
+		},
+		{
+			name:        "Unknown isolation level",
+			input:       "BEGIN ISOLATION LEVEL READ;",
+			expectError: true,
+		},
+		{
+			name:        "Isolation without level",
+			input:       "SET TRANSACTION ISOLATION SERIALIZABLE;",
+			expectError: true,
+		},
+		{
+			name:        "Savepoint without name",
+			input:       "SAVEPOINT;",
+			expectError: true,
+		},
+		{
+			name:        "Rollback to without name",
+			input:       "ROLLBACK TO SAVEPOINT;",
+			expectError: true,
+		},
+		{
+			name:        "Abort to savepoint",
+			input:       "ABORT TO SAVEPOINT sp1;",
+			expectError: true,
+		},
+		{
+			name:        "Start without transaction",
+			input:       "START;",
+			expectError: true,
+		},
+		{
+			name:        "Commit with trailing word",
+			input:       "COMMIT now;",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := NewLexer(tt.input)
+			parser := NewParser(lexer)
+			program := parser.ParseProgram()
+
+			if tt.expectError {
+				assert.NotEmpty(t, parser.Errors(), "Expected parsing errors but got none")
+				return
+			}
+
+			require.Empty(t, parser.Errors(), "Unexpected parsing errors: %v", parser.Errors())
+			require.Len(t, program.Statements, 1, "Expected exactly 1 statement")
+			assert.Equal(t, tt.expected, program.Statements[0])
+		})
+	}
+}
@@ -14,6 +14,7 @@ const (
 	ASTERISK // *
 	SLASH    // /
 	BANG     // !
+	CONCAT   // ||
 
 	EQ     // =
 	LT     // <
@@ -45,6 +46,7 @@ const (
 	FLOAT  // 123.45
 	STRING // "abc"
 	IDENT  // main, foo, bar, x, y, z
+	PARAM  // $1
 
 	// Keywords
 	TRUE       // true
@@ -57,6 +59,7 @@ const (
 	NOT        // not
 	UNIQUE     // unique
 	NULL       // null
+	IS         // is
 	CREATE     // create
 	DROP       // drop
 	SCHEMA     // schema
@@ -67,6 +70,24 @@ const (
 	UPDATE     // update
 	DELETE     // delete
 	EXISTS     // exists
+	FROM       // from
+	WHERE      // where
+	ORDER      // order
+	BY         // by
+	ASC        // asc
+	DESC       // desc
+	LIMIT      // limit
+	OFFSET     // offset
+	INTO       // into
+	VALUES     // values
+	SET        // set
+	ON         // on
+	ALTER      // alter
+	ADD        // add
+	COLUMN     // column
+	RENAME     // rename
+	TO         // to
+	DEFAULT    // default
 	INT_TYPE   // int
 	FLOAT_TYPE // float
 	BOOL_TYPE  // bool
@@ -89,6 +110,8 @@ func (tt TokenType) String() string {
 		return "/"
 	case BANG:
 		return "!"
+	case CONCAT:
+		return "||"
 	case EQ:
 		return "="
 	case LT:
@@ -137,12 +160,16 @@ func (tt TokenType) String() string {
 		return "STRING"
 	case IDENT:
 		return "IDENT"
+	case PARAM:
+		return "PARAM"
 	case TRUE:
 		return "TRUE"
 	case FALSE:
 		return "FALSE"
 	case NULL:
 		return "NULL"
+	case IS:
+		return "IS"
 	case SELECT:
 		return "SELECT"
 	case INSERT:
@@ -175,6 +202,44 @@ func (tt TokenType) String() string {
 		return "NOT"
 	case UNIQUE:
 		return "UNIQUE"
+	case EXISTS:
+		return "EXISTS"
+	case FROM:
+		return "FROM"
+	case WHERE:
+		return "WHERE"
+	case ORDER:
+		return "ORDER"
+	case BY:
+		return "BY"
+	case ASC:
+		return "ASC"
+	case DESC:
+		return "DESC"
+	case LIMIT:
+		return "LIMIT"
+	case OFFSET:
+		return "OFFSET"
+	case INTO:
+		return "INTO"
+	case VALUES:
+		return "VALUES"
+	case SET:
+		return "SET"
+	case ON:
+		return "ON"
+	case ALTER:
+		return "ALTER"
+	case ADD:
+		return "ADD"
+	case COLUMN:
+		return "COLUMN"
+	case RENAME:
+		return "RENAME"
+	case TO:
+		return "TO"
+	case DEFAULT:
+		return "DEFAULT"
 	case INT_TYPE:
 		return "INT_TYPE"
 	case FLOAT_TYPE:
@@ -197,6 +262,7 @@ var keywords = map[string]TokenType{
 	"true":    TRUE,
 	"false":   FALSE,
 	"null":    NULL,
+	"is":      IS,
 	"create":  CREATE,
 	"drop":    DROP,
 	"schema":  SCHEMA,
@@ -214,6 +280,24 @@ var keywords = map[string]TokenType{
 	"not":     NOT,
 	"unique":  UNIQUE,
 	"exists":  EXISTS,
+	"from":    FROM,
+	"where":   WHERE,
+	"order":   ORDER,
+	"by":      BY,
+	"asc":     ASC,
+	"desc":    DESC,
+	"limit":   LIMIT,
+	"offset":  OFFSET,
+	"into":    INTO,
+	"values":  VALUES,
+	"set":     SET,
+	"on":      ON,
+	"alter":   ALTER,
+	"add":     ADD,
+	"column":  COLUMN,
+	"rename":  RENAME,
+	"to":      TO,
+	"default": DEFAULT,
 	"int":     INT_TYPE,
 	"float":   FLOAT_TYPE,
 	"bool":    BOOL_TYPE,
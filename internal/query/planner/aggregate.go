@@ -0,0 +1,215 @@
+package planner
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/query/planner/logical"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// isGrouped tells whether a SELECT groups its rows, as it does when it has
+// a GROUP BY or HAVING clause or calls aggregate functions.
+func isGrouped(stmt *ast.SelectStatement) bool {
+	if len(stmt.GroupBy) > 0 || stmt.Having != nil {
+		return true
+	}
+	for _, column := range stmt.Columns {
+		if expression.ContainsAggregate(column) {
+			return true
+		}
+	}
+	for _, sortExpr := range stmt.OrderBy {
+		if expression.ContainsAggregate(sortExpr.Expr) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkNoAggregate(expr ast.Expression, clause string) error {
+	if expression.ContainsAggregate(expr) {
+		return types.NewError(types.ERR_GROUPING_ERROR, "aggregate functions are not allowed in %s", clause)
+	}
+	return nil
+}
+
+// planGrouping adds the aggregate plan and the HAVING filter on top of plan.
+// The SELECT list and the ORDER BY clause are returned rewritten to refer
+// to the columns of the aggregate plan.
+func planGrouping(stmt *ast.SelectStatement, plan LogicalPlan, items []selectItem) (LogicalPlan, []selectItem, []ast.SortExpr, error) {
+	g := &grouping{input: plan.GetSchema()}
+	for _, key := range stmt.GroupBy {
+		if err := g.addKey(key); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	grouped := make([]selectItem, len(items))
+	for i, item := range items {
+		expr, err := g.rewrite(item.expr)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		grouped[i] = selectItem{expr: expr, name: item.name}
+	}
+	var having ast.Expression
+	if stmt.Having != nil {
+		var err error
+		if having, err = g.rewrite(stmt.Having); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	orderBy := make([]ast.SortExpr, len(stmt.OrderBy))
+	for i, sortExpr := range stmt.OrderBy {
+		expr, err := g.rewrite(sortExpr.Expr)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		orderBy[i] = ast.SortExpr{Expr: expr, Ascending: sortExpr.Ascending}
+	}
+
+	plan = logical.NewAggregatePlan(plan, g.keys, g.aggregates, g.columns)
+	if having != nil {
+		if _, err := expression.BindPredicate(having, plan.GetSchema(), "HAVING"); err != nil {
+			return nil, nil, nil, err
+		}
+		plan = logical.NewFilterPlan(plan, having)
+	}
+	return plan, grouped, orderBy, nil
+}
+
+// grouping collects the group keys and the aggregates of a SELECT, they
+// are the columns of its aggregate plan.
+type grouping struct {
+	// the schema of the grouped rows
+	input      *types.DataSchema
+	keys       []ast.Expression
+	aggregates []*ast.CallExpr
+	columns    []types.DataColumn
+	// the position in input of the columns used as keys, -1 for the
+	// keys that are other expressions
+	keyColumns []int
+}
+
+// a key that is a column keeps its name, other ones are named after their
+// expression so that the expressions evaluated after grouping can refer
+// to them.
+func (g *grouping) addKey(key ast.Expression) error {
+	if err := checkNoAggregate(key, "GROUP BY"); err != nil {
+		return err
+	}
+	bound, err := expression.Bind(key, g.input)
+	if err != nil {
+		return err
+	}
+	if g.keyIndex(key) >= 0 {
+		return nil
+	}
+
+	column := types.DataColumn{Name: key.ToExprString(), DataType: bound.DataType()}
+	columnIdx := -1
+	if identifier, ok := key.(*ast.IdentifierExpr); ok {
+		columnIdx, _ = g.input.ResolveColumn(identifier.Table, identifier.Value)
+		column = g.input.Columns[columnIdx]
+	}
+	g.keys = append(g.keys, key)
+	g.keyColumns = append(g.keyColumns, columnIdx)
+	g.columns = append(g.columns, column)
+	return nil
+}
+
+// keyIndex returns the position of the key expr is, -1 when it is not one
+func (g *grouping) keyIndex(expr ast.Expression) int {
+	if identifier, ok := expr.(*ast.IdentifierExpr); ok {
+		columnIdx, err := g.input.ResolveColumn(identifier.Table, identifier.Value)
+		if err != nil {
+			return -1
+		}
+		return slices.Index(g.keyColumns, columnIdx)
+	}
+	for i, key := range g.keys {
+		if g.keyColumns[i] < 0 && key.ToExprString() == expr.ToExprString() {
+			return i
+		}
+	}
+	return -1
+}
+
+// rewrite replaces the group keys and the aggregates found in expr by
+// references to their column, the columns of the grouped rows can only
+// be used in them.
+func (g *grouping) rewrite(expr ast.Expression) (ast.Expression, error) {
+	if call, ok := expr.(*ast.CallExpr); ok && expression.AggregateName(call) != "" {
+		return g.addAggregate(call)
+	}
+	if idx := g.keyIndex(expr); idx >= 0 {
+		return &ast.IdentifierExpr{Table: g.columns[idx].Table, Value: g.columns[idx].Name}, nil
+	}
+
+	switch e := expr.(type) {
+	case *ast.IdentifierExpr:
+		if _, err := g.input.ResolveColumn(e.Table, e.Value); err != nil {
+			return nil, err
+		}
+		return nil, types.NewError(types.ERR_GROUPING_ERROR, "column %s must appear in the GROUP BY clause or be used in an aggregate function", e.ToExprString())
+	case *ast.PrefixExpr:
+		right, err := g.rewrite(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.PrefixExpr{Operator: e.Operator, Right: right}, nil
+	case *ast.InfixExpr:
+		left, err := g.rewrite(e.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := g.rewrite(e.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.InfixExpr{Left: left, Operator: e.Operator, Right: right}, nil
+	case *ast.IsNullExpr:
+		operand, err := g.rewrite(e.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.IsNullExpr{Expr: operand, Not: e.Not}, nil
+	}
+	return expr, nil
+}
+
+// addAggregate returns the reference to the column of an aggregate, the
+// same call made more than once is computed once.
+func (g *grouping) addAggregate(call *ast.CallExpr) (ast.Expression, error) {
+	name := aggregateColumnName(call)
+	for _, column := range g.columns[len(g.keys):] {
+		if column.Name == name {
+			return &ast.IdentifierExpr{Value: name}, nil
+		}
+	}
+
+	aggregate, err := expression.BindAggregate(call, g.input)
+	if err != nil {
+		return nil, err
+	}
+	g.aggregates = append(g.aggregates, call)
+	g.columns = append(g.columns, types.DataColumn{Name: name, DataType: aggregate.DataType()})
+	return &ast.IdentifierExpr{Value: name}, nil
+}
+
+// the name of the column of an aggregate, it cannot clash with the name of
+// a table column
+func aggregateColumnName(call *ast.CallExpr) string {
+	args := make([]string, len(call.Args))
+	for i, arg := range call.Args {
+		args[i] = arg.ToExprString()
+	}
+	distinct := ""
+	if call.Distinct {
+		distinct = "DISTINCT "
+	}
+	return expression.AggregateName(call) + "(" + distinct + strings.Join(args, ", ") + ")"
+}
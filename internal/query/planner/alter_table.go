@@ -0,0 +1,25 @@
+package planner
+
+import (
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/query/planner/logical"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// the table itself is resolved when the plan runs, under the catalog write lock
+func planAlterTable(stmt *ast.AlterTableStatement, schemaName string) (LogicalPlan, error) {
+	if stmt.Action == ast.ALTER_ADD_COLUMN && stmt.Column.Constraint.PrimaryKey {
+		return nil, types.NewError(types.ERR_FEATURE_NOT_SUPPORTED, "cannot add primary key column %s", stmt.Column.Name)
+	}
+
+	plan := logical.NewAlterTablePlan(stmt, schemaName)
+
+	// defaults can only be constant expressions
+	if plan.Default != nil {
+		if _, err := expression.Bind(plan.Default, &types.DataSchema{}); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
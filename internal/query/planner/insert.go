@@ -0,0 +1,56 @@
+package planner
+
+import (
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/query/planner/logical"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+func (p *Planner) planInsert(stmt *ast.InsertStatement) (LogicalPlan, error) {
+	p.catalog.RLock()
+	defer p.catalog.RUnlock()
+
+	schema, table, err := p.bindWritableTable(stmt.SchemaName, stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+	dataSchema := table.GetDataSchema()
+
+	// without a column list values are given in table order
+	columnIndexes := make([]int, 0, len(dataSchema.Columns))
+	if len(stmt.Columns) == 0 {
+		for idx := range dataSchema.Columns {
+			columnIndexes = append(columnIndexes, idx)
+		}
+	}
+
+	seen := make(map[string]bool, len(stmt.Columns))
+	for _, columnName := range stmt.Columns {
+		if seen[columnName] {
+			return nil, types.NewError(types.ERR_DUPLICATE_COLUMN, "column %s specified more than once", columnName)
+		}
+		seen[columnName] = true
+
+		idx := dataSchema.GetColumnIndex(columnName)
+		if idx < 0 {
+			return nil, types.NewError(types.ERR_UNDEFINED_COLUMN, "column %s of table %s does not exist", columnName, table.GetName())
+		}
+		columnIndexes = append(columnIndexes, idx)
+	}
+
+	// values can only be constant expressions
+	noColumns := &types.DataSchema{}
+	for _, row := range stmt.Values {
+		if len(row) != len(columnIndexes) {
+			return nil, types.NewError(types.ERR_SYNTAX_ERROR, "INSERT has %d values but %d target columns", len(row), len(columnIndexes))
+		}
+		for _, value := range row {
+			if _, err := expression.Bind(value, noColumns); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return logical.NewInsertPlan(schema, table, columnIndexes, stmt.Values), nil
+}
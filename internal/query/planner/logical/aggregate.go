@@ -0,0 +1,32 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// groups the rows of its child by the values of the GroupBy expressions and
+// computes the Aggregates over each group. Its rows hold the group keys
+// followed by the aggregates, all the rows form a single group when
+// GroupBy is empty.
+type AggregatePlan struct {
+	Child      LogicalPlan
+	GroupBy    []ast.Expression
+	Aggregates []*ast.CallExpr
+	dataSchema *types.DataSchema
+}
+
+// columns describes the group keys then the aggregates, their types are
+// only known once the expressions are bound.
+func NewAggregatePlan(child LogicalPlan, groupBy []ast.Expression, aggregates []*ast.CallExpr, columns []types.DataColumn) *AggregatePlan {
+	return &AggregatePlan{
+		Child:      child,
+		GroupBy:    groupBy,
+		Aggregates: aggregates,
+		dataSchema: &types.DataSchema{Columns: columns},
+	}
+}
+
+func (p *AggregatePlan) GetSchema() *types.DataSchema {
+	return p.dataSchema
+}
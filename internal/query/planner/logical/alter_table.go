@@ -0,0 +1,47 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// AlterTablePlan applies a single ALTER TABLE action, Column is only set
+// for ADD COLUMN and Default holds the default expression of the added
+// column or of SET DEFAULT.
+type AlterTablePlan struct {
+	SchemaName string
+	TableName  string
+	Action     ast.AlterTableAction
+	ColumnName string
+	Column     *catalog.Column
+	NewName    string
+	Default    ast.Expression
+}
+
+func NewAlterTablePlan(statement *ast.AlterTableStatement, schemaName string) *AlterTablePlan {
+	plan := &AlterTablePlan{
+		SchemaName: schemaName,
+		TableName:  statement.TableName,
+		Action:     statement.Action,
+		ColumnName: statement.ColumnName,
+		NewName:    statement.NewName,
+		Default:    statement.Default,
+	}
+
+	if statement.Action == ast.ALTER_ADD_COLUMN {
+		colDef := statement.Column
+		constraints := catalog.Constraint{
+			Unique:  colDef.Constraint.Unique,
+			NotNull: colDef.Constraint.NotNull,
+		}
+		plan.ColumnName = colDef.Name
+		plan.Column = catalog.NewColumn(0, colDef.Name, colDef.DataType, constraints)
+		plan.Default = colDef.Default
+	}
+	return plan
+}
+
+func (p *AlterTablePlan) GetSchema() *types.DataSchema {
+	return nil
+}
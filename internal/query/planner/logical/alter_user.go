@@ -0,0 +1,22 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+type AlterUserPlan struct {
+	UserName string
+	Password *string
+}
+
+func NewAlterUserPlan(statement *ast.AlterUserStatement) *AlterUserPlan {
+	return &AlterUserPlan{
+		UserName: statement.UserName,
+		Password: statement.Password,
+	}
+}
+
+func (p *AlterUserPlan) GetSchema() *types.DataSchema {
+	return nil
+}
@@ -0,0 +1,28 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+type CreateIndexPlan struct {
+	IndexName  string
+	SchemaName string
+	TableName  string
+	Columns    []string
+	Unique     bool
+}
+
+func NewCreateIndexPlan(statement *ast.CreateIndexStatement, schemaName string) *CreateIndexPlan {
+	return &CreateIndexPlan{
+		IndexName:  statement.IndexName,
+		SchemaName: schemaName,
+		TableName:  statement.TableName,
+		Columns:    statement.Columns,
+		Unique:     statement.Unique,
+	}
+}
+
+func (p *CreateIndexPlan) GetSchema() *types.DataSchema {
+	return nil
+}
@@ -7,28 +7,39 @@ import (
 )
 
 type CreateTablePlan struct {
+	SchemaName  string
 	TableName   string
 	Columns     []catalog.Column
+	Defaults    []ast.Expression // by column position, nil when not set
 	IfNotExists bool
 	PrimaryKeys []string
 }
 
-func NewCreateTablePlan(statement *ast.CreateTableStatement) *CreateTablePlan {
+func NewCreateTablePlan(statement *ast.CreateTableStatement, schemaName string) *CreateTablePlan {
 	columns := make([]catalog.Column, 0, len(statement.Columns))
+	defaults := make([]ast.Expression, 0, len(statement.Columns))
+	primaryKeys := append([]string{}, statement.PrimaryKeys...)
 	for _, colDef := range statement.Columns {
 		constraints := catalog.Constraint{
 			Unique:  colDef.Constraint.Unique,
 			NotNull: colDef.Constraint.NotNull,
 		}
+		if colDef.Constraint.PrimaryKey {
+			primaryKeys = append(primaryKeys, colDef.Name)
+			constraints.NotNull = true
+		}
 		column := catalog.NewColumn(0, colDef.Name, colDef.DataType, constraints)
 		columns = append(columns, *column)
+		defaults = append(defaults, colDef.Default)
 	}
 
 	return &CreateTablePlan{
+		SchemaName:  schemaName,
 		TableName:   statement.TableName,
 		Columns:     columns,
+		Defaults:    defaults,
 		IfNotExists: statement.IfNotExists,
-		PrimaryKeys: statement.PrimaryKeys,
+		PrimaryKeys: primaryKeys,
 	}
 }
 
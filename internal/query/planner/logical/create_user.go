@@ -0,0 +1,22 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+type CreateUserPlan struct {
+	UserName string
+	Password *string
+}
+
+func NewCreateUserPlan(statement *ast.CreateUserStatement) *CreateUserPlan {
+	return &CreateUserPlan{
+		UserName: statement.UserName,
+		Password: statement.Password,
+	}
+}
+
+func (p *CreateUserPlan) GetSchema() *types.DataSchema {
+	return nil
+}
@@ -0,0 +1,25 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+type DeletePlan struct {
+	Schema    *catalog.Schema
+	Table     *catalog.Table
+	Predicate ast.Expression
+}
+
+func NewDeletePlan(schema *catalog.Schema, table *catalog.Table, predicate ast.Expression) *DeletePlan {
+	return &DeletePlan{
+		Schema:    schema,
+		Table:     table,
+		Predicate: predicate,
+	}
+}
+
+func (p *DeletePlan) GetSchema() *types.DataSchema {
+	return types.COUNT_SCHEMA
+}
@@ -0,0 +1,22 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+type DropIndexPlan struct {
+	SchemaName string
+	IndexName  string
+}
+
+func NewDropIndexPlan(statement *ast.DropIndexStatement, schemaName string) *DropIndexPlan {
+	return &DropIndexPlan{
+		SchemaName: schemaName,
+		IndexName:  statement.IndexName,
+	}
+}
+
+func (p *DropIndexPlan) GetSchema() *types.DataSchema {
+	return nil
+}
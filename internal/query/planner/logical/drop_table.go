@@ -0,0 +1,22 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+type DropTablePlan struct {
+	SchemaName string
+	TableName  string
+}
+
+func NewDropTablePlan(statement *ast.DropTableStatement, schemaName string) *DropTablePlan {
+	return &DropTablePlan{
+		SchemaName: schemaName,
+		TableName:  statement.TableName,
+	}
+}
+
+func (p *DropTablePlan) GetSchema() *types.DataSchema {
+	return nil
+}
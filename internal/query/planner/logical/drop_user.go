@@ -0,0 +1,20 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+type DropUserPlan struct {
+	UserName string
+}
+
+func NewDropUserPlan(statement *ast.DropUserStatement) *DropUserPlan {
+	return &DropUserPlan{
+		UserName: statement.UserName,
+	}
+}
+
+func (p *DropUserPlan) GetSchema() *types.DataSchema {
+	return nil
+}
@@ -0,0 +1,23 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// keeps the rows of its child for which the predicate holds
+type FilterPlan struct {
+	Child     LogicalPlan
+	Predicate ast.Expression
+}
+
+func NewFilterPlan(child LogicalPlan, predicate ast.Expression) *FilterPlan {
+	return &FilterPlan{
+		Child:     child,
+		Predicate: predicate,
+	}
+}
+
+func (p *FilterPlan) GetSchema() *types.DataSchema {
+	return p.Child.GetSchema()
+}
@@ -0,0 +1,28 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+type InsertPlan struct {
+	Schema *catalog.Schema
+	Table  *catalog.Table
+	// position in the table of each of the value expressions of a row
+	ColumnIndexes []int
+	Values        [][]ast.Expression
+}
+
+func NewInsertPlan(schema *catalog.Schema, table *catalog.Table, columnIndexes []int, values [][]ast.Expression) *InsertPlan {
+	return &InsertPlan{
+		Schema:        schema,
+		Table:         table,
+		ColumnIndexes: columnIndexes,
+		Values:        values,
+	}
+}
+
+func (p *InsertPlan) GetSchema() *types.DataSchema {
+	return types.COUNT_SCHEMA
+}
@@ -0,0 +1,60 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// combines the rows of two plans. Its rows hold the left columns followed
+// by the right ones, a join USING columns starts with the merged value of
+// each pair of them.
+type JoinPlan struct {
+	Type  ast.JoinType
+	Left  LogicalPlan
+	Right LogicalPlan
+	// Condition is the ON clause, nil for CROSS joins and joins USING columns
+	Condition ast.Expression
+	// Using pairs the positions in Left and Right of the USING columns
+	Using      []JoinColumns
+	dataSchema *types.DataSchema
+}
+
+// JoinColumns pairs a column of the left side of a join with one of its right side
+type JoinColumns struct {
+	Left  int
+	Right int
+}
+
+func NewJoinPlan(joinType ast.JoinType, left LogicalPlan, right LogicalPlan, condition ast.Expression, using []JoinColumns) *JoinPlan {
+	leftColumns, rightColumns := left.GetSchema().Columns, right.GetSchema().Columns
+	columns := make([]types.DataColumn, 0, len(using)+len(leftColumns)+len(rightColumns))
+
+	// the merged columns take the value of the side the row comes from,
+	// the columns of both sides remain reachable through their table
+	for _, pair := range using {
+		column := types.DataColumn{Name: leftColumns[pair.Left].Name, DataType: leftColumns[pair.Left].DataType}
+		if column.DataType != rightColumns[pair.Right].DataType {
+			column.DataType = types.TYPE_FLOAT
+		}
+		columns = append(columns, column)
+	}
+	columns = append(columns, leftColumns...)
+	columns = append(columns, rightColumns...)
+	for _, pair := range using {
+		columns[len(using)+pair.Left].Hidden = true
+		columns[len(using)+len(leftColumns)+pair.Right].Hidden = true
+	}
+
+	return &JoinPlan{
+		Type:       joinType,
+		Left:       left,
+		Right:      right,
+		Condition:  condition,
+		Using:      using,
+		dataSchema: &types.DataSchema{Columns: columns},
+	}
+}
+
+func (p *JoinPlan) GetSchema() *types.DataSchema {
+	return p.dataSchema
+}
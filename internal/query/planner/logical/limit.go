@@ -0,0 +1,22 @@
+package logical
+
+import "github.com/evanxg852000/foxdb/internal/types"
+
+// skips `Offset` rows then returns at most `Limit` rows, a nil limit means no limit
+type LimitPlan struct {
+	Child  LogicalPlan
+	Limit  *uint64
+	Offset uint64
+}
+
+func NewLimitPlan(child LogicalPlan, limit *uint64, offset uint64) *LimitPlan {
+	return &LimitPlan{
+		Child:  child,
+		Limit:  limit,
+		Offset: offset,
+	}
+}
+
+func (p *LimitPlan) GetSchema() *types.DataSchema {
+	return p.Child.GetSchema()
+}
@@ -0,0 +1,7 @@
+package logical
+
+import "github.com/evanxg852000/foxdb/internal/types"
+
+type LogicalPlan interface {
+	GetSchema() *types.DataSchema
+}
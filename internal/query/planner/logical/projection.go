@@ -0,0 +1,26 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// computes the columns of a SELECT from the rows of its child, Exprs are
+// bound to the schema of the child and columns describes their results.
+type ProjectionPlan struct {
+	Child      LogicalPlan
+	Exprs      []expression.BoundExpr
+	dataSchema *types.DataSchema
+}
+
+func NewProjectionPlan(child LogicalPlan, exprs []expression.BoundExpr, columns []types.DataColumn) *ProjectionPlan {
+	return &ProjectionPlan{
+		Child:      child,
+		Exprs:      exprs,
+		dataSchema: &types.DataSchema{Columns: columns},
+	}
+}
+
+func (p *ProjectionPlan) GetSchema() *types.DataSchema {
+	return p.dataSchema
+}
@@ -0,0 +1,29 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// reads all the records of a table, its columns are qualified by the alias
+// of the table in the query or by its name
+type ScanPlan struct {
+	Schema     *catalog.Schema
+	Table      *catalog.Table
+	dataSchema *types.DataSchema
+}
+
+func NewScanPlan(schema *catalog.Schema, table *catalog.Table, alias string) *ScanPlan {
+	if alias == "" {
+		alias = table.GetName()
+	}
+	return &ScanPlan{
+		Schema:     schema,
+		Table:      table,
+		dataSchema: table.GetDataSchema().Qualify(alias),
+	}
+}
+
+func (p *ScanPlan) GetSchema() *types.DataSchema {
+	return p.dataSchema
+}
@@ -0,0 +1,22 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+type SortPlan struct {
+	Child   LogicalPlan
+	OrderBy []ast.SortExpr
+}
+
+func NewSortPlan(child LogicalPlan, orderBy []ast.SortExpr) *SortPlan {
+	return &SortPlan{
+		Child:   child,
+		OrderBy: orderBy,
+	}
+}
+
+func (p *SortPlan) GetSchema() *types.DataSchema {
+	return p.Child.GetSchema()
+}
@@ -0,0 +1,32 @@
+package logical
+
+import (
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+type Assignment struct {
+	ColumnIndex int
+	Value       ast.Expression
+}
+
+type UpdatePlan struct {
+	Schema      *catalog.Schema
+	Table       *catalog.Table
+	Assignments []Assignment
+	Predicate   ast.Expression
+}
+
+func NewUpdatePlan(schema *catalog.Schema, table *catalog.Table, assignments []Assignment, predicate ast.Expression) *UpdatePlan {
+	return &UpdatePlan{
+		Schema:      schema,
+		Table:       table,
+		Assignments: assignments,
+		Predicate:   predicate,
+	}
+}
+
+func (p *UpdatePlan) GetSchema() *types.DataSchema {
+	return types.COUNT_SCHEMA
+}
@@ -0,0 +1,308 @@
+package planner
+
+import (
+	"strings"
+
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// ParameterTypes infers the type of each parameter of stmt from the column
+// it is assigned to or compared with, parameters nothing tells about are TEXT.
+func (p *Planner) ParameterTypes(queryAst ast.Statement) ([]types.DataType, error) {
+	// without parameters nothing is looked up, the objects the statement
+	// uses may not exist until the statements before it run
+	if parameterCount(queryAst) == 0 {
+		return nil, nil
+	}
+
+	p.catalog.RLock()
+	defer p.catalog.RUnlock()
+
+	inferrer := &parameterInferrer{}
+	switch stmt := queryAst.(type) {
+	case *ast.SelectStatement:
+		plan, err := p.planFrom(stmt)
+		if err != nil {
+			return nil, err
+		}
+		inferrer.dataSchema = plan.GetSchema()
+		for _, join := range stmt.Joins {
+			inferrer.infer(join.On, types.TYPE_BOOL)
+		}
+		inferrer.infer(stmt.WhereClause, types.TYPE_BOOL)
+		for _, column := range stmt.Columns {
+			inferrer.infer(column, 0)
+		}
+		for _, key := range stmt.GroupBy {
+			inferrer.infer(key, 0)
+		}
+		inferrer.infer(stmt.Having, types.TYPE_BOOL)
+		for _, sortExpr := range stmt.OrderBy {
+			inferrer.infer(sortExpr.Expr, 0)
+		}
+	case *ast.InsertStatement:
+		_, table, err := p.bindTable(stmt.SchemaName, stmt.TableName)
+		if err != nil {
+			return nil, err
+		}
+		dataSchema := table.GetDataSchema()
+		for _, row := range stmt.Values {
+			for i, value := range row {
+				var dataType types.DataType
+				if len(stmt.Columns) == 0 && i < len(dataSchema.Columns) {
+					dataType = dataSchema.Columns[i].DataType
+				} else if i < len(stmt.Columns) {
+					dataType = columnType(dataSchema, stmt.Columns[i])
+				}
+				inferrer.infer(value, dataType)
+			}
+		}
+	case *ast.UpdateStatement:
+		_, table, err := p.bindTable(stmt.SchemaName, stmt.TableName)
+		if err != nil {
+			return nil, err
+		}
+		inferrer.dataSchema = table.GetDataSchema()
+		for _, assignment := range stmt.Assignments {
+			inferrer.infer(assignment.Value, columnType(inferrer.dataSchema, assignment.Column))
+		}
+		inferrer.infer(stmt.WhereClause, types.TYPE_BOOL)
+	case *ast.DeleteStatement:
+		_, table, err := p.bindTable(stmt.SchemaName, stmt.TableName)
+		if err != nil {
+			return nil, err
+		}
+		inferrer.dataSchema = table.GetDataSchema()
+		inferrer.infer(stmt.WhereClause, types.TYPE_BOOL)
+	}
+
+	for i, dataType := range inferrer.types {
+		if dataType == 0 {
+			inferrer.types[i] = types.TYPE_TEXT
+		}
+	}
+	return inferrer.types, nil
+}
+
+type parameterInferrer struct {
+	dataSchema *types.DataSchema
+	types      []types.DataType
+}
+
+// infer walks expr, the value expected from it types a parameter found there
+func (pi *parameterInferrer) infer(expr ast.Expression, expected types.DataType) {
+	switch e := expr.(type) {
+	case *ast.ParameterExpr:
+		for len(pi.types) < e.Index {
+			pi.types = append(pi.types, 0)
+		}
+		if pi.types[e.Index-1] == 0 {
+			pi.types[e.Index-1] = expected
+		}
+	case *ast.PrefixExpr:
+		if strings.ToUpper(e.Operator) == "NOT" {
+			expected = types.TYPE_BOOL
+		}
+		pi.infer(e.Right, expected)
+	case *ast.IsNullExpr:
+		pi.infer(e.Expr, 0)
+	case *ast.CallExpr:
+		for _, arg := range e.Args {
+			pi.infer(arg, 0)
+		}
+	case *ast.InfixExpr:
+		switch strings.ToUpper(e.Operator) {
+		case "AND", "OR":
+			pi.infer(e.Left, types.TYPE_BOOL)
+			pi.infer(e.Right, types.TYPE_BOOL)
+		case "=", "!=", "<>", "<", "<=", ">", ">=":
+			// operands of a comparison are typed by each other
+			pi.infer(e.Left, pi.typeOf(e.Right))
+			pi.infer(e.Right, pi.typeOf(e.Left))
+		default:
+			if left := pi.typeOf(e.Left); left != 0 {
+				pi.infer(e.Right, left)
+			} else {
+				pi.infer(e.Right, expected)
+			}
+			pi.infer(e.Left, pi.typeOf(e.Right))
+		}
+	}
+}
+
+// typeOf is the type expr evaluates to, 0 when it is not known yet
+func (pi *parameterInferrer) typeOf(expr ast.Expression) types.DataType {
+	switch e := expr.(type) {
+	case *ast.IntegerLiteralExpr:
+		return types.TYPE_INT
+	case *ast.FloatLiteralExpr:
+		return types.TYPE_FLOAT
+	case *ast.StringLiteralExpr:
+		return types.TYPE_TEXT
+	case *ast.BooleanLiteralExpr, *ast.IsNullExpr:
+		return types.TYPE_BOOL
+	case *ast.IdentifierExpr:
+		if pi.dataSchema != nil {
+			if idx, err := pi.dataSchema.ResolveColumn(e.Table, e.Value); err == nil {
+				return pi.dataSchema.Columns[idx].DataType
+			}
+		}
+	case *ast.ParameterExpr:
+		if e.Index <= len(pi.types) {
+			return pi.types[e.Index-1]
+		}
+	case *ast.PrefixExpr:
+		if strings.ToUpper(e.Operator) == "NOT" {
+			return types.TYPE_BOOL
+		}
+		return pi.typeOf(e.Right)
+	case *ast.CallExpr:
+		if name := expression.AggregateName(e); name != "" && len(e.Args) > 0 {
+			return expression.AggregateType(name, pi.typeOf(e.Args[0]))
+		}
+	case *ast.InfixExpr:
+		switch strings.ToUpper(e.Operator) {
+		case "+", "-", "*", "/":
+			left, right := pi.typeOf(e.Left), pi.typeOf(e.Right)
+			if left == types.TYPE_FLOAT || right == types.TYPE_FLOAT {
+				return types.TYPE_FLOAT
+			}
+			if left != 0 {
+				return left
+			}
+			return right
+		default:
+			return types.TYPE_BOOL
+		}
+	}
+	return 0
+}
+
+func columnType(dataSchema *types.DataSchema, name string) types.DataType {
+	idx := dataSchema.GetColumnIndex(name)
+	if idx < 0 {
+		return 0
+	}
+	return dataSchema.Columns[idx].DataType
+}
+
+// BindParameters returns a copy of stmt where each parameter is replaced by
+// the literal of its value, stmt itself is left untouched so that it can be
+// bound again.
+func BindParameters(queryAst ast.Statement, values []types.Value) (ast.Statement, error) {
+	binder := &parameterBinder{values: values}
+	queryAst = binder.bindStatement(queryAst)
+	if binder.count != len(values) {
+		return nil, types.NewError(types.ERR_PROTOCOL_VIOLATION, "bind message supplies %d parameters, but prepared statement requires %d", len(values), binder.count)
+	}
+	return queryAst, nil
+}
+
+// parameterCount is the number of parameters stmt requires
+func parameterCount(stmt ast.Statement) int {
+	binder := &parameterBinder{}
+	binder.bindStatement(stmt)
+	return binder.count
+}
+
+type parameterBinder struct {
+	values []types.Value
+	// number of parameters the statement requires
+	count int
+}
+
+// bindStatement returns a copy of stmt with its parameters bound
+func (pb *parameterBinder) bindStatement(queryAst ast.Statement) ast.Statement {
+	switch stmt := queryAst.(type) {
+	case *ast.SelectStatement:
+		bound := *stmt
+		bound.Joins = make([]ast.JoinClause, len(stmt.Joins))
+		for i, join := range stmt.Joins {
+			bound.Joins[i] = join
+			bound.Joins[i].On = pb.bind(join.On)
+		}
+		bound.Columns = pb.bindAll(stmt.Columns)
+		bound.WhereClause = pb.bind(stmt.WhereClause)
+		bound.GroupBy = pb.bindAll(stmt.GroupBy)
+		bound.Having = pb.bind(stmt.Having)
+		bound.OrderBy = make([]ast.SortExpr, len(stmt.OrderBy))
+		for i, sortExpr := range stmt.OrderBy {
+			bound.OrderBy[i] = ast.SortExpr{Expr: pb.bind(sortExpr.Expr), Ascending: sortExpr.Ascending}
+		}
+		queryAst = &bound
+	case *ast.InsertStatement:
+		bound := *stmt
+		bound.Values = make([][]ast.Expression, len(stmt.Values))
+		for i, row := range stmt.Values {
+			bound.Values[i] = make([]ast.Expression, len(row))
+			for j, value := range row {
+				bound.Values[i][j] = pb.bind(value)
+			}
+		}
+		queryAst = &bound
+	case *ast.UpdateStatement:
+		bound := *stmt
+		bound.Assignments = make([]ast.Assignment, len(stmt.Assignments))
+		for i, assignment := range stmt.Assignments {
+			bound.Assignments[i] = ast.Assignment{Column: assignment.Column, Value: pb.bind(assignment.Value)}
+		}
+		bound.WhereClause = pb.bind(stmt.WhereClause)
+		queryAst = &bound
+	case *ast.DeleteStatement:
+		bound := *stmt
+		bound.WhereClause = pb.bind(stmt.WhereClause)
+		queryAst = &bound
+	}
+	return queryAst
+}
+
+func (pb *parameterBinder) bind(expr ast.Expression) ast.Expression {
+	switch e := expr.(type) {
+	case *ast.ParameterExpr:
+		pb.count = max(pb.count, e.Index)
+		if e.Index > len(pb.values) {
+			return e
+		}
+		return literalExpr(pb.values[e.Index-1])
+	case *ast.PrefixExpr:
+		return &ast.PrefixExpr{Operator: e.Operator, Right: pb.bind(e.Right)}
+	case *ast.InfixExpr:
+		return &ast.InfixExpr{Left: pb.bind(e.Left), Operator: e.Operator, Right: pb.bind(e.Right)}
+	case *ast.IsNullExpr:
+		return &ast.IsNullExpr{Expr: pb.bind(e.Expr), Not: e.Not}
+	case *ast.CallExpr:
+		return &ast.CallExpr{Function: e.Function, Args: pb.bindAll(e.Args), Distinct: e.Distinct}
+	}
+	return expr
+}
+
+func (pb *parameterBinder) bindAll(exprs []ast.Expression) []ast.Expression {
+	if exprs == nil {
+		return nil
+	}
+	bound := make([]ast.Expression, len(exprs))
+	for i, expr := range exprs {
+		bound[i] = pb.bind(expr)
+	}
+	return bound
+}
+
+func literalExpr(value types.Value) ast.Expression {
+	switch value.GetDataType() {
+	case types.TYPE_INT:
+		v, _ := value.Int()
+		return &ast.IntegerLiteralExpr{Value: v}
+	case types.TYPE_FLOAT:
+		v, _ := value.Float()
+		return &ast.FloatLiteralExpr{Value: v}
+	case types.TYPE_BOOL:
+		v, _ := value.Bool()
+		return &ast.BooleanLiteralExpr{Value: v}
+	case types.TYPE_TEXT:
+		v, _ := value.Text()
+		return &ast.StringLiteralExpr{Value: v}
+	}
+	return &ast.NullLiteralExpr{}
+}
@@ -1,26 +1,25 @@
 package planner
 
 import (
-	"fmt"
-
 	"github.com/evanxg852000/foxdb/internal/catalog"
 	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
 	"github.com/evanxg852000/foxdb/internal/query/planner/logical"
 	"github.com/evanxg852000/foxdb/internal/types"
 )
 
-type LogicalPlan interface {
-	GetSchema() *types.DataSchema
-}
+type LogicalPlan = logical.LogicalPlan
 
 // plan and bind the ast to generate a logical plan
 type Planner struct {
 	catalog *catalog.RootCatalog
+	// schemas unqualified names are looked up in, in order
+	searchPath []string
 }
 
-func NewPlanner(catalog *catalog.RootCatalog) *Planner {
+func NewPlanner(catalog *catalog.RootCatalog, searchPath []string) *Planner {
 	return &Planner{
-		catalog: catalog,
+		catalog:    catalog,
+		searchPath: searchPath,
 	}
 }
 
@@ -28,8 +27,126 @@ func (p *Planner) Plan(queryAst ast.Statement) (LogicalPlan, error) {
 	switch stmt := queryAst.(type) {
 	case *ast.CreateSchemaStatement:
 		return logical.NewCreateSchemaPlan(stmt), nil
+	case *ast.CreateTableStatement:
+		schemaName, err := p.creationSchemaName(stmt.SchemaName)
+		if err != nil {
+			return nil, err
+		}
+		return logical.NewCreateTablePlan(stmt, schemaName), nil
+	case *ast.DropTableStatement:
+		schemaName, err := p.tableSchemaName(stmt.SchemaName, stmt.TableName)
+		if err != nil {
+			return nil, err
+		}
+		return logical.NewDropTablePlan(stmt, schemaName), nil
+	case *ast.AlterTableStatement:
+		schemaName, err := p.tableSchemaName(stmt.SchemaName, stmt.TableName)
+		if err != nil {
+			return nil, err
+		}
+		return planAlterTable(stmt, schemaName)
+	case *ast.CreateIndexStatement:
+		schemaName, err := p.tableSchemaName(stmt.SchemaName, stmt.TableName)
+		if err != nil {
+			return nil, err
+		}
+		return logical.NewCreateIndexPlan(stmt, schemaName), nil
+	case *ast.DropIndexStatement:
+		schemaName, err := p.indexSchemaName(stmt.SchemaName, stmt.IndexName)
+		if err != nil {
+			return nil, err
+		}
+		return logical.NewDropIndexPlan(stmt, schemaName), nil
+	case *ast.CreateUserStatement:
+		return logical.NewCreateUserPlan(stmt), nil
+	case *ast.DropUserStatement:
+		return logical.NewDropUserPlan(stmt), nil
+	case *ast.AlterUserStatement:
+		return logical.NewAlterUserPlan(stmt), nil
+	case *ast.SelectStatement:
+		return p.planSelect(stmt)
+	case *ast.InsertStatement:
+		return p.planInsert(stmt)
+	case *ast.UpdateStatement:
+		return p.planUpdate(stmt)
+	case *ast.DeleteStatement:
+		return p.planDelete(stmt)
 
 	default:
-		return nil, fmt.Errorf("unsupported statement type: %T", queryAst)
+		return nil, types.NewError(types.ERR_FEATURE_NOT_SUPPORTED, "unsupported statement type: %T", queryAst)
+	}
+}
+
+// looks up a table, the catalog read lock must be held by the caller
+func (p *Planner) bindTable(schemaName string, tableName string) (*catalog.Schema, *catalog.Table, error) {
+	schemaName, ok := p.lookupSchemaName(schemaName, func(schema *catalog.Schema) bool {
+		return schema.GetTable(tableName) != nil
+	})
+	if !ok {
+		return nil, nil, types.NewError(types.ERR_UNDEFINED_TABLE, "table %s does not exist", tableName)
+	}
+	schema := p.catalog.GetSchema(schemaName)
+	if schema == nil {
+		return nil, nil, types.NewError(types.ERR_INVALID_SCHEMA_NAME, "schema %s does not exist", schemaName)
+	}
+
+	table := schema.GetTable(tableName)
+	if table == nil {
+		return nil, nil, types.NewError(types.ERR_UNDEFINED_TABLE, "table %s.%s does not exist", schemaName, tableName)
+	}
+	return schema, table, nil
+}
+
+// lookupSchemaName returns schemaName when the name is qualified, otherwise
+// the first schema of the search path that holds the object. The catalog
+// read lock must be held by the caller.
+func (p *Planner) lookupSchemaName(schemaName string, holds func(*catalog.Schema) bool) (string, bool) {
+	if schemaName != "" {
+		return schemaName, true
+	}
+	for _, name := range p.searchPath {
+		if schema := p.catalog.GetSchema(name); schema != nil && holds(schema) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// tableSchemaName resolves the schema of the table a DDL statement names,
+// the table itself is checked again when the plan runs.
+func (p *Planner) tableSchemaName(schemaName string, tableName string) (string, error) {
+	p.catalog.RLock()
+	defer p.catalog.RUnlock()
+	schemaName, ok := p.lookupSchemaName(schemaName, func(schema *catalog.Schema) bool {
+		return schema.GetTable(tableName) != nil
+	})
+	if !ok {
+		return "", types.NewError(types.ERR_UNDEFINED_TABLE, "table %s does not exist", tableName)
+	}
+	return schemaName, nil
+}
+
+func (p *Planner) indexSchemaName(schemaName string, indexName string) (string, error) {
+	p.catalog.RLock()
+	defer p.catalog.RUnlock()
+	schemaName, ok := p.lookupSchemaName(schemaName, func(schema *catalog.Schema) bool {
+		_, index := schema.FindIndex(indexName)
+		return index != nil
+	})
+	if !ok {
+		return "", types.NewError(types.ERR_UNDEFINED_OBJECT, "index %s does not exist", indexName)
+	}
+	return schemaName, nil
+}
+
+// creationSchemaName is the schema an unqualified object is created in,
+// the first schema of the search path that exists.
+func (p *Planner) creationSchemaName(schemaName string) (string, error) {
+	p.catalog.RLock()
+	defer p.catalog.RUnlock()
+	schemaName, ok := p.lookupSchemaName(schemaName, func(*catalog.Schema) bool { return true })
+	if !ok {
+		return "", types.NewError(types.ERR_INVALID_SCHEMA_NAME, "no schema has been selected to create in")
 	}
+	return schemaName, nil
 }
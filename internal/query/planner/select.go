@@ -0,0 +1,200 @@
+package planner
+
+import (
+	"strings"
+
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/query/planner/logical"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// builds scan [-> join ...] -> filter [-> aggregate -> filter] -> sort -> limit -> projection
+func (p *Planner) planSelect(stmt *ast.SelectStatement) (LogicalPlan, error) {
+	p.catalog.RLock()
+	defer p.catalog.RUnlock()
+
+	plan, err := p.planFrom(stmt)
+	if err != nil {
+		return nil, err
+	}
+	dataSchema := plan.GetSchema()
+
+	if stmt.WhereClause != nil {
+		if err := checkNoAggregate(stmt.WhereClause, "WHERE"); err != nil {
+			return nil, err
+		}
+		if _, err := expression.BindPredicate(stmt.WhereClause, dataSchema, "WHERE"); err != nil {
+			return nil, err
+		}
+		plan = logical.NewFilterPlan(plan, stmt.WhereClause)
+	}
+
+	items, err := selectItems(stmt.Columns, dataSchema)
+	if err != nil {
+		return nil, err
+	}
+	orderBy := stmt.OrderBy
+	if isGrouped(stmt) {
+		// the clauses evaluated after grouping refer to its columns
+		if plan, items, orderBy, err = planGrouping(stmt, plan, items); err != nil {
+			return nil, err
+		}
+		dataSchema = plan.GetSchema()
+	}
+
+	if len(orderBy) > 0 {
+		for _, sortExpr := range orderBy {
+			if _, err := expression.Bind(sortExpr.Expr, dataSchema); err != nil {
+				return nil, err
+			}
+		}
+		plan = logical.NewSortPlan(plan, orderBy)
+	}
+
+	if stmt.Limit != nil || stmt.Offset > 0 {
+		plan = logical.NewLimitPlan(plan, stmt.Limit, stmt.Offset)
+	}
+
+	// the SELECT list is bound to the rows it is computed from
+	exprs := make([]expression.BoundExpr, len(items))
+	columns := make([]types.DataColumn, len(items))
+	for i, item := range items {
+		if exprs[i], err = expression.Bind(item.expr, dataSchema); err != nil {
+			return nil, err
+		}
+		columns[i] = selectColumn(item, exprs[i], dataSchema)
+	}
+	return logical.NewProjectionPlan(plan, exprs, columns), nil
+}
+
+// planFrom plans the FROM clause, its tables are joined from left to
+// right. The catalog read lock must be held by the caller.
+func (p *Planner) planFrom(stmt *ast.SelectStatement) (LogicalPlan, error) {
+	schema, table, err := p.bindTable(stmt.SchemaName, stmt.FromClause)
+	if err != nil {
+		return nil, err
+	}
+	var plan LogicalPlan = logical.NewScanPlan(schema, table, stmt.Alias)
+
+	names := map[string]bool{tableName(stmt.FromClause, stmt.Alias): true}
+	for _, join := range stmt.Joins {
+		name := tableName(join.TableName, join.Alias)
+		if names[name] {
+			return nil, types.NewError(types.ERR_DUPLICATE_ALIAS, "table name %s specified more than once", name)
+		}
+		names[name] = true
+
+		schema, table, err := p.bindTable(join.SchemaName, join.TableName)
+		if err != nil {
+			return nil, err
+		}
+		right := logical.NewScanPlan(schema, table, join.Alias)
+		if plan, err = planJoin(plan, right, join); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// the name qualifying the columns of a table in a query
+func tableName(name string, alias string) string {
+	if alias != "" {
+		return alias
+	}
+	return name
+}
+
+func planJoin(left LogicalPlan, right LogicalPlan, join ast.JoinClause) (LogicalPlan, error) {
+	if join.On != nil {
+		if err := checkNoAggregate(join.On, "JOIN/ON"); err != nil {
+			return nil, err
+		}
+		plan := logical.NewJoinPlan(join.Type, left, right, join.On, nil)
+		// the condition sees the columns of both sides, not the merged ones
+		if _, err := expression.BindPredicate(join.On, plan.GetSchema(), "JOIN/ON"); err != nil {
+			return nil, err
+		}
+		return plan, nil
+	}
+
+	using := make([]logical.JoinColumns, len(join.Using))
+	for i, name := range join.Using {
+		leftIdx, err := left.GetSchema().ResolveColumn("", name)
+		if err != nil {
+			return nil, usingError(err, name, "left")
+		}
+		rightIdx, err := right.GetSchema().ResolveColumn("", name)
+		if err != nil {
+			return nil, usingError(err, name, "right")
+		}
+		leftType, rightType := left.GetSchema().Columns[leftIdx].DataType, right.GetSchema().Columns[rightIdx].DataType
+		if leftType != rightType && !(isNumeric(leftType) && isNumeric(rightType)) {
+			return nil, types.NewError(types.ERR_DATATYPE_MISMATCH, "JOIN/USING types %s and %s cannot be matched", leftType, rightType)
+		}
+		using[i] = logical.JoinColumns{Left: leftIdx, Right: rightIdx}
+	}
+	return logical.NewJoinPlan(join.Type, left, right, nil, using), nil
+}
+
+func usingError(err error, name string, side string) error {
+	if types.GetErrorCode(err) == types.ERR_UNDEFINED_COLUMN {
+		return types.NewError(types.ERR_UNDEFINED_COLUMN, "column %s specified in USING clause does not exist in %s table", name, side)
+	}
+	return err
+}
+
+func isNumeric(dataType types.DataType) bool {
+	return dataType == types.TYPE_INT || dataType == types.TYPE_FLOAT
+}
+
+// selectItem is an expression of the SELECT list and the name of its column
+// when it is not the name of the column the expression refers to.
+type selectItem struct {
+	expr ast.Expression
+	name string
+}
+
+// selectColumn describes the result of a SELECT item, a column is copied
+// and other expressions are named ?column? unless the item names them.
+func selectColumn(item selectItem, bound expression.BoundExpr, dataSchema *types.DataSchema) types.DataColumn {
+	column := types.DataColumn{Name: "?column?", DataType: bound.DataType()}
+	if identifier, ok := item.expr.(*ast.IdentifierExpr); ok {
+		idx, _ := dataSchema.ResolveColumn(identifier.Table, identifier.Value)
+		column = dataSchema.Columns[idx]
+	}
+	if item.name != "" {
+		column.Name = item.name
+	}
+	return column
+}
+
+// selectItems expands the stars of the SELECT list, * stands for the
+// columns of all the tables and table.* for the columns of one of them.
+func selectItems(columns []ast.Expression, dataSchema *types.DataSchema) ([]selectItem, error) {
+	items := make([]selectItem, 0, len(columns))
+	for _, column := range columns {
+		star, ok := column.(*ast.StarExpr)
+		if !ok {
+			item := selectItem{expr: column}
+			// aggregates are named after their function
+			if call, ok := column.(*ast.CallExpr); ok {
+				item.name = strings.ToLower(expression.AggregateName(call))
+			}
+			items = append(items, item)
+			continue
+		}
+
+		found := false
+		for _, column := range dataSchema.Columns {
+			if (star.Table == "" && !column.Hidden) || (star.Table != "" && column.Table == star.Table) {
+				items = append(items, selectItem{expr: &ast.IdentifierExpr{Table: column.Table, Value: column.Name}})
+				found = true
+			}
+		}
+		if star.Table != "" && !found {
+			return nil, types.NewError(types.ERR_UNDEFINED_TABLE, "missing FROM-clause entry for table %s", star.Table)
+		}
+	}
+	return items, nil
+}
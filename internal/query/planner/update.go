@@ -0,0 +1,74 @@
+package planner
+
+import (
+	"github.com/evanxg852000/foxdb/internal/catalog"
+	"github.com/evanxg852000/foxdb/internal/query/expression"
+	"github.com/evanxg852000/foxdb/internal/query/parser/ast"
+	"github.com/evanxg852000/foxdb/internal/query/planner/logical"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+func (p *Planner) planUpdate(stmt *ast.UpdateStatement) (LogicalPlan, error) {
+	p.catalog.RLock()
+	defer p.catalog.RUnlock()
+
+	schema, table, err := p.bindWritableTable(stmt.SchemaName, stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+	dataSchema := table.GetDataSchema()
+
+	assignments := make([]logical.Assignment, 0, len(stmt.Assignments))
+	seen := make(map[string]bool, len(stmt.Assignments))
+	for _, assignment := range stmt.Assignments {
+		if seen[assignment.Column] {
+			return nil, types.NewError(types.ERR_DUPLICATE_COLUMN, "multiple assignments to column %s", assignment.Column)
+		}
+		seen[assignment.Column] = true
+
+		idx := dataSchema.GetColumnIndex(assignment.Column)
+		if idx < 0 {
+			return nil, types.NewError(types.ERR_UNDEFINED_COLUMN, "column %s of table %s does not exist", assignment.Column, table.GetName())
+		}
+		if _, err := expression.Bind(assignment.Value, dataSchema); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, logical.Assignment{ColumnIndex: idx, Value: assignment.Value})
+	}
+
+	if stmt.WhereClause != nil {
+		if _, err := expression.BindPredicate(stmt.WhereClause, dataSchema, "WHERE"); err != nil {
+			return nil, err
+		}
+	}
+	return logical.NewUpdatePlan(schema, table, assignments, stmt.WhereClause), nil
+}
+
+func (p *Planner) planDelete(stmt *ast.DeleteStatement) (LogicalPlan, error) {
+	p.catalog.RLock()
+	defer p.catalog.RUnlock()
+
+	schema, table, err := p.bindWritableTable(stmt.SchemaName, stmt.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.WhereClause != nil {
+		if _, err := expression.BindPredicate(stmt.WhereClause, table.GetDataSchema(), "WHERE"); err != nil {
+			return nil, err
+		}
+	}
+	return logical.NewDeletePlan(schema, table, stmt.WhereClause), nil
+}
+
+// looks up a table that statements can write to, system tables are read only
+func (p *Planner) bindWritableTable(schemaName string, tableName string) (*catalog.Schema, *catalog.Table, error) {
+	schema, table, err := p.bindTable(schemaName, tableName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if schema.GetName() == catalog.INFORMATION_SCHEMA_NAME {
+		return nil, nil, types.NewError(types.ERR_INSUFFICIENT_PRIVILEGE, "cannot modify system table %s", table.GetName())
+	}
+	return schema, table, nil
+}
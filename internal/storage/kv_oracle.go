@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// IsolationLevel decides which concurrent commits make a transaction fail
+type IsolationLevel int
+
+const (
+	// each statement reads the latest commits, a transaction fails when a
+	// key it wrote was committed after the statement that wrote it started
+	READ_COMMITTED IsolationLevel = iota + 1
+	// the transaction reads a snapshot taken when it began and fails when
+	// a key it wrote was committed since
+	REPEATABLE_READ
+	// as REPEATABLE_READ, and the transaction fails as well when a key or
+	// a range of keys it read was written by a commit since it began
+	SERIALIZABLE
+)
+
+// kvOracle detects the conflicts between transactions, badger's own
+// detection only covers the keys a transaction read. Commits are numbered,
+// snapshots are the number of the last commit they see.
+type kvOracle struct {
+	sync.Mutex
+	lastCommit uint64
+	// commits that open transactions may conflict with, oldest first
+	commits []kvCommit
+	active  map[*KvTxn]bool
+}
+
+type kvCommit struct {
+	seq  uint64
+	keys []string
+}
+
+func newKvOracle() *kvOracle {
+	return &kvOracle{active: map[*KvTxn]bool{}}
+}
+
+// begin starts a transaction reading the latest commits, the lock makes
+// the badger snapshot and the commit number match.
+func (o *kvOracle) begin(db *badger.DB, level IsolationLevel) *KvTxn {
+	o.Lock()
+	defer o.Unlock()
+	txn := newKvTxn(db.NewTransaction(true), level, o.lastCommit)
+	o.active[txn] = true
+	return txn
+}
+
+// refresh moves a transaction to a snapshot of the latest commits, its
+// writes are carried over to the new badger transaction.
+func (o *kvOracle) refresh(db *badger.DB, txn *KvTxn) error {
+	o.Lock()
+	defer o.Unlock()
+	txn.Txn.Discard()
+	txn.Txn, txn.snapshot = db.NewTransaction(true), o.lastCommit
+	return setWrites(txn.Txn, txn.writes)
+}
+
+// setWrites adds writes to the pending writes of a badger transaction
+func setWrites(txn *badger.Txn, writes map[string]*kvWrite) error {
+	for key, write := range writes {
+		var err error
+		if write.deleted {
+			err = txn.Delete([]byte(key))
+		} else {
+			err = txn.Set([]byte(key), write.value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyWrites commits writes in txn
+func applyWrites(txn *badger.Txn, writes map[string]*kvWrite) error {
+	defer txn.Discard()
+	if err := setWrites(txn, writes); err != nil {
+		return err
+	}
+	return txn.Commit()
+}
+
+// commit checks the transaction against the commits it did not see then
+// applies its write set, read only transactions never conflict. The badger
+// transaction only serves the reads of the transaction: after a rollback
+// to a savepoint it still holds writes that are no longer in the set.
+func (o *kvOracle) commit(db *badger.DB, txn *KvTxn) error {
+	o.Lock()
+	defer o.Unlock()
+	defer o.finish(txn)
+	if len(txn.writes) == 0 {
+		txn.Txn.Discard()
+		return nil
+	}
+
+	for _, commit := range o.commits {
+		for _, key := range commit.keys {
+			if txn.conflictsWith(commit.seq, key) {
+				txn.Txn.Discard()
+				return types.NewError(types.ERR_SERIALIZATION_FAILURE, "could not serialize access due to concurrent update")
+			}
+		}
+	}
+
+	txn.Txn.Discard()
+	if err := applyWrites(db.NewTransaction(true), txn.writes); err != nil {
+		return err
+	}
+	o.lastCommit++
+	keys := make([]string, 0, len(txn.writes))
+	for key := range txn.writes {
+		keys = append(keys, key)
+	}
+	o.commits = append(o.commits, kvCommit{seq: o.lastCommit, keys: keys})
+	return nil
+}
+
+func (o *kvOracle) discard(txn *KvTxn) {
+	o.Lock()
+	defer o.Unlock()
+	txn.Txn.Discard()
+	o.finish(txn)
+}
+
+// finish forgets the commits every open transaction has seen
+func (o *kvOracle) finish(txn *KvTxn) {
+	delete(o.active, txn)
+	oldest := o.lastCommit
+	for active := range o.active {
+		oldest = min(oldest, active.begin)
+	}
+	idx := 0
+	for idx < len(o.commits) && o.commits[idx].seq <= oldest {
+		idx++
+	}
+	o.commits = o.commits[idx:]
+}
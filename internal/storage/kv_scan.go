@@ -1,13 +1,20 @@
 package storage
 
-import "github.com/dgraph-io/badger/v3"
+import (
+	"bytes"
+
+	"github.com/dgraph-io/badger/v3"
+)
 
 type KvScan struct {
 	txn      *badger.Txn
 	iterator *badger.Iterator
 	prefix   []byte
+	end      []byte
 	keyDst   []byte
 	valueDst []byte
+	// the transaction outlives the scan when it belongs to the caller
+	sharedTxn bool
 }
 
 func NewKvScan(txn *badger.Txn, prefix []byte) *KvScan {
@@ -21,11 +28,31 @@ func NewKvScan(txn *badger.Txn, prefix []byte) *KvScan {
 	return &KvScan{
 		txn:      txn,
 		iterator: it,
+		prefix:   prefix,
+	}
+}
+
+// NewKvRangeScan iterates over the keys in [start, end), a nil end
+// means no upper bound.
+func NewKvRangeScan(txn *badger.Txn, start []byte, end []byte) *KvScan {
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = true
+
+	it := txn.NewIterator(opts)
+	it.Seek(start)
+
+	return &KvScan{
+		txn:      txn,
+		iterator: it,
+		end:      end,
 	}
 }
 
 func (it *KvScan) Valid() bool {
-	return it.iterator.ValidForPrefix(it.prefix)
+	if !it.iterator.ValidForPrefix(it.prefix) {
+		return false
+	}
+	return it.end == nil || bytes.Compare(it.iterator.Item().Key(), it.end) < 0
 }
 
 func (it *KvScan) Next() {
@@ -43,7 +70,15 @@ func (it *KvScan) Item() ([]byte, []byte, error) {
 	return it.keyDst, it.valueDst, nil
 }
 
+// keepTxn leaves the transaction open when the scan is closed
+func (it *KvScan) keepTxn() *KvScan {
+	it.sharedTxn = true
+	return it
+}
+
 func (it *KvScan) Close() {
 	it.iterator.Close()
-	it.txn.Discard()
+	if !it.sharedTxn {
+		it.txn.Discard()
+	}
 }
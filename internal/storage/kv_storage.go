@@ -1,22 +1,29 @@
 package storage
 
 import (
+	"bytes"
 	"os"
 
 	"github.com/dgraph-io/badger/v3"
 )
 
 type KvStorage struct {
-	db *badger.DB
+	db     *badger.DB
+	oracle *kvOracle
+	// txn is the explicit transaction every read and write goes through,
+	// without one each call runs in a transaction of its own.
+	txn *KvTxn
 }
 
 func NewKvStorage(dbPath string) (*KvStorage, error) {
-	opts := badger.DefaultOptions(dbPath).WithLogger(nil) // Disable Badger logging
+	opts := badger.DefaultOptions(dbPath).
+		WithLogger(nil).           // Disable Badger logging
+		WithDetectConflicts(false) // the kvOracle detects them
 	db, err := badger.Open(opts)
 	if err != nil {
 		return nil, err
 	}
-	return &KvStorage{db: db}, nil
+	return &KvStorage{db: db, oracle: newKvOracle()}, nil
 }
 
 func (s *KvStorage) Close() error {
@@ -32,15 +39,68 @@ func (s *KvStorage) Remove() error {
 	return os.RemoveAll(s.db.Opts().Dir)
 }
 
+// Begin starts an explicit transaction, the returned storage reads and
+// writes through it until Commit or Rollback is called.
+func (s *KvStorage) Begin(level IsolationLevel) *KvStorage {
+	return &KvStorage{db: s.db, oracle: s.oracle, txn: s.oracle.begin(s.db, level)}
+}
+
+// Snapshot returns a storage reading the commits made so far, whatever
+// commits later. A statement outside of an explicit transaction reads
+// through one so that all its operators see the same data, it cannot
+// write and is released by Rollback.
+func (s *KvStorage) Snapshot() *KvStorage {
+	return &KvStorage{db: s.db, oracle: s.oracle, txn: newKvTxn(s.db.NewTransaction(false), READ_COMMITTED, 0)}
+}
+
+// StartStatement gives a read committed transaction a snapshot of the
+// latest commits, the snapshot of other levels does not change.
+func (s *KvStorage) StartStatement() error {
+	if s.txn.level != READ_COMMITTED {
+		return nil
+	}
+	return s.oracle.refresh(s.db, s.txn)
+}
+
+// IsolationLevel is the level of the explicit transaction
+func (s *KvStorage) IsolationLevel() IsolationLevel {
+	return s.txn.level
+}
+
+// Commit applies the writes of the explicit transaction, it fails with a
+// serialization failure when it conflicts with a concurrent commit.
+func (s *KvStorage) Commit() error {
+	return s.oracle.commit(s.db, s.txn)
+}
+
+// Rollback discards the writes of the explicit transaction
+func (s *KvStorage) Rollback() {
+	s.oracle.discard(s.txn)
+}
+
+// Savepoint, RollbackTo and Release give partial rollbacks inside the
+// explicit transaction, see KvTxn.
+func (s *KvStorage) Savepoint(name string) {
+	s.txn.Savepoint(name)
+}
+
+func (s *KvStorage) RollbackTo(name string) error {
+	return s.txn.RollbackTo(name)
+}
+
+func (s *KvStorage) Release(name string) error {
+	return s.txn.Release(name)
+}
+
 func (s *KvStorage) Set(key, value []byte) error {
-	return s.db.Update(func(txn *badger.Txn) error {
+	return s.Batch(func(txn *KvTxn) error {
 		return txn.Set(key, value)
 	})
 }
 
 func (s *KvStorage) Get(key []byte) ([]byte, error) {
 	var valCopy []byte
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *KvTxn) error {
 		item, err := txn.Get(key)
 		if err != nil {
 			return err
@@ -59,15 +119,76 @@ func (s *KvStorage) Get(key []byte) ([]byte, error) {
 }
 
 func (s *KvStorage) Delete(key []byte) error {
-	return s.db.Update(func(txn *badger.Txn) error {
+	return s.Batch(func(txn *KvTxn) error {
 		return txn.Delete(key)
 	})
 }
 
-func (s *KvStorage) Batch(fn func(txn *badger.Txn) error) error {
-	return s.db.Update(fn)
+// Batch runs fn in a read committed transaction committed when it
+// returns, inside an explicit transaction fn writes to it and nothing
+// is committed.
+func (s *KvStorage) Batch(fn func(txn *KvTxn) error) error {
+	if s.txn != nil {
+		return fn(s.txn)
+	}
+
+	txn := s.oracle.begin(s.db, READ_COMMITTED)
+	if err := fn(txn); err != nil {
+		s.oracle.discard(txn)
+		return err
+	}
+	return s.oracle.commit(s.db, txn)
+}
+
+func (s *KvStorage) view(fn func(txn *KvTxn) error) error {
+	if s.txn != nil {
+		return fn(s.txn)
+	}
+	return s.db.View(func(txn *badger.Txn) error {
+		return fn(newKvTxn(txn, READ_COMMITTED, 0))
+	})
+}
+
+// DropPrefix removes all the keys starting with prefix.
+func (s *KvStorage) DropPrefix(prefix []byte) error {
+	return s.db.DropPrefix(prefix)
+}
+
+// LastKey returns the greatest key starting with prefix or nil when there is none.
+func (s *KvStorage) LastKey(prefix []byte) ([]byte, error) {
+	var lastKey []byte
+	err := s.view(func(txn *KvTxn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Reverse = true
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		// seek past every key of the prefix and walk backward
+		seekKey := append(append([]byte{}, prefix...), bytes.Repeat([]byte{0xFF}, 16)...)
+		it.Seek(seekKey)
+		if it.ValidForPrefix(prefix) {
+			lastKey = it.Item().KeyCopy(nil)
+		}
+		return nil
+	})
+	return lastKey, err
 }
 
 func (s *KvStorage) Scan(prefix []byte) *KvScan {
+	if s.txn != nil {
+		s.txn.readRange(prefix, prefixEnd(prefix))
+		return NewKvScan(s.txn.Txn, prefix).keepTxn()
+	}
 	return NewKvScan(s.db.NewTransaction(false), prefix)
 }
+
+// ScanRange iterates over the keys in [start, end), a nil end means no upper bound.
+func (s *KvStorage) ScanRange(start []byte, end []byte) *KvScan {
+	if s.txn != nil {
+		s.txn.readRange(start, end)
+		return NewKvRangeScan(s.txn.Txn, start, end).keepTxn()
+	}
+	return NewKvRangeScan(s.db.NewTransaction(false), start, end)
+}
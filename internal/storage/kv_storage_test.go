@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/dgraph-io/badger/v3"
+	"github.com/evanxg852000/foxdb/internal/types"
 )
 
 func setupTestDB(t *testing.T) (*KvStorage, func()) {
@@ -233,7 +234,7 @@ func TestKvStorageBatch(t *testing.T) {
 	defer cleanup()
 
 	// Test batch operations
-	err := storage.Batch(func(txn *badger.Txn) error {
+	err := storage.Batch(func(txn *KvTxn) error {
 		if err := txn.Set([]byte("batch_key1"), []byte("batch_value1")); err != nil {
 			return err
 		}
@@ -268,7 +269,7 @@ func TestKvStorageBatchError(t *testing.T) {
 	defer cleanup()
 
 	// Test batch with error - transaction should be rolled back
-	err := storage.Batch(func(txn *badger.Txn) error {
+	err := storage.Batch(func(txn *KvTxn) error {
 		if err := txn.Set([]byte("batch_key1"), []byte("batch_value1")); err != nil {
 			return err
 		}
@@ -287,6 +288,371 @@ func TestKvStorageBatchError(t *testing.T) {
 	}
 }
 
+func TestKvStorageTransaction(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := storage.Set([]byte("txn_key1"), []byte("old")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	txn := storage.Begin(READ_COMMITTED)
+	if err := txn.Set([]byte("txn_key1"), []byte("new")); err != nil {
+		t.Fatalf("Set in transaction failed: %v", err)
+	}
+	if err := txn.Set([]byte("txn_key2"), []byte("added")); err != nil {
+		t.Fatalf("Set in transaction failed: %v", err)
+	}
+
+	// the transaction sees its own writes, others do not until it commits
+	value, err := txn.Get([]byte("txn_key1"))
+	if err != nil || !bytes.Equal(value, []byte("new")) {
+		t.Errorf("Transaction should read its write: got %q, %v", value, err)
+	}
+	value, err = storage.Get([]byte("txn_key1"))
+	if err != nil || !bytes.Equal(value, []byte("old")) {
+		t.Errorf("Uncommitted write should not be visible: got %q, %v", value, err)
+	}
+
+	scan := txn.Scan([]byte("txn_"))
+	count := 0
+	for ; scan.Valid(); scan.Next() {
+		count++
+	}
+	scan.Close()
+	if count != 2 {
+		t.Errorf("Expected 2 keys in transaction scan, got %d", count)
+	}
+
+	// closing the scan leaves the transaction open
+	if err := txn.Delete([]byte("txn_key1")); err != nil {
+		t.Fatalf("Delete in transaction failed: %v", err)
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	if _, err := storage.Get([]byte("txn_key1")); err != badger.ErrKeyNotFound {
+		t.Errorf("Expected deleted key after commit, got %v", err)
+	}
+	value, err = storage.Get([]byte("txn_key2"))
+	if err != nil || !bytes.Equal(value, []byte("added")) {
+		t.Errorf("Committed write should be visible: got %q, %v", value, err)
+	}
+}
+
+func TestKvStorageTransactionRollback(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	txn := storage.Begin(READ_COMMITTED)
+	if err := txn.Set([]byte("rollback_key"), []byte("value")); err != nil {
+		t.Fatalf("Set in transaction failed: %v", err)
+	}
+	txn.Rollback()
+
+	if _, err := storage.Get([]byte("rollback_key")); err != badger.ErrKeyNotFound {
+		t.Errorf("Rolled back key should not exist, got %v", err)
+	}
+}
+
+func TestKvStorageTransactionConflict(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := storage.Set([]byte("conflict_key"), []byte("0")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// both transactions read the key before writing it
+	first := storage.Begin(READ_COMMITTED)
+	second := storage.Begin(READ_COMMITTED)
+	for _, txn := range []*KvStorage{first, second} {
+		if _, err := txn.Get([]byte("conflict_key")); err != nil {
+			t.Fatalf("Get in transaction failed: %v", err)
+		}
+		if err := txn.Set([]byte("conflict_key"), []byte("1")); err != nil {
+			t.Fatalf("Set in transaction failed: %v", err)
+		}
+	}
+
+	if err := first.Commit(); err != nil {
+		t.Fatalf("First commit failed: %v", err)
+	}
+	err := second.Commit()
+	if code := types.GetErrorCode(err); code != types.ERR_SERIALIZATION_FAILURE {
+		t.Errorf("Expected a serialization failure, got %v (%s)", err, code)
+	}
+}
+
+func TestKvStorageGuards(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// read committed transactions fail on the guarded keys committed since
+	// the statement that guarded them, later statements do not lift it
+	txn := storage.Begin(READ_COMMITTED)
+	err := txn.Batch(func(kvTxn *KvTxn) error {
+		kvTxn.GuardKey([]byte("guard_version"))
+		kvTxn.GuardPrefix([]byte("guard_rows_"))
+		return kvTxn.Set([]byte("guard_own"), []byte("1"))
+	})
+	if err != nil {
+		t.Fatalf("Batch in transaction failed: %v", err)
+	}
+	if err := storage.Set([]byte("guard_version"), nil); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := txn.StartStatement(); err != nil {
+		t.Fatalf("StartStatement failed: %v", err)
+	}
+	err = txn.Commit()
+	if code := types.GetErrorCode(err); code != types.ERR_SERIALIZATION_FAILURE {
+		t.Errorf("Expected a serialization failure, got %v (%s)", err, code)
+	}
+
+	txn = storage.Begin(READ_COMMITTED)
+	err = txn.Batch(func(kvTxn *KvTxn) error {
+		kvTxn.GuardPrefix([]byte("guard_rows_"))
+		return kvTxn.Set([]byte("guard_own"), []byte("2"))
+	})
+	if err != nil {
+		t.Fatalf("Batch in transaction failed: %v", err)
+	}
+	if err := storage.Set([]byte("guard_other"), nil); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatalf("Commit should not conflict outside of the guards, got %v", err)
+	}
+
+	txn = storage.Begin(READ_COMMITTED)
+	err = txn.Batch(func(kvTxn *KvTxn) error {
+		kvTxn.GuardPrefix([]byte("guard_rows_"))
+		return kvTxn.Set([]byte("guard_own"), []byte("3"))
+	})
+	if err != nil {
+		t.Fatalf("Batch in transaction failed: %v", err)
+	}
+	if err := storage.Set([]byte("guard_rows_1"), nil); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	err = txn.Commit()
+	if code := types.GetErrorCode(err); code != types.ERR_SERIALIZATION_FAILURE {
+		t.Errorf("Expected a serialization failure, got %v (%s)", err, code)
+	}
+}
+
+func TestKvStorageSnapshot(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := storage.Set([]byte("snap_1"), []byte("old")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	snapshot := storage.Snapshot()
+	defer snapshot.Rollback()
+	if err := storage.Set([]byte("snap_1"), []byte("new")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := storage.Set([]byte("snap_2"), []byte("new")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// reads and scans of the snapshot ignore the later commits
+	value, err := snapshot.Get([]byte("snap_1"))
+	if err != nil || string(value) != "old" {
+		t.Errorf("Expected old, got %q (%v)", value, err)
+	}
+	scan := snapshot.Scan([]byte("snap_"))
+	count := 0
+	for ; scan.Valid(); scan.Next() {
+		count++
+	}
+	scan.Close()
+	if count != 1 {
+		t.Errorf("Expected 1 key, got %d", count)
+	}
+}
+
+func TestKvStorageSavepoints(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := storage.Set([]byte("sp_key1"), []byte("v0")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	txn := storage.Begin(READ_COMMITTED)
+	defer txn.Rollback()
+	expect := func(key string, expected string) {
+		t.Helper()
+		value, err := txn.Get([]byte(key))
+		if expected == "" {
+			if err != badger.ErrKeyNotFound {
+				t.Errorf("Expected %s to be absent, got %q, %v", key, value, err)
+			}
+			return
+		}
+		if err != nil || !bytes.Equal(value, []byte(expected)) {
+			t.Errorf("Expected %q for %s, got %q, %v", expected, key, value, err)
+		}
+	}
+
+	txn.Savepoint("a")
+	txn.Set([]byte("sp_key1"), []byte("v1"))
+	txn.Set([]byte("sp_key2"), []byte("v1"))
+	txn.Savepoint("b")
+	txn.Set([]byte("sp_key1"), []byte("v2"))
+	txn.Delete([]byte("sp_key2"))
+
+	if err := txn.RollbackTo("b"); err != nil {
+		t.Fatalf("RollbackTo failed: %v", err)
+	}
+	expect("sp_key1", "v1")
+	expect("sp_key2", "v1")
+
+	// writes of a released savepoint belong to the outer one
+	txn.Set([]byte("sp_key3"), []byte("v2"))
+	if err := txn.Release("b"); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if err := txn.RollbackTo("b"); types.GetErrorCode(err) != types.ERR_INVALID_SAVEPOINT {
+		t.Errorf("Expected released savepoint to be gone, got %v", err)
+	}
+	if err := txn.RollbackTo("a"); err != nil {
+		t.Fatalf("RollbackTo failed: %v", err)
+	}
+	expect("sp_key1", "v0")
+	expect("sp_key2", "")
+	expect("sp_key3", "")
+
+	// a reused name refers to the latest savepoint
+	txn.Savepoint("a")
+	txn.Set([]byte("sp_key1"), []byte("v3"))
+	txn.Savepoint("a")
+	txn.Set([]byte("sp_key1"), []byte("v4"))
+	if err := txn.RollbackTo("a"); err != nil {
+		t.Fatalf("RollbackTo failed: %v", err)
+	}
+	expect("sp_key1", "v3")
+}
+
+func TestKvStorageRollbackToLeavesWriteSet(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := storage.Set([]byte("undo_key"), []byte("v0")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	txn := storage.Begin(REPEATABLE_READ)
+	if err := txn.Set([]byte("undo_other"), []byte("v1")); err != nil {
+		t.Fatalf("Set in transaction failed: %v", err)
+	}
+	txn.Savepoint("a")
+	if err := txn.Set([]byte("undo_key"), []byte("v1")); err != nil {
+		t.Fatalf("Set in transaction failed: %v", err)
+	}
+	if err := txn.RollbackTo("a"); err != nil {
+		t.Fatalf("RollbackTo failed: %v", err)
+	}
+
+	// the key the transaction no longer modifies does not conflict
+	if err := storage.Set([]byte("undo_key"), []byte("v2")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatalf("Commit should not conflict on a rolled back key, got %v", err)
+	}
+
+	value, err := storage.Get([]byte("undo_key"))
+	if err != nil || !bytes.Equal(value, []byte("v2")) {
+		t.Errorf("Expected the concurrent write to remain, got %q, %v", value, err)
+	}
+	value, err = storage.Get([]byte("undo_other"))
+	if err != nil || !bytes.Equal(value, []byte("v1")) {
+		t.Errorf("Expected the write before the savepoint to be committed, got %q, %v", value, err)
+	}
+}
+
+func TestKvStorageIsolationLevels(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, key := range []string{"doctor_alice", "doctor_bob"} {
+		if err := storage.Set([]byte(key), []byte("on call")); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	// each transaction checks both doctors are on call then takes one off
+	writeSkew := func(level IsolationLevel) error {
+		first := storage.Begin(level)
+		second := storage.Begin(level)
+		for i, txn := range []*KvStorage{first, second} {
+			scan := txn.Scan([]byte("doctor_"))
+			for ; scan.Valid(); scan.Next() {
+			}
+			scan.Close()
+			key := []string{"doctor_alice", "doctor_bob"}[i]
+			if err := txn.Set([]byte(key), []byte("off")); err != nil {
+				t.Fatalf("Set in transaction failed: %v", err)
+			}
+		}
+		if err := first.Commit(); err != nil {
+			t.Fatalf("First commit failed: %v", err)
+		}
+		return second.Commit()
+	}
+
+	if err := writeSkew(REPEATABLE_READ); err != nil {
+		t.Errorf("Repeatable read should allow write skew, got %v", err)
+	}
+	err := writeSkew(SERIALIZABLE)
+	if code := types.GetErrorCode(err); code != types.ERR_SERIALIZATION_FAILURE {
+		t.Errorf("Serializable should prevent write skew, got %v (%s)", err, code)
+	}
+
+	// a key inserted in a range scanned by a serializable transaction
+	txn := storage.Begin(SERIALIZABLE)
+	scan := txn.Scan([]byte("nurse_"))
+	if scan.Valid() {
+		t.Errorf("Expected no nurse")
+	}
+	scan.Close()
+	if err := txn.Set([]byte("nurse_carol"), []byte("on call")); err != nil {
+		t.Fatalf("Set in transaction failed: %v", err)
+	}
+	if err := storage.Set([]byte("nurse_dave"), []byte("on call")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	err = txn.Commit()
+	if code := types.GetErrorCode(err); code != types.ERR_SERIALIZATION_FAILURE {
+		t.Errorf("Serializable should fail on a phantom, got %v (%s)", err, code)
+	}
+
+	// read committed statements see the latest commits, repeatable read ones do not
+	readCommitted := storage.Begin(READ_COMMITTED)
+	defer readCommitted.Rollback()
+	repeatableRead := storage.Begin(REPEATABLE_READ)
+	defer repeatableRead.Rollback()
+	if err := storage.Set([]byte("doctor_alice"), []byte("back")); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	for _, txn := range []*KvStorage{readCommitted, repeatableRead} {
+		if err := txn.StartStatement(); err != nil {
+			t.Fatalf("StartStatement failed: %v", err)
+		}
+	}
+	if value, _ := readCommitted.Get([]byte("doctor_alice")); !bytes.Equal(value, []byte("back")) {
+		t.Errorf("Read committed should see the latest commit, got %q", value)
+	}
+	if value, _ := repeatableRead.Get([]byte("doctor_alice")); !bytes.Equal(value, []byte("off")) {
+		t.Errorf("Repeatable read should keep its snapshot, got %q", value)
+	}
+}
+
 func TestKvScanBasic(t *testing.T) {
 	storage, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -434,6 +800,50 @@ func TestKvScanIteratorLifecycle(t *testing.T) {
 	scan.Close()
 }
 
+func TestKvScanRange(t *testing.T) {
+	storage, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for i := 0; i < 5; i++ {
+		key := fmt.Sprintf("range:%d", i)
+		err := storage.Set([]byte(key), []byte("value"))
+		if err != nil {
+			t.Fatalf("Set failed for %s: %v", key, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		start    string
+		end      []byte
+		expected []string
+	}{
+		{"bounded", "range:1", []byte("range:3"), []string{"range:1", "range:2"}},
+		{"unbounded", "range:3", nil, []string{"range:3", "range:4"}},
+		{"empty", "range:3", []byte("range:3"), []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scan := storage.ScanRange([]byte(tt.start), tt.end)
+			defer scan.Close()
+
+			keys := []string{}
+			for ; scan.Valid(); scan.Next() {
+				key, _, err := scan.Item()
+				if err != nil {
+					t.Fatalf("Item() failed: %v", err)
+				}
+				keys = append(keys, string(key))
+			}
+
+			if fmt.Sprint(keys) != fmt.Sprint(tt.expected) {
+				t.Errorf("Expected keys %v, got %v", tt.expected, keys)
+			}
+		})
+	}
+}
+
 func TestKvScanMultipleScans(t *testing.T) {
 	storage, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/dgraph-io/badger/v3"
+	"github.com/evanxg852000/foxdb/internal/types"
+)
+
+// KvTxn is the badger transaction handed to Batch functions, it keeps
+// what the kvOracle needs to detect conflicts and logs writes while
+// savepoints are open so that they can be undone.
+type KvTxn struct {
+	*badger.Txn
+	level IsolationLevel
+	// commits seen when the transaction began and by its current snapshot
+	begin    uint64
+	snapshot uint64
+	writes   map[string]*kvWrite
+	// ranges of keys read, only tracked by serializable transactions
+	reads []keyRange
+	// ranges of keys the transaction depends on at every isolation level
+	guards     []keyRange
+	savepoints []*savepoint
+}
+
+// last write of a key, snapshot is the one of its first write
+type kvWrite struct {
+	value    []byte
+	deleted  bool
+	snapshot uint64
+}
+
+// keys in [start, end), a nil end means no upper bound
+type keyRange struct {
+	start []byte
+	end   []byte
+	// commits seen when a guard was set
+	snapshot uint64
+}
+
+// savepoint keeps the value each key had before its first write since
+// the savepoint was set, in the order the keys were written.
+type savepoint struct {
+	name    string
+	undoLog []undoEntry
+	logged  map[string]bool
+}
+
+type undoEntry struct {
+	key   []byte
+	value []byte
+	// the key did not exist, undoing its writes deletes it
+	absent bool
+	// the transaction wrote the key before the savepoint, undoing the
+	// writes made since then does not take it out of the write set
+	written bool
+}
+
+func newKvTxn(txn *badger.Txn, level IsolationLevel, snapshot uint64) *KvTxn {
+	return &KvTxn{
+		Txn:      txn,
+		level:    level,
+		begin:    snapshot,
+		snapshot: snapshot,
+		writes:   map[string]*kvWrite{},
+	}
+}
+
+func (t *KvTxn) Get(key []byte) (*badger.Item, error) {
+	t.readRange(key, append(append([]byte{}, key...), 0))
+	return t.Txn.Get(key)
+}
+
+func (t *KvTxn) NewIterator(opts badger.IteratorOptions) *badger.Iterator {
+	t.readRange(opts.Prefix, prefixEnd(opts.Prefix))
+	return t.Txn.NewIterator(opts)
+}
+
+func (t *KvTxn) Set(key, value []byte) error {
+	if err := t.logUndo(key); err != nil {
+		return err
+	}
+	return t.write(key, value, false)
+}
+
+func (t *KvTxn) Delete(key []byte) error {
+	if err := t.logUndo(key); err != nil {
+		return err
+	}
+	return t.write(key, nil, true)
+}
+
+func (t *KvTxn) write(key []byte, value []byte, deleted bool) error {
+	var err error
+	if deleted {
+		err = t.Txn.Delete(key)
+	} else {
+		err = t.Txn.Set(key, value)
+	}
+	if err != nil {
+		return err
+	}
+
+	write, ok := t.writes[string(key)]
+	if !ok {
+		write = &kvWrite{snapshot: t.snapshot}
+		t.writes[string(key)] = write
+	}
+	write.value, write.deleted = value, deleted
+	return nil
+}
+
+// readRange records a read of the keys in [start, end)
+func (t *KvTxn) readRange(start []byte, end []byte) {
+	if t.level == SERIALIZABLE {
+		t.reads = append(t.reads, keyRange{start: start, end: end})
+	}
+}
+
+// GuardKey makes the transaction fail when a commit it does not see
+// writes key, whatever its isolation level.
+func (t *KvTxn) GuardKey(key []byte) {
+	t.guards = append(t.guards, keyRange{start: key, end: append(append([]byte{}, key...), 0), snapshot: t.snapshot})
+}
+
+// GuardPrefix makes the transaction fail when a commit it does not see
+// writes a key starting with prefix, whatever its isolation level.
+func (t *KvTxn) GuardPrefix(prefix []byte) {
+	t.guards = append(t.guards, keyRange{start: prefix, end: prefixEnd(prefix), snapshot: t.snapshot})
+}
+
+// conflictsWith tells whether the commit numbered seq writing key makes
+// the transaction fail at its isolation level.
+func (t *KvTxn) conflictsWith(seq uint64, key string) bool {
+	if write, ok := t.writes[key]; ok && seq > write.snapshot {
+		return true
+	}
+	for _, guard := range t.guards {
+		if seq > guard.snapshot && guard.contains(key) {
+			return true
+		}
+	}
+	if t.level != SERIALIZABLE || seq <= t.begin {
+		return false
+	}
+	for _, read := range t.reads {
+		if read.contains(key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r keyRange) contains(key string) bool {
+	return key >= string(r.start) && (r.end == nil || key < string(r.end))
+}
+
+// prefixEnd is the first key after all the keys starting with prefix,
+// nil when there is none.
+func prefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xFF {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// logUndo saves the current value of key in the innermost savepoint
+func (t *KvTxn) logUndo(key []byte) error {
+	if len(t.savepoints) == 0 {
+		return nil
+	}
+	current := t.savepoints[len(t.savepoints)-1]
+	if current.logged[string(key)] {
+		return nil
+	}
+
+	_, written := t.writes[string(key)]
+	entry := undoEntry{key: append([]byte{}, key...), written: written}
+	item, err := t.Txn.Get(key)
+	switch {
+	case errors.Is(err, badger.ErrKeyNotFound):
+		entry.absent = true
+	case err != nil:
+		return err
+	default:
+		if entry.value, err = item.ValueCopy(nil); err != nil {
+			return err
+		}
+	}
+	current.undoLog = append(current.undoLog, entry)
+	current.logged[string(key)] = true
+	return nil
+}
+
+// Savepoint marks the point RollbackTo goes back to, a name can be
+// reused and then refers to the latest savepoint.
+func (t *KvTxn) Savepoint(name string) {
+	t.savepoints = append(t.savepoints, &savepoint{name: name, logged: map[string]bool{}})
+}
+
+// RollbackTo undoes the writes made since the savepoint was set, the
+// savepoint stays and the ones set after it are removed. The keys first
+// written after the savepoint are back to what the transaction read, they
+// leave the write set so that they are neither committed nor checked for
+// conflicts.
+func (t *KvTxn) RollbackTo(name string) error {
+	idx, err := t.findSavepoint(name)
+	if err != nil {
+		return err
+	}
+
+	// the outermost entry of a key is undone last
+	for i := len(t.savepoints) - 1; i >= idx; i-- {
+		undoLog := t.savepoints[i].undoLog
+		for j := len(undoLog) - 1; j >= 0; j-- {
+			entry := undoLog[j]
+			if err := t.write(entry.key, entry.value, entry.absent); err != nil {
+				return err
+			}
+			if !entry.written {
+				delete(t.writes, string(entry.key))
+			}
+		}
+	}
+
+	t.savepoints = t.savepoints[:idx+1]
+	t.savepoints[idx].undoLog = nil
+	t.savepoints[idx].logged = map[string]bool{}
+	return nil
+}
+
+// Release removes the savepoint and the ones set after it, their writes
+// can still be undone by rolling back to an outer savepoint.
+func (t *KvTxn) Release(name string) error {
+	idx, err := t.findSavepoint(name)
+	if err != nil {
+		return err
+	}
+
+	if idx > 0 {
+		outer := t.savepoints[idx-1]
+		for _, released := range t.savepoints[idx:] {
+			for _, entry := range released.undoLog {
+				if !outer.logged[string(entry.key)] {
+					outer.undoLog = append(outer.undoLog, entry)
+					outer.logged[string(entry.key)] = true
+				}
+			}
+		}
+	}
+	t.savepoints = t.savepoints[:idx]
+	return nil
+}
+
+func (t *KvTxn) findSavepoint(name string) (int, error) {
+	for i := len(t.savepoints) - 1; i >= 0; i-- {
+		if t.savepoints[i].name == name {
+			return i, nil
+		}
+	}
+	return -1, types.NewError(types.ERR_INVALID_SAVEPOINT, "savepoint %q does not exist", name)
+}
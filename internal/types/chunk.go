@@ -1,31 +1,140 @@
 package types
 
+// schema of the result of statements reporting a number of affected rows
+var COUNT_SCHEMA = &DataSchema{Columns: []DataColumn{{Name: "count", DataType: TYPE_INT}}}
+
+// DataChunk is a batch of rows stored by column, one vector per column of
+// its schema. The selection lists the positions in the vectors of the rows
+// the chunk holds so that filtering a chunk does not copy its vectors.
 type DataChunk struct {
-	schema *DataSchema
-	rows   []DataRow
+	schema  *DataSchema
+	columns []*Vector
+	// the length of the vectors
+	length int
+	// positions of the rows of the chunk, nil until needed when it holds
+	// all of them
+	selection []int
 }
 
 func NewChunk(schema *DataSchema) *DataChunk {
-	return &DataChunk{
-		schema: schema,
-		rows:   make([]DataRow, 0),
+	columns := make([]*Vector, len(schema.Columns))
+	for i, column := range schema.Columns {
+		columns[i] = NewVector(column.DataType, 0)
 	}
+	return &DataChunk{schema: schema, columns: columns}
 }
 
 func NewWith(schema *DataSchema, rows []DataRow) *DataChunk {
-	return &DataChunk{schema, rows}
+	chunk := &DataChunk{schema: schema, columns: make([]*Vector, len(schema.Columns))}
+	for i, column := range schema.Columns {
+		chunk.columns[i] = NewVector(column.DataType, len(rows))
+	}
+	for _, row := range rows {
+		chunk.AppendRow(row)
+	}
+	return chunk
+}
+
+// NewVectorChunk builds a chunk over vectors of the same length, selection
+// is nil when the chunk holds all their rows.
+func NewVectorChunk(schema *DataSchema, columns []*Vector, length int, selection []int) *DataChunk {
+	return &DataChunk{schema: schema, columns: columns, length: length, selection: selection}
 }
 
 func (c *DataChunk) GetSchema() *DataSchema {
 	return c.schema
 }
 
+// Len returns the number of rows of the chunk
+func (c *DataChunk) Len() int {
+	if c.selection != nil {
+		return len(c.selection)
+	}
+	return c.length
+}
+
+// VectorLen returns the length of the vectors of the chunk
+func (c *DataChunk) VectorLen() int {
+	return c.length
+}
+
+func (c *DataChunk) GetColumn(idx int) *Vector {
+	return c.columns[idx]
+}
+
+// GetSelection returns the positions of the rows of the chunk in its vectors
+func (c *DataChunk) GetSelection() []int {
+	if c.selection == nil {
+		c.selection = make([]int, c.length)
+		for i := range c.selection {
+			c.selection[i] = i
+		}
+	}
+	return c.selection
+}
+
+// Select returns a chunk holding the rows at the given positions of the
+// vectors, it shares the vectors of c.
+func (c *DataChunk) Select(selection []int) *DataChunk {
+	return &DataChunk{schema: c.schema, columns: c.columns, length: c.length, selection: selection}
+}
+
+// Slice returns a chunk holding the rows from start to end, it shares the
+// vectors of c.
+func (c *DataChunk) Slice(start int, end int) *DataChunk {
+	return c.Select(c.GetSelection()[start:end])
+}
+
+// Project returns a chunk made of some columns of c, it shares their vectors
+func (c *DataChunk) Project(schema *DataSchema, columnIndexes []int) *DataChunk {
+	columns := make([]*Vector, len(columnIndexes))
+	for i, idx := range columnIndexes {
+		columns[i] = c.columns[idx]
+	}
+	return &DataChunk{schema: schema, columns: columns, length: c.length, selection: c.selection}
+}
+
+// GetRow returns the i-th row of the chunk
+func (c *DataChunk) GetRow(i int) DataRow {
+	if c.selection != nil {
+		i = c.selection[i]
+	}
+	row := DataRow{Values: make([]Value, len(c.columns))}
+	for idx, column := range c.columns {
+		row.Values[idx] = column.Get(i)
+	}
+	return row
+}
+
+// GetRows returns the rows of the chunk, each call builds them again
 func (c *DataChunk) GetRows() []DataRow {
-	return c.rows
+	rows := make([]DataRow, c.Len())
+	for i := range rows {
+		rows[i] = c.GetRow(i)
+	}
+	return rows
 }
 
+// AppendRow adds a row to a chunk being built, its vectors must not be
+// shared with other chunks.
 func (c *DataChunk) AppendRow(row DataRow) {
-	c.rows = append(c.rows, row)
+	i := c.AppendNullRow()
+	for idx, column := range c.columns {
+		column.Set(i, row.Values[idx])
+	}
+}
+
+// AppendNullRow adds a row of NULLs to a chunk being built and returns its
+// position in the vectors, the values can then be set by position.
+func (c *DataChunk) AppendNullRow() int {
+	for _, column := range c.columns {
+		column.AppendNull()
+	}
+	if c.selection != nil {
+		c.selection = append(c.selection, c.length)
+	}
+	c.length++
+	return c.length - 1
 }
 
 func (c *DataChunk) GetColumnNames() []string {
@@ -35,3 +144,7 @@ func (c *DataChunk) GetColumnNames() []string {
 	}
 	return names
 }
+
+func NewCountChunk(count int) *DataChunk {
+	return NewWith(COUNT_SCHEMA, []DataRow{{Values: []Value{*NewIntValue(int64(count))}}})
+}
@@ -0,0 +1,93 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var chunkSchema = &DataSchema{Columns: []DataColumn{
+	{Name: "id", DataType: TYPE_INT},
+	{Name: "score", DataType: TYPE_FLOAT},
+	{Name: "active", DataType: TYPE_BOOL},
+	{Name: "name", DataType: TYPE_TEXT},
+}}
+
+func chunkRows() []DataRow {
+	rows := make([]DataRow, 100)
+	for i := range rows {
+		name := *NewNullValue()
+		if i%3 != 0 {
+			name = *NewTextValue(string(rune('a' + i%26)))
+		}
+		rows[i] = DataRow{Values: []Value{*NewIntValue(int64(i)), *NewFloatValue(float64(i) / 2), *NewBoolValue(i%2 == 0), name}}
+	}
+	return rows
+}
+
+func TestChunkColumns(t *testing.T) {
+	rows := chunkRows()
+	chunk := NewWith(chunkSchema, rows)
+	require.Equal(t, 100, chunk.Len())
+	assert.Equal(t, rows, chunk.GetRows())
+
+	ids := chunk.GetColumn(0)
+	assert.Equal(t, TYPE_INT, ids.GetDataType())
+	assert.Equal(t, int64(42), ids.Ints()[42])
+	names := chunk.GetColumn(3)
+	assert.True(t, names.IsNull(0))
+	assert.True(t, names.IsNull(99))
+	assert.False(t, names.IsNull(64))
+	assert.Equal(t, "m", names.Texts()[64])
+
+	// INT values are converted for FLOAT columns
+	chunk.AppendRow(DataRow{Values: []Value{*NewIntValue(100), *NewIntValue(3), *NewNullValue(), *NewNullValue()}})
+	assert.Equal(t, 101, chunk.Len())
+	assert.Equal(t, []Value{*NewIntValue(100), *NewFloatValue(3), {}, {}}, chunk.GetRow(100).Values)
+}
+
+func TestChunkSelection(t *testing.T) {
+	rows := chunkRows()
+	chunk := NewWith(chunkSchema, rows)
+
+	selected := chunk.Select([]int{3, 70, 71})
+	assert.Equal(t, 3, selected.Len())
+	assert.Equal(t, 100, selected.VectorLen())
+	assert.Equal(t, []DataRow{rows[3], rows[70], rows[71]}, selected.GetRows())
+	assert.Same(t, chunk.GetColumn(1), selected.GetColumn(1), "vectors are shared")
+
+	sliced := selected.Slice(1, 3)
+	assert.Equal(t, []DataRow{rows[70], rows[71]}, sliced.GetRows())
+	assert.Equal(t, rows[10:12], chunk.Slice(10, 12).GetRows())
+
+	projected := sliced.Project(&DataSchema{Columns: []DataColumn{chunkSchema.Columns[3], chunkSchema.Columns[0]}}, []int{3, 0})
+	assert.Equal(t, []DataRow{
+		{Values: []Value{*NewTextValue("s"), *NewIntValue(70)}},
+		{Values: []Value{*NewTextValue("t"), *NewIntValue(71)}},
+	}, projected.GetRows())
+	assert.Same(t, chunk.GetColumn(0), projected.GetColumn(1))
+}
+
+func TestNullVector(t *testing.T) {
+	vector := NewNullVector(TYPE_INT, 130)
+	assert.Equal(t, 130, vector.Len())
+	vector.SetInt(129, 5)
+	assert.True(t, vector.IsNull(128))
+	assert.False(t, vector.IsNull(129))
+	assert.Equal(t, *NewIntValue(5), vector.Get(129))
+	assert.Equal(t, Value{}, vector.Get(0))
+
+	// values are stored by position, INT is converted for FLOAT vectors
+	vector = NewNullVector(TYPE_FLOAT, 3)
+	vector.Set(2, *NewIntValue(4))
+	vector.Set(0, *NewFloatValue(1.5))
+	vector.Set(0, Value{})
+	assert.Equal(t, []Value{{}, {}, *NewFloatValue(4)}, []Value{vector.Get(0), vector.Get(1), vector.Get(2)})
+
+	// a vector without data type only holds NULLs
+	vector = NewVector(0, 2)
+	vector.Append(Value{})
+	assert.True(t, vector.IsNull(0))
+	assert.Panics(t, func() { NewVector(TYPE_TEXT, 1).Append(*NewIntValue(1)) })
+}
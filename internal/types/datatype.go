@@ -4,7 +4,8 @@ import "strings"
 
 type DataType uint8
 
-//NOTE: for now let just use nil pointer for NULL and see how far we get
+// NOTE: NULL has no data type of its own, it is a Value without data
+// and a column of any type can hold it unless declared NOT NULL.
 
 const (
 	TYPE_INT DataType = iota + 1
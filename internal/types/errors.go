@@ -0,0 +1,67 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode is the SQLSTATE reported to clients along with an error
+type ErrorCode string
+
+const (
+	ERR_FEATURE_NOT_SUPPORTED      ErrorCode = "0A000"
+	ERR_INVALID_PARAMETER_VALUE    ErrorCode = "22023"
+	ERR_DIVISION_BY_ZERO           ErrorCode = "22012"
+	ERR_NUMERIC_VALUE_OUT_OF_RANGE ErrorCode = "22003"
+	ERR_NOT_NULL_VIOLATION         ErrorCode = "23502"
+	ERR_UNIQUE_VIOLATION           ErrorCode = "23505"
+	ERR_PROTOCOL_VIOLATION         ErrorCode = "08P01"
+	ERR_INVALID_SCHEMA_NAME        ErrorCode = "3F000"
+	ERR_SYNTAX_ERROR               ErrorCode = "42601"
+	ERR_INSUFFICIENT_PRIVILEGE     ErrorCode = "42501"
+	ERR_DATATYPE_MISMATCH          ErrorCode = "42804"
+	ERR_UNDEFINED_COLUMN           ErrorCode = "42703"
+	ERR_AMBIGUOUS_COLUMN           ErrorCode = "42702"
+	ERR_GROUPING_ERROR             ErrorCode = "42803"
+	ERR_UNDEFINED_FUNCTION         ErrorCode = "42883"
+	ERR_UNDEFINED_TABLE            ErrorCode = "42P01"
+	ERR_UNDEFINED_OBJECT           ErrorCode = "42704"
+	ERR_DUPLICATE_COLUMN           ErrorCode = "42701"
+	ERR_DUPLICATE_SCHEMA           ErrorCode = "42P06"
+	ERR_DUPLICATE_TABLE            ErrorCode = "42P07"
+	ERR_DUPLICATE_OBJECT           ErrorCode = "42710"
+	ERR_DUPLICATE_ALIAS            ErrorCode = "42712"
+	ERR_INVALID_DEFINITION         ErrorCode = "42P16"
+	ERR_ACTIVE_TRANSACTION         ErrorCode = "25001"
+	ERR_NO_ACTIVE_TRANSACTION      ErrorCode = "25P01"
+	ERR_IN_FAILED_TRANSACTION      ErrorCode = "25P02"
+	ERR_INVALID_SAVEPOINT          ErrorCode = "3B001"
+	ERR_SERIALIZATION_FAILURE      ErrorCode = "40001"
+	ERR_QUERY_CANCELED             ErrorCode = "57014"
+	ERR_INTERNAL                   ErrorCode = "XX000"
+)
+
+// Error is an error raised while running a statement, it carries
+// the SQLSTATE code the wire protocol reports to the client.
+type Error struct {
+	Code    ErrorCode
+	Message string
+}
+
+func NewError(code ErrorCode, format string, args ...any) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// GetErrorCode returns the code of the first Error in err's chain,
+// errors raised without a code are internal errors.
+func GetErrorCode(err error) ErrorCode {
+	var sqlErr *Error
+	if errors.As(err, &sqlErr) {
+		return sqlErr.Code
+	}
+	return ERR_INTERNAL
+}
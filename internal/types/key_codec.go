@@ -0,0 +1,236 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Key values are encoded so that the byte-wise order of the encoded keys
+// matches the SQL order of the values, letting the storage serve range
+// scans over primary keys and index values.
+//
+// Each component starts with a tag byte followed by its payload:
+//   - NULL:  tag only, NULLs sort before any other value
+//   - BOOL:  one byte, false before true
+//   - INT:   8 bytes big endian with the sign bit flipped
+//   - FLOAT: 8 bytes big endian, the sign bit flipped for positive numbers
+//     and all the bits flipped for negative ones
+//   - TEXT:  the bytes with 0x00 escaped as 0x00 0xFF, terminated by 0x00 0x01
+//
+// A descending component has all of its bytes, tag included, inverted.
+// Components are self delimiting so tuples are simple concatenations.
+
+const (
+	keyTagNull  byte = 0x05
+	keyTagBool  byte = 0x10
+	keyTagInt   byte = 0x20
+	keyTagFloat byte = 0x30
+	keyTagText  byte = 0x40
+)
+
+const (
+	keyTextEscape     byte = 0x00
+	keyTextEscapedNul byte = 0xFF
+	keyTextTerminator byte = 0x01
+)
+
+// EncodeKey encodes values as an ascending key tuple.
+func EncodeKey(values []Value) ([]byte, error) {
+	return EncodeKeyWithOrder(values, nil)
+}
+
+// EncodeKeyWithOrder encodes values as a key tuple, the component i is
+// descending when descending[i] is true. A nil descending means all ascending.
+func EncodeKeyWithOrder(values []Value, descending []bool) ([]byte, error) {
+	if descending != nil && len(descending) != len(values) {
+		return nil, fmt.Errorf("expected %d key orders, got %d", len(values), len(descending))
+	}
+
+	key := []byte{}
+	for i, value := range values {
+		var err error
+		key, err = AppendKeyValue(key, value, descending != nil && descending[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// AppendKeyValue appends the encoding of a single key component to key.
+func AppendKeyValue(key []byte, value Value, descending bool) ([]byte, error) {
+	start := len(key)
+	if value.IsNull() {
+		key = append(key, keyTagNull)
+	} else {
+		switch value.dataType {
+		case TYPE_INT:
+			key = append(key, keyTagInt)
+			key = binary.BigEndian.AppendUint64(key, uint64(value.data.(int64))^(1<<63))
+		case TYPE_FLOAT:
+			key = append(key, keyTagFloat)
+			key = binary.BigEndian.AppendUint64(key, encodeKeyFloat(value.data.(float64)))
+		case TYPE_BOOL:
+			key = append(key, keyTagBool, 0)
+			if value.data.(bool) {
+				key[len(key)-1] = 1
+			}
+		case TYPE_TEXT:
+			key = append(key, keyTagText)
+			text := value.data.(string)
+			for i := 0; i < len(text); i++ {
+				if text[i] == keyTextEscape {
+					key = append(key, keyTextEscape, keyTextEscapedNul)
+				} else {
+					key = append(key, text[i])
+				}
+			}
+			key = append(key, keyTextEscape, keyTextTerminator)
+		default:
+			return nil, fmt.Errorf("cannot encode value of type %s in a key", value.dataType)
+		}
+	}
+
+	if descending {
+		invertBytes(key[start:])
+	}
+	return key, nil
+}
+
+// DecodeKey decodes an ascending key tuple.
+func DecodeKey(key []byte) ([]Value, error) {
+	return DecodeKeyWithOrder(key, nil)
+}
+
+// DecodeKeyWithOrder decodes a key tuple encoded with EncodeKeyWithOrder.
+// The key must hold exactly len(descending) components unless descending
+// is nil, in which case all the components are decoded as ascending.
+func DecodeKeyWithOrder(key []byte, descending []bool) ([]Value, error) {
+	values := []Value{}
+	for len(key) > 0 {
+		desc := false
+		if descending != nil {
+			if len(values) >= len(descending) {
+				return nil, fmt.Errorf("corrupted key: more than %d components", len(descending))
+			}
+			desc = descending[len(values)]
+		}
+
+		value, rest, err := DecodeKeyValue(key, desc)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		key = rest
+	}
+
+	if descending != nil && len(values) != len(descending) {
+		return nil, fmt.Errorf("corrupted key: expected %d components, got %d", len(descending), len(values))
+	}
+	return values, nil
+}
+
+// DecodeKeyValue decodes the first component of key and returns the
+// remaining bytes.
+func DecodeKeyValue(key []byte, descending bool) (Value, []byte, error) {
+	if len(key) == 0 {
+		return Value{}, nil, fmt.Errorf("corrupted key: missing component")
+	}
+
+	readByte := func(b byte) byte {
+		if descending {
+			return ^b
+		}
+		return b
+	}
+	readUint64 := func(data []byte) uint64 {
+		v := binary.BigEndian.Uint64(data)
+		if descending {
+			return ^v
+		}
+		return v
+	}
+
+	switch tag := readByte(key[0]); tag {
+	case keyTagNull:
+		return Value{}, key[1:], nil
+	case keyTagBool:
+		if len(key) < 2 {
+			return Value{}, nil, fmt.Errorf("corrupted key: truncated BOOL")
+		}
+		return *NewBoolValue(readByte(key[1]) != 0), key[2:], nil
+	case keyTagInt:
+		if len(key) < 9 {
+			return Value{}, nil, fmt.Errorf("corrupted key: truncated INT")
+		}
+		return *NewIntValue(int64(readUint64(key[1:9]) ^ (1 << 63))), key[9:], nil
+	case keyTagFloat:
+		if len(key) < 9 {
+			return Value{}, nil, fmt.Errorf("corrupted key: truncated FLOAT")
+		}
+		return *NewFloatValue(decodeKeyFloat(readUint64(key[1:9]))), key[9:], nil
+	case keyTagText:
+		text := []byte{}
+		for i := 1; i < len(key); i++ {
+			b := readByte(key[i])
+			if b != keyTextEscape {
+				text = append(text, b)
+				continue
+			}
+			if i+1 >= len(key) {
+				break
+			}
+			i++
+			switch readByte(key[i]) {
+			case keyTextTerminator:
+				return *NewTextValue(string(text)), key[i+1:], nil
+			case keyTextEscapedNul:
+				text = append(text, 0)
+			default:
+				return Value{}, nil, fmt.Errorf("corrupted key: invalid TEXT escape")
+			}
+		}
+		return Value{}, nil, fmt.Errorf("corrupted key: unterminated TEXT")
+	default:
+		return Value{}, nil, fmt.Errorf("corrupted key: unknown tag 0x%02x", tag)
+	}
+}
+
+// KeyPrefixEnd returns the smallest key greater than every key starting
+// with prefix, or nil when there is no such key.
+func KeyPrefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xFF {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+func encodeKeyFloat(f float64) uint64 {
+	if f == 0 {
+		// -0 and +0 are equal and must share the same key
+		f = 0
+	}
+	bits := math.Float64bits(f)
+	if bits&(1<<63) != 0 {
+		return ^bits
+	}
+	return bits | (1 << 63)
+}
+
+func decodeKeyFloat(bits uint64) float64 {
+	if bits&(1<<63) != 0 {
+		return math.Float64frombits(bits &^ (1 << 63))
+	}
+	return math.Float64frombits(^bits)
+}
+
+func invertBytes(data []byte) {
+	for i := range data {
+		data[i] = ^data[i]
+	}
+}
@@ -0,0 +1,127 @@
+package types
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyEncodingPreservesOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []Value
+	}{
+		{"int", []Value{{}, *NewIntValue(math.MinInt64), *NewIntValue(-256), *NewIntValue(-1), *NewIntValue(0), *NewIntValue(1), *NewIntValue(255), *NewIntValue(math.MaxInt64)}},
+		{"float", []Value{{}, *NewFloatValue(math.Inf(-1)), *NewFloatValue(-1e10), *NewFloatValue(-0.5), *NewFloatValue(0), *NewFloatValue(1e-300), *NewFloatValue(2.5), *NewFloatValue(math.Inf(1))}},
+		{"bool", []Value{{}, *NewBoolValue(false), *NewBoolValue(true)}},
+		{"text", []Value{{}, *NewTextValue(""), *NewTextValue("\x00"), *NewTextValue("\x00\x00"), *NewTextValue("\x01"), *NewTextValue("a"), *NewTextValue("a\x00b"), *NewTextValue("ab"), *NewTextValue("b"), *NewTextValue("\xff")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, descending := range []bool{false, true} {
+				for i := 1; i < len(tt.values); i++ {
+					previous, err := AppendKeyValue(nil, tt.values[i-1], descending)
+					require.NoError(t, err)
+					current, err := AppendKeyValue(nil, tt.values[i], descending)
+					require.NoError(t, err)
+
+					expected := -1
+					if descending {
+						expected = 1
+					}
+					assert.Equal(t, expected, bytes.Compare(previous, current), "%s and %s (descending: %t)", tt.values[i-1], tt.values[i], descending)
+				}
+			}
+		})
+	}
+}
+
+func TestCompositeKeyEncoding(t *testing.T) {
+	tuples := [][]Value{
+		{*NewTextValue("a"), *NewIntValue(3)},
+		{*NewTextValue("a"), *NewIntValue(-2)},
+		{*NewTextValue("ab"), *NewIntValue(5)},
+		{*NewTextValue("b"), *NewIntValue(9)},
+		{*NewTextValue("b"), *NewIntValue(1)},
+		{*NewTextValue("b"), {}},
+	}
+
+	// ordered by the first component ascending then the second descending,
+	// NULLs come last in descending order
+	descending := []bool{false, true}
+	keys := make([][]byte, 0, len(tuples))
+	for _, tuple := range tuples {
+		key, err := EncodeKeyWithOrder(tuple, descending)
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	for i := 1; i < len(keys); i++ {
+		assert.Equal(t, -1, bytes.Compare(keys[i-1], keys[i]), "%v and %v", tuples[i-1], tuples[i])
+	}
+
+	for i, key := range keys {
+		decoded, err := DecodeKeyWithOrder(key, descending)
+		require.NoError(t, err)
+		assert.Equal(t, tuples[i], decoded)
+	}
+}
+
+func TestKeyEncodingRoundTrip(t *testing.T) {
+	values := []Value{
+		{},
+		*NewIntValue(math.MinInt64),
+		*NewIntValue(42),
+		*NewFloatValue(-3.75),
+		*NewFloatValue(math.Inf(1)),
+		*NewBoolValue(true),
+		*NewTextValue("fox\x00db"),
+		*NewTextValue(""),
+	}
+
+	key, err := EncodeKey(values)
+	require.NoError(t, err)
+	decoded, err := DecodeKey(key)
+	require.NoError(t, err)
+	assert.Equal(t, values, decoded)
+
+	// negative zero is stored as zero
+	key, err = EncodeKey([]Value{*NewFloatValue(math.Copysign(0, -1))})
+	require.NoError(t, err)
+	zero, err := EncodeKey([]Value{*NewFloatValue(0)})
+	require.NoError(t, err)
+	assert.Equal(t, zero, key)
+}
+
+func TestDecodeCorruptedKey(t *testing.T) {
+	tests := []struct {
+		name string
+		key  []byte
+	}{
+		{"unknown tag", []byte{0x99}},
+		{"truncated int", []byte{keyTagInt, 0x80}},
+		{"unterminated text", []byte{keyTagText, 'a'}},
+		{"invalid escape", []byte{keyTagText, 0x00, 0x42}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeKey(tt.key)
+			assert.Error(t, err)
+		})
+	}
+
+	key, err := EncodeKey([]Value{*NewIntValue(1), *NewIntValue(2)})
+	require.NoError(t, err)
+	_, err = DecodeKeyWithOrder(key, []bool{false})
+	assert.Error(t, err, "extra component")
+}
+
+func TestKeyPrefixEnd(t *testing.T) {
+	assert.Equal(t, []byte{0x01, 0x03}, KeyPrefixEnd([]byte{0x01, 0x02}))
+	assert.Equal(t, []byte{0x02}, KeyPrefixEnd([]byte{0x01, 0xFF}))
+	assert.Nil(t, KeyPrefixEnd([]byte{0xFF, 0xFF}))
+}
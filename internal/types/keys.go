@@ -1 +1,79 @@
 package types
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Records are stored under `t_{schemaId}{tableId}_{primaryKey}` where both
+// ids are fixed width big endian so that all the records of a table share
+// a common prefix and can be retrieved with a single prefix scan.
+
+// Secondary index entries are stored under
+// `i_{schemaId}{tableId}{indexId}_{indexValue}{primaryKey}` with an empty
+// value, one per record. The entries of unique indexes are stored under
+// `i_{schemaId}{tableId}{indexId}_{indexValue}` with the primary key as
+// value. The entries checking a UNIQUE column use the column id as index id.
+
+// Keys starting with `c_` are reserved for the catalog. The DDL statements
+// changing a table write its version key `c_t{schemaId}{tableId}`.
+
+const TABLE_KEY_PREFIX = 't'
+const INDEX_KEY_PREFIX = 'i'
+const CATALOG_KEY_PREFIX = 'c'
+
+var CATALOG_KEY = []byte("c_root")
+
+func TableVersionKey(schemaId uint32, tableId uint32) []byte {
+	key := make([]byte, 0, 11)
+	key = append(key, CATALOG_KEY_PREFIX, '_', 't')
+	key = binary.BigEndian.AppendUint32(key, schemaId)
+	key = binary.BigEndian.AppendUint32(key, tableId)
+	return key
+}
+
+func TableKeyPrefix(schemaId uint32, tableId uint32) []byte {
+	key := make([]byte, 0, 11)
+	key = append(key, TABLE_KEY_PREFIX, '_')
+	key = binary.BigEndian.AppendUint32(key, schemaId)
+	key = binary.BigEndian.AppendUint32(key, tableId)
+	key = append(key, '_')
+	return key
+}
+
+func TableRecordKey(schemaId uint32, tableId uint32, primaryKey []byte) []byte {
+	return append(TableKeyPrefix(schemaId, tableId), primaryKey...)
+}
+
+// prefix shared by the entries of all the indexes of a table
+func TableIndexesKeyPrefix(schemaId uint32, tableId uint32) []byte {
+	key := make([]byte, 0, 10)
+	key = append(key, INDEX_KEY_PREFIX, '_')
+	key = binary.BigEndian.AppendUint32(key, schemaId)
+	key = binary.BigEndian.AppendUint32(key, tableId)
+	return key
+}
+
+func IndexKeyPrefix(schemaId uint32, tableId uint32, indexId uint32) []byte {
+	key := TableIndexesKeyPrefix(schemaId, tableId)
+	key = binary.BigEndian.AppendUint32(key, indexId)
+	key = append(key, '_')
+	return key
+}
+
+func IndexEntryKey(schemaId uint32, tableId uint32, indexId uint32, indexValue []byte) []byte {
+	return append(IndexKeyPrefix(schemaId, tableId, indexId), indexValue...)
+}
+
+// Tables without primary key use a hidden, monotonically increasing
+// row id as key, encoded as a fixed width big endian integer.
+func EncodeRowId(rowId uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, rowId)
+}
+
+func DecodeRowId(primaryKey []byte) (uint64, error) {
+	if len(primaryKey) != 8 {
+		return 0, fmt.Errorf("invalid row id key of length %d", len(primaryKey))
+	}
+	return binary.BigEndian.Uint64(primaryKey), nil
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
+	"math"
 )
 
 type Record struct {
@@ -34,6 +36,23 @@ func (r *Record) SetText(colIndex uint, v string) error {
 	return r.setAt(colIndex, *NewTextValue(v))
 }
 
+func (r *Record) SetValue(colIndex uint, v Value) error {
+	return r.setAt(colIndex, v)
+}
+
+func (r *Record) GetValue(colIndex uint) (*Value, error) {
+	return r.getAt(colIndex)
+}
+
+// resets a column to NULL
+func (r *Record) ClearValue(colIndex uint) error {
+	if colIndex >= uint(len(r.values)) {
+		return fmt.Errorf("invalid column index: %d", colIndex)
+	}
+	r.values[colIndex] = nil
+	return nil
+}
+
 func (r *Record) GetInt(colIndex uint) (int64, error) {
 	val, err := r.getAt(colIndex)
 	if err != nil {
@@ -66,21 +85,31 @@ func (r *Record) GetText(colIndex uint) (string, error) {
 	return val.Text()
 }
 
+// Records are encoded as a format version byte followed by the number of
+// stored columns and, for each of them, its column id, its data type (0 for
+// NULL) and its value. Columns are looked up by id when decoding so that
+// records written before columns were added or dropped still decode: added
+// columns take their missing value and dropped ones are skipped.
+
+const RECORD_FORMAT_VERSION byte = 1
+
 func (r *Record) Encode() ([]byte, error) {
 	buf := new(bytes.Buffer)
-	for _, val := range r.values {
-		if val == nil {
-			binary.Write(buf, binary.LittleEndian, nil)
+	buf.WriteByte(RECORD_FORMAT_VERSION)
+	buf.Write(binary.AppendUvarint(nil, uint64(len(r.values))))
+
+	for idx, val := range r.values {
+		buf.Write(binary.AppendUvarint(nil, uint64(r.tableDesc.Columns[idx].Id)))
+		if val == nil || val.IsNull() {
+			buf.WriteByte(0)
 			continue
 		}
 
+		buf.WriteByte(byte(val.dataType))
 		if val.dataType == TYPE_TEXT {
 			strVal := val.data.(string)
-			err := binary.Write(buf, binary.LittleEndian, uint16(len(strVal)))
-			if err != nil {
-				return nil, err
-			}
-			_, err = buf.WriteString(strVal)
+			buf.Write(binary.AppendUvarint(nil, uint64(len(strVal))))
+			_, err := buf.WriteString(strVal)
 			if err != nil {
 				return nil, err
 			}
@@ -98,44 +127,208 @@ func (r *Record) Encode() ([]byte, error) {
 
 func (r *Record) Decode(data []byte) error {
 	reader := bytes.NewReader(data)
+	version, err := reader.ReadByte()
+	if err != nil {
+		return err
+	}
+	if version != RECORD_FORMAT_VERSION {
+		return fmt.Errorf("unsupported record format version %d", version)
+	}
+
+	columnIndexes := make(map[uint32]int, len(r.tableDesc.Columns))
 	for idx, column := range r.tableDesc.Columns {
-		switch column.DataType {
-		case TYPE_INT:
-			var v int64
-			err := binary.Read(reader, binary.LittleEndian, &v)
+		if _, exists := columnIndexes[column.Id]; exists {
+			return fmt.Errorf("duplicate column id %d", column.Id)
+		}
+		columnIndexes[column.Id] = idx
+		r.values[idx] = nil
+		if !column.Missing.IsNull() {
+			r.setAt(uint(idx), column.Missing)
+		}
+	}
+
+	count, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		columnId, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return err
+		}
+		value, err := decodeRecordValue(reader)
+		if err != nil {
+			return err
+		}
+
+		idx, ok := columnIndexes[uint32(columnId)]
+		if !ok {
+			// the column has been dropped
+			continue
+		}
+		if err := r.setAt(uint(idx), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeRecordValue(reader *bytes.Reader) (Value, error) {
+	dataType, err := reader.ReadByte()
+	if err != nil {
+		return Value{}, err
+	}
+
+	switch DataType(dataType) {
+	case 0:
+		return Value{}, nil
+	case TYPE_INT:
+		var v int64
+		err := binary.Read(reader, binary.LittleEndian, &v)
+		if err != nil {
+			return Value{}, err
+		}
+		return *NewIntValue(v), nil
+	case TYPE_FLOAT:
+		var v float64
+		err := binary.Read(reader, binary.LittleEndian, &v)
+		if err != nil {
+			return Value{}, err
+		}
+		return *NewFloatValue(v), nil
+	case TYPE_BOOL:
+		var v bool
+		err := binary.Read(reader, binary.LittleEndian, &v)
+		if err != nil {
+			return Value{}, err
+		}
+		return *NewBoolValue(v), nil
+	case TYPE_TEXT:
+		strLen, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return Value{}, err
+		}
+		if strLen > uint64(reader.Len()) {
+			return Value{}, io.ErrUnexpectedEOF
+		}
+
+		stringBytes := make([]byte, strLen)
+		if strLen > 0 {
+			_, err = io.ReadFull(reader, stringBytes)
 			if err != nil {
-				return err
+				return Value{}, err
 			}
-			r.SetInt(uint(idx), v)
-		case TYPE_FLOAT:
-			var v float64
-			err := binary.Read(reader, binary.LittleEndian, &v)
-			if err != nil {
+		}
+		return *NewTextValue(string(stringBytes)), nil
+	}
+	return Value{}, fmt.Errorf("unknown data type %d in record", dataType)
+}
+
+// RecordDecoder decodes the records of a schema as rows of a chunk, the
+// fields are stored in the typed vectors without building values.
+type RecordDecoder struct {
+	schema        *DataSchema
+	columnIndexes map[uint32]int
+}
+
+func NewRecordDecoder(schema *DataSchema) (*RecordDecoder, error) {
+	columnIndexes := make(map[uint32]int, len(schema.Columns))
+	for idx, column := range schema.Columns {
+		if _, exists := columnIndexes[column.Id]; exists {
+			return nil, fmt.Errorf("duplicate column id %d", column.Id)
+		}
+		columnIndexes[column.Id] = idx
+	}
+	return &RecordDecoder{schema: schema, columnIndexes: columnIndexes}, nil
+}
+
+// DecodeInto appends the record encoded in data to a chunk of the decoder
+// schema being built.
+func (d *RecordDecoder) DecodeInto(data []byte, chunk *DataChunk) error {
+	reader := bytes.NewReader(data)
+	version, err := reader.ReadByte()
+	if err != nil {
+		return err
+	}
+	if version != RECORD_FORMAT_VERSION {
+		return fmt.Errorf("unsupported record format version %d", version)
+	}
+
+	row := chunk.AppendNullRow()
+	for idx, column := range d.schema.Columns {
+		if !column.Missing.IsNull() {
+			chunk.columns[idx].Set(row, column.Missing)
+		}
+	}
+
+	count, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return err
+	}
+	var fixed [8]byte
+	for i := uint64(0); i < count; i++ {
+		columnId, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return err
+		}
+		dataType, err := reader.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		// the fields of dropped columns are read and skipped
+		var vector *Vector
+		if idx, ok := d.columnIndexes[uint32(columnId)]; ok {
+			vector = chunk.columns[idx]
+			if dataType != 0 && DataType(dataType) != vector.dataType {
+				column := d.schema.Columns[idx]
+				return NewError(ERR_DATATYPE_MISMATCH, "column %s is of type %s but value is of type %s", column.Name, column.DataType, DataType(dataType))
+			}
+		}
+
+		switch DataType(dataType) {
+		case 0:
+			if vector != nil {
+				vector.SetNull(row)
+			}
+		case TYPE_INT, TYPE_FLOAT:
+			if _, err := io.ReadFull(reader, fixed[:8]); err != nil {
 				return err
 			}
-			r.SetFloat(uint(idx), v)
+			if vector == nil {
+				continue
+			}
+			bits := binary.LittleEndian.Uint64(fixed[:8])
+			if dataType == byte(TYPE_INT) {
+				vector.SetInt(row, int64(bits))
+			} else {
+				vector.SetFloat(row, math.Float64frombits(bits))
+			}
 		case TYPE_BOOL:
-			var v bool
-			err := binary.Read(reader, binary.LittleEndian, &v)
+			b, err := reader.ReadByte()
 			if err != nil {
 				return err
 			}
-			r.SetBool(uint(idx), v)
+			if vector != nil {
+				vector.SetBool(row, b != 0)
+			}
 		case TYPE_TEXT:
-			var strLen uint16
-			err := binary.Read(reader, binary.LittleEndian, &strLen)
+			strLen, err := binary.ReadUvarint(reader)
 			if err != nil {
 				return err
 			}
-
-			stringBytes := make([]byte, strLen)
-			if strLen > 0 {
-				_, err = reader.Read(stringBytes)
-				if err != nil {
-					return err
-				}
+			if strLen > uint64(reader.Len()) {
+				return io.ErrUnexpectedEOF
+			}
+			text := data[len(data)-reader.Len():][:strLen]
+			if _, err := reader.Seek(int64(strLen), io.SeekCurrent); err != nil {
+				return err
+			}
+			if vector != nil {
+				vector.SetText(row, string(text))
 			}
-			r.SetText(uint(idx), string(stringBytes))
+		default:
+			return fmt.Errorf("unknown data type %d in record", dataType)
 		}
 	}
 	return nil
@@ -146,9 +339,14 @@ func (r *Record) setAt(colIndex uint, v Value) error {
 		return fmt.Errorf("invalid column index: %d", colIndex)
 	}
 
+	if v.IsNull() {
+		r.values[colIndex] = nil
+		return nil
+	}
+
 	col := r.tableDesc.Columns[colIndex]
 	if col.DataType != v.dataType {
-		return fmt.Errorf("")
+		return NewError(ERR_DATATYPE_MISMATCH, "column %s is of type %s but value is of type %s", col.Name, col.DataType, v.dataType)
 	}
 
 	r.values[colIndex] = &v
@@ -161,3 +359,13 @@ func (r *Record) getAt(colIndex uint) (*Value, error) {
 	}
 	return r.values[colIndex], nil
 }
+
+func (r *Record) ToRow() DataRow {
+	row := DataRow{Values: make([]Value, len(r.values))}
+	for idx, val := range r.values {
+		if val != nil {
+			row.Values[idx] = *val
+		}
+	}
+	return row
+}
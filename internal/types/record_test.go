@@ -1,6 +1,7 @@
 package types
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -127,10 +128,10 @@ func TestRecordEncodeDecodeMultipleColumns(t *testing.T) {
 	// Create table descriptor with multiple columns
 	tableDesc := &DataSchema{
 		Columns: []DataColumn{
-			{Name: "columnName1", DataType: TYPE_INT},
-			{Name: "columnName2", DataType: TYPE_FLOAT},
-			{Name: "columnName3", DataType: TYPE_BOOL},
-			{Name: "columnName4", DataType: TYPE_TEXT},
+			{Name: "columnName1", Id: 1, DataType: TYPE_INT},
+			{Name: "columnName2", Id: 2, DataType: TYPE_FLOAT},
+			{Name: "columnName3", Id: 3, DataType: TYPE_BOOL},
+			{Name: "columnName4", Id: 4, DataType: TYPE_TEXT},
 		},
 	}
 
@@ -200,3 +201,189 @@ func TestRecordEncodeDecodeEmptyString(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "", value)
 }
+
+func TestRecordEncodeDecodeNull(t *testing.T) {
+	// Create table descriptor with more columns than a single bitmap byte
+	tableDesc := &DataSchema{Columns: []DataColumn{}}
+	for i := 0; i < 10; i++ {
+		tableDesc.Columns = append(tableDesc.Columns, DataColumn{Name: fmt.Sprintf("column%d", i), Id: uint32(i + 1), DataType: TYPE_INT})
+	}
+	tableDesc.Columns[3].DataType = TYPE_TEXT
+
+	// Leave every other column NULL
+	record := NewRecord(tableDesc)
+	for i := 1; i < 10; i += 2 {
+		if i != 3 {
+			err := record.SetValue(uint(i), *NewIntValue(int64(i)))
+			require.NoError(t, err)
+		}
+	}
+	err := record.SetText(3, "text")
+	require.NoError(t, err)
+	err = record.SetValue(9, *NewNullValue())
+	require.NoError(t, err)
+
+	// Encode
+	encoded, err := record.Encode()
+	require.NoError(t, err)
+
+	// Decode into a record holding stale values
+	decodedRecord := NewRecord(tableDesc)
+	for i := 0; i < 10; i++ {
+		if i != 3 {
+			require.NoError(t, decodedRecord.SetInt(uint(i), 100))
+		}
+	}
+	err = decodedRecord.Decode(encoded)
+	require.NoError(t, err)
+
+	// Verify decoded values
+	row := decodedRecord.ToRow()
+	for i, value := range row.Values {
+		switch {
+		case i == 3:
+			assert.Equal(t, "text", value.String())
+		case i%2 == 1 && i != 9:
+			assert.Equal(t, *NewIntValue(int64(i)), value)
+		default:
+			assert.True(t, value.IsNull(), "column %d should be NULL", i)
+		}
+	}
+}
+
+func TestRecordDecodeUnderEvolvedSchema(t *testing.T) {
+	// Create table descriptor at version one
+	oldDesc := &DataSchema{
+		Columns: []DataColumn{
+			{Name: "id", Id: 1, DataType: TYPE_INT},
+			{Name: "legacy", Id: 2, DataType: TYPE_TEXT},
+			{Name: "score", Id: 3, DataType: TYPE_FLOAT},
+		},
+	}
+
+	record := NewRecord(oldDesc)
+	require.NoError(t, record.SetInt(0, 7))
+	require.NoError(t, record.SetText(1, "dropped later"))
+	require.NoError(t, record.SetValue(2, *NewNullValue()))
+
+	encoded, err := record.Encode()
+	require.NoError(t, err)
+
+	// The legacy column has been dropped, two columns have been added
+	// and the remaining ones are listed in a different order
+	newDesc := &DataSchema{
+		Columns: []DataColumn{
+			{Name: "score", Id: 3, DataType: TYPE_FLOAT, Missing: *NewFloatValue(1.5)},
+			{Name: "id", Id: 1, DataType: TYPE_INT},
+			{Name: "active", Id: 4, DataType: TYPE_BOOL, Missing: *NewBoolValue(true)},
+			{Name: "note", Id: 5, DataType: TYPE_TEXT},
+		},
+	}
+
+	decodedRecord := NewRecord(newDesc)
+	err = decodedRecord.Decode(encoded)
+	require.NoError(t, err)
+
+	// A stored NULL is kept, the missing value only applies to absent columns
+	assert.Equal(t, []Value{{}, *NewIntValue(7), *NewBoolValue(true), {}}, decodedRecord.ToRow().Values)
+}
+
+func TestRecordDecodeInvalidData(t *testing.T) {
+	tableDesc := &DataSchema{
+		Columns: []DataColumn{
+			{Name: "columnName", Id: 1, DataType: TYPE_TEXT},
+		},
+	}
+
+	record := NewRecord(tableDesc)
+	require.NoError(t, record.SetText(0, "value"))
+	encoded, err := record.Encode()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", []byte{}},
+		{"unknown version", append([]byte{RECORD_FORMAT_VERSION + 1}, encoded[1:]...)},
+		{"truncated", encoded[:len(encoded)-1]},
+		{"unknown data type", []byte{RECORD_FORMAT_VERSION, 1, 1, 42}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewRecord(tableDesc).Decode(tt.data)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRecordDecoder(t *testing.T) {
+	oldDesc := &DataSchema{
+		Columns: []DataColumn{
+			{Name: "id", Id: 1, DataType: TYPE_INT},
+			{Name: "legacy", Id: 2, DataType: TYPE_TEXT},
+			{Name: "score", Id: 3, DataType: TYPE_FLOAT},
+			{Name: "name", Id: 6, DataType: TYPE_TEXT},
+			{Name: "sold", Id: 7, DataType: TYPE_BOOL},
+		},
+	}
+	newDesc := &DataSchema{
+		Columns: []DataColumn{
+			{Name: "score", Id: 3, DataType: TYPE_FLOAT, Missing: *NewFloatValue(1.5)},
+			{Name: "id", Id: 1, DataType: TYPE_INT},
+			{Name: "active", Id: 4, DataType: TYPE_BOOL, Missing: *NewBoolValue(true)},
+			{Name: "note", Id: 5, DataType: TYPE_TEXT},
+			{Name: "name", Id: 6, DataType: TYPE_TEXT},
+			{Name: "sold", Id: 7, DataType: TYPE_BOOL},
+		},
+	}
+
+	rows := [][]Value{
+		{*NewIntValue(7), *NewTextValue("dropped later"), {}, *NewTextValue("fox"), *NewBoolValue(false)},
+		{*NewIntValue(-3), {}, *NewFloatValue(2.25), *NewTextValue(""), *NewBoolValue(true)},
+	}
+	decoder, err := NewRecordDecoder(newDesc)
+	require.NoError(t, err)
+	chunk := NewChunk(newDesc)
+	for _, values := range rows {
+		record := NewRecord(oldDesc)
+		for idx, value := range values {
+			require.NoError(t, record.SetValue(uint(idx), value))
+		}
+		encoded, err := record.Encode()
+		require.NoError(t, err)
+		require.NoError(t, decoder.DecodeInto(encoded, chunk))
+
+		// the rows match the records decoded one value at a time
+		decoded := NewRecord(newDesc)
+		require.NoError(t, decoded.Decode(encoded))
+		assert.Equal(t, decoded.ToRow(), chunk.GetRow(chunk.Len()-1))
+	}
+	assert.Equal(t, []int64{7, -3}, chunk.GetColumn(1).Ints())
+	assert.True(t, chunk.GetColumn(0).IsNull(0))
+	assert.True(t, chunk.GetColumn(3).IsNull(1))
+
+	_, err = NewRecordDecoder(&DataSchema{Columns: []DataColumn{{Id: 1}, {Id: 1}}})
+	assert.Error(t, err)
+
+	encoded := []byte{RECORD_FORMAT_VERSION, 1, 1, byte(TYPE_TEXT), 5, 'v', 'a'}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", []byte{}},
+		{"unknown version", []byte{RECORD_FORMAT_VERSION + 1, 0}},
+		{"truncated", encoded},
+		{"unknown data type", []byte{RECORD_FORMAT_VERSION, 1, 1, 42}},
+		{"type mismatch", []byte{RECORD_FORMAT_VERSION, 1, 1, byte(TYPE_BOOL), 1}},
+	}
+	textDesc := &DataSchema{Columns: []DataColumn{{Name: "columnName", Id: 1, DataType: TYPE_TEXT}}}
+	decoder, err = NewRecordDecoder(textDesc)
+	require.NoError(t, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, decoder.DecodeInto(tt.data, NewChunk(textDesc)))
+		})
+	}
+}
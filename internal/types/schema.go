@@ -3,12 +3,79 @@ package types
 type DataColumn struct {
 	Name     string
 	DataType DataType
+	// Id identifies the column in the encoded records of a table, it
+	// stays the same when other columns are added or dropped.
+	Id uint32
+	// Missing is used for the columns missing from an encoded record,
+	// written before they were added. The zero Value stands for NULL.
+	Missing Value
+	// Table is the name or alias of the table the column belongs to in a
+	// query, qualified column references are looked up by it.
+	Table string
+	// Hidden columns are left out of * and of unqualified references, as
+	// the columns of both tables merged by JOIN USING.
+	Hidden bool
 }
 
 type DataSchema struct {
 	Columns []DataColumn
 }
 
+// returns the position of the named column or -1 when it does not exist
+func (s *DataSchema) GetColumnIndex(name string) int {
+	for idx, column := range s.Columns {
+		if column.Name == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+// ResolveColumn returns the position of the column a reference names, the
+// reference is qualified when table is not empty. It fails when no column
+// or more than one matches the reference.
+func (s *DataSchema) ResolveColumn(table string, name string) (int, error) {
+	found, tableFound := -1, false
+	for idx, column := range s.Columns {
+		if table != "" {
+			if column.Table != table {
+				continue
+			}
+			tableFound = true
+		} else if column.Hidden {
+			continue
+		}
+		if column.Name != name {
+			continue
+		}
+		if found >= 0 {
+			return -1, NewError(ERR_AMBIGUOUS_COLUMN, "column reference %s is ambiguous", name)
+		}
+		found = idx
+	}
+
+	if found < 0 && table != "" && !tableFound {
+		return -1, NewError(ERR_UNDEFINED_TABLE, "missing FROM-clause entry for table %s", table)
+	}
+	if found < 0 {
+		if table != "" {
+			name = table + "." + name
+		}
+		return -1, NewError(ERR_UNDEFINED_COLUMN, "column %s does not exist", name)
+	}
+	return found, nil
+}
+
+// Qualify returns a copy of the schema whose columns belong to table
+func (s *DataSchema) Qualify(table string) *DataSchema {
+	columns := make([]DataColumn, len(s.Columns))
+	for i, column := range s.Columns {
+		column.Table = table
+		columns[i] = column
+	}
+	return &DataSchema{Columns: columns}
+}
+
 type DataRow struct {
 	Values []Value
 }
@@ -1,6 +1,10 @@
 package types
 
-import "fmt"
+import (
+	"cmp"
+	"fmt"
+	"strings"
+)
 
 type Value struct {
 	dataType DataType
@@ -35,6 +39,12 @@ func NewTextValue(v string) *Value {
 	}
 }
 
+// NewNullValue returns the NULL value, it has no data type and
+// can be stored in a column of any type.
+func NewNullValue() *Value {
+	return &Value{}
+}
+
 func (v *Value) Int() (int64, error) {
 	if v.dataType != TYPE_INT {
 		return 0, fmt.Errorf("value is not of type INT")
@@ -62,3 +72,67 @@ func (v *Value) Text() (string, error) {
 	}
 	return v.data.(string), nil
 }
+
+func (v *Value) GetDataType() DataType {
+	return v.dataType
+}
+
+// a zero value (no data type) stands for NULL
+func (v *Value) IsNull() bool {
+	return v.data == nil
+}
+
+// Compare returns -1, 0 or 1 depending on whether v is lower, equal or
+// greater than other. NULL is greater than every value so that it sorts last
+// in ascending order and first in descending order, INT/FLOAT are compared
+// numerically.
+func (v *Value) Compare(other *Value) (int, error) {
+	if v.IsNull() || other.IsNull() {
+		switch {
+		case v.IsNull() && other.IsNull():
+			return 0, nil
+		case v.IsNull():
+			return 1, nil
+		default:
+			return -1, nil
+		}
+	}
+
+	switch {
+	case v.dataType == TYPE_INT && other.dataType == TYPE_INT:
+		return cmp.Compare(v.data.(int64), other.data.(int64)), nil
+	case isNumeric(v.dataType) && isNumeric(other.dataType):
+		return cmp.Compare(v.asFloat(), other.asFloat()), nil
+	case v.dataType != other.dataType:
+		return 0, NewError(ERR_DATATYPE_MISMATCH, "cannot compare %s with %s", v.dataType, other.dataType)
+	case v.dataType == TYPE_BOOL:
+		left, right := v.data.(bool), other.data.(bool)
+		if left == right {
+			return 0, nil
+		} else if !left {
+			return -1, nil
+		}
+		return 1, nil
+	case v.dataType == TYPE_TEXT:
+		return strings.Compare(v.data.(string), other.data.(string)), nil
+	}
+	return 0, NewError(ERR_DATATYPE_MISMATCH, "cannot compare values of type %s", v.dataType)
+}
+
+func (v Value) String() string {
+	if v.IsNull() {
+		return "NULL"
+	}
+	return fmt.Sprintf("%v", v.data)
+}
+
+func (v *Value) asFloat() float64 {
+	if v.dataType == TYPE_INT {
+		return float64(v.data.(int64))
+	}
+	return v.data.(float64)
+}
+
+func isNumeric(dataType DataType) bool {
+	return dataType == TYPE_INT || dataType == TYPE_FLOAT
+}
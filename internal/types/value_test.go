@@ -0,0 +1,35 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		left     *Value
+		right    *Value
+		expected int
+	}{
+		{"ints", NewIntValue(1), NewIntValue(2), -1},
+		{"int and float", NewIntValue(2), NewFloatValue(1.5), 1},
+		{"texts", NewTextValue("b"), NewTextValue("b"), 0},
+		{"bools", NewBoolValue(false), NewBoolValue(true), -1},
+		{"null after value", NewNullValue(), NewIntValue(1), 1},
+		{"value before null", NewTextValue("z"), NewNullValue(), -1},
+		{"nulls", NewNullValue(), NewNullValue(), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.left.Compare(tt.right)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	_, err := NewTextValue("a").Compare(NewFloatValue(1))
+	assert.Equal(t, ERR_DATATYPE_MISMATCH, GetErrorCode(err))
+}
@@ -0,0 +1,169 @@
+package types
+
+import "fmt"
+
+// Vector holds the values of a column of a chunk in a slice of their type,
+// only the slice of its data type is used. NULLs are marked in the
+// validity bitmap, their slot keeps the zero value of the type. A vector
+// without data type only holds NULLs.
+type Vector struct {
+	dataType DataType
+	length   int
+	ints     []int64
+	floats   []float64
+	bools    []bool
+	texts    []string
+	// bit i is set when value i is not NULL
+	validity []uint64
+}
+
+// NewVector returns an empty vector with room for capacity values
+func NewVector(dataType DataType, capacity int) *Vector {
+	v := &Vector{dataType: dataType, validity: make([]uint64, 0, (capacity+63)/64)}
+	switch dataType {
+	case TYPE_INT:
+		v.ints = make([]int64, 0, capacity)
+	case TYPE_FLOAT:
+		v.floats = make([]float64, 0, capacity)
+	case TYPE_BOOL:
+		v.bools = make([]bool, 0, capacity)
+	case TYPE_TEXT:
+		v.texts = make([]string, 0, capacity)
+	}
+	return v
+}
+
+// NewNullVector returns a vector of length NULLs, the values are then set
+// by position.
+func NewNullVector(dataType DataType, length int) *Vector {
+	v := &Vector{dataType: dataType, length: length, validity: make([]uint64, (length+63)/64)}
+	switch dataType {
+	case TYPE_INT:
+		v.ints = make([]int64, length)
+	case TYPE_FLOAT:
+		v.floats = make([]float64, length)
+	case TYPE_BOOL:
+		v.bools = make([]bool, length)
+	case TYPE_TEXT:
+		v.texts = make([]string, length)
+	}
+	return v
+}
+
+func (v *Vector) GetDataType() DataType {
+	return v.dataType
+}
+
+func (v *Vector) Len() int {
+	return v.length
+}
+
+// the values of the vector by type, to be read along with IsNull
+func (v *Vector) Ints() []int64     { return v.ints }
+func (v *Vector) Floats() []float64 { return v.floats }
+func (v *Vector) Bools() []bool     { return v.bools }
+func (v *Vector) Texts() []string   { return v.texts }
+
+func (v *Vector) IsNull(i int) bool {
+	return v.validity[i/64]&(1<<(i%64)) == 0
+}
+
+func (v *Vector) setValid(i int) {
+	v.validity[i/64] |= 1 << (i % 64)
+}
+
+func (v *Vector) SetInt(i int, value int64) {
+	v.ints[i] = value
+	v.setValid(i)
+}
+
+func (v *Vector) SetFloat(i int, value float64) {
+	v.floats[i] = value
+	v.setValid(i)
+}
+
+func (v *Vector) SetBool(i int, value bool) {
+	v.bools[i] = value
+	v.setValid(i)
+}
+
+func (v *Vector) SetText(i int, value string) {
+	v.texts[i] = value
+	v.setValid(i)
+}
+
+// Append adds a value of the vector type or NULL at the end of the vector,
+// INT values are converted for FLOAT vectors.
+func (v *Vector) Append(value Value) {
+	v.Set(v.AppendNull(), value)
+}
+
+// AppendNull adds a NULL at the end of the vector and returns its position,
+// the value can then be set by position.
+func (v *Vector) AppendNull() int {
+	i := v.length
+	v.length++
+	if i%64 == 0 {
+		v.validity = append(v.validity, 0)
+	}
+	switch v.dataType {
+	case TYPE_INT:
+		v.ints = append(v.ints, 0)
+	case TYPE_FLOAT:
+		v.floats = append(v.floats, 0)
+	case TYPE_BOOL:
+		v.bools = append(v.bools, false)
+	case TYPE_TEXT:
+		v.texts = append(v.texts, "")
+	}
+	return i
+}
+
+// Set stores a value of the vector type or NULL at position i, INT values
+// are converted for FLOAT vectors.
+func (v *Vector) Set(i int, value Value) {
+	if value.IsNull() {
+		v.SetNull(i)
+		return
+	}
+
+	switch {
+	case v.dataType == value.dataType:
+		switch v.dataType {
+		case TYPE_INT:
+			v.SetInt(i, value.data.(int64))
+		case TYPE_FLOAT:
+			v.SetFloat(i, value.data.(float64))
+		case TYPE_BOOL:
+			v.SetBool(i, value.data.(bool))
+		case TYPE_TEXT:
+			v.SetText(i, value.data.(string))
+		}
+	case v.dataType == TYPE_FLOAT && value.dataType == TYPE_INT:
+		v.SetFloat(i, float64(value.data.(int64)))
+	default:
+		panic(fmt.Sprintf("cannot store a %s value in a %s vector", value.dataType, v.dataType))
+	}
+}
+
+// SetNull marks value i as NULL, its slot keeps its previous content
+func (v *Vector) SetNull(i int) {
+	v.validity[i/64] &^= 1 << (i % 64)
+}
+
+// Get returns the value at position i
+func (v *Vector) Get(i int) Value {
+	if v.IsNull(i) {
+		return Value{}
+	}
+	switch v.dataType {
+	case TYPE_INT:
+		return *NewIntValue(v.ints[i])
+	case TYPE_FLOAT:
+		return *NewFloatValue(v.floats[i])
+	case TYPE_BOOL:
+		return *NewBoolValue(v.bools[i])
+	default:
+		return *NewTextValue(v.texts[i])
+	}
+}